@@ -0,0 +1,303 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/database"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/framework/goroutines"
+	"github.com/wieku/rplpa"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Judgement struct {
+	Number int64   `json:"number"`
+	Time   int64   `json:"time"`
+	Result string  `json:"result"`
+	X      float32 `json:"x"`
+	Y      float32 `json:"y"`
+	Combo  uint    `json:"combo"`
+}
+
+type PP struct {
+	Aim        float64 `json:"aim"`
+	Speed      float64 `json:"speed"`
+	Acc        float64 `json:"acc"`
+	Flashlight float64 `json:"flashlight"`
+	Total      float64 `json:"total"`
+}
+
+type Score struct {
+	Score        int64   `json:"score"`
+	Accuracy     float64 `json:"accuracy"`
+	Grade        string  `json:"grade"`
+	Combo        uint    `json:"combo"`
+	MaxCombo     int     `json:"max_combo"`
+	PerfectCombo bool    `json:"perfect_combo"`
+	Count300     uint    `json:"count_300"`
+	CountGeki    uint    `json:"count_geki"`
+	Count100     uint    `json:"count_100"`
+	CountKatu    uint    `json:"count_katu"`
+	Count50      uint    `json:"count_50"`
+	CountMiss    uint    `json:"count_miss"`
+	CountSB      uint    `json:"count_sb"`
+	Stars        float64 `json:"stars"`
+	PP           PP      `json:"pp"`
+}
+
+type Beatmap struct {
+	MD5        string `json:"md5"`
+	ID         int64  `json:"id"`
+	SetID      int64  `json:"set_id"`
+	Artist     string `json:"artist"`
+	Title      string `json:"title"`
+	Difficulty string `json:"difficulty"`
+	Creator    string `json:"creator"`
+}
+
+type Result struct {
+	Beatmap       Beatmap     `json:"beatmap"`
+	Player        string      `json:"player"`
+	Mods          string      `json:"mods"`
+	ExpectedScore int32       `json:"expected_score"`
+	Failed        bool        `json:"failed"`
+	Score         Score       `json:"score"`
+	Judgements    []Judgement `json:"judgements"`
+}
+
+// Run scores a replay using danser's ruleset without creating a window or GL context. Result is written as JSON to stdout or the file given by -out.
+func Run(args []string) {
+	runCommand(func() {
+		analyze(args)
+	})
+}
+
+// runCommand runs a headless command on the main thread, panics are logged and exit the process with code 1
+func runCommand(command func()) {
+	goroutines.RunMain(func() {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Println("panic:", err)
+
+				for _, s := range goroutines.GetStackTrace(4) {
+					log.Println(s)
+				}
+
+				os.Exit(1)
+			}
+		}()
+
+		command()
+	})
+}
+
+func analyze(args []string) {
+	// stdout is reserved for the result
+	log.SetOutput(os.Stderr)
+
+	flags := flag.NewFlagSet("analyze", flag.ExitOnError)
+
+	replayPath := flags.String("replay", "", "Replay file to analyze")
+	flags.StringVar(replayPath, "r", "", "Replay file to analyze (shorthand)")
+
+	settingsVersion := flags.String("settings", "", "Specify settings version, -settings=b/abc means that settings/b/abc.json will be loaded")
+	out := flags.String("out", "", "Write the result to the given file instead of stdout")
+	pretty := flags.Bool("pretty", false, "Indent JSON output")
+	noDbCheck := flags.Bool("nodbcheck", false, "Don't validate the database and only import new beatmap sets if there are any")
+	export := flags.String("export", "", "Additionally export all judgements and clicks next to the replay file. Possible values: csv, json")
+
+	_ = flags.Parse(args)
+
+	replay := readReplay(*replayPath)
+
+	emu := newEmulation(replay, *replayPath, *settingsVersion, *noDbCheck)
+
+	bMap, ruleset, cursor := emu.bMap, emu.ruleset, emu.cursor
+
+	var exporter *osu.JudgementExporter
+
+	if *export != "" {
+		exporter = osu.NewJudgementExporter()
+		ruleset.SetExporter(exporter)
+	}
+
+	result := &Result{
+		Beatmap:       newBeatmap(bMap),
+		Player:        replay.Username,
+		Mods:          ruleset.GetPlayerDifficulty(cursor).GetModString(),
+		ExpectedScore: replay.Score,
+		Judgements:    make([]Judgement, 0),
+	}
+
+	ruleset.SetListener(func(c *graphics.Cursor, judgementResult osu.JudgementResult, score osu.Score) {
+		if c != cursor || judgementResult.HitResult&osu.BaseHitsM == 0 {
+			return
+		}
+
+		result.Judgements = append(result.Judgements, Judgement{
+			Number: judgementResult.Number,
+			Time:   judgementResult.Time,
+			Result: resultName(judgementResult.HitResult),
+			X:      judgementResult.Position.X,
+			Y:      judgementResult.Position.Y,
+			Combo:  score.CurrentCombo,
+		})
+	})
+
+	if err := emu.run(nil); err != nil {
+		panic(err)
+	}
+
+	if exporter != nil {
+		paths, err := exporter.Save(strings.TrimSuffix(*replayPath, filepath.Ext(*replayPath)), *export)
+		if err != nil {
+			panic(fmt.Sprintf("Failed to export judgements: %s", err))
+		}
+
+		for _, path := range paths {
+			log.Println("Judgements exported to:", path)
+		}
+	}
+
+	score := ruleset.GetScore(cursor)
+	attribs := ruleset.GetFinalDiffAttribs(cursor)
+
+	result.Failed = ruleset.HasFailed(cursor)
+	result.Score = Score{
+		Score:        score.Score,
+		Accuracy:     score.Accuracy,
+		Grade:        score.Grade.String(),
+		Combo:        score.Combo,
+		MaxCombo:     attribs.MaxCombo,
+		PerfectCombo: score.PerfectCombo,
+		Count300:     score.Count300,
+		CountGeki:    score.CountGeki,
+		Count100:     score.Count100,
+		CountKatu:    score.CountKatu,
+		Count50:      score.Count50,
+		CountMiss:    score.CountMiss,
+		CountSB:      score.CountSB,
+		Stars:        attribs.Total,
+		PP: PP{
+			Aim:        score.PP.Aim,
+			Speed:      score.PP.Speed,
+			Acc:        score.PP.Acc,
+			Flashlight: score.PP.Flashlight,
+			Total:      score.PP.Total,
+		},
+	}
+
+	writeJSON(result, *pretty, *out)
+}
+
+func newBeatmap(bMap *beatmap.BeatMap) Beatmap {
+	return Beatmap{
+		MD5:        bMap.MD5,
+		ID:         bMap.ID,
+		SetID:      bMap.SetID,
+		Artist:     bMap.Artist,
+		Title:      bMap.Name,
+		Difficulty: bMap.Difficulty,
+		Creator:    bMap.Creator,
+	}
+}
+
+// writeJSON writes the result to stdout or to the given file if out is not empty
+func writeJSON(result any, pretty bool, out string) {
+	var output []byte
+	var err error
+
+	if pretty {
+		output, err = json.MarshalIndent(result, "", "\t")
+	} else {
+		output, err = json.Marshal(result)
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	if out != "" {
+		if err = os.WriteFile(out, output, 0644); err != nil {
+			panic(err)
+		}
+
+		log.Println("Result saved to:", out)
+
+		return
+	}
+
+	fmt.Println(string(output))
+}
+
+func findBeatmap(md5 string, noDbCheck bool) (bMap *beatmap.BeatMap) {
+	if err := database.Init(); err != nil {
+		panic(fmt.Sprintf("Failed to initialize database: %s", err))
+	}
+
+	defer database.Close()
+
+	for _, b := range database.LoadBeatmaps(noDbCheck, nil) {
+		if strings.EqualFold(b.MD5, md5) {
+			return b
+		}
+	}
+
+	return nil
+}
+
+// setMods mirrors mod handling of -replay flag
+func setMods(bMap *beatmap.BeatMap, replay *rplpa.Replay) {
+	mods, modsNew := replayMods(replay)
+
+	if modsNew != nil {
+		bMap.Diff.SetMods2(modsNew)
+	} else {
+		bMap.Diff.SetMods(mods)
+	}
+}
+
+// replayMods returns replay's legacy mods and lazer's mods with settings, the latter is nil if replay doesn't have them
+func replayMods(replay *rplpa.Replay) (difficulty.Modifier, []rplpa.ModInfo) {
+	mods := difficulty.ModifierFromLegacy(replay.Mods)
+
+	var modsNew []rplpa.ModInfo
+
+	if replay.ScoreInfo != nil && len(replay.ScoreInfo.Mods) > 0 {
+		modsNew = make([]rplpa.ModInfo, 0, len(replay.ScoreInfo.Mods))
+
+		for _, mod := range replay.ScoreInfo.Mods {
+			modsNew = append(modsNew, *mod)
+		}
+	}
+
+	if replay.OsuVersion >= 30000000 { // Lazer is 1000 years in the future
+		mods = mods.With(difficulty.Lazer)
+
+		if modsNew != nil {
+			modsNew = append(modsNew, rplpa.ModInfo{Acronym: "LZ"})
+		}
+	}
+
+	return mods, modsNew
+}
+
+func resultName(result osu.HitResult) string {
+	switch {
+	case result&osu.Hit300 > 0:
+		return "300"
+	case result&osu.Hit100 > 0:
+		return "100"
+	case result&osu.Hit50 > 0:
+		return "50"
+	}
+
+	return "miss"
+}
@@ -0,0 +1,128 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/dance"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/build"
+	"github.com/wieku/danser-go/framework/assets"
+	"github.com/wieku/rplpa"
+	"os"
+)
+
+// emulation is a replay prepared to be run through danser's ruleset without a window or GL context
+type emulation struct {
+	replay *rplpa.Replay
+	bMap   *beatmap.BeatMap
+
+	controller *dance.ReplayController
+	ruleset    *osu.OsuRuleSet
+	cursor     *graphics.Cursor
+}
+
+// parseReplay reads the replay without checking whether it can be emulated
+func parseReplay(path string) *rplpa.Replay {
+	if path == "" {
+		panic("Replay file has to be specified with -replay")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		panic(err)
+	}
+
+	replay, err := rplpa.ParseReplay(data)
+	if err != nil {
+		panic(err)
+	}
+
+	return replay
+}
+
+func readReplay(path string) *rplpa.Replay {
+	replay := parseReplay(path)
+
+	if replay.PlayMode != 0 {
+		panic("Modes other than osu!standard are not supported")
+	}
+
+	if replay.ReplayData == nil || len(replay.ReplayData) < 2 {
+		panic("Replay is missing input data")
+	}
+
+	return replay
+}
+
+func newEmulation(replay *rplpa.Replay, replayPath, settingsVersion string, noDbCheck bool) *emulation {
+	settings.HEADLESS = true
+	settings.KNOCKOUT = true
+	settings.REPLAY = replayPath
+
+	loadSettings(settingsVersion)
+
+	bMap := findBeatmap(replay.BeatmapMD5, noDbCheck)
+	if bMap == nil {
+		panic("Beatmap not found")
+	}
+
+	assets.Init(build.Stream == "Dev")
+
+	setMods(bMap, replay)
+
+	beatmap.ParseTimingPointsAndPauses(bMap)
+	beatmap.ParseObjects(bMap, false, false)
+
+	for _, o := range bMap.HitObjects {
+		o.DisableAudioSubmission(true)
+	}
+
+	bMap.Reset()
+
+	controller := dance.NewReplayController().(*dance.ReplayController)
+	controller.SetBeatMap(bMap)
+	controller.InitCursors()
+
+	return &emulation{
+		replay:     replay,
+		bMap:       bMap,
+		controller: controller,
+		ruleset:    controller.GetRuleset(),
+		cursor:     controller.GetCursors()[0],
+	}
+}
+
+func loadSettings(settingsVersion string) {
+	if settingsVersion == "credentials" || settingsVersion == "launcher" {
+		panic(fmt.Sprintf("flag -settings: name \"%s\" is forbidden", settingsVersion))
+	}
+
+	settings.LoadSettings(settingsVersion)
+}
+
+// run plays the whole replay in 1ms steps, onUpdate is called after every step if it's not nil.
+// Returns an error if the beatmap has nothing to emulate.
+func (e *emulation) run(onUpdate func(time float64)) error {
+	objs := e.bMap.HitObjects
+
+	if len(objs) == 0 {
+		return errors.New("beatmap has no hit objects")
+	}
+
+	startTime := min(0, objs[0].GetStartTime()-e.bMap.Diff.Preempt)
+	endTime := objs[len(objs)-1].GetEndTime() + float64(e.bMap.Diff.Hit50) + difficulty.HitFadeOut + 1000
+
+	for t := startTime; t <= endTime && !e.ruleset.HasEnded(); t++ {
+		e.controller.Update(t, 1)
+
+		if onUpdate != nil {
+			onUpdate(t)
+		}
+	}
+
+	return nil
+}
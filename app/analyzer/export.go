@@ -0,0 +1,227 @@
+package analyzer
+
+import (
+	"archive/zip"
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/ffmpeg"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/files"
+	"github.com/wieku/danser-go/framework/math/mutils"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// files which don't follow the new timing, they're left out of the exported set
+var skippedExtensions = []string{".osu", ".osb", ".avi", ".flv", ".mp4", ".m4v", ".mkv", ".mov", ".mpg", ".webm", ".wmv"}
+
+// Export writes beatmap with rate, AR, OD, CS and HP changes baked in as a new difficulty, packaged in .osz together with re-encoded audio
+func Export(args []string) {
+	runCommand(func() {
+		export(args)
+	})
+}
+
+func export(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+
+	selection := addSelectionFlags(flags)
+
+	mods := flags.String("mods", "", "Specify mods to bake in. Only mods changing rate, difficulty values or object positions have an effect")
+	mods2 := flags.String("mods2", "", "Specify mods to bake in, lazer style")
+
+	speed := flags.Float64("speed", 1.0, "Change map's speed, ignored if -mods already change it")
+	ar := flags.Float64("ar", math.NaN(), "Modify map's AR")
+	od := flags.Float64("od", math.NaN(), "Modify map's OD")
+	cs := flags.Float64("cs", math.NaN(), "Modify map's CS")
+	hp := flags.Float64("hp", math.NaN(), "Modify map's HP")
+
+	diffName := flags.String("diffname", "", "Name of the new difficulty, generated from changed values if empty")
+
+	settingsVersion := flags.String("settings", "", "Specify settings version, -settings=b/abc means that settings/b/abc.json will be loaded")
+	noDbCheck := flags.Bool("nodbcheck", false, "Don't validate the database and only import new beatmap sets if there are any")
+	out := flags.String("out", "", "Directory where .osz file will be saved, current directory is used if empty")
+
+	_ = flags.Parse(args)
+
+	modsParsed, modsNew := parseModFlags(*mods, *mods2)
+
+	if modsNew == nil {
+		modsNew = modsParsed.ConvertToModInfoList()
+	}
+
+	modsNew = difficulty.AddCustomAdjustments(modsNew, *ar, *od, *cs, *hp, *speed)
+
+	loadSettings(*settingsVersion)
+
+	// spinners have to be written as well
+	settings.Objects.LoadSpinners = true
+
+	found := selection.find(*noDbCheck)
+
+	if len(found) == 0 {
+		panic("Beatmap not found")
+	} else if len(found) > 1 {
+		panic(fmt.Sprintf("%d beatmaps match given flags, only one can be exported", len(found)))
+	}
+
+	bMap := found[0]
+
+	if bMap.Mode != 0 {
+		panic("Only osu!standard beatmaps can be exported")
+	}
+
+	bMap.Diff.SetMods2(modsNew)
+
+	beatmap.ParseTimingPointsAndPauses(bMap)
+	beatmap.ParseObjects(bMap, true, false)
+
+	diff := bMap.Diff
+
+	if diff.ARReal > 10 || diff.ODReal > 10 || diff.ODReal < 0 {
+		log.Println("Warning: AR and OD outside of 0-10 range are clamped by osu!")
+	}
+
+	audioPath, err := bMap.GetAudioFile()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to find beatmap's audio: %s", err))
+	}
+
+	exported := bMap.Copy()
+	exported.HitObjects = bMap.HitObjects
+	exported.Timings = bMap.Timings
+	exported.Pauses = bMap.Pauses
+
+	// let osu! treat it as a new unsubmitted difficulty
+	exported.ID = 0
+	exported.SetID = -1
+
+	exported.Difficulty = *diffName
+	if exported.Difficulty == "" {
+		exported.Difficulty = bMap.Difficulty + " (" + describeChanges(diff) + ")"
+	}
+
+	if diff.GetSpeed() != 1 {
+		exported.Audio = fmt.Sprintf("audio_%sx%s", mutils.FormatWOZeros(diff.GetSpeed(), 2), filepath.Ext(audioPath))
+	}
+
+	var osuData bytes.Buffer
+
+	if err = beatmap.WriteBeatMap(&osuData, exported); err != nil {
+		panic(err)
+	}
+
+	audioData := audioPath
+
+	if exported.Audio != bMap.Audio {
+		tempDir, err1 := os.MkdirTemp("", "danser-export")
+		if err1 != nil {
+			panic(err1)
+		}
+
+		defer os.RemoveAll(tempDir)
+
+		audioData = filepath.Join(tempDir, exported.Audio)
+
+		log.Println("Changing audio speed...")
+
+		if err = ffmpeg.ChangeAudioSpeed(audioPath, audioData, diff.GetSpeed(), diff.AdjustsPitch()); err != nil {
+			panic(err)
+		}
+	}
+
+	outDir := *out
+	if outDir == "" {
+		outDir = "."
+	}
+
+	if err = os.MkdirAll(outDir, 0755); err != nil {
+		panic(err)
+	}
+
+	baseName := files.FixName(fmt.Sprintf("%s - %s (%s) [%s]", exported.Artist, exported.Name, exported.Creator, exported.Difficulty))
+	oszPath := filepath.Join(outDir, baseName+".osz")
+
+	oszFile, err := os.Create(oszPath)
+	if err != nil {
+		panic(err)
+	}
+
+	defer oszFile.Close()
+
+	archive := zip.NewWriter(oszFile)
+
+	writeEntry := func(name string, r io.Reader) {
+		w, err1 := archive.Create(name)
+		if err1 == nil {
+			_, err1 = io.Copy(w, r)
+		}
+
+		if err1 != nil {
+			panic(fmt.Sprintf("Failed to write %s to .osz: %s", name, err1))
+		}
+	}
+
+	writeEntry(baseName+".osu", &osuData)
+
+	// skins, hitsounds and backgrounds of the set, without other difficulties and the original audio
+	setDir := filepath.Dir(bMap.GetFilePath())
+
+	for name, path := range bMap.GetFiles() {
+		if path == audioPath || slices.Contains(skippedExtensions, strings.ToLower(filepath.Ext(name))) {
+			continue
+		}
+
+		// keep the original case where possible, lazer's file store keeps only lowercase names
+		if rel, err1 := filepath.Rel(setDir, path); err1 == nil && !strings.HasPrefix(rel, "..") {
+			name = filepath.ToSlash(rel)
+		}
+
+		copyFileEntry(writeEntry, name, path)
+	}
+
+	copyFileEntry(writeEntry, exported.Audio, audioData)
+
+	if err = archive.Close(); err != nil {
+		panic(err)
+	}
+
+	log.Println("Beatmap exported to:", oszPath)
+}
+
+func copyFileEntry(writeEntry func(name string, r io.Reader), name, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		panic(err)
+	}
+
+	defer file.Close()
+
+	writeEntry(name, file)
+}
+
+// describeChanges creates a difficulty name suffix like "1.5x AR10.33 OD9.78 CS4 HP6"
+func describeChanges(diff *difficulty.Difficulty) string {
+	var parts []string
+
+	if speed := diff.GetSpeed(); speed != 1 {
+		parts = append(parts, mutils.FormatWOZeros(speed, 2)+"x")
+	}
+
+	parts = append(parts,
+		"AR"+mutils.FormatWOZeros(diff.ARReal, 2),
+		"OD"+mutils.FormatWOZeros(diff.ODReal, 2),
+		"CS"+mutils.FormatWOZeros(diff.CSMod, 2),
+		"HP"+mutils.FormatWOZeros(diff.HPMod, 2),
+	)
+
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,344 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/database"
+	"github.com/wieku/danser-go/app/rulesets/osu/performance"
+	"github.com/wieku/danser-go/app/rulesets/osu/performance/api"
+	"github.com/wieku/rplpa"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+type BeatmapInfo struct {
+	Beatmap
+	Path        string  `json:"path"`
+	Mode        int64   `json:"mode"`
+	Status      string  `json:"status"`
+	Stars       float64 `json:"stars"` // -1 if it wasn't calculated yet
+	AR          float64 `json:"ar"`
+	OD          float64 `json:"od"`
+	CS          float64 `json:"cs"`
+	HP          float64 `json:"hp"`
+	MinBPM      float64 `json:"bpm_min"`
+	MaxBPM      float64 `json:"bpm_max"`
+	Length      int     `json:"length"` // in milliseconds
+	Circles     int     `json:"circles"`
+	Sliders     int     `json:"sliders"`
+	Spinners    int     `json:"spinners"`
+	PlayCount   int64   `json:"play_count"`
+	LastPlayed  int64   `json:"last_played"` // unix milliseconds
+	DateAdded   int64   `json:"date_added"`  // unix milliseconds
+	LocalOffset int     `json:"local_offset"`
+}
+
+type ReplayInfo struct {
+	Player       string           `json:"player"`
+	BeatmapMD5   string           `json:"beatmap_md5"`
+	ReplayMD5    string           `json:"replay_md5"`
+	Mode         int8             `json:"mode"`
+	Version      int32            `json:"version"`
+	Lazer        bool             `json:"lazer"`
+	Mods         string           `json:"mods"`
+	LegacyMods   uint32           `json:"legacy_mods"`
+	Counts       Counts           `json:"counts"`
+	PerfectCombo bool             `json:"perfect_combo"`
+	Timestamp    time.Time        `json:"timestamp"`
+	ScoreID      int64            `json:"score_id"`
+	Frames       int              `json:"frames"`
+	ScoreInfo    *rplpa.ScoreInfo `json:"score_info,omitempty"` // lazer's mods with settings and hit statistics
+}
+
+type BeatmapAttributes struct {
+	Beatmap    Beatmap        `json:"beatmap"`
+	Mods       string         `json:"mods"`
+	Attributes api.Attributes `json:"attributes"`
+}
+
+// List prints beatmaps from danser's database matching selection flags as JSON
+func List(args []string) {
+	runCommand(func() {
+		list(args)
+	})
+}
+
+// InspectReplay prints replay's metadata as JSON
+func InspectReplay(args []string) {
+	runCommand(func() {
+		replayInfo(args)
+	})
+}
+
+// Attributes prints difficulty attributes of beatmaps matching selection flags under given mods as JSON
+func Attributes(args []string) {
+	runCommand(func() {
+		attributes(args)
+	})
+}
+
+func list(args []string) {
+	// stdout is reserved for the result
+	log.SetOutput(os.Stderr)
+
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+
+	selection := addSelectionFlags(flags)
+
+	settingsVersion := flags.String("settings", "", "Specify settings version, -settings=b/abc means that settings/b/abc.json will be loaded")
+	noDbCheck := flags.Bool("nodbcheck", false, "Don't validate the database and only import new beatmap sets if there are any")
+	out := flags.String("out", "", "Write the result to the given file instead of stdout")
+	pretty := flags.Bool("pretty", false, "Indent JSON output")
+
+	_ = flags.Parse(args)
+
+	loadSettings(*settingsVersion)
+
+	result := make([]BeatmapInfo, 0)
+
+	for _, bMap := range selection.find(*noDbCheck) {
+		result = append(result, BeatmapInfo{
+			Beatmap:     newBeatmap(bMap),
+			Path:        bMap.GetFilePath(),
+			Mode:        bMap.Mode,
+			Status:      bMap.Status.String(),
+			Stars:       bMap.Stars,
+			AR:          bMap.Diff.GetAR(),
+			OD:          bMap.Diff.GetOD(),
+			CS:          bMap.Diff.GetCS(),
+			HP:          bMap.Diff.GetHP(),
+			MinBPM:      bMap.MinBPM,
+			MaxBPM:      bMap.MaxBPM,
+			Length:      bMap.Length,
+			Circles:     bMap.Circles,
+			Sliders:     bMap.Sliders,
+			Spinners:    bMap.Spinners,
+			PlayCount:   bMap.PlayCount,
+			LastPlayed:  bMap.LastPlayed,
+			DateAdded:   bMap.TimeAdded,
+			LocalOffset: bMap.LocalOffset,
+		})
+	}
+
+	writeJSON(result, *pretty, *out)
+}
+
+func replayInfo(args []string) {
+	// stdout is reserved for the result
+	log.SetOutput(os.Stderr)
+
+	flags := flag.NewFlagSet("replayinfo", flag.ExitOnError)
+
+	replayPath := flags.String("replay", "", "Replay file to inspect")
+	flags.StringVar(replayPath, "r", "", "Replay file to inspect (shorthand)")
+
+	out := flags.String("out", "", "Write the result to the given file instead of stdout")
+	pretty := flags.Bool("pretty", false, "Indent JSON output")
+
+	_ = flags.Parse(args)
+
+	replay := parseReplay(*replayPath)
+
+	diff := difficulty.NewDifficulty(5, 5, 5, 5)
+
+	if mods, modsNew := replayMods(replay); modsNew != nil {
+		diff.SetMods2(modsNew)
+	} else {
+		diff.SetMods(mods)
+	}
+
+	writeJSON(&ReplayInfo{
+		Player:     replay.Username,
+		BeatmapMD5: replay.BeatmapMD5,
+		ReplayMD5:  replay.ReplayMD5,
+		Mode:       replay.PlayMode,
+		Version:    replay.OsuVersion,
+		Lazer:      diff.CheckModActive(difficulty.Lazer),
+		Mods:       diff.GetModString(),
+		LegacyMods: replay.Mods,
+		Counts: Counts{
+			Score:     int64(replay.Score),
+			MaxCombo:  uint(replay.MaxCombo),
+			Count300:  uint(replay.Count300),
+			CountGeki: uint(replay.CountGeki),
+			Count100:  uint(replay.Count100),
+			CountKatu: uint(replay.CountKatu),
+			Count50:   uint(replay.Count50),
+			CountMiss: uint(replay.CountMiss),
+		},
+		PerfectCombo: replay.Fullcombo,
+		Timestamp:    replay.Timestamp,
+		ScoreID:      replay.ScoreID,
+		Frames:       len(replay.ReplayData),
+		ScoreInfo:    replay.ScoreInfo,
+	}, *pretty, *out)
+}
+
+func attributes(args []string) {
+	// stdout is reserved for the result
+	log.SetOutput(os.Stderr)
+
+	flags := flag.NewFlagSet("attributes", flag.ExitOnError)
+
+	selection := addSelectionFlags(flags)
+
+	mods := flags.String("mods", "", "Specify beatmap/play mods")
+	mods2 := flags.String("mods2", "", "Specify beatmap/play mods, lazer style")
+
+	settingsVersion := flags.String("settings", "", "Specify settings version, -settings=b/abc means that settings/b/abc.json will be loaded")
+	noDbCheck := flags.Bool("nodbcheck", false, "Don't validate the database and only import new beatmap sets if there are any")
+	out := flags.String("out", "", "Write the result to the given file instead of stdout")
+	pretty := flags.Bool("pretty", false, "Indent JSON output")
+
+	_ = flags.Parse(args)
+
+	modsParsed, modsNew := parseModFlags(*mods, *mods2)
+
+	loadSettings(*settingsVersion)
+
+	calculator := performance.GetDifficultyCalculator()
+
+	result := make([]BeatmapAttributes, 0)
+
+	for _, bMap := range selection.find(*noDbCheck) {
+		if bMap.Mode != 0 {
+			log.Println("Skipping non osu!standard beatmap:", bMap.File)
+			continue
+		}
+
+		if modsNew != nil {
+			bMap.Diff.SetMods2(modsNew)
+		} else {
+			bMap.Diff.SetMods(modsParsed)
+		}
+
+		beatmap.ParseTimingPointsAndPauses(bMap)
+		beatmap.ParseObjects(bMap, true, false)
+
+		result = append(result, BeatmapAttributes{
+			Beatmap:    newBeatmap(bMap),
+			Mods:       bMap.Diff.GetModString(),
+			Attributes: calculator.CalculateSingle(bMap.HitObjects, bMap.Diff),
+		})
+
+		bMap.Clear()
+	}
+
+	writeJSON(result, *pretty, *out)
+}
+
+// parseModFlags parses -mods and -mods2 flags, modsNew is nil if -mods2 wasn't used
+func parseModFlags(mods, mods2 string) (modsParsed difficulty.Modifier, modsNew []rplpa.ModInfo) {
+	if mods != "" && mods2 != "" {
+		panic("Incompatible flags selected: -mods, -mods2")
+	}
+
+	if mods2 != "" {
+		if err := json.Unmarshal([]byte(mods2), &modsNew); err != nil {
+			panic(fmt.Sprintf("Failed to parse mods: %s", err))
+		}
+	}
+
+	modsParsed = difficulty.ParseMods(mods)
+
+	if modsNew != nil {
+		tempDiff := difficulty.NewDifficulty(1, 1, 1, 1)
+		tempDiff.SetMods2(modsNew)
+		modsParsed = tempDiff.Mods
+	}
+
+	if !modsParsed.Compatible() {
+		panic("Incompatible mods selected!")
+	}
+
+	return
+}
+
+// beatmapSelection holds beatmap search flags, they mirror the ones used to pick a map for rendering
+type beatmapSelection struct {
+	id *int64
+
+	md5, query *string
+
+	artist, title, difficulty, creator *string
+}
+
+func addSelectionFlags(flags *flag.FlagSet) *beatmapSelection {
+	s := &beatmapSelection{
+		id:         flags.Int64("id", -1, "Select beatmaps with the given id"),
+		md5:        flags.String("md5", "", "Select beatmaps with the given md5 hash"),
+		query:      flags.String("query", "", "Select beatmaps matching the query, e.g. \"stars>6 ar>=9.5 creator=abc status=ranked\""),
+		artist:     flags.String("artist", "", "Select beatmaps with the given artist, if there are none, ones containing the text are selected"),
+		title:      flags.String("title", "", "Select beatmaps with the given title, if there are none, ones containing the text are selected"),
+		difficulty: flags.String("difficulty", "", "Select beatmaps with the given difficulty(version), if there are none, ones containing the text are selected"),
+		creator:    flags.String("creator", "", "Select beatmaps with the given creator, if there are none, ones containing the text are selected"),
+	}
+
+	flags.StringVar(s.artist, "a", "", "Select beatmaps with the given artist, if there are none, ones containing the text are selected (shorthand)")
+	flags.StringVar(s.title, "t", "", "Select beatmaps with the given title, if there are none, ones containing the text are selected (shorthand)")
+	flags.StringVar(s.difficulty, "d", "", "Select beatmaps with the given difficulty(version), if there are none, ones containing the text are selected (shorthand)")
+	flags.StringVar(s.creator, "c", "", "Select beatmaps with the given creator, if there are none, ones containing the text are selected (shorthand)")
+
+	return s
+}
+
+// find returns beatmaps from the database matching all given flags, all beatmaps are returned if none were specified
+func (s *beatmapSelection) find(noDbCheck bool) (result []*beatmap.BeatMap) {
+	query, err := beatmap.ParseQuery(*s.query)
+	if err != nil {
+		panic(err)
+	}
+
+	if err = database.Init(); err != nil {
+		panic(fmt.Sprintf("Failed to initialize database: %s", err))
+	}
+
+	defer database.Close()
+
+	bMaps := database.LoadBeatmaps(noDbCheck, nil)
+
+	database.CheckStatusFilter(query)
+
+	var candidates []*beatmap.BeatMap
+
+	for _, b := range bMaps {
+		if (*s.id < 0 || b.ID == *s.id) &&
+			(*s.md5 == "" || strings.EqualFold(b.MD5, *s.md5)) &&
+			query.Matches(b) {
+			candidates = append(candidates, b)
+		}
+	}
+
+	// Same as when picking a map for rendering: exact metadata matches first, partial ones only if there are none
+	result = s.matchMetadata(candidates, strings.EqualFold)
+
+	if len(result) == 0 && *s.artist+*s.title+*s.difficulty+*s.creator != "" {
+		log.Println("Beatmaps with exact parameters not found, searching partially...")
+
+		result = s.matchMetadata(candidates, func(text, sub string) bool {
+			return strings.Contains(strings.ToLower(text), strings.ToLower(sub))
+		})
+	}
+
+	return
+}
+
+// matchMetadata returns beatmaps whose metadata matches all given artist, title, difficulty and creator flags
+func (s *beatmapSelection) matchMetadata(bMaps []*beatmap.BeatMap, match func(text, value string) bool) (result []*beatmap.BeatMap) {
+	matches := func(text, value string) bool {
+		return value == "" || match(text, value)
+	}
+
+	for _, b := range bMaps {
+		if matches(b.Artist, *s.artist) && matches(b.Name, *s.title) && matches(b.Difficulty, *s.difficulty) && matches(b.Creator, *s.creator) {
+			result = append(result, b)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,294 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/rplpa"
+	"log"
+	"math"
+	"os"
+	"strings"
+)
+
+// ExitDesync is the exit code of verify command when the replay doesn't match danser's emulation
+const ExitDesync = 2
+
+type Counts struct {
+	Score     int64 `json:"score"`
+	MaxCombo  uint  `json:"max_combo"`
+	Count300  uint  `json:"count_300"`
+	CountGeki uint  `json:"count_geki"`
+	Count100  uint  `json:"count_100"`
+	CountKatu uint  `json:"count_katu"`
+	Count50   uint  `json:"count_50"`
+	CountMiss uint  `json:"count_miss"`
+}
+
+type Mismatch struct {
+	Field    string `json:"field"`
+	Expected int64  `json:"expected"`
+	Actual   int64  `json:"actual"`
+}
+
+// Divergence is the latest point by which the emulation provably differs from the replay.
+// Most checks compare against replay's final totals, so the actual desync can happen earlier.
+type Divergence struct {
+	Time   int64  `json:"time"`
+	Number int64  `json:"number"` // Number of the judged object, -1 if it's not tied to a judgement
+	Reason string `json:"reason"`
+}
+
+type Verification struct {
+	Beatmap    Beatmap     `json:"beatmap"`
+	Player     string      `json:"player"`
+	Mods       string      `json:"mods"`
+	Consistent bool        `json:"consistent"`
+	Expected   Counts      `json:"expected"`
+	Actual     Counts      `json:"actual"`
+	Mismatches []Mismatch  `json:"mismatches"`
+	DivergedBy *Divergence `json:"diverged_by,omitempty"`
+}
+
+// Verify runs a replay through danser's ruleset and compares the result with replay's header.
+// Exits with ExitDesync if they don't match.
+func Verify(args []string) {
+	runCommand(func() {
+		if !verify(args) {
+			os.Exit(ExitDesync)
+		}
+	})
+}
+
+func verify(args []string) bool {
+	// stdout is reserved for the result
+	log.SetOutput(os.Stderr)
+
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+
+	replayPath := flags.String("replay", "", "Replay file to verify")
+	flags.StringVar(replayPath, "r", "", "Replay file to verify (shorthand)")
+
+	settingsVersion := flags.String("settings", "", "Specify settings version, -settings=b/abc means that settings/b/abc.json will be loaded")
+	noDbCheck := flags.Bool("nodbcheck", false, "Don't validate the database and only import new beatmap sets if there are any")
+	jsonOut := flags.Bool("json", false, "Print the result as JSON")
+	hpTolerance := flags.Float64("hptolerance", 0.05, "Maximum allowed difference between emulated HP and replay's life bar graph, in 0-1 range. Negative value disables the check")
+
+	_ = flags.Parse(args)
+
+	replay := readReplay(*replayPath)
+
+	emu := newEmulation(replay, *replayPath, *settingsVersion, *noDbCheck)
+
+	result := &Verification{
+		Beatmap: newBeatmap(emu.bMap),
+		Player:  replay.Username,
+		Mods:    emu.ruleset.GetPlayerDifficulty(emu.cursor).GetModString(),
+		Expected: Counts{
+			Score:     int64(replay.Score),
+			MaxCombo:  uint(replay.MaxCombo),
+			Count300:  uint(replay.Count300),
+			CountGeki: uint(replay.CountGeki),
+			Count100:  uint(replay.Count100),
+			CountKatu: uint(replay.CountKatu),
+			Count50:   uint(replay.Count50),
+			CountMiss: uint(replay.CountMiss),
+		},
+		Mismatches: make([]Mismatch, 0),
+	}
+
+	var lastJudgement *osu.JudgementResult
+
+	setDivergence := func(time, number int64, reason string) {
+		if result.DivergedBy == nil || time < result.DivergedBy.Time {
+			result.DivergedBy = &Divergence{
+				Time:   time,
+				Number: number,
+				Reason: reason,
+			}
+		}
+	}
+
+	expectedTicks := lazerTickStatistics(replay, emu.ruleset.GetPlayerDifficulty(emu.cursor))
+	actualTicks := make(map[rplpa.LazerHitResult]int64)
+
+	// Counts only grow, so the first judgement pushing any of them over replay's value is the latest point the emulation could have diverged by
+	emu.ruleset.SetListener(func(c *graphics.Cursor, judgementResult osu.JudgementResult, score osu.Score) {
+		if c != emu.cursor || result.DivergedBy != nil {
+			return
+		}
+
+		lastJudgement = &judgementResult
+
+		if exceeded := compareCounts(result.Expected, countsFromScore(score), false); len(exceeded) > 0 {
+			setDivergence(judgementResult.Time, judgementResult.Number, fmt.Sprintf("%s exceeded %d", exceeded[0].Field, exceeded[0].Expected))
+			return
+		}
+
+		// Slider ticks are judged much more often than objects, so they narrow the divergence down if lazer stored them
+		if tick, ok := lazerTickResult(judgementResult); ok && expectedTicks != nil {
+			actualTicks[tick]++
+
+			if actualTicks[tick] > expectedTicks[tick] {
+				setDivergence(judgementResult.Time, judgementResult.Number, fmt.Sprintf("%s exceeded %d", tick, expectedTicks[tick]))
+			}
+		}
+	})
+
+	lifeBar := replay.LifebarGraph
+	lifeIndex := 0
+
+	err := emu.run(func(time float64) {
+		if *hpTolerance < 0 || result.DivergedBy != nil {
+			return
+		}
+
+		for lifeIndex < len(lifeBar) && float64(lifeBar[lifeIndex].Time) <= time {
+			sample := lifeBar[lifeIndex]
+			lifeIndex++
+
+			hp := emu.ruleset.GetHP(emu.cursor)
+
+			if math.Abs(hp-float64(sample.HP)) > *hpTolerance {
+				number := int64(-1)
+				if lastJudgement != nil {
+					number = lastJudgement.Number
+				}
+
+				setDivergence(int64(sample.Time), number, fmt.Sprintf("HP is %.2f, replay has %.2f", hp, sample.HP))
+
+				return
+			}
+		}
+	})
+
+	if err != nil {
+		panic(err)
+	}
+
+	result.Actual = countsFromScore(emu.ruleset.GetScore(emu.cursor))
+	result.Mismatches = append(result.Mismatches, compareCounts(result.Expected, result.Actual, true)...)
+
+	// HP divergences alone are reported, but don't make the replay inconsistent
+	result.Consistent = len(result.Mismatches) == 0
+
+	if *jsonOut {
+		output, err := json.Marshal(result)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Println(string(output))
+	} else {
+		fmt.Println(result.String())
+	}
+
+	return result.Consistent
+}
+
+// lazerTickStatistics returns slider tick statistics stored in lazer replays, nil if the replay doesn't have them or isn't judged with lazer's rules
+func lazerTickStatistics(replay *rplpa.Replay, diff *difficulty.Difficulty) map[rplpa.LazerHitResult]int64 {
+	if replay.ScoreInfo == nil || replay.ScoreInfo.MaximumStatistics[rplpa.LazerLargeTickHit] == 0 || !diff.CheckModActive(difficulty.Lazer) {
+		return nil
+	}
+
+	return map[rplpa.LazerHitResult]int64{
+		rplpa.LazerLargeTickHit:  replay.ScoreInfo.Statistics[rplpa.LazerLargeTickHit],
+		rplpa.LazerLargeTickMiss: replay.ScoreInfo.Statistics[rplpa.LazerLargeTickMiss],
+	}
+}
+
+// lazerTickResult maps slider tick and repeat judgements to lazer's statistics
+func lazerTickResult(judgementResult osu.JudgementResult) (rplpa.LazerHitResult, bool) {
+	if judgementResult.MaxResult&(osu.SliderPoint|osu.SliderRepeat) == 0 {
+		return "", false
+	}
+
+	if judgementResult.HitResult == osu.SliderMiss {
+		return rplpa.LazerLargeTickMiss, true
+	}
+
+	return rplpa.LazerLargeTickHit, true
+}
+
+func countsFromScore(score osu.Score) Counts {
+	return Counts{
+		Score:     score.Score,
+		MaxCombo:  score.Combo,
+		Count300:  score.Count300,
+		CountGeki: score.CountGeki,
+		Count100:  score.Count100,
+		CountKatu: score.CountKatu,
+		Count50:   score.Count50,
+		CountMiss: score.CountMiss,
+	}
+}
+
+// compareCounts returns fields that differ between expected and actual. If exact is false, only fields where actual exceeds expected are returned
+func compareCounts(expected, actual Counts, exact bool) (mismatches []Mismatch) {
+	fields := []struct {
+		name             string
+		expected, actual int64
+	}{
+		{"count_300", int64(expected.Count300), int64(actual.Count300)},
+		{"count_geki", int64(expected.CountGeki), int64(actual.CountGeki)},
+		{"count_100", int64(expected.Count100), int64(actual.Count100)},
+		{"count_katu", int64(expected.CountKatu), int64(actual.CountKatu)},
+		{"count_50", int64(expected.Count50), int64(actual.Count50)},
+		{"count_miss", int64(expected.CountMiss), int64(actual.CountMiss)},
+		{"max_combo", int64(expected.MaxCombo), int64(actual.MaxCombo)},
+		{"score", expected.Score, actual.Score},
+	}
+
+	for _, f := range fields {
+		if (exact && f.actual != f.expected) || (!exact && f.actual > f.expected) {
+			mismatches = append(mismatches, Mismatch{
+				Field:    f.name,
+				Expected: f.expected,
+				Actual:   f.actual,
+			})
+		}
+	}
+
+	return
+}
+
+func (v *Verification) String() string {
+	var sb strings.Builder
+
+	if v.Consistent {
+		sb.WriteString("Replay is consistent with danser's emulation")
+	} else {
+		sb.WriteString("Replay desynced:")
+
+		for _, m := range v.Mismatches {
+			sb.WriteString(fmt.Sprintf("\n\t%s: expected %d, got %d", m.Field, m.Expected, m.Actual))
+		}
+	}
+
+	if d := v.DivergedBy; d != nil {
+		sb.WriteString(fmt.Sprintf("\nDiverged by %s at the latest", FormatTime(d.Time)))
+
+		if d.Number >= 0 {
+			sb.WriteString(fmt.Sprintf(" (object #%d)", d.Number))
+		}
+
+		sb.WriteString(": " + d.Reason)
+	}
+
+	return sb.String()
+}
+
+// FormatTime formats time in milliseconds as mm:ss.SSS
+func FormatTime(time int64) string {
+	sign := ""
+	if time < 0 {
+		sign = "-"
+		time = -time
+	}
+
+	return fmt.Sprintf("%s%02d:%02d.%03d", sign, time/60000, (time/1000)%60, time%1000)
+}
@@ -38,6 +38,7 @@ import (
 	"io/ioutil"
 	"log"
 	"math"
+	"math/rand"
 	"os"
 	"runtime"
 	"slices"
@@ -75,6 +76,9 @@ var screenshotTime float64
 
 var preciseProgress bool
 
+// progressListener is called by mainLoopRecord each time progress is logged
+var progressListener func(progress int, speed float64, eta int)
+
 var monitorHz int
 
 func run() {
@@ -85,6 +89,9 @@ func run() {
 		}
 	}()
 
+	// segmented recordings are done by child processes
+	segmented := false
+
 	goroutines.CallMain(func() {
 		id := flag.Int64("id", -1, "Specify the beatmap id. Overrides other beatmap search flags")
 
@@ -102,6 +109,9 @@ func run() {
 		creator := flag.String("creator", "", creatorDesc)
 		flag.StringVar(creator, "c", "", creatorDesc+shorthand)
 
+		query := flag.String("query", "", "Specify the beatmap using a query, e.g. \"stars>6 ar>=9.5 bpm>200 length<3:00 creator=abc status=ranked\". Words without an operator are searched in map's metadata. osu!taiko maps are matched only with a mode filter, e.g. \"mode=taiko\". Ranked status is known only for maps imported from osu!.db or osu!lazer. Overrides artist, title, difficulty and creator flags")
+		randomMatch := flag.Bool("random", false, "Pick a random beatmap matching -query instead of the first one")
+
 		settingsVersion := flag.String("settings", "", "Specify settings version, -settings=b/abc means that settings/b/abc.json will be loaded. \"Credentials\"")
 		cursors := flag.Int("cursors", 1, "How many repeated cursors should be visible, recommended 2 for mirror, 8 for mandala")
 		tag := flag.Int("tag", 1, "How many cursors should be \"playing\" specific map. 2 means that 1st cursor clicks the 1st object, 2nd clicks 2nd object, 1st clicks 3rd and so on")
@@ -124,7 +134,7 @@ func run() {
 		quickstart := flag.Bool("quickstart", false, "Sets -skip flag, sets LeadInTime and LeadInHold settings temporarily to 0")
 
 		record := flag.Bool("record", false, "Records a video")
-		out := flag.String("out", "", "If -ss flag is used, sets the name of screenshot, extension is PNG. If not, it overrides -record flag, specifies the name of recorded video file, extension is managed by settings")
+		out := flag.String("out", "", "If -ss flag is used, sets the name of screenshot, extension is PNG. If not, it overrides -record flag, specifies the name of recorded video file, extension is managed by settings. Recording interrupted by a crash is resumed if it's started again with the same -out, or without -out if it wasn't used")
 		ss := flag.Float64("ss", math.NaN(), "Screenshot mode. Snap single frame from danser at given time in seconds. Specify the name of file by -out, resolution is managed by Recording settings")
 
 		mods := flag.String("mods", "", "Specify beatmap/play mods")
@@ -149,6 +159,12 @@ func run() {
 
 		sPatch := flag.String("sPatch", "", "Patches the currently loaded settings")
 
+		batchFile := flag.String("batch", "", "Records multiple videos in one go. Path to a JSON file with a list of jobs: [{\"replay\": \"a.osr\", \"md5\": \"\", \"id\": 0, \"settings\": \"\", \"sPatch\": \"\", \"mods\": \"\", \"skin\": \"\", \"out\": \"a\"}]. -settings, -sPatch, -mods and -skin are used by jobs that don't specify them. Replay's mods override -mods")
+		serverAddr := flag.String("server", "", "Starts a local HTTP API for recording replays at given address, e.g. -server=127.0.0.1:9000. Jobs are recorded one by one. -settings, -sPatch, -mods and -skin are used by jobs that don't specify them. The API has no authentication, don't expose it to untrusted networks")
+
+		segments := flag.Int("segments", 1, "Splits the recording into given number of segments rendered by separate danser processes in parallel, then joins them into one video. Speeds up long recordings on machines with spare CPU/GPU power")
+		segment := flag.Int("segment", 0, "Records only the given segment (1-based) of a recording split by -segments. Used internally by segmented recording")
+
 		flag.Parse()
 
 		if *mods != "" && *mods2 != "" {
@@ -176,10 +192,54 @@ func run() {
 			}
 		}
 
+		// batch and server modes record jobs in one process instead of a single beatmap
+		jobMode := *batchFile != "" || *serverAddr != ""
+
+		if jobMode {
+			if *play || *replay != "" || *knockout || !math.IsNaN(*ss) {
+				panic("Incompatible flags selected: -batch and -server can't be used with -play, -replay, -knockout or -ss")
+			} else if *batchFile != "" && *serverAddr != "" {
+				panic("Incompatible flags selected: -batch, -server")
+			}
+
+			*record = true
+
+			jobDefaults := &renderJob{
+				Settings: *settingsVersion,
+				SPatch:   *sPatch,
+				Mods:     *mods,
+				Skin:     *skin,
+			}
+
+			if *batchFile != "" {
+				batchJobs = loadBatchJobs(*batchFile, jobDefaults)
+			} else {
+				server = newRenderServer(*serverAddr, jobDefaults)
+
+				// Server reports progress to clients, so it has to be fine-grained
+				preciseProgress = true
+			}
+		}
+
 		recordMode = *record
 		screenshotMode = !math.IsNaN(*ss)
 		screenshotTime = *ss
 
+		if *segments < 1 {
+			panic("-segments has to be at least 1")
+		} else if *segments > 1 && (!*record || jobMode) {
+			panic("Incompatible flags selected: -segments works only with a single recording (-record or -out)")
+		} else if *segment != 0 && (*segments == 1 || *segment < 1 || *segment > *segments) {
+			panic("-segment has to be between 1 and the number of -segments")
+		}
+
+		if *segment > 0 {
+			segmentIndex = *segment
+			segmentCount = *segments
+
+			ffmpeg.SetSegment(segmentIndex)
+		}
+
 		if *record && *play {
 			panic("Incompatible flags selected: -record, -play")
 		} else if *replay != "" && *play {
@@ -197,44 +257,11 @@ func run() {
 		modsParsed := difficulty2.ParseMods(*mods)
 		var modsNew []rplpa.ModInfo = nil
 
-		if *replay != "" {
-			bytes, err := ioutil.ReadFile(*replay)
-			if err != nil {
-				panic(err)
-			}
+		taikoReplay := false
 
-			rp, err := rplpa.ParseReplay(bytes)
-			if err != nil {
-				panic(err)
-			}
-
-			if rp.PlayMode != 0 {
-				panic("Modes other than osu!standard are not supported")
-			}
-
-			if rp.ReplayData == nil || len(rp.ReplayData) < 2 {
-				panic("Replay is missing input data")
-			}
-
-			*md5 = rp.BeatmapMD5
+		if *replay != "" {
+			*md5, modsParsed, modsNew, taikoReplay = loadReplay(*replay)
 			*id = -1
-			modsParsed = difficulty2.Modifier(rp.Mods)
-
-			if rp.ScoreInfo != nil && rp.ScoreInfo.Mods != nil && len(rp.ScoreInfo.Mods) > 0 {
-				modsNew = make([]rplpa.ModInfo, 0, len(rp.ScoreInfo.Mods))
-
-				for _, mod := range rp.ScoreInfo.Mods {
-					modsNew = append(modsNew, *mod)
-				}
-			}
-
-			if rp.OsuVersion >= 30000000 { // Lazer is 1000 years in the future
-				modsParsed |= difficulty2.Lazer
-
-				if modsNew != nil {
-					modsNew = append(modsNew, rplpa.ModInfo{Acronym: "LZ"})
-				}
-			}
 
 			*knockout = true
 			settings.REPLAY = *replay
@@ -248,6 +275,10 @@ func run() {
 			}
 
 			modsNew = mods2I
+
+			if *replay != "" {
+				modsNew = removeReplayDrawnPositionMods(modsNew)
+			}
 		}
 
 		if modsNew != nil {
@@ -262,7 +293,7 @@ func run() {
 
 		closeAfterSettingsLoad := false
 
-		if (*md5+*artist+*title+*difficulty+*creator) == "" && *id < 0 {
+		if (*md5+*query+*artist+*title+*difficulty+*creator) == "" && *id < 0 && !jobMode {
 			log.Println("No beatmap specified, closing...")
 			closeAfterSettingsLoad = true
 		}
@@ -271,6 +302,7 @@ func run() {
 		settings.KNOCKOUT = *knockout
 		settings.KNOCKOUTREPLAYS = knockoutReplays
 		settings.PLAY = *play
+		settings.TAIKO = taikoReplay
 		settings.DIVIDES = *cursors
 		settings.TAG = *tag
 		settings.SPEED = *speed
@@ -300,67 +332,56 @@ func run() {
 
 		player = nil
 		var beatMap *beatmap.BeatMap = nil
+		var beatmaps []*beatmap.BeatMap
 
 		if !closeAfterSettingsLoad {
 			err := database.Init()
 			if err != nil {
 				log.Println("Failed to initialize database:", err)
 			} else {
-				beatmaps := database.LoadBeatmaps(*noDbCheck, nil)
+				beatmaps = database.LoadBeatmaps(*noDbCheck, nil)
 
-				if *id > -1 {
-					for _, b := range beatmaps {
-						if b.ID == *id {
-							beatMap = b
+				if !jobMode {
+					beatMap = findBeatmap(beatmaps, *id, *md5, *query, *randomMatch, *artist, *title, *difficulty, *creator)
+				}
+			}
 
-							break
-						}
+			if jobMode {
+				if len(beatmaps) == 0 {
+					log.Println("No beatmaps available, closing...")
+					closeAfterSettingsLoad = true
+				}
+			} else if beatMap == nil {
+				log.Println("Beatmap not found, closing...")
+				closeAfterSettingsLoad = true
+			} else {
+				if beatMap.Mode == 1 {
+					if settings.PLAY {
+						panic("osu!taiko maps can't be played")
 					}
-				} else if *md5 != "" {
-					for _, b := range beatmaps {
-						if strings.EqualFold(b.MD5, *md5) {
-							beatMap = b
 
-							break
-						}
-					}
-				} else {
-					for _, b := range beatmaps {
-						if (*artist == "" || strings.EqualFold(*artist, b.Artist)) &&
-							(*title == "" || strings.EqualFold(*title, b.Name)) &&
-							(*difficulty == "" || strings.EqualFold(*difficulty, b.Difficulty)) &&
-							(*creator == "" || strings.EqualFold(*creator, b.Creator)) {
-							beatMap = b
-
-							break
-						}
-					}
+					settings.TAIKO = true
+				}
 
-					if beatMap == nil {
-						log.Println("Beatmap with exact parameters not found, searching partially...")
-						for _, b := range beatmaps {
-							if (*artist == "" || strings.Contains(strings.ToLower(b.Artist), strings.ToLower(*artist))) &&
-								(*title == "" || strings.Contains(strings.ToLower(b.Name), strings.ToLower(*title))) &&
-								(*difficulty == "" || strings.Contains(strings.ToLower(b.Difficulty), strings.ToLower(*difficulty))) &&
-								(*creator == "" || strings.Contains(strings.ToLower(b.Creator), strings.ToLower(*creator))) {
-								beatMap = b
-
-								break
-							}
-						}
-					}
+				// segments are parts of a recording started by the parent process, it updates the stats once
+				if segmentIndex == 0 {
+					beatMap.UpdatePlayStats()
+					database.UpdatePlayStats(beatMap)
 				}
 			}
 
-			if beatMap == nil {
-				log.Println("Beatmap not found, closing...")
-				closeAfterSettingsLoad = true
-			} else {
-				beatMap.UpdatePlayStats()
-				database.UpdatePlayStats(beatMap)
+			// Job modes keep the database open to update play stats of each job
+			if !jobMode || closeAfterSettingsLoad {
+				database.Close()
 			}
+		}
+
+		if *segments > 1 && segmentIndex == 0 && !closeAfterSettingsLoad {
+			renderSegments(beatMap, *segments)
 
-			database.Close()
+			segmented = true
+
+			return
 		}
 
 		assets.Init(build.Stream == "Dev")
@@ -426,14 +447,7 @@ func run() {
 		}
 
 		if settings.RECORD {
-			//HACK: some in-app variables depend on these settings so we force them here
-			settings.Graphics.VSync = false
-			settings.Graphics.ShowFPS = false
-			settings.DEBUG = false
-			settings.Graphics.Fullscreen = false
-			settings.Graphics.WindowWidth = int64(settings.Recording.FrameWidth)
-			settings.Graphics.WindowHeight = int64(settings.Recording.FrameHeight)
-			settings.Playfield.LeadInTime = 0
+			overrideRecordSettings()
 		}
 
 		if screenshotMode {
@@ -466,7 +480,12 @@ func run() {
 			})
 		}
 
-		win.SetTitle("danser " + build.VERSION + " - " + beatMap.Artist + " - " + beatMap.Name + " [" + beatMap.Difficulty + "]")
+		if beatMap != nil {
+			win.SetTitle("danser " + build.VERSION + " - " + beatMap.Artist + " - " + beatMap.Name + " [" + beatMap.Difficulty + "]")
+		} else {
+			win.SetTitle("danser " + build.VERSION + " - render queue")
+		}
+
 		input.Win = win
 
 		if cTime := time.Now(); cTime.Month() == 12 && cTime.Day() >= 6 {
@@ -515,81 +534,27 @@ func run() {
 		lastVSync = true
 
 		bass.Init(settings.RECORD)
-		audio.LoadSamples()
 
-		if settings.PLAY || !settings.KNOCKOUT || allowDA {
-			if modsNew == nil {
-				modsNew = modsParsed.ConvertToModInfoList()
-			}
+		if jobMode {
+			runner = newJobRunner(beatmaps, *quickstart)
 
-			daMap := make(map[string]any)
-
-			if !math.IsNaN(*ar) {
-				daMap["approach_rate"] = *ar
-			}
-
-			if !math.IsNaN(*od) {
-				daMap["overall_difficulty"] = *od
+			if server != nil {
+				server.start()
 			}
 
-			if !math.IsNaN(*cs) {
-				daMap["circle_size"] = *cs
-			}
+			return
+		}
 
-			if !math.IsNaN(*hp) {
-				daMap["drain_rate"] = *hp
-			}
+		audio.LoadSamples()
 
-			// Add DA only if DA hasn't been added already
-			if len(daMap) > 0 && !slices.ContainsFunc(modsNew, func(info rplpa.ModInfo) bool { return info.Acronym == "DA" }) {
-				modsNew = append(modsNew, rplpa.ModInfo{
-					Acronym:  "DA",
-					Settings: daMap,
-				})
+		if settings.PLAY || !settings.KNOCKOUT || allowDA {
+			if modsNew == nil {
+				modsNew = modsParsed.ConvertToModInfoList()
 			}
 
-			if math.Abs(settings.SPEED-1) > 0.001 {
-				skipMods := []string{"HT", "DC", "DT", "NC"}
-
-				found := slices.ContainsFunc(modsNew, func(info rplpa.ModInfo) bool { return slices.Contains(skipMods, info.Acronym) })
-
-				// Don't modify current mods
-				//if settings.SPEED >= 1 {
-				//	if i := slices.IndexFunc(modsNew, func(info rplpa.ModInfo) bool {
-				//		return info.Acronym == "DT" || info.Acronym == "NC"
-				//	}); i != -1 {
-				//		found = true
-				//		modsNew[i].Settings["speed_change"] = settings.SPEED
-				//	}
-				//} else {
-				//	if i := slices.IndexFunc(modsNew, func(info rplpa.ModInfo) bool {
-				//		return info.Acronym == "HT" || info.Acronym == "DC"
-				//	}); i != -1 {
-				//		found = true
-				//		modsNew[i].Settings["speed_change"] = settings.SPEED
-				//	}
-				//}
-
-				if !found {
-					modsNew = slices.DeleteFunc(modsNew, func(info rplpa.ModInfo) bool {
-						return info.Acronym == "DT" || info.Acronym == "NC" || info.Acronym == "HT" || info.Acronym == "DC"
-					})
-
-					acr := "HT"
-					if settings.SPEED >= 1 {
-						acr = "DT"
-					}
+			modsNew = difficulty2.AddCustomAdjustments(modsNew, *ar, *od, *cs, *hp, settings.SPEED)
 
-					modsNew = append(modsNew, rplpa.ModInfo{
-						Acronym: acr,
-						Settings: map[string]any{
-							"speed_change": settings.SPEED,
-						},
-					})
-				}
-
-				settings.SPEED = 1
-			}
+			settings.SPEED = 1
 		}
 
 		if modsNew != nil {
@@ -606,7 +571,13 @@ func run() {
 		limiter = frame.NewLimiter(int(settings.Graphics.FPSCap))
 	})
 
-	if recordMode {
+	if segmented {
+		return
+	} else if server != nil {
+		server.process(runner)
+	} else if runner != nil {
+		runner.runBatch(batchJobs)
+	} else if recordMode {
 		mainLoopRecord()
 	} else if screenshotMode {
 		mainLoopSS()
@@ -629,11 +600,21 @@ func mainLoopRecord() {
 
 	var fbo *buffer.Framebuffer
 
-	goroutines.CallMain(func() {
+	callMain(func() {
 		fbo = buffer.NewFrameMultisampleScreen(w, h, false, 0)
 	})
 
-	ffmpeg.StartFFmpeg(int(fps), w, h, audioFPS, output)
+	defer fbo.Dispose()
+
+	p, _ := player.(*states.Player)
+
+	resumeTime := ffmpeg.StartFFmpeg(int(fps), w, h, audioFPS, output, getJobFingerprint(p))
+
+	if settings.Recording.IsLayered() {
+		p.SetLayerHook(func(layer states.Layer) {
+			ffmpeg.SwitchLayer(int(layer))
+		})
+	}
 
 	updateFPS := max(fps, 1000)
 	updateDelta := 1000 / updateFPS
@@ -643,7 +624,26 @@ func mainLoopRecord() {
 	deltaSumF := fpsDelta
 	deltaSumA := 0.0
 
-	p, _ := player.(*states.Player)
+	rangeStart, rangeEnd := getSegmentRange(p.RunningTime)
+
+	// parts saved by an interrupted recording are skipped
+	recordStart := max(rangeStart, resumeTime)
+
+	if resumeTime > 0 {
+		log.Println(fmt.Sprintf("Resuming recording at %s, fast-forwarding...", util.FormatSeconds(int(resumeTime/1000))))
+	}
+
+	oversample := int64(1)
+	if settings.Recording.MotionBlur.Enabled {
+		oversample = int64(settings.Recording.MotionBlur.OversampleMultiplier)
+	}
+
+	// Segments and parts are decided at the start of each output frame, so oversampled frames are never split between them.
+	// Audio goes to the segment of the latest frame, that way parts of all segments add up to the continuous audio.
+	frameIndex := int64(0)
+	frameTime := 0.0
+	inRange := recordStart <= 0
+	preRoll := false
 
 	lastCount := int64(0)
 	lastRealTime := qpc.GetMilliTimeF()
@@ -657,58 +657,99 @@ func mainLoopRecord() {
 	for !p.Update(updateDelta) {
 		deltaSumA += updateDelta
 		for deltaSumA >= audioDelta {
-			ffmpeg.PushAudio()
+			if inRange {
+				ffmpeg.PushAudio()
+			} else {
+				ffmpeg.DiscardAudio()
+			}
 
 			deltaSumA -= audioDelta
 		}
 
 		deltaSumF += updateDelta
 		if deltaSumF >= fpsDelta {
-			goroutines.CallMain(func() {
-				fbo.Bind()
+			newFrame := frameIndex%oversample == 0
 
-				ffmpeg.PreFrame()
+			if newFrame {
+				frameTime = p.GetTimeOffset()
 
-				viewport.Push(int(settings.Graphics.GetWidth()), int(settings.Graphics.GetHeight()))
-				pushFrame()
-				viewport.Pop()
+				if frameTime >= rangeEnd && segmentIndex < segmentCount {
+					break
+				}
 
-				ffmpeg.MakeFrame()
+				inRange = inRange || frameTime >= recordStart
+				preRoll = !inRange && frameTime >= recordStart-segmentPreRoll
+			}
 
-				fbo.Unbind()
+			frameIndex++
 
-				count++
+			if inRange || preRoll {
+				callMain(func() {
+					if inRange && newFrame {
+						ffmpeg.SetTime(frameTime)
+					}
 
-				timeOffset := p.GetTimeOffset()
-				progress = int(math.Round(timeOffset / p.RunningTime * 100))
+					fbo.Bind()
 
-				if (preciseProgress || progress%5 == 0) && lastProgress != progress {
-					speed := float64(count-lastCount) * (1000 / fps) / (qpc.GetMilliTimeF() - lastRealTime)
+					ffmpeg.PreFrame()
 
-					eta := int((p.RunningTime - timeOffset) / 1000 / speed)
+					viewport.Push(int(settings.Graphics.GetWidth()), int(settings.Graphics.GetHeight()))
+					pushFrame()
+					viewport.Pop()
 
-					etaText := util.FormatSeconds(eta)
+					if !inRange {
+						ffmpeg.DiscardFrame()
+						fbo.Unbind()
 
-					if settings.Recording.ShowFFmpegLogs {
-						fmt.Println()
+						return
 					}
 
-					log.Println(fmt.Sprintf("Progress: %d%%, Speed: %.2fx, ETA: %s", progress, speed, etaText))
+					ffmpeg.MakeFrame()
 
-					lastProgress = progress
+					fbo.Unbind()
 
-					lastCount = count
-					lastRealTime = qpc.GetMilliTimeF()
-				}
-			})
+					count++
+
+					timeOffset := p.GetTimeOffset()
+					progress = int(math.Round((timeOffset - rangeStart) / (rangeEnd - rangeStart) * 100))
+
+					if (preciseProgress || progress%5 == 0) && lastProgress != progress {
+						speed := float64(count-lastCount) * (1000 / fps) / (qpc.GetMilliTimeF() - lastRealTime)
+
+						eta := int((rangeEnd - timeOffset) / 1000 / speed)
+
+						etaText := util.FormatSeconds(eta)
+
+						if settings.Recording.ShowFFmpegLogs {
+							fmt.Println()
+						}
+
+						log.Println(fmt.Sprintf("Progress: %d%%, Speed: %.2fx, ETA: %s", progress, speed, etaText))
+
+						if progressListener != nil {
+							progressListener(progress, speed, eta)
+						}
+
+						lastProgress = progress
+
+						lastCount = count
+						lastRealTime = qpc.GetMilliTimeF()
+					}
+				})
+			}
 
 			deltaSumF -= fpsDelta
 		}
 	}
 
-	goroutines.CallMain(func() {
+	callMain(func() {
 		ffmpeg.StopFFmpeg()
 	})
+
+	// judgements of the whole map are known only to the last segment
+	if segmentIndex == segmentCount {
+		p.ExportJudgements(ffmpeg.GetOutputPath())
+	}
 }
 
 func mainLoopSS() {
@@ -850,18 +891,26 @@ func pushFrame() {
 		lastSamples = int(settings.Graphics.MSAA)
 	}
 
-	if lastSamples > 0 {
+	// layers are drawn to their own framebuffers, the last one stays bound after drawing
+	useScreenFBO := lastSamples > 0 && !(settings.RECORD && settings.Recording.IsLayered())
+
+	if useScreenFBO {
 		screenFBO.Bind()
 	}
 
-	gl.ClearColor(0, 0, 0, 1)
+	if settings.RECORD && settings.Recording.IsTransparent() {
+		gl.ClearColor(0, 0, 0, 0)
+	} else {
+		gl.ClearColor(0, 0, 0, 1)
+	}
+
 	gl.Clear(gl.COLOR_BUFFER_BIT)
 
 	if player != nil {
 		player.Draw(0)
 	}
 
-	if lastSamples > 0 {
+	if useScreenFBO {
 		screenFBO.Unbind()
 	}
 
@@ -871,6 +920,153 @@ func pushFrame() {
 	profiler.EndGroup()
 }
 
+// loadReplay parses the replay file and returns md5 of the played beatmap along with mods used and whether it's an osu!taiko play
+// removeReplayDrawnPositionMods removes mods which danser can't judge from replay's mods, with a warning
+func removeReplayDrawnPositionMods(mods []rplpa.ModInfo) []rplpa.ModInfo {
+	if !difficulty2.HasDrawnPositionMods(mods) {
+		return mods
+	}
+
+	log.Println("WARNING: Magnetised, Repel and Wiggle move objects only on screen in danser, while osu!lazer judges hits against moved objects. These mods are removed from the replay, objects are drawn and judged at their original positions, so hits and misses may differ from osu!lazer.")
+
+	return difficulty2.RemoveDrawnPositionMods(mods)
+}
+
+func loadReplay(path string) (md5 string, mods difficulty2.Modifier, modsNew []rplpa.ModInfo, taiko bool) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		panic(err)
+	}
+
+	rp, err := rplpa.ParseReplay(bytes)
+	if err != nil {
+		panic(err)
+	}
+
+	if rp.PlayMode != rplpa.OSU && rp.PlayMode != rplpa.TAIKO {
+		panic("Modes other than osu!standard and osu!taiko are not supported")
+	}
+
+	taiko = rp.PlayMode == rplpa.TAIKO
+
+	if rp.ReplayData == nil || len(rp.ReplayData) < 2 {
+		panic("Replay is missing input data")
+	}
+
+	mods = difficulty2.ModifierFromLegacy(rp.Mods)
+
+	if rp.ScoreInfo != nil && rp.ScoreInfo.Mods != nil && len(rp.ScoreInfo.Mods) > 0 {
+		modsNew = make([]rplpa.ModInfo, 0, len(rp.ScoreInfo.Mods))
+
+		for _, mod := range rp.ScoreInfo.Mods {
+			modsNew = append(modsNew, *mod)
+		}
+	}
+
+	modsNew = removeReplayDrawnPositionMods(modsNew)
+
+	if rp.OsuVersion >= 30000000 { // Lazer is 1000 years in the future
+		mods = mods.With(difficulty2.Lazer)
+
+		if modsNew != nil {
+			modsNew = append(modsNew, rplpa.ModInfo{Acronym: "LZ"})
+		}
+	}
+
+	return rp.BeatmapMD5, mods, modsNew, taiko
+}
+
+func findBeatmap(beatmaps []*beatmap.BeatMap, id int64, md5, query string, randomMatch bool, artist, title, difficulty, creator string) *beatmap.BeatMap {
+	if id > -1 {
+		for _, b := range beatmaps {
+			if b.ID == id {
+				return b
+			}
+		}
+
+		return nil
+	}
+
+	if md5 != "" {
+		for _, b := range beatmaps {
+			if strings.EqualFold(b.MD5, md5) {
+				return b
+			}
+		}
+
+		return nil
+	}
+
+	if query != "" {
+		q, err := beatmap.ParseQuery(query)
+		if err != nil {
+			panic(err)
+		}
+
+		database.CheckStatusFilter(q)
+
+		var matches []*beatmap.BeatMap
+
+		for _, b := range beatmaps {
+			// osu!taiko maps are picked only when asked for with a mode filter
+			if (b.Mode == 0 || q.HasMode()) && q.Matches(b) {
+				matches = append(matches, b)
+			}
+		}
+
+		log.Println(fmt.Sprintf("Found %d beatmaps matching the query", len(matches)))
+
+		if len(matches) == 0 {
+			return nil
+		}
+
+		if randomMatch {
+			return matches[rand.Intn(len(matches))]
+		}
+
+		return matches[0]
+	}
+
+	// Metadata flags can't select a game mode, so they match only osu!standard maps
+	beatmaps = slices.DeleteFunc(slices.Clone(beatmaps), func(b *beatmap.BeatMap) bool {
+		return b.Mode != 0
+	})
+
+	for _, b := range beatmaps {
+		if (artist == "" || strings.EqualFold(artist, b.Artist)) &&
+			(title == "" || strings.EqualFold(title, b.Name)) &&
+			(difficulty == "" || strings.EqualFold(difficulty, b.Difficulty)) &&
+			(creator == "" || strings.EqualFold(creator, b.Creator)) {
+			return b
+		}
+	}
+
+	log.Println("Beatmap with exact parameters not found, searching partially...")
+
+	for _, b := range beatmaps {
+		if (artist == "" || strings.Contains(strings.ToLower(b.Artist), strings.ToLower(artist))) &&
+			(title == "" || strings.Contains(strings.ToLower(b.Name), strings.ToLower(title))) &&
+			(difficulty == "" || strings.Contains(strings.ToLower(b.Difficulty), strings.ToLower(difficulty))) &&
+			(creator == "" || strings.Contains(strings.ToLower(b.Creator), strings.ToLower(creator))) {
+			return b
+		}
+	}
+
+	return nil
+}
+
+// overrideRecordSettings forces settings that in-app variables depend on while recording
+func overrideRecordSettings() {
+	//HACK: some in-app variables depend on these settings so we force them here
+	settings.Graphics.VSync = false
+	settings.Graphics.ShowFPS = false
+	settings.DEBUG = false
+	settings.Graphics.Fullscreen = false
+	settings.Graphics.WindowWidth = int64(settings.Recording.FrameWidth)
+	settings.Graphics.WindowHeight = int64(settings.Recording.FrameHeight)
+	settings.Playfield.LeadInTime = 0
+}
+
 func checkForUpdates() {
 	status, url, err := utils.CheckForUpdate()
 
@@ -905,7 +1101,7 @@ func Run() {
 
 	goroutines.SetCrashHandler(closeHandler)
 
-	platform.StartLogging("danser")
+	platform.StartLogging(getLogName())
 
 	platform.DisableQuickEdit()
 
@@ -5,6 +5,7 @@ import (
 	"github.com/wieku/danser-go/app/skin"
 	"github.com/wieku/danser-go/framework/bass"
 	"github.com/wieku/danser-go/framework/math/mutils"
+	"slices"
 	"strconv"
 	"strings"
 	"unicode"
@@ -35,30 +36,64 @@ func AddListener(function func(sampleSet int, hitsoundIndex, index int, volume f
 	listeners = append(listeners, function)
 }
 
+type hitsoundListener struct {
+	id       int
+	function func(sampleSet, additionSet, hitsound, index int)
+}
+
+var hitsoundListeners = make([]hitsoundListener, 0)
+var lastHitsoundListener int
+
+// AddHitsoundListener registers a function called on each hitsound playback, before it's split into separate samples.
+// Returns an id used to remove the listener with RemoveHitsoundListener.
+func AddHitsoundListener(function func(sampleSet, additionSet, hitsound, index int)) int {
+	lastHitsoundListener++
+
+	hitsoundListeners = append(hitsoundListeners, hitsoundListener{
+		id:       lastHitsoundListener,
+		function: function,
+	})
+
+	return lastHitsoundListener
+}
+
+// RemoveHitsoundListener removes the listener with given id returned by AddHitsoundListener
+func RemoveHitsoundListener(id int) {
+	hitsoundListeners = slices.DeleteFunc(hitsoundListeners, func(l hitsoundListener) bool {
+		return l.id == id
+	})
+}
+
+// ResetListeners removes all registered listeners
+func ResetListeners() {
+	listeners = listeners[:0]
+	hitsoundListeners = hitsoundListeners[:0]
+}
+
 func LoadSamples() {
-	Samples[0][0] = LoadSample("normal-hitnormal")
-	Samples[0][1] = LoadSample("normal-hitwhistle")
-	Samples[0][2] = LoadSample("normal-hitfinish")
-	Samples[0][3] = LoadSample("normal-hitclap")
-	Samples[0][4] = LoadSample("normal-slidertick")
-	Samples[0][5] = LoadSample("normal-sliderslide")
-	Samples[0][6] = LoadSample("normal-sliderwhistle")
-
-	Samples[1][0] = LoadSample("soft-hitnormal")
-	Samples[1][1] = LoadSample("soft-hitwhistle")
-	Samples[1][2] = LoadSample("soft-hitfinish")
-	Samples[1][3] = LoadSample("soft-hitclap")
-	Samples[1][4] = LoadSample("soft-slidertick")
-	Samples[1][5] = LoadSample("soft-sliderslide")
-	Samples[1][6] = LoadSample("soft-sliderwhistle")
-
-	Samples[2][0] = LoadSample("drum-hitnormal")
-	Samples[2][1] = LoadSample("drum-hitwhistle")
-	Samples[2][2] = LoadSample("drum-hitfinish")
-	Samples[2][3] = LoadSample("drum-hitclap")
-	Samples[2][4] = LoadSample("drum-slidertick")
-	Samples[2][5] = LoadSample("drum-sliderslide")
-	Samples[2][6] = LoadSample("drum-sliderwhistle")
+	Samples[0][0] = LoadGameplaySample("normal-hitnormal")
+	Samples[0][1] = LoadGameplaySample("normal-hitwhistle")
+	Samples[0][2] = LoadGameplaySample("normal-hitfinish")
+	Samples[0][3] = LoadGameplaySample("normal-hitclap")
+	Samples[0][4] = LoadGameplaySample("normal-slidertick")
+	Samples[0][5] = LoadGameplaySample("normal-sliderslide")
+	Samples[0][6] = LoadGameplaySample("normal-sliderwhistle")
+
+	Samples[1][0] = LoadGameplaySample("soft-hitnormal")
+	Samples[1][1] = LoadGameplaySample("soft-hitwhistle")
+	Samples[1][2] = LoadGameplaySample("soft-hitfinish")
+	Samples[1][3] = LoadGameplaySample("soft-hitclap")
+	Samples[1][4] = LoadGameplaySample("soft-slidertick")
+	Samples[1][5] = LoadGameplaySample("soft-sliderslide")
+	Samples[1][6] = LoadGameplaySample("soft-sliderwhistle")
+
+	Samples[2][0] = LoadGameplaySample("drum-hitnormal")
+	Samples[2][1] = LoadGameplaySample("drum-hitwhistle")
+	Samples[2][2] = LoadGameplaySample("drum-hitfinish")
+	Samples[2][3] = LoadGameplaySample("drum-hitclap")
+	Samples[2][4] = LoadGameplaySample("drum-slidertick")
+	Samples[2][5] = LoadGameplaySample("drum-sliderslide")
+	Samples[2][6] = LoadGameplaySample("drum-sliderwhistle")
 }
 
 func PlaySample(sampleSet, additionSet, hitsound, index int, volume float64, objNum int64, xPos float64) {
@@ -66,6 +101,10 @@ func PlaySample(sampleSet, additionSet, hitsound, index int, volume float64, obj
 		additionSet = sampleSet
 	}
 
+	for _, l := range hitsoundListeners {
+		l.function(sampleSet, additionSet, hitsound, index)
+	}
+
 	volume = max(volume, 0.08)
 
 	// Play normal
@@ -202,6 +241,8 @@ func PlaySliderTick(sampleSet, index int, volume float64, objNum int64, xPos flo
 }
 
 func LoadBeatmapSamples(fMap map[string]string) {
+	MapSamples = [3][7]map[int]*bass.Sample{}
+
 	splitBeforeDigit := func(name string) []string {
 		for i, r := range name {
 			if unicode.IsDigit(r) {
@@ -250,7 +291,12 @@ func LoadBeatmapSamples(fMap map[string]string) {
 				MapSamples[setID-1][hitSoundID-1] = make(map[int]*bass.Sample)
 			}
 
-			MapSamples[setID-1][hitSoundID-1][hitSoundIndex] = bass.NewSample(fName)
+			sample := bass.NewSample(fName)
+			if sample != nil {
+				sample.SetStem(bass.StemHitsounds)
+			}
+
+			MapSamples[setID-1][hitSoundID-1][hitSoundIndex] = sample
 		}
 	}
 }
@@ -259,6 +305,16 @@ func LoadSample(name string) *bass.Sample {
 	return skin.GetSample(name)
 }
 
+// LoadGameplaySample loads a skin sample played by hit objects, it's mixed into the hitsounds stem
+func LoadGameplaySample(name string) *bass.Sample {
+	sample := LoadSample(name)
+	if sample != nil {
+		sample.SetStem(bass.StemHitsounds)
+	}
+
+	return sample
+}
+
 func PlayFailSound() {
 	sample := LoadSample("failsound")
 	if sample != nil {
@@ -0,0 +1,376 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/audio"
+	"github.com/wieku/danser-go/app/beatmap"
+	difficulty2 "github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/database"
+	"github.com/wieku/danser-go/app/ffmpeg"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/skin"
+	"github.com/wieku/danser-go/app/states"
+	"github.com/wieku/danser-go/build"
+	"github.com/wieku/danser-go/framework/bass"
+	"github.com/wieku/danser-go/framework/goroutines"
+	"github.com/wieku/danser-go/framework/util"
+	"github.com/wieku/rplpa"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// renderJob describes a single recording. Replay overrides MD5, ID and Mods.
+type renderJob struct {
+	Replay   string `json:"replay"`
+	MD5      string `json:"md5"`
+	ID       int64  `json:"id"`
+	Settings string `json:"settings"`
+	SPatch   string `json:"sPatch"`
+	Mods     string `json:"mods"`
+	Skin     string `json:"skin"`
+	Out      string `json:"out"`
+}
+
+func (job *renderJob) getName() string {
+	switch {
+	case job.Replay != "":
+		return job.Replay
+	case job.MD5 != "":
+		return job.MD5
+	default:
+		return strconv.FormatInt(job.ID, 10)
+	}
+}
+
+// fillDefaults fills empty fields with values from defaults
+func (job *renderJob) fillDefaults(defaults *renderJob) {
+	if job.Settings == "" {
+		job.Settings = defaults.Settings
+	}
+
+	if job.SPatch == "" {
+		job.SPatch = defaults.SPatch
+	}
+
+	if job.Mods == "" {
+		job.Mods = defaults.Mods
+	}
+
+	if strings.TrimSpace(job.Skin) == "" {
+		job.Skin = defaults.Skin
+	}
+}
+
+func (job *renderJob) validate() error {
+	if job.Replay == "" && job.MD5 == "" && job.ID <= 0 {
+		return errors.New("replay or beatmap not specified")
+	}
+
+	if job.Settings == "credentials" || job.Settings == "launcher" {
+		return fmt.Errorf("settings name \"%s\" is forbidden", job.Settings)
+	}
+
+	if !settings.Exists(job.Settings) {
+		return fmt.Errorf("settings \"%s\" don't exist", job.Settings)
+	}
+
+	if strings.TrimSpace(job.SPatch) != "" && !json.Valid([]byte(job.SPatch)) {
+		return errors.New("settings patch is not a valid JSON")
+	}
+
+	return nil
+}
+
+type jobResult struct {
+	job      *renderJob
+	output   string
+	files    []string
+	err      error
+	duration time.Duration
+}
+
+var batchJobs []*renderJob
+
+var runner *jobRunner
+
+// loadBatchJobs reads the list of jobs, empty fields are filled from defaults
+func loadBatchJobs(path string, defaults *renderJob) []*renderJob {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to read batch file: %s", err))
+	}
+
+	var jobs []*renderJob
+
+	if err = json.Unmarshal(data, &jobs); err != nil {
+		panic(fmt.Sprintf("Failed to parse batch file: %s", err))
+	}
+
+	if len(jobs) == 0 {
+		panic("Batch file doesn't contain any jobs")
+	}
+
+	timestamp := time.Now().Format("2006-01-02_15-04-05")
+
+	for i, job := range jobs {
+		if job == nil {
+			panic(fmt.Sprintf("Job %d is empty", i+1))
+		}
+
+		job.fillDefaults(defaults)
+
+		if err = job.validate(); err != nil {
+			panic(fmt.Sprintf("Job %d: %s", i+1, err))
+		}
+
+		if job.Out == "" {
+			if job.Replay != "" {
+				job.Out = strings.TrimSuffix(filepath.Base(job.Replay), filepath.Ext(job.Replay))
+			} else {
+				job.Out = fmt.Sprintf("danser_%s_%d", timestamp, i+1)
+			}
+		}
+	}
+
+	return jobs
+}
+
+// jobRunner records jobs one after another, reusing database, window, skin and BASS between them
+type jobRunner struct {
+	beatmaps   []*beatmap.BeatMap
+	quickstart bool
+
+	skinName         string
+	fallbackSkinName string
+}
+
+func newJobRunner(beatmaps []*beatmap.BeatMap, quickstart bool) *jobRunner {
+	return &jobRunner{
+		beatmaps:   beatmaps,
+		quickstart: quickstart,
+	}
+}
+
+func (runner *jobRunner) runBatch(jobs []*renderJob) {
+	results := make([]*jobResult, 0, len(jobs))
+
+	for i, job := range jobs {
+		log.Println(fmt.Sprintf("Batch: Starting job %d/%d: %s", i+1, len(jobs), job.getName()))
+
+		result := runner.run(job)
+
+		if result.err != nil {
+			log.Println(fmt.Sprintf("Batch: Job %d/%d failed: %s", i+1, len(jobs), result.err))
+		}
+
+		results = append(results, result)
+	}
+
+	database.Close()
+
+	succeeded := 0
+
+	for _, result := range results {
+		if result.err == nil {
+			succeeded++
+		}
+	}
+
+	log.Println(fmt.Sprintf("Batch: Finished, %d of %d jobs succeeded:", succeeded, len(results)))
+
+	for i, result := range results {
+		duration := util.FormatSeconds(int(result.duration.Seconds()))
+
+		if result.err != nil {
+			log.Println(fmt.Sprintf("Batch: [%d/%d] FAILED (%s) %s: %s", i+1, len(results), duration, result.job.getName(), result.err))
+		} else {
+			log.Println(fmt.Sprintf("Batch: [%d/%d] OK (%s) %s: %s", i+1, len(results), duration, result.job.getName(), result.output))
+		}
+	}
+}
+
+// run records a single job. Panics are recovered and returned as job's error, so one broken job doesn't stop the queue.
+func (runner *jobRunner) run(job *renderJob) (result *jobResult) {
+	result = &jobResult{job: job}
+
+	startTime := time.Now()
+
+	defer func() {
+		if err := recover(); err != nil {
+			result.err = fmt.Errorf("%v", err)
+
+			for _, s := range goroutines.GetStackTrace(4) {
+				log.Println(s)
+			}
+
+			// ffmpeg processes would be left running with nothing to write to them
+			ffmpeg.Abort()
+		}
+
+		result.duration = time.Since(startTime)
+
+		runner.cleanup()
+	}()
+
+	callMain(func() {
+		runner.setup(job)
+	})
+
+	mainLoopRecord()
+
+	result.output = ffmpeg.GetResultPath()
+	result.files = ffmpeg.GetResultFiles()
+
+	return
+}
+
+// callMain runs f on main thread like goroutines.CallMain, but a panic is passed back to the calling goroutine,
+// so a failed job is recovered by run instead of taking down the whole batch
+func callMain(f func()) {
+	var err any
+
+	goroutines.CallMain(func() {
+		defer func() {
+			if err = recover(); err != nil {
+				for _, s := range goroutines.GetStackTrace(4) {
+					log.Println(s)
+				}
+			}
+		}()
+
+		f()
+	})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// setup loads job's settings and beatmap and creates the player, has to be called from main thread
+func (runner *jobRunner) setup(job *renderJob) {
+	settings.LoadSettings(job.Settings)
+
+	settings.JsonPatch = job.SPatch
+	settings.LoadPatch()
+
+	log.Println("Current config:", settings.GetCompressedString())
+
+	if strings.TrimSpace(job.Skin) != "" {
+		settings.Skin.CurrentSkin = job.Skin
+	}
+
+	if runner.quickstart {
+		settings.SKIP = true
+		settings.Playfield.LeadInTime = 0
+		settings.Playfield.LeadInHold = 0
+	}
+
+	overrideRecordSettings()
+
+	runner.checkSkin()
+
+	audio.LoadSamples()
+
+	id, md5 := job.ID, job.MD5
+	if id <= 0 {
+		id = -1
+	}
+
+	modsParsed := difficulty2.ParseMods(job.Mods)
+	var modsNew []rplpa.ModInfo = nil
+
+	settings.KNOCKOUT = false
+	settings.KNOCKOUTREPLAYS = nil
+	settings.REPLAY = ""
+	settings.TAIKO = false
+
+	if job.Replay != "" {
+		md5, modsParsed, modsNew, settings.TAIKO = loadReplay(job.Replay)
+		id = -1
+
+		settings.KNOCKOUT = true
+		settings.REPLAY = job.Replay
+	}
+
+	if modsNew != nil {
+		tempDiff := difficulty2.NewDifficulty(1, 1, 1, 1)
+		tempDiff.SetMods2(modsNew)
+		modsParsed = tempDiff.Mods
+	}
+
+	if !modsParsed.Compatible() {
+		panic("Incompatible mods selected!")
+	}
+
+	found := findBeatmap(runner.beatmaps, id, md5, "", false, "", "", "", "")
+	if found == nil {
+		panic("Beatmap not found")
+	}
+
+	if found.Mode == 1 {
+		settings.TAIKO = true
+	}
+
+	found.UpdatePlayStats()
+	database.UpdatePlayStats(found)
+
+	beatMap := found.Copy()
+
+	allowDA := false
+
+	if !settings.KNOCKOUT && modsParsed.Active(difficulty2.Autoplay) {
+		settings.KNOCKOUT = true
+		settings.Knockout.MaxPlayers = 0
+		allowDA = true
+	}
+
+	if (!settings.KNOCKOUT || allowDA) && modsNew == nil {
+		modsNew = modsParsed.ConvertToModInfoList()
+	}
+
+	if modsNew != nil {
+		beatMap.Diff.SetMods2(modsNew)
+	} else {
+		beatMap.Diff.SetMods(modsParsed)
+	}
+
+	beatmap.ParseTimingPointsAndPauses(beatMap)
+	beatmap.ParseObjects(beatMap, false, true)
+	beatMap.LoadCustomSamples()
+
+	win.SetTitle("danser " + build.VERSION + " - " + beatMap.Artist + " - " + beatMap.Name + " [" + beatMap.Difficulty + "]")
+
+	output = job.Out
+
+	player = states.NewPlayer(beatMap)
+}
+
+// checkSkin drops skin caches if the job uses a different skin than the previous one
+func (runner *jobRunner) checkSkin() {
+	if runner.skinName == settings.Skin.CurrentSkin && runner.fallbackSkinName == settings.Skin.FallbackSkin {
+		return
+	}
+
+	skin.Reset()
+
+	runner.skinName = settings.Skin.CurrentSkin
+	runner.fallbackSkinName = settings.Skin.FallbackSkin
+}
+
+// cleanup removes state left by the previous player
+func (runner *jobRunner) cleanup() {
+	if player != nil {
+		player.Dispose()
+		player = nil
+	}
+
+	audio.StopSliderLoops()
+	audio.ResetListeners()
+	bass.StopLoops()
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/wieku/danser-go/app/audio"
 	"github.com/wieku/danser-go/app/beatmap/difficulty"
 	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/lazer"
 	"github.com/wieku/danser-go/app/settings"
 	"github.com/wieku/danser-go/framework/files"
 	"math"
@@ -65,6 +66,8 @@ type BeatMap struct {
 
 	LocalOffset int
 
+	Status RankedStatus
+
 	pathCache *files.FileMap
 
 	stackCalcCache map[int64]bool
@@ -94,11 +97,52 @@ func (beatMap *BeatMap) Reset() {
 	}
 }
 
+// Rewind resets objects that are still visible at given time, so playback can be simulated again from that point.
+// Has to be called from the main thread because slider bodies are recreated.
+func (beatMap *BeatMap) Rewind(time float64) {
+	untouched := make(map[objects.IHitObject]bool, len(beatMap.Queue))
+	for _, o := range beatMap.Queue {
+		untouched[o] = true
+	}
+
+	beatMap.Queue = make([]objects.IHitObject, 0, len(beatMap.HitObjects))
+	beatMap.processed = make([]objects.IHitObject, 0)
+
+	for _, o := range beatMap.HitObjects {
+		if time >= o.GetEndTime()+difficulty.HitFadeOut+float64(beatMap.Diff.Hit50) {
+			continue
+		}
+
+		if !untouched[o] {
+			o.SetDifficulty(beatMap.Diff)
+		}
+
+		beatMap.Queue = append(beatMap.Queue, o)
+	}
+
+	beatMap.Timings.Update(time)
+}
+
 func (beatMap *BeatMap) Clear() {
 	beatMap.HitObjects = make([]objects.IHitObject, 0)
 	beatMap.Timings.Clear()
 }
 
+// Copy returns a copy of beatmap's metadata without timing points and objects, so the same beatmap can be loaded more than once
+func (beatMap *BeatMap) Copy() *BeatMap {
+	bMap := *beatMap
+
+	bMap.Diff = beatMap.Diff.Clone()
+	bMap.Timings = objects.NewTimings()
+	bMap.HitObjects = nil
+	bMap.Pauses = nil
+	bMap.Queue = nil
+	bMap.processed = nil
+	bMap.stackCalcCache = make(map[int64]bool)
+
+	return &bMap
+}
+
 func (beatMap *BeatMap) Update(time float64) {
 	beatMap.Timings.Update(time)
 
@@ -142,6 +186,26 @@ func (beatMap *BeatMap) Update(time float64) {
 	}
 }
 
+// setupRateChange passes object times to beatmap's difficulty, so Wind Up, Wind Down and Adaptive Speed can calculate the rate
+func (beatMap *BeatMap) setupRateChange() {
+	if len(beatMap.HitObjects) == 0 {
+		return
+	}
+
+	hitTimes := make([]float64, 0, len(beatMap.HitObjects))
+	lastEnd := 0.0
+
+	for _, o := range beatMap.HitObjects {
+		if o.GetType()&(objects.CIRCLE|objects.SLIDER) > 0 {
+			hitTimes = append(hitTimes, o.GetStartTime())
+		}
+
+		lastEnd = max(lastEnd, o.GetEndTime())
+	}
+
+	beatMap.Diff.SetObjectTimes(hitTimes, beatMap.HitObjects[0].GetStartTime(), lastEnd)
+}
+
 func (beatMap *BeatMap) GetObjectsCopy() []objects.IHitObject {
 	objs := make([]objects.IHitObject, len(beatMap.HitObjects))
 	copy(objs, beatMap.HitObjects)
@@ -217,12 +281,35 @@ func (beatMap *BeatMap) UpdatePlayStats() {
 
 func (beatMap *BeatMap) getPathCache() *files.FileMap {
 	if beatMap.pathCache == nil {
-		beatMap.pathCache, _ = files.NewFileMap(filepath.Join(settings.General.GetSongsDir(), beatMap.Dir))
+		if lazer.IsLazerDir(beatMap.Dir) {
+			beatMap.pathCache, _ = lazer.GetSetFileMap(beatMap.Dir)
+		} else {
+			beatMap.pathCache, _ = files.NewFileMap(filepath.Join(settings.General.GetSongsDir(), beatMap.Dir))
+		}
 	}
 
 	return beatMap.pathCache
 }
 
+// GetPath returns path of a file in beatmap's directory. Files of lazer beatmaps are resolved through lazer's file store.
+func (beatMap *BeatMap) GetPath(name string) string {
+	if lazer.IsLazerDir(beatMap.Dir) {
+		path, _ := beatMap.GetRelatedFile(name)
+		return path
+	}
+
+	return filepath.Join(settings.General.GetSongsDir(), beatMap.Dir, name)
+}
+
+// GetFiles returns all files in beatmap's directory, lowercase names relative to the directory are mapped to absolute paths
+func (beatMap *BeatMap) GetFiles() map[string]string {
+	return beatMap.getPathCache().GetMap()
+}
+
+func (beatMap *BeatMap) GetFilePath() string {
+	return beatMap.GetPath(beatMap.File)
+}
+
 func (beatMap *BeatMap) GetRelatedFile(path string) (string, error) {
 	return beatMap.getPathCache().GetFile(path)
 }
@@ -53,6 +53,8 @@ type Difficulty struct {
 	Hit300 int64
 
 	HPMod           float64
+	ODMod           float64
+	CSMod           float64
 	SpinnerRatio    float64
 	LzSpinnerMinRPS float64
 	LzSpinnerMaxRPS float64
@@ -63,9 +65,16 @@ type Difficulty struct {
 
 	BaseModSpeed float64
 
-	modSettings map[reflect.Type]any
+	modSettings map[Mod]any
 	adjustPitch bool
 
+	timeRamp *TimeRampSettings
+	adaptive *adaptiveSpeed
+
+	hitTimes  []float64
+	rampStart float64
+	rampEnd   float64
+
 	DiffCalcMode bool
 }
 
@@ -86,7 +95,7 @@ func NewDifficulty(hp, cs, od, ar float64) *Difficulty {
 
 	diff.Speed = 1
 
-	diff.modSettings = make(map[reflect.Type]any)
+	diff.modSettings = make(map[Mod]any)
 
 	diff.calculate()
 
@@ -96,7 +105,7 @@ func NewDifficulty(hp, cs, od, ar float64) *Difficulty {
 func (diff *Difficulty) calculate() {
 	diff.hp, diff.cs, diff.od, diff.ar = diff.baseHP, diff.baseCS, diff.baseOD, diff.baseAR
 
-	dS, dOk := diff.modSettings[rfType[DiffAdjustSettings]()].(DiffAdjustSettings)
+	dS, dOk := GetModConfig[DiffAdjustSettings](diff)
 
 	if dOk {
 		diff.ar = dS.ApproachRate
@@ -107,21 +116,28 @@ func (diff *Difficulty) calculate() {
 
 	hpDrain, cs, od, ar := diff.hp, diff.cs, diff.od, diff.ar
 
-	if diff.Mods&HardRock > 0 {
+	if diff.Mods.Active(HardRock) {
 		ar = min(ar*1.4, cMax(dOk, 10, dS.ApproachRate))
 		cs = min(cs*1.3, cMax(dOk, 10, dS.CircleSize))
 		od = min(od*1.4, cMax(dOk, 10, dS.OverallDifficulty))
 		hpDrain = min(hpDrain*1.4, cMax(dOk, 10, dS.DrainRate))
 	}
 
-	if diff.Mods&Easy > 0 {
+	if diff.Mods.Active(Easy) {
 		ar /= 2
 		cs /= 2
 		od /= 2
 		hpDrain /= 2
 	}
 
+	if diff.Mods.Active(Target) { // Target Practice gives more time to read generated circles
+		ar *= 0.5
+		cs *= 1.15
+	}
+
 	diff.HPMod = hpDrain
+	diff.ODMod = od
+	diff.CSMod = cs
 
 	diff.CircleRadiusU = DifficultyRate(cs, 54.4, 32, 9.6)
 	diff.CircleRadius = diff.CircleRadiusU * 1.00041 //some weird allowance osu has
@@ -146,9 +162,9 @@ func (diff *Difficulty) calculate() {
 	diff.LzSpinnerMinRPS = DifficultyRate(od, 90, 150, 225) / 60
 	diff.LzSpinnerMaxRPS = DifficultyRate(od, 250, 380, 430) / 60
 
-	if diff.Mods&DoubleTime > 0 {
+	if diff.Mods.Active(DoubleTime) {
 		diff.BaseModSpeed = 1.5
-	} else if diff.Mods&HalfTime > 0 {
+	} else if diff.Mods.Active(HalfTime) {
 		diff.BaseModSpeed = 0.75
 	} else {
 		diff.BaseModSpeed = 1
@@ -156,7 +172,7 @@ func (diff *Difficulty) calculate() {
 
 	diff.Speed = diff.BaseModSpeed
 
-	if s, ok := diff.modSettings[rfType[SpeedSettings]()].(SpeedSettings); ok {
+	if s, ok := GetModConfig[SpeedSettings](diff); ok {
 		if diff.BaseModSpeed != s.SpeedChange {
 			diff.Speed = s.SpeedChange
 		}
@@ -164,6 +180,25 @@ func (diff *Difficulty) calculate() {
 		diff.adjustPitch = s.AdjustPitch
 	}
 
+	diff.timeRamp = nil
+
+	if s, ok := GetModConfig[TimeRampSettings](diff); ok && diff.Mods.Active(WindUp, WindDown) {
+		diff.timeRamp = &s
+		diff.Speed = s.InitialRate
+		diff.adjustPitch = s.AdjustPitch
+	}
+
+	if s, ok := GetModConfig[AdaptiveSpeedSettings](diff); ok && diff.Mods.Active(AdaptiveSpeed) {
+		if diff.adaptive == nil {
+			diff.adaptive = newAdaptiveSpeed(s.InitialRate)
+		}
+
+		diff.Speed = s.InitialRate
+		diff.adjustPitch = s.AdjustPitch
+	} else {
+		diff.adaptive = nil
+	}
+
 	diff.ARReal = DiffFromRate(diff.GetModifiedTime(diff.PreemptU), 1800, 1200, 450)
 	diff.ODReal = (80 - diff.GetModifiedTime(diff.Hit300U)) / 6 //DiffFromRate(diff.GetModifiedTime(diff.Hit300U), 80, 50, 20)
 }
@@ -179,180 +214,97 @@ func cMax(cond bool, a, b float64) float64 {
 func (diff *Difficulty) SetMods(mods Modifier) {
 	clear(diff.modSettings)
 
-	diff.Mods = 0
+	diff.Mods = None
 
-	diff.AddMod(mods)
+	diff.AddMod(mods.List()...)
 }
 
-func (diff *Difficulty) AddMod(mods Modifier) {
-	diff.Mods |= mods
-
-	if mods.Active(HalfTime | Daycore) {
-		diff.modSettings[rfType[SpeedSettings]()] = NewSpeedSettings(0.75, mods.Active(Daycore))
-	} else if mods.Active(DoubleTime | Nightcore) {
-		diff.modSettings[rfType[SpeedSettings]()] = NewSpeedSettings(1.5, mods.Active(Nightcore))
-	}
-
-	if mods.Active(Easy) {
-		diff.modSettings[rfType[EasySettings]()] = NewEasySettings()
-	}
-
-	if mods.Active(Flashlight) {
-		diff.modSettings[rfType[FlashlightSettings]()] = NewFlashlightSettings()
-	}
+// AddMod activates mods along with mods they imply, their settings are reset to defaults
+func (diff *Difficulty) AddMod(mods ...Mod) {
+	added := NewModifier(mods...).withImplied()
 
-	if mods.Active(DifficultyAdjust) {
-		diff.modSettings[rfType[DiffAdjustSettings]()] = NewDiffAdjustSettings(diff.baseAR, diff.baseCS, diff.baseHP, diff.baseOD)
-	}
+	diff.Mods = diff.Mods.Union(added)
 
-	if mods.Active(Classic) {
-		diff.modSettings[rfType[ClassicSettings]()] = NewClassicSettings()
-	}
+	added.forEach(func(def *ModDefinition) {
+		if def.HasSettings() {
+			diff.modSettings[def.Mod] = def.newSettings(diff, nil)
+		}
+	})
 
-	if mods.Active(Mirror) {
-		diff.modSettings[rfType[MirrorSettings]()] = NewMirrorSettings()
-	}
+	diff.removeImpliedSettings()
 
 	diff.calculate()
 }
 
-func (diff *Difficulty) RemoveMod(mods Modifier) {
-	if mods.Active(Nightcore) {
-		mods |= DoubleTime
-	}
-
-	if mods.Active(Daycore) {
-		mods |= HalfTime
-	}
-
-	if mods.Active(Perfect) {
-		mods |= SuddenDeath
-	}
+// RemoveMod deactivates mods along with mods they imply
+func (diff *Difficulty) RemoveMod(mods ...Mod) {
+	removed := NewModifier(mods...).withImplied()
 
-	diff.Mods &= ^mods
+	diff.Mods = diff.Mods.Difference(removed)
 
-	if mods.Active(HalfTime | Daycore | DoubleTime | Nightcore) {
-		delete(diff.modSettings, rfType[SpeedSettings]())
-	}
-
-	if mods.Active(Easy) {
-		delete(diff.modSettings, rfType[EasySettings]())
-	}
-
-	if mods.Active(Flashlight) {
-		delete(diff.modSettings, rfType[FlashlightSettings]())
-	}
-
-	if mods.Active(DifficultyAdjust) {
-		delete(diff.modSettings, rfType[DiffAdjustSettings]())
-	}
-
-	if mods.Active(Classic) {
-		delete(diff.modSettings, rfType[ClassicSettings]())
-	}
-
-	if mods.Active(Mirror) {
-		delete(diff.modSettings, rfType[MirrorSettings]())
-	}
+	removed.forEach(func(def *ModDefinition) {
+		delete(diff.modSettings, def.Mod)
+	})
 
 	diff.calculate()
 }
 
+// removeImpliedSettings drops settings of mods implied by other active mods, settings of mods like Nightcore take precedence
+func (diff *Difficulty) removeImpliedSettings() {
+	diff.Mods.forEach(func(def *ModDefinition) {
+		if diff.Mods.Intersects(GetImpliedBy(def.Mod)) {
+			delete(diff.modSettings, def.Mod)
+		}
+	})
+}
+
 func (diff *Difficulty) SetMods2(mods []rplpa.ModInfo) {
 	clear(diff.modSettings)
 
 	mComp := None
 
 	for _, mInfo := range mods {
-		mod := ParseFromAcronym(mInfo.Acronym)
-
-		if mod != None {
-			mComp |= mod
-
-			if mod.Active(HalfTime | Daycore) {
-				diff.modSettings[rfType[SpeedSettings]()] = parseConfig(NewSpeedSettings(0.75, mod.Active(Daycore)), mInfo.Settings)
-			} else if mod.Active(DoubleTime | Nightcore) {
-				diff.modSettings[rfType[SpeedSettings]()] = parseConfig(NewSpeedSettings(1.5, mod.Active(Nightcore)), mInfo.Settings)
-			}
-
-			if mod.Active(Easy) {
-				diff.modSettings[rfType[EasySettings]()] = parseConfig(NewEasySettings(), mInfo.Settings)
-			}
-
-			if mod.Active(Flashlight) {
-				diff.modSettings[rfType[FlashlightSettings]()] = parseConfig(NewFlashlightSettings(), mInfo.Settings)
-			}
-
-			if mod.Active(DifficultyAdjust) {
-				diff.modSettings[rfType[DiffAdjustSettings]()] = parseConfig(NewDiffAdjustSettings(diff.baseAR, diff.baseCS, diff.baseHP, diff.baseOD), mInfo.Settings)
-			}
-
-			if mod.Active(Classic) {
-				diff.modSettings[rfType[ClassicSettings]()] = parseConfig(NewClassicSettings(), mInfo.Settings)
-			}
-
-			if mod.Active(Mirror) {
-				diff.modSettings[rfType[MirrorSettings]()] = parseConfig(NewMirrorSettings(), mInfo.Settings)
-			}
+		def := GetModByAcronym(mInfo.Acronym)
+		if def == nil {
+			continue
 		}
-	}
 
-	if mComp.Active(Nightcore) {
-		mComp |= DoubleTime
-	}
+		mComp = mComp.With(def.Mod)
 
-	if mComp.Active(Perfect) {
-		mComp |= SuddenDeath
+		if def.HasSettings() {
+			diff.modSettings[def.Mod] = def.newSettings(diff, mInfo.Settings)
+		}
 	}
 
-	if mComp.Active(Daycore) {
-		mComp |= HalfTime
-	}
+	diff.Mods = mComp.withImplied()
 
-	diff.Mods = mComp
+	diff.removeImpliedSettings()
 
 	diff.calculate()
 }
 
 func (diff *Difficulty) ExportMods2() (mods []rplpa.ModInfo) {
-	mComp := diff.Mods
-
-	if mComp.Active(Nightcore) {
-		mComp &= ^DoubleTime
-	}
-
-	if mComp.Active(Daycore) {
-		mComp &= ^HalfTime
-	}
-
-	if mComp.Active(Perfect) {
-		mComp &= ^SuddenDeath
-	}
+	diff.Mods.withoutImplied().forEach(func(def *ModDefinition) {
+		var modSettings map[string]any
 
-	for i := 0; i <= 62; i++ {
-		mTest := Modifier(1 << i)
-
-		if mComp.Active(mTest) {
-			var modSettings map[string]any
-
-			if cType, ok := modConfigs[mTest]; ok {
-				if cConf, exists := diff.modSettings[cType]; exists {
-					modSettings = exportConfig(cConf)
-				}
+		if def.HasSettings() {
+			if cConf, exists := diff.modSettings[def.Mod]; exists {
+				modSettings = exportConfig(cConf)
 			}
-
-			mods = append(mods, rplpa.ModInfo{
-				Acronym:  modsString[i],
-				Settings: modSettings,
-			})
 		}
-	}
+
+		mods = append(mods, rplpa.ModInfo{
+			Acronym:  def.GetLazerAcronym(),
+			Settings: modSettings,
+		})
+	})
 
 	return
 }
 
-func (diff *Difficulty) CheckModActive(mods Modifier) bool {
-	return diff.Mods&mods > 0
+// CheckModActive returns true if any of given mods is active
+func (diff *Difficulty) CheckModActive(mods ...Mod) bool {
+	return diff.Mods.Active(mods...)
 }
 
 func (diff *Difficulty) GetModifiedTime(time float64) float64 {
@@ -376,11 +328,11 @@ func (diff *Difficulty) GetPitch() float64 {
 }
 
 func (diff *Difficulty) GetRadius() float32 {
-	if diff.Mods&Lazer > 0 {
+	if diff.Mods.Active(Lazer) {
 		return float32(diff.CircleRadiusL)
 	}
 
-	if diff.Mods&Relax2 > 0 {
+	if diff.Mods.Active(Relax2) {
 		return 100
 	}
 
@@ -388,9 +340,11 @@ func (diff *Difficulty) GetRadius() float32 {
 }
 
 func (diff *Difficulty) GetScoreMultiplier() float64 {
-	baseMultiplier := (diff.Mods & (^(HalfTime | Daycore | DoubleTime | Nightcore | Flashlight))).GetScoreMultiplier()
+	baseMultiplier := diff.Mods.Without(HalfTime, Daycore, DoubleTime, Nightcore, Flashlight).GetScoreMultiplier()
 
-	if diff.Mods.Active(Lazer) {
+	if diff.Mods.Active(WindUp, WindDown, AdaptiveSpeed) {
+		// Multiplier of mods changing the rate during playback doesn't depend on the speed
+	} else if diff.Mods.Active(Lazer) {
 		value := math.Floor(diff.Speed*10)/10 - 1
 
 		if diff.Speed >= 1 {
@@ -430,7 +384,7 @@ func (diff *Difficulty) GetScoreMultiplier() float64 {
 }
 
 func (diff *Difficulty) GetModStringFull() []string {
-	mods := (diff.Mods & (^DifficultyAdjust)).StringFull()
+	mods := diff.Mods.Without(DifficultyAdjust).StringFull()
 
 	var daBase []string
 
@@ -455,11 +409,11 @@ func (diff *Difficulty) GetModStringFull() []string {
 	}
 
 	if cSpeed := diff.Speed; math.Abs(cSpeed-diff.BaseModSpeed) > 0.001 {
-		toCheck := []Modifier{DoubleTime, Nightcore, HalfTime, Daycore}
+		toCheck := []Mod{DoubleTime, Nightcore, HalfTime, Daycore, WindUp, WindDown, AdaptiveSpeed}
 		anyFound := false
 
 		for _, ms := range toCheck {
-			if i := slices.Index(mods, ms.StringFull()[0]); i != -1 {
+			if i := slices.Index(mods, ms.Definition().Name); i != -1 {
 				anyFound = true
 				mods[i] += ":" + mutils.FormatWOZeros(cSpeed, 2) + "x"
 			}
@@ -482,7 +436,7 @@ func (diff *Difficulty) GetModStringMasked() string {
 }
 
 func (diff *Difficulty) getModStringBase(mod Modifier) string {
-	mods := (mod & ^Mirror).String()
+	mods := mod.Without(Mirror).String()
 
 	if ar := diff.GetAR(); math.Abs(ar-diff.GetBaseAR()) > 0.001 {
 		mods += fmt.Sprintf("AR%s", mutils.FormatWOZeros(ar, 2))
@@ -593,11 +547,16 @@ func (diff *Difficulty) SetARCustom(ar float64) {
 
 func (diff *Difficulty) Clone() *Difficulty {
 	diff2 := *diff
-	diff2.modSettings = make(map[reflect.Type]any)
+	diff2.modSettings = make(map[Mod]any)
 	for k, v := range diff.modSettings {
 		diff2.modSettings[k] = v
 	}
 
+	if diff.adaptive != nil {
+		aSpeed := *diff.adaptive
+		diff2.adaptive = &aSpeed
+	}
+
 	return &diff2
 }
 
@@ -1,306 +1,340 @@
 package difficulty
 
-import "github.com/wieku/rplpa"
+import (
+	"github.com/wieku/rplpa"
+	"math"
+	"slices"
+)
 
-type Modifier int64
+// Mod identifies a single registered mod by its danser acronym
+type Mod string
 
 const (
-	None   = Modifier(iota)
-	NoFail = Modifier(1 << (iota - uint(1)))
-	Easy
-	TouchDevice
-	Hidden
-	HardRock
-	SuddenDeath
-	DoubleTime
-	Relax
-	HalfTime
-	Nightcore // Only set along with DoubleTime. i.e: NC only gives 576
-	Flashlight
-	Autoplay
-	SpunOut
-	Relax2  // Autopilot
-	Perfect // Only set along with SuddenDeath. i.e: PF only gives 16416
-	Key4
-	Key5
-	Key6
-	Key7
-	Key8
-	FadeIn
-	Random
-	Cinema
-	Target
-	Key9
-	KeyCoop
-	Key1
-	Key3
-	Key2
-	ScoreV2
-	LastMod
-	Daycore
-	Lazer
-	Classic
-	DifficultyAdjust
-	Mirror
-	Traceable
-
-	// DifficultyAdjustMask is outdated, use GetDiffMaskedMods instead
-	DifficultyAdjustMask    = HardRock | Easy | DoubleTime | Nightcore | HalfTime | Daycore | Flashlight | Relax
-	difficultyAdjustMaskNew = HardRock | Easy | DoubleTime | HalfTime | Flashlight | Relax | Relax2 | TouchDevice
+	NoFail            = Mod("NF")
+	Easy              = Mod("EZ")
+	TouchDevice       = Mod("TD")
+	Hidden            = Mod("HD")
+	HardRock          = Mod("HR")
+	SuddenDeath       = Mod("SD")
+	DoubleTime        = Mod("DT")
+	Relax             = Mod("RX")
+	HalfTime          = Mod("HT")
+	Nightcore         = Mod("NC") // Always active along with DoubleTime
+	Flashlight        = Mod("FL")
+	Autoplay          = Mod("AT")
+	SpunOut           = Mod("SO")
+	Relax2            = Mod("AP") // Autopilot
+	Perfect           = Mod("PF") // Always active along with SuddenDeath
+	Key4              = Mod("K4")
+	Key5              = Mod("K5")
+	Key6              = Mod("K6")
+	Key7              = Mod("K7")
+	Key8              = Mod("K8")
+	FadeIn            = Mod("FI")
+	Random            = Mod("RN")
+	Cinema            = Mod("CN")
+	Target            = Mod("TG")
+	Key9              = Mod("K9")
+	KeyCoop           = Mod("K0")
+	Key1              = Mod("K1")
+	Key3              = Mod("K3")
+	Key2              = Mod("K2")
+	ScoreV2           = Mod("V2")
+	LastMod           = Mod("LM")
+	Daycore           = Mod("DC")
+	Lazer             = Mod("LZ")
+	Classic           = Mod("CL")
+	DifficultyAdjust  = Mod("DA")
+	Mirror            = Mod("MR")
+	Traceable         = Mod("TC")
+	WindUp            = Mod("WU")
+	WindDown          = Mod("WD")
+	AdaptiveSpeed     = Mod("AS")
+	StrictTracking    = Mod("ST")
+	AccuracyChallenge = Mod("AC")
+	Alternate         = Mod("AL")
+	SingleTap         = Mod("SG")
+	Transform         = Mod("TR")
+	Wiggle            = Mod("WG")
+	SpinIn            = Mod("SI")
+	Grow              = Mod("GR")
+	Deflate           = Mod("DF")
+	BarrelRoll        = Mod("BR")
+	Depth             = Mod("DP")
+	Magnetised        = Mod("MG")
+	Repel             = Mod("RP")
+	Bubbles           = Mod("BU")
+	NoScope           = Mod("NS")
+	FreezeFrame       = Mod("FR")
 )
 
-// GetDiffMaskedMods should be used instead of DifficultyAdjustMask. In 220930 deployment, HDFL is a separate mod difficulty wise
-func GetDiffMaskedMods(mods Modifier) Modifier {
-	//Probably redundant
-	if mods.Active(Nightcore) {
-		mods = (mods & (^Nightcore)) | DoubleTime
-	}
+// DrawnPositionMods move objects only while they are drawn, hits are still judged at original positions. osu!lazer judges hits
+// against moved objects, so replays with these mods can't be judged correctly.
+var DrawnPositionMods = []Mod{Magnetised, Repel, Wiggle}
 
-	if mods.Active(Daycore) {
-		mods = (mods & (^Daycore)) | HalfTime
-	}
+// HasDrawnPositionMods reports whether any of lazer style mods is one of DrawnPositionMods
+func HasDrawnPositionMods(mods []rplpa.ModInfo) bool {
+	return slices.ContainsFunc(mods, isDrawnPositionMod)
+}
 
-	base := difficultyAdjustMaskNew & mods
+// RemoveDrawnPositionMods returns lazer style mods without DrawnPositionMods
+func RemoveDrawnPositionMods(mods []rplpa.ModInfo) []rplpa.ModInfo {
+	return slices.DeleteFunc(slices.Clone(mods), isDrawnPositionMod)
+}
 
-	if mods&(Hidden|Flashlight) == (Hidden | Flashlight) {
-		base |= Hidden
-	}
+func isDrawnPositionMod(mod rplpa.ModInfo) bool {
+	def := GetModByAcronym(mod.Acronym)
+	return def != nil && slices.Contains(DrawnPositionMods, def.Mod)
+}
 
-	return base
+// Definition returns the registry entry of the mod, nil if it's not registered
+func (mod Mod) Definition() *ModDefinition {
+	return byAcronym[string(mod)]
 }
 
-var modsString = [...]string{
-	"NF",
-	"EZ",
-	"TD",
-	"HD",
-	"HR",
-	"SD",
-	"DT",
-	"RX",
-	"HT",
-	"NC",
-	"FL",
-	"AT", // Auto.
-	"SO",
-	"AP", // Autopilot.
-	"PF",
-	"K4",
-	"K5",
-	"K6",
-	"K7",
-	"K8",
-	"FI",
-	"RN", // Random
-	"CN",
-	"TG",
-	"K9",
-	"K0",
-	"K1",
-	"K3",
-	"K2",
-	"V2",
-	"LM",
-	"DC",
-	"LZ",
-	"CL",
-	"DA",
-	"MR",
-	"TC",
+func (mod Mod) String() string {
+	return string(mod)
 }
 
-var modsStringFull = [...]string{
-	"NoFail",
-	"Easy",
-	"TouchDevice",
-	"Hidden",
-	"HardRock",
-	"SuddenDeath",
-	"DoubleTime",
-	"Relax",
-	"HalfTime",
-	"Nightcore",
-	"Flashlight",
-	"Autoplay",
-	"SpunOut",
-	"Relax2",
-	"Perfect",
-	"Key4",
-	"Key5",
-	"Key6",
-	"Key7",
-	"Key8",
-	"FadeIn",
-	"Random",
-	"Cinema",
-	"Target",
-	"Key9",
-	"KeyCoop",
-	"Key1",
-	"Key3",
-	"Key2",
-	"ScoreV2",
-	"LastMod",
-	"Daycore",
-	"Lazer",
-	"Classic",
-	"DifficultyAdjust",
-	"Mirror",
-	"Traceable",
+// maxMods is the capacity of Modifier, it's not tied to any stored format, so it can be raised freely
+const maxMods = 256
+
+// Modifier is a set of mods. Mods are identified by their position in the registry, which is only valid while danser is running.
+// Legacy and ModifierFromLegacy convert it to stable's replay bitmask, other stored formats should use acronyms.
+type Modifier struct {
+	set [maxMods / 64]uint64
 }
 
-func (mods Modifier) GetScoreMultiplier() float64 {
-	multiplier := 1.0
+var None = Modifier{}
 
-	if mods&NoFail > 0 && mods&ScoreV2 == 0 {
-		multiplier *= 0.5
-	}
+// Masks are built after built-in mods are registered
+var (
+	// DifficultyAdjustMask is outdated, use GetDiffMaskedMods instead
+	DifficultyAdjustMask    Modifier
+	difficultyAdjustMaskNew Modifier
+)
 
-	if mods&Easy > 0 {
-		multiplier *= 0.5
+// NewModifier creates a set of given mods, mods that aren't registered are ignored
+func NewModifier(mods ...Mod) (m Modifier) {
+	for _, mod := range mods {
+		if def := mod.Definition(); def != nil {
+			m.set[def.index/64] |= 1 << (def.index % 64)
+		}
 	}
 
-	if mods&HalfTime > 0 {
-		multiplier *= 0.3
-	}
+	return
+}
 
-	if mods&Hidden > 0 {
-		multiplier *= 1.06
-	}
+// Active returns true if any of given mods is in the set
+func (mods Modifier) Active(mod ...Mod) bool {
+	return mods.Intersects(NewModifier(mod...))
+}
 
-	if mods&HardRock > 0 {
-		if mods&ScoreV2 > 0 {
-			multiplier *= 1.10
-		} else {
-			multiplier *= 1.06
+// Intersects returns true if both sets have any mod in common
+func (mods Modifier) Intersects(other Modifier) bool {
+	for i := range mods.set {
+		if mods.set[i]&other.set[i] != 0 {
+			return true
 		}
 	}
 
-	if mods&DoubleTime > 0 {
-		if mods&ScoreV2 > 0 {
-			multiplier *= 1.20
-		} else {
-			multiplier *= 1.12
-		}
-	}
+	return false
+}
 
-	if mods&Flashlight > 0 {
-		multiplier *= 1.12
-	}
+// Contains returns true if all mods of other are in the set
+func (mods Modifier) Contains(other Modifier) bool {
+	return mods.Intersect(other) == other
+}
 
-	if (mods&Relax | mods&Relax2) > 0 {
-		if mods&Lazer > 0 {
-			multiplier *= 0.1
-		} else {
-			multiplier = 0
-		}
-	}
+func (mods Modifier) IsEmpty() bool {
+	return mods == None
+}
 
-	if mods&SpunOut > 0 {
-		multiplier *= 0.9
-	}
+// With returns the set with given mods added
+func (mods Modifier) With(mod ...Mod) Modifier {
+	return mods.Union(NewModifier(mod...))
+}
 
-	if mods&Classic > 0 {
-		multiplier *= 0.96
-	}
+// Without returns the set with given mods removed
+func (mods Modifier) Without(mod ...Mod) Modifier {
+	return mods.Difference(NewModifier(mod...))
+}
 
-	if mods&DifficultyAdjust > 0 {
-		multiplier *= 0.5
+func (mods Modifier) Union(other Modifier) Modifier {
+	for i := range mods.set {
+		mods.set[i] |= other.set[i]
 	}
 
-	return multiplier
+	return mods
 }
 
-func (mods Modifier) String() (s string) {
-	if mods.Active(Nightcore) {
-		mods &= ^DoubleTime
+func (mods Modifier) Intersect(other Modifier) Modifier {
+	for i := range mods.set {
+		mods.set[i] &= other.set[i]
 	}
 
-	if mods.Active(Daycore) {
-		mods &= ^HalfTime
-	}
+	return mods
+}
 
-	if mods.Active(Perfect) {
-		mods &= ^SuddenDeath
+func (mods Modifier) Difference(other Modifier) Modifier {
+	for i := range mods.set {
+		mods.set[i] &= ^other.set[i]
 	}
 
-	for i := 0; i < len(modsString); i++ {
-		activated := mods&1 == 1
-		if activated {
-			s += modsString[i]
-		}
+	return mods
+}
 
-		mods >>= 1
-	}
+// List returns mods in the set, legacy mods first in stable's bit order
+func (mods Modifier) List() (list []Mod) {
+	mods.forEach(func(def *ModDefinition) {
+		list = append(list, def.Mod)
+	})
 
 	return
 }
 
-func (mods Modifier) StringFull() (s []string) {
+// GetDiffMaskedMods should be used instead of DifficultyAdjustMask. In 220930 deployment, HDFL is a separate mod difficulty wise
+func GetDiffMaskedMods(mods Modifier) Modifier {
+	//Probably redundant
 	if mods.Active(Nightcore) {
-		mods &= ^DoubleTime
+		mods = mods.Without(Nightcore).With(DoubleTime)
 	}
 
 	if mods.Active(Daycore) {
-		mods &= ^HalfTime
+		mods = mods.Without(Daycore).With(HalfTime)
 	}
 
-	if mods.Active(Perfect) {
-		mods &= ^SuddenDeath
+	base := mods.Intersect(difficultyAdjustMaskNew)
+
+	if mods.Contains(NewModifier(Hidden, Flashlight)) {
+		base = base.With(Hidden)
 	}
 
-	return mods.StringFull2()
+	return base
 }
 
-func (mods Modifier) StringFull2() (s []string) {
-	for i := 0; i < len(modsString); i++ {
-		activated := mods&1 == 1
-		if activated {
-			s = append(s, modsStringFull[i])
+func (mods Modifier) GetScoreMultiplier() float64 {
+	multiplier := 1.0
+
+	mods.forEach(func(def *ModDefinition) {
+		if def.ScoreMultiplier != nil {
+			multiplier *= def.ScoreMultiplier(mods)
 		}
+	})
 
-		mods >>= 1
-	}
+	return multiplier
+}
+
+func (mods Modifier) String() (s string) {
+	mods.withoutImplied().forEach(func(def *ModDefinition) {
+		s += string(def.Mod)
+	})
+
+	return
+}
+
+func (mods Modifier) StringFull() (s []string) {
+	return mods.withoutImplied().StringFull2()
+}
+
+func (mods Modifier) StringFull2() (s []string) {
+	mods.forEach(func(def *ModDefinition) {
+		s = append(s, def.Name)
+	})
 
 	return
 }
 
 func ParseFromAcronym(mod string) (m Modifier) {
-	for index, availableMod := range modsString {
-		if availableMod == mod {
-			m = 1 << uint(index)
-			break
-		}
+	if def := GetModByAcronym(mod); def != nil {
+		return NewModifier(def.Mod)
 	}
 
 	return
 }
 
 func (mods Modifier) ConvertToModInfoList() (mi []rplpa.ModInfo) {
-	if mods.Active(Nightcore) {
-		mods &= ^DoubleTime
+	mods.withoutImplied().forEach(func(def *ModDefinition) {
+		mi = append(mi, rplpa.ModInfo{
+			Acronym:  def.GetLazerAcronym(),
+			Settings: make(map[string]any),
+		})
+	})
+
+	return
+}
+
+// AddCustomAdjustments adds Difficulty Adjust with given values (NaN values are skipped) unless mods already contain it.
+// If speed isn't 1 and mods don't change the rate already, Double Time or Half Time with that speed is added as well.
+func AddCustomAdjustments(mods []rplpa.ModInfo, ar, od, cs, hp, speed float64) []rplpa.ModInfo {
+	daMap := make(map[string]any)
+
+	if !math.IsNaN(ar) {
+		daMap["approach_rate"] = ar
 	}
 
-	if mods.Active(Daycore) {
-		mods &= ^HalfTime
+	if !math.IsNaN(od) {
+		daMap["overall_difficulty"] = od
+	}
+
+	if !math.IsNaN(cs) {
+		daMap["circle_size"] = cs
 	}
 
-	if mods.Active(Perfect) {
-		mods &= ^SuddenDeath
+	if !math.IsNaN(hp) {
+		daMap["drain_rate"] = hp
 	}
 
-	for i := 0; i < len(modsString); i++ {
-		if mods&1 == 1 {
-			mi = append(mi, rplpa.ModInfo{
-				Acronym:  modsString[i],
-				Settings: make(map[string]any),
+	// Add DA only if DA hasn't been added already
+	if len(daMap) > 0 && !slices.ContainsFunc(mods, func(info rplpa.ModInfo) bool { return info.Acronym == "DA" }) {
+		mods = append(mods, rplpa.ModInfo{
+			Acronym:  "DA",
+			Settings: daMap,
+		})
+	}
+
+	if math.Abs(speed-1) > 0.001 {
+		skipMods := []string{"HT", "DC", "DT", "NC"}
+
+		found := slices.ContainsFunc(mods, func(info rplpa.ModInfo) bool { return slices.Contains(skipMods, info.Acronym) })
+
+		// Don't modify current mods
+		//if speed >= 1 {
+		//	if i := slices.IndexFunc(mods, func(info rplpa.ModInfo) bool {
+		//		return info.Acronym == "DT" || info.Acronym == "NC"
+		//	}); i != -1 {
+		//		found = true
+		//		mods[i].Settings["speed_change"] = speed
+		//	}
+		//} else {
+		//	if i := slices.IndexFunc(mods, func(info rplpa.ModInfo) bool {
+		//		return info.Acronym == "HT" || info.Acronym == "DC"
+		//	}); i != -1 {
+		//		found = true
+		//		mods[i].Settings["speed_change"] = speed
+		//	}
+		//}
+
+		if !found {
+			mods = slices.DeleteFunc(mods, func(info rplpa.ModInfo) bool {
+				return info.Acronym == "DT" || info.Acronym == "NC" || info.Acronym == "HT" || info.Acronym == "DC"
 			})
-		}
 
-		mods >>= 1
+			acr := "HT"
+			if speed >= 1 {
+				acr = "DT"
+			}
+
+			mods = append(mods, rplpa.ModInfo{
+				Acronym: acr,
+				Settings: map[string]any{
+					"speed_change": speed,
+				},
+			})
+		}
 	}
 
-	return
+	return mods
 }
 
 func ParseMods(mods string) (m Modifier) {
@@ -310,44 +344,20 @@ func ParseMods(mods string) (m Modifier) {
 	}
 
 	for _, mod := range modsSl {
-		m |= ParseFromAcronym(mod)
+		m = m.Union(ParseFromAcronym(mod))
 	}
 
-	if m.Active(Nightcore) {
-		m |= DoubleTime
-	}
-
-	if m.Active(Perfect) {
-		m |= SuddenDeath
-	}
-
-	if m.Active(Daycore) {
-		m |= HalfTime
-	}
-
-	return
-}
-
-func (mods Modifier) Active(mod Modifier) bool {
-	return mods&mod > 0
+	return m.withImplied()
 }
 
 func (mods Modifier) Compatible() bool {
-	if mods == None {
-		return true
-	}
+	compatible := true
 
-	if mods.Active(Target) ||
-		(mods.Active(HardRock) && mods.Active(Easy)) ||
-		(mods.Active(HardRock) && mods.Active(Mirror)) ||
-		(mods.Active(Lazer) && mods.Active(ScoreV2)) ||
-		((mods.Active(Nightcore) || mods.Active(DoubleTime)) && (mods.Active(HalfTime) || mods.Active(Daycore))) ||
-		((mods.Active(Perfect) || mods.Active(SuddenDeath)) && mods.Active(NoFail)) ||
-		(mods.Active(Relax) && mods.Active(Relax2)) ||
-		((mods.Active(Relax) || mods.Active(Relax2)) && (mods.Active(SuddenDeath) || mods.Active(Perfect) || mods.Active(Autoplay) || mods.Active(NoFail))) ||
-		(mods.Active(Relax2) && mods.Active(SpunOut)) {
-		return false
-	}
+	mods.forEach(func(def *ModDefinition) {
+		if mods.Active(def.Incompatible...) || (len(def.Requires) > 0 && !mods.Active(def.Requires...)) {
+			compatible = false
+		}
+	})
 
-	return true
+	return compatible
 }
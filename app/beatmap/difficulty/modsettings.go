@@ -1,27 +1,11 @@
 package difficulty
 
-import "reflect"
-
-var modConfigs map[Modifier]reflect.Type
+import "math/rand"
 
 type modSetting[T any] interface {
 	postLoad() T
 }
 
-func init() {
-	modConfigs = map[Modifier]reflect.Type{
-		HalfTime:         rfType[SpeedSettings](),
-		Daycore:          rfType[SpeedSettings](),
-		DoubleTime:       rfType[SpeedSettings](),
-		Nightcore:        rfType[SpeedSettings](),
-		Easy:             rfType[EasySettings](),
-		Classic:          rfType[ClassicSettings](),
-		Flashlight:       rfType[FlashlightSettings](),
-		DifficultyAdjust: rfType[DiffAdjustSettings](),
-		Mirror:           rfType[MirrorSettings](),
-	}
-}
-
 type SpeedSettings struct {
 	SpeedChange float64 `json:"speed_change"`
 	AdjustPitch bool    `json:"adjust_pitch"`
@@ -137,3 +121,198 @@ func NewMirrorSettings() MirrorSettings {
 func (s MirrorSettings) postLoad() MirrorSettings {
 	return s
 }
+
+type TimeRampSettings struct {
+	InitialRate float64 `json:"initial_rate"`
+	FinalRate   float64 `json:"final_rate"`
+	AdjustPitch bool    `json:"adjust_pitch"`
+}
+
+func NewTimeRampSettings(initialRate, finalRate float64) TimeRampSettings {
+	return TimeRampSettings{
+		InitialRate: initialRate,
+		FinalRate:   finalRate,
+		AdjustPitch: true,
+	}
+}
+
+func (s TimeRampSettings) postLoad() TimeRampSettings {
+	return s
+}
+
+type AdaptiveSpeedSettings struct {
+	InitialRate float64 `json:"initial_rate"`
+	AdjustPitch bool    `json:"adjust_pitch"`
+}
+
+func NewAdaptiveSpeedSettings() AdaptiveSpeedSettings {
+	return AdaptiveSpeedSettings{
+		InitialRate: 1,
+		AdjustPitch: true,
+	}
+}
+
+func (s AdaptiveSpeedSettings) postLoad() AdaptiveSpeedSettings {
+	return s
+}
+
+const (
+	AccuracyStandard      = 0
+	AccuracyMaxAchievable = 1
+)
+
+type AccuracyChallengeSettings struct {
+	MinimumAccuracy   float64 `json:"minimum_accuracy"`
+	AccuracyJudgeMode int     `json:"accuracy_judge_mode"`
+}
+
+func NewAccuracyChallengeSettings() AccuracyChallengeSettings {
+	return AccuracyChallengeSettings{
+		MinimumAccuracy:   0.9,
+		AccuracyJudgeMode: AccuracyStandard,
+	}
+}
+
+func (s AccuracyChallengeSettings) postLoad() AccuracyChallengeSettings {
+	return s
+}
+
+type TargetPracticeSettings struct {
+	Seed int `json:"seed"`
+}
+
+// NewTargetPracticeSettings picks a random seed, the same way lazer does when it's not set
+func NewTargetPracticeSettings() TargetPracticeSettings {
+	return TargetPracticeSettings{
+		Seed: int(rand.Int31()),
+	}
+}
+
+func (s TargetPracticeSettings) postLoad() TargetPracticeSettings {
+	return s
+}
+
+type RandomSettings struct {
+	Seed           int     `json:"seed"`
+	AngleSharpness float64 `json:"angle_sharpness"`
+}
+
+// NewRandomSettings picks a random seed, the same way lazer does when it's not set
+func NewRandomSettings() RandomSettings {
+	return RandomSettings{
+		Seed:           int(rand.Int31()),
+		AngleSharpness: 7,
+	}
+}
+
+func (s RandomSettings) postLoad() RandomSettings {
+	return s
+}
+
+type WiggleSettings struct {
+	Strength float64 `json:"strength"`
+}
+
+func NewWiggleSettings() WiggleSettings {
+	return WiggleSettings{
+		Strength: 1,
+	}
+}
+
+func (s WiggleSettings) postLoad() WiggleSettings {
+	return s
+}
+
+// ScaleSettings are shared by Grow and Deflate, they differ only by the default starting scale
+type ScaleSettings struct {
+	StartScale float64 `json:"start_scale"`
+}
+
+func NewScaleSettings(startScale float64) ScaleSettings {
+	return ScaleSettings{
+		StartScale: startScale,
+	}
+}
+
+func (s ScaleSettings) postLoad() ScaleSettings {
+	return s
+}
+
+const (
+	RotationClockwise        = 0
+	RotationCounterclockwise = 1
+)
+
+type BarrelRollSettings struct {
+	SpinSpeed float64 `json:"spin_speed"`
+	Direction int     `json:"direction"`
+}
+
+func NewBarrelRollSettings() BarrelRollSettings {
+	return BarrelRollSettings{
+		SpinSpeed: 0.5,
+		Direction: RotationClockwise,
+	}
+}
+
+func (s BarrelRollSettings) postLoad() BarrelRollSettings {
+	return s
+}
+
+type DepthSettings struct {
+	MaxDepth            float64 `json:"max_depth"`
+	ShowApproachCircles bool    `json:"show_approach_circles"`
+}
+
+func NewDepthSettings() DepthSettings {
+	return DepthSettings{
+		MaxDepth:            100,
+		ShowApproachCircles: true,
+	}
+}
+
+func (s DepthSettings) postLoad() DepthSettings {
+	return s
+}
+
+type MagnetisedSettings struct {
+	AttractionStrength float64 `json:"attraction_strength"`
+}
+
+func NewMagnetisedSettings() MagnetisedSettings {
+	return MagnetisedSettings{
+		AttractionStrength: 0.5,
+	}
+}
+
+func (s MagnetisedSettings) postLoad() MagnetisedSettings {
+	return s
+}
+
+type RepelSettings struct {
+	RepulsionStrength float64 `json:"repulsion_strength"`
+}
+
+func NewRepelSettings() RepelSettings {
+	return RepelSettings{
+		RepulsionStrength: 0.5,
+	}
+}
+
+func (s RepelSettings) postLoad() RepelSettings {
+	return s
+}
+
+type NoScopeSettings struct {
+	HiddenComboCount int `json:"hidden_combo_count"`
+}
+
+func NewNoScopeSettings() NoScopeSettings {
+	return NoScopeSettings{
+		HiddenComboCount: 10,
+	}
+}
+
+func (s NoScopeSettings) postLoad() NoScopeSettings {
+	return s
+}
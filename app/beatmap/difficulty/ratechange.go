@@ -0,0 +1,167 @@
+package difficulty
+
+import (
+	"github.com/wieku/danser-go/framework/math/mutils"
+	"math"
+	"slices"
+)
+
+const (
+	// rampProgress is the part of the beatmap after which Wind Up and Wind Down reach their final rate
+	rampProgress = 0.75
+
+	asMinRate       = 0.4
+	asMaxRate       = 2.5
+	asMinRateChange = 0.9
+	asMaxRateChange = 1.11
+	asMissChange    = 0.95
+	asRecentCount   = 8
+	asHalfTime      = 50.0
+)
+
+// adaptiveSpeed holds the state of Adaptive Speed, its rate follows how early or late the player hits objects.
+// Fields are plain values, so the state can be copied to save and restore it.
+type adaptiveSpeed struct {
+	rate       float64
+	targetRate float64
+	lastTime   float64
+
+	recentRates [asRecentCount]float64
+}
+
+func newAdaptiveSpeed(initialRate float64) *adaptiveSpeed {
+	aSpeed := &adaptiveSpeed{
+		rate:       initialRate,
+		targetRate: initialRate,
+		lastTime:   math.Inf(-1),
+	}
+
+	for i := range aSpeed.recentRates {
+		aSpeed.recentRates[i] = initialRate
+	}
+
+	return aSpeed
+}
+
+func (aSpeed *adaptiveSpeed) update(time float64) {
+	if time > aSpeed.lastTime && !math.IsInf(aSpeed.lastTime, -1) {
+		aSpeed.rate = mutils.Lerp(aSpeed.rate, aSpeed.targetRate, 1-math.Pow(0.5, (time-aSpeed.lastTime)/asHalfTime))
+		aSpeed.rate = mutils.Clamp(aSpeed.rate, asMinRate, asMaxRate)
+	}
+
+	aSpeed.lastTime = time
+}
+
+func (aSpeed *adaptiveSpeed) addResult(relativeChange float64) {
+	copy(aSpeed.recentRates[:], aSpeed.recentRates[1:])
+	aSpeed.recentRates[asRecentCount-1] = mutils.Clamp(relativeChange*aSpeed.rate, asMinRate, asMaxRate)
+
+	// Values rising or falling consistently mean that the player keeps hitting too early or too late
+	consistency := 0.0
+	average := aSpeed.recentRates[0]
+
+	for i := 1; i < asRecentCount; i++ {
+		consistency += mutils.Signum(aSpeed.recentRates[i] - aSpeed.recentRates[i-1])
+		average += aSpeed.recentRates[i]
+	}
+
+	average /= asRecentCount
+
+	aSpeed.targetRate = mutils.Lerp(aSpeed.targetRate, average, mutils.Abs(consistency)/(asRecentCount-1))
+}
+
+// SetObjectTimes passes times of beatmap's objects to mods that change the rate during playback.
+// hitTimes are start times of objects with hit windows (circles and sliders).
+func (diff *Difficulty) SetObjectTimes(hitTimes []float64, firstStart, lastEnd float64) {
+	diff.hitTimes = slices.Compact(slices.Sorted(slices.Values(hitTimes)))
+
+	diff.rampStart = firstStart
+	diff.rampEnd = firstStart + rampProgress*(lastEnd-firstStart)
+}
+
+// GetSpeedAt returns playback rate at given time. It differs from GetSpeed only when Wind Up, Wind Down or Adaptive Speed are active.
+func (diff *Difficulty) GetSpeedAt(time float64) float64 {
+	if diff.adaptive != nil {
+		return diff.adaptive.rate
+	}
+
+	if diff.timeRamp != nil {
+		amount := (time - diff.rampStart) / max(1, diff.rampEnd-diff.rampStart)
+		rate := diff.timeRamp.InitialRate + (diff.timeRamp.FinalRate-diff.timeRamp.InitialRate)*mutils.Clamp(amount, 0, 1)
+
+		return math.Round(rate*100) / 100
+	}
+
+	return diff.Speed
+}
+
+// GetModifiedTimeAt converts duration in beatmap time to real time using the rate at given time
+func (diff *Difficulty) GetModifiedTimeAt(time, duration float64) float64 {
+	return duration / diff.GetSpeedAt(time)
+}
+
+// GetPitchAt is GetPitch for time-varying rates
+func (diff *Difficulty) GetPitchAt(time float64) float64 {
+	if speed := diff.GetSpeedAt(time); diff.adjustPitch && speed != 1 {
+		return speed
+	}
+
+	return 1
+}
+
+// HasVariableRate returns true if playback rate changes during the play
+func (diff *Difficulty) HasVariableRate() bool {
+	return diff.timeRamp != nil || diff.adaptive != nil
+}
+
+// UpdateRate advances Adaptive Speed's rate towards its target
+func (diff *Difficulty) UpdateRate(time float64) {
+	if diff.adaptive != nil {
+		diff.adaptive.update(time)
+	}
+}
+
+// AddRateResult feeds Adaptive Speed with a judgement of an object that starts at objectTime.
+// hitTime is ignored if the object was missed.
+func (diff *Difficulty) AddRateResult(objectTime, hitTime float64, hit bool) {
+	if diff.adaptive == nil {
+		return
+	}
+
+	index, _ := slices.BinarySearch(diff.hitTimes, objectTime)
+	if index == 0 {
+		return
+	}
+
+	change := asMissChange
+
+	if hit {
+		prevTime := diff.hitTimes[index-1]
+		change = mutils.Clamp((objectTime-prevTime)/(hitTime-prevTime), asMinRateChange, asMaxRateChange)
+	}
+
+	diff.adaptive.addResult(change)
+}
+
+// LinkRate makes this difficulty follow the rate of another one, used to drive music with player's Adaptive Speed
+func (diff *Difficulty) LinkRate(source *Difficulty) {
+	if diff.adaptive != nil && source.adaptive != nil {
+		diff.adaptive = source.adaptive
+	}
+}
+
+// SaveRateState returns a copy of the state of Adaptive Speed, nil if it's not active
+func (diff *Difficulty) SaveRateState() any {
+	if diff.adaptive == nil {
+		return nil
+	}
+
+	return *diff.adaptive
+}
+
+// RestoreRateState restores the state saved with SaveRateState
+func (diff *Difficulty) RestoreRateState(state any) {
+	if aState, ok := state.(adaptiveSpeed); ok && diff.adaptive != nil {
+		*diff.adaptive = aState
+	}
+}
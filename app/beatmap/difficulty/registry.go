@@ -0,0 +1,738 @@
+package difficulty
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ModCategory groups mods the same way lazer's mod select does
+type ModCategory int
+
+const (
+	CategoryReduction = ModCategory(iota)
+	CategoryIncrease
+	CategorySpecial
+	CategoryConversion
+	CategoryChallenge
+	CategoryFun
+	CategorySystem // Not selectable by the user, e.g. Autoplay or mania keys
+)
+
+var categoryNames = [...]string{
+	"Reduction",
+	"Increase",
+	"Special",
+	"Conversion",
+	"Challenge",
+	"Fun",
+	"System",
+}
+
+func (c ModCategory) String() string {
+	return categoryNames[c]
+}
+
+// HookStage is a step of beatmap loading at which mods can modify hit objects
+type HookStage int
+
+const (
+	PreTimingHooks  = HookStage(iota) // After combos are calculated, but before objects receive their timing
+	PostTimingHooks                   // After objects receive their timing
+
+	hookStageCount
+)
+
+// ModHook modifies hit objects of a loaded beatmap. beatMap is a *beatmap.BeatMap, which can't be referenced here
+// without an import cycle, so hooks are attached by the beatmap package with SetHook.
+type ModHook func(beatMap any, diffCalcOnly bool)
+
+// ModDefinition describes everything danser needs to know about a mod
+type ModDefinition struct {
+	// Mod is the stable/danser acronym of the mod, it's the key of the registry
+	Mod Mod
+
+	LazerAcronym string // Acronym used by lazer if it's different from stable/danser one
+	Name         string
+
+	Category ModCategory
+
+	// LegacyBit is the mod's bit in stable's replay bitmask, 0 if the mod doesn't exist in stable
+	LegacyBit uint32
+
+	// Implies is a mod that is always set together with this one, e.g. DoubleTime for Nightcore
+	Implies Mod
+
+	Incompatible []Mod
+	Requires     []Mod
+
+	// ScoreMultiplier receives all active mods, nil means the mod doesn't change the score
+	ScoreMultiplier func(mods Modifier) float64
+
+	settings *modSettingsFactory
+	hooks    [hookStageCount]ModHook
+
+	index int
+}
+
+type modSettingsFactory struct {
+	sType  reflect.Type
+	create func(diff *Difficulty, config map[string]any) any
+}
+
+// settingsOf creates a settings factory for mod, config is applied on top of defaults returned by ctor
+func settingsOf[T modSetting[T]](ctor func(diff *Difficulty) T) *modSettingsFactory {
+	return &modSettingsFactory{
+		sType: rfType[T](),
+		create: func(diff *Difficulty, config map[string]any) any {
+			if config == nil {
+				return ctor(diff)
+			}
+
+			return parseConfig(ctor(diff), config)
+		},
+	}
+}
+
+func (def *ModDefinition) GetLazerAcronym() string {
+	if def.LazerAcronym != "" {
+		return def.LazerAcronym
+	}
+
+	return string(def.Mod)
+}
+
+func (def *ModDefinition) HasSettings() bool {
+	return def.settings != nil
+}
+
+func (def *ModDefinition) newSettings(diff *Difficulty, config map[string]any) any {
+	return def.settings.create(diff, config)
+}
+
+// SetHook attaches a hook that runs at given stage of beatmap loading while the mod is active
+func (def *ModDefinition) SetHook(stage HookStage, hook ModHook) {
+	def.hooks[stage] = hook
+}
+
+var (
+	definitions []*ModDefinition
+	byAcronym   = make(map[string]*ModDefinition)
+
+	// ordered has legacy mods first in stable's bit order, so mod strings look the same as in stable, followed by the rest in registration order
+	ordered []*ModDefinition
+)
+
+// RegisterMod adds a mod to the registry. It panics if mod or its acronyms are already registered, or the registry is full
+func RegisterMod(def *ModDefinition) {
+	if def.Mod == "" {
+		panic("Mod " + def.Name + " has no acronym")
+	}
+
+	if len(definitions) == maxMods {
+		panic("Can't register mod " + def.Name + ", registry is full")
+	}
+
+	acronyms := []string{string(def.Mod)}
+	if def.LazerAcronym != "" {
+		acronyms = append(acronyms, def.LazerAcronym)
+	}
+
+	for _, acr := range acronyms {
+		if _, ok := byAcronym[strings.ToUpper(acr)]; ok {
+			panic("Acronym " + acr + " is already registered")
+		}
+	}
+
+	def.index = len(definitions)
+
+	definitions = append(definitions, def)
+
+	ordered = append(ordered, def)
+
+	slices.SortStableFunc(ordered, func(a, b *ModDefinition) int {
+		if (a.LegacyBit == 0) != (b.LegacyBit == 0) {
+			if a.LegacyBit == 0 {
+				return 1
+			}
+
+			return -1
+		}
+
+		return cmp.Compare(a.LegacyBit, b.LegacyBit)
+	})
+
+	for _, acr := range acronyms {
+		byAcronym[strings.ToUpper(acr)] = def
+	}
+}
+
+// GetModByAcronym returns the definition of a mod with given stable/danser or lazer acronym, case-insensitive
+func GetModByAcronym(acronym string) *ModDefinition {
+	return byAcronym[strings.ToUpper(acronym)]
+}
+
+// GetMods returns all registered mods in registration order
+func GetMods() []*ModDefinition {
+	return definitions
+}
+
+// GetModsInCategory returns registered mods of given category in registration order
+func GetModsInCategory(category ModCategory) (defs []*ModDefinition) {
+	for _, def := range definitions {
+		if def.Category == category {
+			defs = append(defs, def)
+		}
+	}
+
+	return
+}
+
+// GetIncompatible returns all mods incompatible with given mod, regardless of which side declared the incompatibility
+func GetIncompatible(mod Mod) (incompat Modifier) {
+	for _, def := range definitions {
+		if def.Mod == mod {
+			incompat = incompat.With(def.Incompatible...)
+		} else if slices.Contains(def.Incompatible, mod) {
+			incompat = incompat.With(def.Mod)
+		}
+	}
+
+	return incompat.Without(mod)
+}
+
+// GetImpliedBy returns all mods that imply given mod, e.g. Nightcore for DoubleTime
+func GetImpliedBy(mod Mod) (implying Modifier) {
+	for _, def := range definitions {
+		if def.Implies == mod {
+			implying = implying.With(def.Mod)
+		}
+	}
+
+	return
+}
+
+// ModifierFromLegacy converts stable's replay bitmask, bits unknown to stable are dropped
+func ModifierFromLegacy(mods uint32) (m Modifier) {
+	for _, def := range definitions {
+		if def.LegacyBit != 0 && mods&def.LegacyBit != 0 {
+			m = m.With(def.Mod)
+		}
+	}
+
+	return
+}
+
+// Legacy converts mods to stable's replay bitmask, mods that don't exist in stable are dropped
+func (mods Modifier) Legacy() (legacy uint32) {
+	mods.forEach(func(def *ModDefinition) {
+		legacy |= def.LegacyBit
+	})
+
+	return
+}
+
+// IsLegacy reports whether all mods can be stored in stable's replay bitmask
+func (mods Modifier) IsLegacy() bool {
+	legacy := true
+
+	mods.forEach(func(def *ModDefinition) {
+		legacy = legacy && def.LegacyBit != 0
+	})
+
+	return legacy
+}
+
+// RunHooks runs hooks of given stage of all mods in the set, legacy mods first
+func (mods Modifier) RunHooks(stage HookStage, beatMap any, diffCalcOnly bool) {
+	mods.forEach(func(def *ModDefinition) {
+		if hook := def.hooks[stage]; hook != nil {
+			hook(beatMap, diffCalcOnly)
+		}
+	})
+}
+
+// forEach calls f for every mod in mods, legacy mods first in stable's bit order
+func (mods Modifier) forEach(f func(def *ModDefinition)) {
+	for _, def := range ordered {
+		if mods.set[def.index/64]&(1<<(def.index%64)) != 0 {
+			f(def)
+		}
+	}
+}
+
+// withoutImplied removes mods implied by other active mods, e.g. DoubleTime when Nightcore is active
+func (mods Modifier) withoutImplied() Modifier {
+	ret := mods
+
+	mods.forEach(func(def *ModDefinition) {
+		if def.Implies != "" {
+			ret = ret.Without(def.Implies)
+		}
+	})
+
+	return ret
+}
+
+// withImplied adds mods implied by active mods
+func (mods Modifier) withImplied() Modifier {
+	ret := mods
+
+	mods.forEach(func(def *ModDefinition) {
+		if def.Implies != "" {
+			ret = ret.With(def.Implies)
+		}
+	})
+
+	return ret
+}
+
+func multiplier(value float64) func(mods Modifier) float64 {
+	return func(_ Modifier) float64 {
+		return value
+	}
+}
+
+func init() {
+	lazerOnly := []Mod{Lazer}
+	speedAdjust := []Mod{HalfTime, Daycore, DoubleTime, Nightcore}
+	rateChanging := []Mod{WindUp, WindDown, AdaptiveSpeed}
+	hiddenLike := []Mod{Hidden, Traceable}
+
+	relaxMultiplier := func(mods Modifier) float64 {
+		if mods.Active(Lazer) {
+			return 0.1
+		}
+
+		return 0
+	}
+
+	// Reduction
+
+	RegisterMod(&ModDefinition{
+		Mod:             Easy,
+		Name:            "Easy",
+		Category:        CategoryReduction,
+		LegacyBit:       1 << 1,
+		Incompatible:    []Mod{HardRock},
+		ScoreMultiplier: multiplier(0.5),
+		settings:        settingsOf(func(_ *Difficulty) EasySettings { return NewEasySettings() }),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          NoFail,
+		Name:         "NoFail",
+		Category:     CategoryReduction,
+		LegacyBit:    1 << 0,
+		Incompatible: []Mod{SuddenDeath, Perfect, Relax, Relax2},
+		ScoreMultiplier: func(mods Modifier) float64 {
+			if mods.Active(ScoreV2) {
+				return 1
+			}
+
+			return 0.5
+		},
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:             HalfTime,
+		Name:            "HalfTime",
+		Category:        CategoryReduction,
+		LegacyBit:       1 << 8,
+		Incompatible:    slices.Concat(rateChanging, []Mod{DoubleTime, Nightcore}),
+		ScoreMultiplier: multiplier(0.3),
+		settings:        settingsOf(func(_ *Difficulty) SpeedSettings { return NewSpeedSettings(0.75, false) }),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          Daycore,
+		Name:         "Daycore",
+		Category:     CategoryReduction,
+		Implies:      HalfTime,
+		Incompatible: slices.Concat(rateChanging, []Mod{DoubleTime, Nightcore}),
+		settings:     settingsOf(func(_ *Difficulty) SpeedSettings { return NewSpeedSettings(0.75, true) }),
+	})
+
+	// Increase
+
+	RegisterMod(&ModDefinition{
+		Mod:          HardRock,
+		Name:         "HardRock",
+		Category:     CategoryIncrease,
+		LegacyBit:    1 << 4,
+		Incompatible: []Mod{Easy, Mirror},
+		ScoreMultiplier: func(mods Modifier) float64 {
+			if mods.Active(ScoreV2) {
+				return 1.10
+			}
+
+			return 1.06
+		},
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          SuddenDeath,
+		Name:         "SuddenDeath",
+		Category:     CategoryIncrease,
+		LegacyBit:    1 << 5,
+		Incompatible: []Mod{NoFail, Relax, Relax2},
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          Perfect,
+		Name:         "Perfect",
+		Category:     CategoryIncrease,
+		LegacyBit:    1 << 14,
+		Implies:      SuddenDeath,
+		Incompatible: []Mod{NoFail, Relax, Relax2},
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          DoubleTime,
+		Name:         "DoubleTime",
+		Category:     CategoryIncrease,
+		LegacyBit:    1 << 6,
+		Incompatible: slices.Concat(rateChanging, []Mod{HalfTime, Daycore}),
+		ScoreMultiplier: func(mods Modifier) float64 {
+			if mods.Active(ScoreV2) {
+				return 1.20
+			}
+
+			return 1.12
+		},
+		settings: settingsOf(func(_ *Difficulty) SpeedSettings { return NewSpeedSettings(1.5, false) }),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          Nightcore,
+		Name:         "Nightcore",
+		Category:     CategoryIncrease,
+		LegacyBit:    1 << 9,
+		Implies:      DoubleTime,
+		Incompatible: slices.Concat(rateChanging, []Mod{HalfTime, Daycore}),
+		settings:     settingsOf(func(_ *Difficulty) SpeedSettings { return NewSpeedSettings(1.5, true) }),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:             Hidden,
+		Name:            "Hidden",
+		Category:        CategoryIncrease,
+		LegacyBit:       1 << 3,
+		Incompatible:    []Mod{Traceable},
+		ScoreMultiplier: multiplier(1.06),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:             Flashlight,
+		Name:            "Flashlight",
+		Category:        CategoryIncrease,
+		LegacyBit:       1 << 10,
+		ScoreMultiplier: multiplier(1.12),
+		settings:        settingsOf(func(_ *Difficulty) FlashlightSettings { return NewFlashlightSettings() }),
+	})
+
+	// Special
+
+	RegisterMod(&ModDefinition{
+		Mod:             Relax,
+		Name:            "Relax",
+		Category:        CategorySpecial,
+		LegacyBit:       1 << 7,
+		Incompatible:    []Mod{Relax2, Autoplay},
+		ScoreMultiplier: relaxMultiplier,
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:             Relax2,
+		Name:            "Relax2",
+		Category:        CategorySpecial,
+		LegacyBit:       1 << 13,
+		Incompatible:    []Mod{Relax, SpunOut, Autoplay},
+		ScoreMultiplier: relaxMultiplier,
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:             SpunOut,
+		Name:            "SpunOut",
+		Category:        CategorySpecial,
+		LegacyBit:       1 << 12,
+		ScoreMultiplier: multiplier(0.9),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:             DifficultyAdjust,
+		Name:            "DifficultyAdjust",
+		Category:        CategorySpecial,
+		ScoreMultiplier: multiplier(0.5),
+		settings: settingsOf(func(diff *Difficulty) DiffAdjustSettings {
+			return NewDiffAdjustSettings(diff.baseAR, diff.baseCS, diff.baseHP, diff.baseOD)
+		}),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:      Mirror,
+		Name:     "Mirror",
+		Category: CategorySpecial,
+		settings: settingsOf(func(_ *Difficulty) MirrorSettings { return NewMirrorSettings() }),
+	})
+
+	// Conversion
+
+	RegisterMod(&ModDefinition{
+		Mod:          ScoreV2,
+		Name:         "ScoreV2",
+		Category:     CategoryConversion,
+		LegacyBit:    1 << 29,
+		Incompatible: []Mod{Lazer, Classic},
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:      Lazer,
+		Name:     "Lazer",
+		Category: CategoryConversion,
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:             Classic,
+		Name:            "Classic",
+		Category:        CategoryConversion,
+		Requires:        lazerOnly,
+		ScoreMultiplier: multiplier(0.96),
+		settings:        settingsOf(func(_ *Difficulty) ClassicSettings { return NewClassicSettings() }),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:             Target,
+		LazerAcronym:    "TP",
+		Name:            "Target",
+		Category:        CategoryConversion,
+		LegacyBit:       1 << 23,
+		Incompatible:    []Mod{Random, SpunOut, StrictTracking, SuddenDeath, Perfect},
+		Requires:        lazerOnly,
+		ScoreMultiplier: multiplier(0.1),
+		settings:        settingsOf(func(_ *Difficulty) TargetPracticeSettings { return NewTargetPracticeSettings() }),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          Random,
+		LazerAcronym: "RD",
+		Name:         "Random",
+		Category:     CategoryConversion,
+		LegacyBit:    1 << 21,
+		Requires:     lazerOnly,
+		settings:     settingsOf(func(_ *Difficulty) RandomSettings { return NewRandomSettings() }),
+	})
+
+	// Challenge
+
+	RegisterMod(&ModDefinition{
+		Mod:          Alternate,
+		Name:         "Alternate",
+		Category:     CategoryChallenge,
+		Incompatible: []Mod{SingleTap, Relax, Autoplay},
+		Requires:     lazerOnly,
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          SingleTap,
+		Name:         "SingleTap",
+		Category:     CategoryChallenge,
+		Incompatible: []Mod{Relax, Autoplay},
+		Requires:     lazerOnly,
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          StrictTracking,
+		Name:         "StrictTracking",
+		Category:     CategoryChallenge,
+		Incompatible: []Mod{Classic},
+		Requires:     lazerOnly,
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          AccuracyChallenge,
+		Name:         "AccuracyChallenge",
+		Category:     CategoryChallenge,
+		Incompatible: []Mod{NoFail, Easy, Relax, Relax2, Autoplay},
+		Requires:     lazerOnly,
+		settings:     settingsOf(func(_ *Difficulty) AccuracyChallengeSettings { return NewAccuracyChallengeSettings() }),
+	})
+
+	// Fun
+
+	RegisterMod(&ModDefinition{
+		Mod:      Traceable,
+		Name:     "Traceable",
+		Category: CategoryFun,
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:             WindUp,
+		Name:            "WindUp",
+		Category:        CategoryFun,
+		Incompatible:    slices.Concat(speedAdjust, []Mod{WindDown, AdaptiveSpeed}),
+		Requires:        lazerOnly,
+		ScoreMultiplier: multiplier(0.5),
+		settings:        settingsOf(func(_ *Difficulty) TimeRampSettings { return NewTimeRampSettings(1, 1.5) }),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:             WindDown,
+		Name:            "WindDown",
+		Category:        CategoryFun,
+		Incompatible:    slices.Concat(speedAdjust, []Mod{AdaptiveSpeed}),
+		Requires:        lazerOnly,
+		ScoreMultiplier: multiplier(0.5),
+		settings:        settingsOf(func(_ *Difficulty) TimeRampSettings { return NewTimeRampSettings(1, 0.75) }),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:             AdaptiveSpeed,
+		Name:            "AdaptiveSpeed",
+		Category:        CategoryFun,
+		Incompatible:    slices.Concat(speedAdjust, []Mod{Autoplay}),
+		Requires:        lazerOnly,
+		ScoreMultiplier: multiplier(0.5),
+		settings:        settingsOf(func(_ *Difficulty) AdaptiveSpeedSettings { return NewAdaptiveSpeedSettings() }),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          Transform,
+		Name:         "Transform",
+		Category:     CategoryFun,
+		Incompatible: []Mod{Wiggle, Magnetised, Repel, Depth},
+		Requires:     lazerOnly,
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          Wiggle,
+		Name:         "Wiggle",
+		Category:     CategoryFun,
+		Incompatible: []Mod{Magnetised, Repel},
+		Requires:     lazerOnly,
+		settings:     settingsOf(func(_ *Difficulty) WiggleSettings { return NewWiggleSettings() }),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          SpinIn,
+		Name:         "SpinIn",
+		Category:     CategoryFun,
+		Incompatible: slices.Concat(hiddenLike, []Mod{Grow, Deflate, Depth}),
+		Requires:     lazerOnly,
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          Grow,
+		Name:         "Grow",
+		Category:     CategoryFun,
+		Incompatible: slices.Concat(hiddenLike, []Mod{Deflate, Depth}),
+		Requires:     lazerOnly,
+		settings:     settingsOf(func(_ *Difficulty) ScaleSettings { return NewScaleSettings(0.5) }),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          Deflate,
+		Name:         "Deflate",
+		Category:     CategoryFun,
+		Incompatible: slices.Concat(hiddenLike, []Mod{Depth}),
+		Requires:     lazerOnly,
+		settings:     settingsOf(func(_ *Difficulty) ScaleSettings { return NewScaleSettings(2) }),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:      BarrelRoll,
+		Name:     "BarrelRoll",
+		Category: CategoryFun,
+		Requires: lazerOnly,
+		settings: settingsOf(func(_ *Difficulty) BarrelRollSettings { return NewBarrelRollSettings() }),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          Depth,
+		Name:         "Depth",
+		Category:     CategoryFun,
+		Incompatible: slices.Concat(hiddenLike, []Mod{Magnetised, Repel, FreezeFrame}),
+		Requires:     lazerOnly,
+		settings:     settingsOf(func(_ *Difficulty) DepthSettings { return NewDepthSettings() }),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:             Magnetised,
+		Name:            "Magnetised",
+		Category:        CategoryFun,
+		Incompatible:    []Mod{Repel, Autoplay, Relax},
+		Requires:        lazerOnly,
+		ScoreMultiplier: multiplier(0.5),
+		settings:        settingsOf(func(_ *Difficulty) MagnetisedSettings { return NewMagnetisedSettings() }),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          Repel,
+		Name:         "Repel",
+		Category:     CategoryFun,
+		Incompatible: []Mod{Autoplay, Relax},
+		Requires:     lazerOnly,
+		settings:     settingsOf(func(_ *Difficulty) RepelSettings { return NewRepelSettings() }),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:      Bubbles,
+		Name:     "Bubbles",
+		Category: CategoryFun,
+		Requires: lazerOnly,
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          NoScope,
+		Name:         "NoScope",
+		Category:     CategoryFun,
+		Incompatible: []Mod{Flashlight},
+		Requires:     lazerOnly,
+		settings:     settingsOf(func(_ *Difficulty) NoScopeSettings { return NewNoScopeSettings() }),
+	})
+
+	RegisterMod(&ModDefinition{
+		Mod:          FreezeFrame,
+		Name:         "FreezeFrame",
+		Category:     CategoryFun,
+		Incompatible: []Mod{Target},
+		Requires:     lazerOnly,
+	})
+
+	// System
+
+	systemMods := []struct {
+		mod       Mod
+		name      string
+		legacyBit uint32
+	}{
+		{TouchDevice, "TouchDevice", 1 << 2},
+		{Autoplay, "Autoplay", 1 << 11},
+		{Key4, "Key4", 1 << 15},
+		{Key5, "Key5", 1 << 16},
+		{Key6, "Key6", 1 << 17},
+		{Key7, "Key7", 1 << 18},
+		{Key8, "Key8", 1 << 19},
+		{FadeIn, "FadeIn", 1 << 20},
+		{Cinema, "Cinema", 1 << 22},
+		{Key9, "Key9", 1 << 24},
+		{KeyCoop, "KeyCoop", 1 << 25},
+		{Key1, "Key1", 1 << 26},
+		{Key3, "Key3", 1 << 27},
+		{Key2, "Key2", 1 << 28},
+		{LastMod, "LastMod", 0},
+	}
+
+	for _, m := range systemMods {
+		RegisterMod(&ModDefinition{
+			Mod:       m.mod,
+			Name:      m.name,
+			Category:  CategorySystem,
+			LegacyBit: m.legacyBit,
+		})
+	}
+
+	DifficultyAdjustMask = NewModifier(HardRock, Easy, DoubleTime, Nightcore, HalfTime, Daycore, Flashlight, Relax)
+	difficultyAdjustMaskNew = NewModifier(HardRock, Easy, DoubleTime, HalfTime, Flashlight, Relax, Relax2, TouchDevice)
+}
@@ -44,9 +44,12 @@ func exportConfig[T any](toExp T) map[string]any {
 	return expMap
 }
 
+// GetModConfig returns settings of type T of the first active mod that has them
 func GetModConfig[T any](diff *Difficulty) (T, bool) {
-	if s, ok := diff.modSettings[rfType[T]()].(T); ok {
-		return s, true
+	for _, def := range ordered {
+		if s, ok := diff.modSettings[def.Mod].(T); ok && diff.Mods.Active(def.Mod) {
+			return s, true
+		}
 	}
 
 	var ret T
@@ -61,6 +64,12 @@ func tryExportConfig[T any](diff *Difficulty) map[string]any {
 	return nil
 }
 
+// SetModConfig replaces settings of the active mod which uses settings of type T, it does nothing if there's no such mod
 func SetModConfig[T any](diff *Difficulty, config T) {
-	diff.modSettings[rfType[T]()] = config
+	for _, def := range ordered {
+		if def.HasSettings() && def.settings.sType == rfType[T]() && diff.Mods.Active(def.Mod) && !diff.Mods.Intersects(GetImpliedBy(def.Mod)) {
+			diff.modSettings[def.Mod] = config
+			return
+		}
+	}
 }
@@ -0,0 +1,19 @@
+package beatmap
+
+//Original code by: https://github.com/ppy/osu/blob/master/osu.Game.Rulesets.Osu/Mods/OsuModFreezeFrame.cs
+
+// applyFreezeFrame makes all objects in a combo appear together with the first one, the same way osu!lazer's Freeze Frame mod does
+func applyFreezeFrame(beatMap *BeatMap) {
+	lastNewComboTime := 0.0
+
+	for i, obj := range beatMap.HitObjects {
+		// first object always starts a new combo
+		if i == 0 || obj.IsNewCombo() {
+			lastNewComboTime = obj.GetStartTime()
+		}
+
+		if base := getObjectBase(obj); base != nil {
+			base.ExtraPreempt = obj.GetStartTime() - lastNewComboTime
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package beatmap
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/framework/math/math32"
+	"github.com/wieku/danser-go/framework/math/mutils"
+	"github.com/wieku/danser-go/framework/math/netrand"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"math"
+)
+
+//Original code by: https://github.com/ppy/osu/blob/master/osu.Game.Rulesets.Osu/Utils/OsuHitObjectGenerationUtils.cs
+
+const (
+	playfieldWidth  = 512.0
+	playfieldHeight = 384.0
+
+	// playfieldEdgeRatio is the relative distance to the edge of the playfield before objects' positions should start to "turn around" and curve towards the middle
+	playfieldEdgeRatio = 0.375
+
+	borderDistanceX = playfieldWidth * playfieldEdgeRatio
+	borderDistanceY = playfieldHeight * playfieldEdgeRatio
+)
+
+var playfieldCentre = vector.NewVec2f(playfieldWidth/2, playfieldHeight/2)
+
+// rotateAwayFromEdge rotates the vector pointing from previous object to the next one towards the centre of the playfield.
+// The closer the previous object is to the edge, the stronger the rotation is.
+func rotateAwayFromEdge(prevObjectPos, posRelativeToPrev vector.Vector2f, rotationRatio float32) vector.Vector2f {
+	relativeRotationDistance := float32(0)
+
+	if prevObjectPos.X < playfieldCentre.X {
+		relativeRotationDistance = max((borderDistanceX-prevObjectPos.X)/borderDistanceX, relativeRotationDistance)
+	} else {
+		relativeRotationDistance = max((prevObjectPos.X-(playfieldWidth-borderDistanceX))/borderDistanceX, relativeRotationDistance)
+	}
+
+	if prevObjectPos.Y < playfieldCentre.Y {
+		relativeRotationDistance = max((borderDistanceY-prevObjectPos.Y)/borderDistanceY, relativeRotationDistance)
+	} else {
+		relativeRotationDistance = max((prevObjectPos.Y-(playfieldHeight-borderDistanceY))/borderDistanceY, relativeRotationDistance)
+	}
+
+	return rotateVectorTowardsVector(posRelativeToPrev, playfieldCentre.Sub(prevObjectPos), min(1, relativeRotationDistance*rotationRatio))
+}
+
+// rotateVectorTowardsVector rotates initial vector towards the destination, rotationRatio of 1 makes it point in the same direction
+func rotateVectorTowardsVector(initial, destination vector.Vector2f, rotationRatio float32) vector.Vector2f {
+	initialAngle := initial.AngleR()
+	destAngle := destination.AngleR()
+
+	diff := destAngle - initialAngle
+
+	for diff < -math32.Pi {
+		diff += 2 * math32.Pi
+	}
+
+	for diff > math32.Pi {
+		diff -= 2 * math32.Pi
+	}
+
+	finalAngle := initialAngle + rotationRatio*diff
+
+	return vector.NewVec2f(initial.Len()*math32.Cos(finalAngle), initial.Len()*math32.Sin(finalAngle))
+}
+
+func rotateVector(v vector.Vector2f, rotation float32) vector.Vector2f {
+	angle := v.AngleR() + rotation
+	length := v.Len()
+
+	return vector.NewVec2f(length*math32.Cos(angle), length*math32.Sin(angle))
+}
+
+func clampToPlayfieldWithPadding(position vector.Vector2f, padding float32) vector.Vector2f {
+	return vector.NewVec2f(
+		mutils.Clamp(position.X, padding, playfieldWidth-padding),
+		mutils.Clamp(position.Y, padding, playfieldHeight-padding),
+	)
+}
+
+// randomGaussian returns a normally distributed random number
+func randomGaussian(rng *netrand.Random, mean, stdDev float32) float32 {
+	// Both numbers have to be in (0, 1] range, log(0) is undefined
+	x1 := 1 - rng.NextDouble()
+	x2 := 1 - rng.NextDouble()
+
+	stdNormal := math.Sqrt(-2*math.Log(x1)) * math.Sin(2*math.Pi*x2)
+
+	return mean + stdDev*float32(stdNormal)
+}
+
+// getObjectBase returns shared object data, nil for unknown types
+func getObjectBase(obj objects.IHitObject) *objects.HitObject {
+	switch o := obj.(type) {
+	case *objects.Circle:
+		return o.HitObject
+	case *objects.Slider:
+		return o.HitObject
+	case *objects.Spinner:
+		return o.HitObject
+	}
+
+	return nil
+}
+
+// setObjectPosition moves the object to given position, it has to be called before SetTiming
+func setObjectPosition(obj objects.IHitObject, position vector.Vector2f) {
+	if slider, ok := obj.(*objects.Slider); ok {
+		offset := position.Sub(slider.StartPosRaw)
+
+		slider.TransformPath(func(point vector.Vector2f) vector.Vector2f {
+			return point.Add(offset)
+		})
+
+		return
+	}
+
+	if base := getObjectBase(obj); base != nil {
+		base.StartPosRaw = position
+		base.EndPosRaw = position
+	}
+}
+
+// getObjectEndPosition returns the position where the object ends, taking slider's repeats into account
+func getObjectEndPosition(obj objects.IHitObject) vector.Vector2f {
+	if slider, ok := obj.(*objects.Slider); ok {
+		if slider.RepeatCount%2 == 0 {
+			return slider.StartPosRaw
+		}
+
+		return slider.StartPosRaw.Add(sliderPathPositionAt(slider, 1))
+	}
+
+	return obj.GetStartPosition()
+}
+
+// sliderPathPositionAt returns the position on the slider's path relative to its head
+func sliderPathPositionAt(slider *objects.Slider, progress float64) vector.Vector2f {
+	return slider.GetCurve().PointAtLazer(progress).Sub(slider.StartPosRaw)
+}
+
+func getSliderRotation(slider *objects.Slider) float32 {
+	return sliderPathPositionAt(slider, 1).AngleR()
+}
+
+func rotateSlider(slider *objects.Slider, rotation float32) {
+	start := slider.StartPosRaw
+
+	slider.TransformPath(func(point vector.Vector2f) vector.Vector2f {
+		return rotateVector(point.Sub(start), rotation).Add(start)
+	})
+}
+
+func flipSliderInPlaceHorizontally(slider *objects.Slider) {
+	start := slider.StartPosRaw
+
+	slider.TransformPath(func(point vector.Vector2f) vector.Vector2f {
+		return vector.NewVec2f(2*start.X-point.X, point.Y)
+	})
+}
+
+// isObjectOnBeat checks whether the object is placed on a beat (or a downbeat) of its timing point
+func isObjectOnBeat(beatMap *BeatMap, obj objects.IHitObject, downbeatsOnly bool) bool {
+	timingPoint := beatMap.Timings.GetOriginalPointAt(obj.GetStartTime())
+
+	timeSinceTimingPoint := obj.GetStartTime() - timingPoint.Time
+
+	beatLength := timingPoint.GetBaseBeatLength()
+	if downbeatsOnly {
+		beatLength *= float64(timingPoint.Signature)
+	}
+
+	// Ensure within 1ms of expected location
+	return math.Mod(math.Abs(timeSinceTimingPoint+1), beatLength) < 2
+}
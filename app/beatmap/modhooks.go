@@ -0,0 +1,17 @@
+package beatmap
+
+import "github.com/wieku/danser-go/app/beatmap/difficulty"
+
+// Hooks of mods that change hit objects are implemented here, as the difficulty package can't reference beatmaps
+func init() {
+	setModHook(difficulty.Random, difficulty.PreTimingHooks, func(beatMap *BeatMap, _ bool) { applyRandom(beatMap) })
+
+	setModHook(difficulty.Target, difficulty.PostTimingHooks, applyTargetPractice)
+	setModHook(difficulty.FreezeFrame, difficulty.PostTimingHooks, func(beatMap *BeatMap, _ bool) { applyFreezeFrame(beatMap) })
+}
+
+func setModHook(mod difficulty.Mod, stage difficulty.HookStage, hook func(beatMap *BeatMap, diffCalcOnly bool)) {
+	mod.Definition().SetHook(stage, func(beatMap any, diffCalcOnly bool) {
+		hook(beatMap.(*BeatMap), diffCalcOnly)
+	})
+}
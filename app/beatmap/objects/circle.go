@@ -63,6 +63,25 @@ func NewCircle(data []string) *Circle {
 	return circle
 }
 
+// NewGeneratedCircle creates a playable circle that doesn't come from beatmap file, used in beatmap conversions
+func NewGeneratedCircle(pos vector.Vector2f, time float64, sample int, hitSound audio.HitSoundInfo) *Circle {
+	circle := &Circle{
+		HitObject: &HitObject{
+			StartPosRaw:   pos,
+			EndPosRaw:     pos,
+			StartTime:     time,
+			EndTime:       time,
+			HitObjectID:   -1,
+			StackIndexMap: make(map[int64]int64),
+			BasicHitSound: hitSound,
+		},
+		sample:      sample,
+		textureName: defaultCircleName,
+	}
+
+	return circle
+}
+
 func DummyCircle(pos vector.Vector2f, time float64) *Circle {
 	return DummyCircleInherit(pos, time, false, false, false)
 }
@@ -136,14 +155,24 @@ func (circle *Circle) PlaySound() {
 	audio.PlaySample(sampleSet, circle.BasicHitSound.AdditionSet, circle.sample, index, point.SampleVolume, circle.HitObjectID, circle.GetStackedStartPositionMod(circle.diff).X64())
 }
 
+// GetSample returns hitsound bitmask of the circle
+func (circle *Circle) GetSample() int {
+	return circle.sample
+}
+
 func (circle *Circle) SetTiming(timings *Timings, _ int, _ bool) {
 	circle.Timings = timings
 }
 
 func (circle *Circle) SetDifficulty(diff *difficulty.Difficulty) {
 	circle.diff = diff
+	circle.lastTime = 0
+
+	circle.sprites = nil
+	circle.reverseArrow = nil
+	circle.approachCircle = nil
 
-	startTime := circle.StartTime - diff.Preempt
+	startTime := circle.StartTime - diff.Preempt - circle.ExtraPreempt
 
 	if circle.SliderPoint {
 		startTime = circle.appearTime
@@ -216,15 +245,31 @@ func (circle *Circle) SetDifficulty(diff *difficulty.Difficulty) {
 
 		circle.sprites = append(circle.sprites, circle.approachCircle)
 
-		if !diff.CheckModActive(difficulty.Hidden) || circle.HitObjectID == 0 {
-			circle.approachCircle.AddTransform(animation.NewSingleTransform(animation.Fade, easing.Linear, startTime, min(endTime, endTime-diff.Preempt+diff.TimeFadeIn*2), 0.0, 0.9))
+		if !hidesApproachCircles(diff) && (!diff.CheckModActive(difficulty.Hidden) || circle.HitObjectID == 0) {
+			// With Freeze Frame approach circle starts bigger, so it still reaches the object at the original approach rate
+			approachScale := 4.0 * (diff.Preempt + circle.ExtraPreempt) / diff.Preempt
+
+			circle.approachCircle.AddTransform(animation.NewSingleTransform(animation.Fade, easing.Linear, startTime, min(endTime, startTime+diff.TimeFadeIn*2), 0.0, 0.9))
 			circle.approachCircle.AddTransform(animation.NewSingleTransform(animation.Fade, easing.Linear, endTime, endTime, 0.0, 0.0))
 
-			circle.approachCircle.AddTransform(animation.NewSingleTransform(animation.Scale, easing.Linear, startTime, endTime, 4.0, 1.0))
+			circle.approachCircle.AddTransform(animation.NewSingleTransform(animation.Scale, easing.Linear, startTime, endTime, approachScale, 1.0))
 		}
 	}
 }
 
+// hidesApproachCircles checks if any of the active mods removes approach circles
+func hidesApproachCircles(diff *difficulty.Difficulty) bool {
+	if diff.CheckModActive(difficulty.Target, difficulty.SpinIn, difficulty.Grow, difficulty.Deflate) {
+		return true
+	}
+
+	if conf, ok := difficulty.GetModConfig[difficulty.DepthSettings](diff); ok && diff.CheckModActive(difficulty.Depth) {
+		return !conf.ShowApproachCircles
+	}
+
+	return false
+}
+
 func (circle *Circle) Arm(clicked bool, time float64) {
 	circle.hitCircle.ClearTransformations()
 	circle.hitCircleOverlay.ClearTransformations()
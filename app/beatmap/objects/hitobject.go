@@ -47,6 +47,7 @@ type IHitObject interface {
 	GetType() Type
 
 	DisableAudioSubmission(value bool)
+	IsAudioSubmissionDisabled() bool
 
 	Finalize()
 }
@@ -82,6 +83,9 @@ type HitObject struct {
 	ComboSetHax int64
 	ColorOffset int64
 
+	// ExtraPreempt makes the object appear earlier than difficulty's preempt would allow, used by Freeze Frame
+	ExtraPreempt float64
+
 	BasicHitSound           audio.HitSoundInfo
 	audioSubmissionDisabled bool
 }
@@ -204,28 +208,39 @@ func (hitObject *HitObject) DisableAudioSubmission(value bool) {
 	hitObject.audioSubmissionDisabled = value
 }
 
+func (hitObject *HitObject) IsAudioSubmissionDisabled() bool {
+	return hitObject.audioSubmissionDisabled
+}
+
 func ModifyPosition(hitObject *HitObject, basePosition vector.Vector2f, diff *difficulty.Difficulty) vector.Vector2f {
+	basePosition = FlipPosition(basePosition, diff)
+
+	stackIndex := hitObject.GetStackIndexMod(diff)
+
+	stackOffset := float32(stackIndex) * float32(diff.CircleRadius) / 10
+	if diff.CheckModActive(difficulty.Lazer) || diff.DiffCalcMode {
+		stackOffset = float32(stackIndex) * diff.CircleScaleL * 6.4
+	}
+
+	return basePosition.SubS(stackOffset, stackOffset)
+}
+
+// FlipPosition mirrors the position on the playfield the way Hard Rock and Mirror mods do
+func FlipPosition(position vector.Vector2f, diff *difficulty.Difficulty) vector.Vector2f {
 	mS, mOk := difficulty.GetModConfig[difficulty.MirrorSettings](diff)
 
 	vFlip := diff.CheckModActive(difficulty.HardRock) != (mOk && (mS.FlipMode+1)&2 == 2)
 	hFlip := mOk && (mS.FlipMode+1)&1 == 1
 
 	if hFlip {
-		basePosition.X = 512 - basePosition.X
+		position.X = 512 - position.X
 	}
 
 	if vFlip {
-		basePosition.Y = 384 - basePosition.Y
+		position.Y = 384 - position.Y
 	}
 
-	stackIndex := hitObject.GetStackIndexMod(diff)
-
-	stackOffset := float32(stackIndex) * float32(diff.CircleRadius) / 10
-	if diff.CheckModActive(difficulty.Lazer) || diff.DiffCalcMode {
-		stackOffset = float32(stackIndex) * diff.CircleScaleL * 6.4
-	}
-
-	return basePosition.SubS(stackOffset, stackOffset)
+	return position
 }
 
 func (hitObject *HitObject) Finalize() {}
@@ -55,6 +55,7 @@ type Slider struct {
 	*HitObject
 
 	multiCurve  *curves.MultiCurve
+	curveDefs   []curves.CurveDef
 	scorePath   []PathLine
 	Timings     *Timings
 	TPoint      TimingPoint
@@ -251,9 +252,46 @@ func (slider *Slider) parseCurve(curveData string) *curves.MultiCurve {
 		}
 	}
 
+	slider.curveDefs = defs
+
 	return curves.NewMultiCurveT(defs, slider.pixelLength)
 }
 
+// TransformPath moves every control point of slider's path with the transform function and rebuilds the path.
+// It's meant for beatmap conversions, so it has to be called before SetTiming.
+func (slider *Slider) TransformPath(transform func(point vector.Vector2f) vector.Vector2f) {
+	defs := make([]curves.CurveDef, len(slider.curveDefs))
+
+	for i, def := range slider.curveDefs {
+		points := make([]vector.Vector2f, len(def.Points))
+
+		for j, point := range def.Points {
+			points[j] = transform(point)
+		}
+
+		defs[i] = curves.CurveDef{
+			CurveType: def.CurveType,
+			Points:    points,
+		}
+	}
+
+	slider.curveDefs = defs
+	slider.multiCurve = curves.NewMultiCurveT(defs, slider.pixelLength)
+
+	slider.StartPosRaw = transform(slider.StartPosRaw)
+	slider.EndPosRaw = slider.multiCurve.PointAt(1.0)
+	slider.Pos = slider.StartPosRaw
+}
+
+// GetCurveDefs returns segments of slider's path, the first point of the first segment is slider's start position
+func (slider *Slider) GetCurveDefs() []curves.CurveDef {
+	return slider.curveDefs
+}
+
+func (slider *Slider) GetCurve() *curves.MultiCurve {
+	return slider.multiCurve
+}
+
 func tryGetType(str string) curves.CType {
 	switch str {
 	case "P":
@@ -273,6 +311,20 @@ func (slider *Slider) GetLength() float32 {
 	return slider.multiCurve.GetLength()
 }
 
+func (slider *Slider) GetPixelLength() float64 {
+	return slider.pixelLength
+}
+
+// GetBaseSample returns hitsound bitmask of the slider body
+func (slider *Slider) GetBaseSample() int {
+	return slider.baseSample
+}
+
+// GetEdgeSample returns hitsound bitmask and sample sets of slider's head, repeat or tail
+func (slider *Slider) GetEdgeSample(index int) (sample, sampleSet, additionSet int) {
+	return slider.samples[index], slider.sampleSets[index], slider.additionSets[index]
+}
+
 func (slider *Slider) GetStartAngleMod(diff *difficulty.Difficulty) float32 {
 	return slider.GetStackedStartPositionMod(diff).AngleRV(slider.GetStackedPositionAtMod(slider.StartTime+min(10, slider.partLen), diff)) //temporary solution
 }
@@ -556,21 +608,39 @@ func copySliderHOData(target, base *HitObject) {
 	target.HitObjectID = base.HitObjectID
 	target.StackLeniency = base.StackLeniency
 	target.StackIndexMap = base.StackIndexMap
+	target.ExtraPreempt = base.ExtraPreempt
 }
 
 func (slider *Slider) SetDifficulty(diff *difficulty.Difficulty) {
 	slider.diff = diff
+
+	// SetDifficulty may be called again when playback is rewound, so previous render state has to be dropped
+	if slider.body != nil {
+		slider.body.Dispose()
+	}
+
+	slider.lastTime = 0
+	slider.isSliding = false
+	slider.lastScorePoint = 0
+	slider.updatedAtLeastOnce = false
+
+	slider.edges = nil
+	slider.endCircles = nil
+	slider.headEndCircles = nil
+	slider.tailEndCircles = nil
 	slider.sliderSnakeTail = animation.NewGlider(0)
 	slider.sliderSnakeHead = animation.NewGlider(0)
 
+	appearTime := slider.StartTime - diff.Preempt - slider.ExtraPreempt
+
 	slider.fade = animation.NewGlider(0)
-	slider.fade.AddEvent(slider.StartTime-diff.Preempt, slider.StartTime-(diff.Preempt-diff.TimeFadeIn), 1)
+	slider.fade.AddEvent(appearTime, appearTime+diff.TimeFadeIn, 1)
 
 	slider.bodyFade = animation.NewGlider(0)
-	slider.bodyFade.AddEvent(slider.StartTime-diff.Preempt, slider.StartTime-(diff.Preempt-diff.TimeFadeIn), 1)
+	slider.bodyFade.AddEvent(appearTime, appearTime+diff.TimeFadeIn, 1)
 
 	if diff.CheckModActive(difficulty.Hidden) {
-		slider.bodyFade.AddEventEase(slider.StartTime-diff.Preempt+diff.TimeFadeIn, slider.EndTime, 0, easing.OutQuad)
+		slider.bodyFade.AddEventEase(appearTime+diff.TimeFadeIn, slider.EndTime, 0, easing.OutQuad)
 	}
 
 	slider.fade.AddEvent(slider.EndTime, slider.EndTime+difficulty.HitFadeOut, 0)
@@ -624,6 +694,8 @@ func (slider *Slider) SetDifficulty(diff *difficulty.Difficulty) {
 
 	for i, p := range slider.TickPoints {
 		p.Pos = slider.GetStackedPositionAtMod(p.Time, slider.diff)
+		p.fade = animation.NewGlider(0.0)
+		p.scale = animation.NewGlider(0.0)
 
 		slider.TickPoints[i] = p
 	}
@@ -829,8 +901,8 @@ func (slider *Slider) ArmStart(clicked bool, time float64) {
 }
 
 func (slider *Slider) initSnake() {
-	slSnInS := slider.StartTime - slider.diff.Preempt
-	slSnInE := slider.StartTime - slider.diff.Preempt*2/3
+	slSnInS := slider.StartTime - slider.diff.Preempt - slider.ExtraPreempt
+	slSnInE := slider.StartTime - (slider.diff.Preempt+slider.ExtraPreempt)*2/3
 
 	if settings.Objects.Sliders.Snaking.Out {
 		slider.ball.SetAlpha(0)
@@ -840,7 +912,7 @@ func (slider *Slider) initSnake() {
 		fadeMultiplier := 1.0 - mutils.Clamp(settings.Objects.Sliders.Snaking.FadeMultiplier, 0.0, 1.0)
 		durationMultiplier := mutils.Clamp(settings.Objects.Sliders.Snaking.DurationMultiplier, 0.0, 1.0)
 
-		slSnInE = slider.StartTime - slider.diff.Preempt*2/3*fadeMultiplier + slider.partLen*durationMultiplier
+		slSnInE = slider.StartTime - (slider.diff.Preempt+slider.ExtraPreempt)*2/3*fadeMultiplier + slider.partLen*durationMultiplier
 
 		slider.sliderSnakeTail.AddEvent(slSnInS, slSnInE, 1)
 	} else {
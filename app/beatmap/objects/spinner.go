@@ -96,6 +96,11 @@ func (spinner *Spinner) GetPosition() vector.Vector2f {
 	return spinner.pos
 }
 
+// GetSample returns hitsound bitmask of the spinner
+func (spinner *Spinner) GetSample() int {
+	return spinner.sample
+}
+
 func (spinner *Spinner) SetTiming(timings *Timings, _ int, _ bool) {
 	spinner.Timings = timings
 }
@@ -103,6 +108,16 @@ func (spinner *Spinner) SetTiming(timings *Timings, _ int, _ bool) {
 func (spinner *Spinner) SetDifficulty(diff *difficulty.Difficulty) {
 	spinner.diff = diff
 
+	if spinner.loopSample != nil {
+		bass.StopSample(spinner.loopSample)
+		spinner.loopSample = nil
+	}
+
+	spinner.lastTime = 0
+	spinner.rpm = 0
+	spinner.completion = 0
+	spinner.bonus = 0
+
 	spinner.ScaledHeight = 768
 	spinner.ScaledWidth = settings.Graphics.GetAspectRatio() * spinner.ScaledHeight
 
@@ -169,7 +184,7 @@ func (spinner *Spinner) SetDifficulty(diff *difficulty.Difficulty) {
 
 	spinner.frontSprites.Add(spinner.spin)
 
-	spinner.spinnerbonus = audio.LoadSample("spinnerbonus")
+	spinner.spinnerbonus = audio.LoadGameplaySample("spinnerbonus")
 	spinner.bonusFade = animation.NewGlider(0.0)
 	spinner.bonusScale = animation.NewGlider(0.0)
 
@@ -382,7 +397,7 @@ func (spinner *Spinner) StartSpinSample() {
 	}
 
 	if spinner.loopSample == nil {
-		sample := audio.LoadSample("spinnerspin")
+		sample := audio.LoadGameplaySample("spinnerspin")
 		if sample != nil {
 			spinner.loopSample = sample.PlayLoop()
 		}
@@ -40,6 +40,11 @@ func (t TimingPoint) GetRatio2() float64 {
 	return 100 / (-t.beatLength)
 }
 
+// GetRawBeatLength returns beat length as written in .osu file, negative values are slider velocity multipliers of inherited points
+func (t TimingPoint) GetRawBeatLength() float64 {
+	return t.beatLength
+}
+
 func (t TimingPoint) GetBaseBeatLength() float64 {
 	return t.beatLengthBase
 }
@@ -146,6 +151,16 @@ func (tim *Timings) GetOriginalPointAt(time float64) TimingPoint {
 	return tim.originalPoints[max(0, index-1)]
 }
 
+// GetOriginalPoints returns uninherited (red) timing points
+func (tim *Timings) GetOriginalPoints() []TimingPoint {
+	return tim.originalPoints
+}
+
+// GetPoints returns all timing points, both uninherited (red) and inherited (green) ones
+func (tim *Timings) GetPoints() []TimingPoint {
+	return tim.points
+}
+
 func (tim *Timings) GetScoringDistance() float64 {
 	return (100 * tim.SliderMult) / tim.TickRate
 }
@@ -3,6 +3,7 @@ package beatmap
 import (
 	"cmp"
 	"errors"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
 	"github.com/wieku/danser-go/app/beatmap/objects"
 	"github.com/wieku/danser-go/app/settings"
 	"github.com/wieku/danser-go/app/skin"
@@ -115,6 +116,41 @@ func parseHitObjects(line []string, beatMap *BeatMap) {
 	}
 }
 
+// calculateCombos assigns IDs, combo numbers and combo colors to objects
+func calculateCombos(beatMap *BeatMap) {
+	num := 0
+	comboNumber := 1
+	comboSet := 0
+	comboSetHax := 0
+	forceNewCombo := false
+
+	for i, iO := range beatMap.HitObjects {
+		if iO.GetType() == objects.SPINNER {
+			forceNewCombo = true
+		} else if iO.IsNewCombo() || forceNewCombo {
+			iO.SetNewCombo(true)
+			comboNumber = 1
+			comboSet++
+			comboSetHax += int(iO.GetColorOffset()) + 1
+
+			forceNewCombo = false
+		}
+
+		if iO.IsNewCombo() && i > 0 {
+			beatMap.HitObjects[i-1].SetLastInCombo(true)
+		}
+
+		iO.SetID(int64(num))
+		iO.SetComboNumber(int64(comboNumber))
+		iO.SetComboSet(int64(comboSet))
+		iO.SetComboSetHax(int64(comboSetHax))
+		iO.SetStackLeniency(beatMap.StackLeniency)
+
+		comboNumber++
+		num++
+	}
+}
+
 func tokenize(line, delimiter string) []string {
 	return tokenizeN(line, delimiter, -1)
 }
@@ -150,7 +186,16 @@ var bufferPool = &sync.Pool{
 }
 
 func ParseBeatMap(beatMap *BeatMap) error {
-	file, err := os.Open(filepath.Join(settings.General.GetSongsDir(), beatMap.Dir, beatMap.File))
+	return parseBeatMap(beatMap, false)
+}
+
+// ParseBeatMapHeaders parses only sections preceding [TimingPoints] and [HitObjects], object counts, length and BPM have to be provided elsewhere
+func ParseBeatMapHeaders(beatMap *BeatMap) error {
+	return parseBeatMap(beatMap, true)
+}
+
+func parseBeatMap(beatMap *BeatMap, headersOnly bool) error {
+	file, err := os.Open(beatMap.GetFilePath())
 	if err != nil {
 		return err
 	}
@@ -173,6 +218,10 @@ func ParseBeatMap(beatMap *BeatMap) error {
 
 		section := getSection(line)
 		if section != "" {
+			if headersOnly && (section == "TimingPoints" || section == "HitObjects") {
+				break
+			}
+
 			currentSection = section
 			continue
 		}
@@ -231,7 +280,7 @@ func ParseBeatMap(beatMap *BeatMap) error {
 
 	file.Seek(0, 0)
 
-	if beatMap.Name+beatMap.Artist+beatMap.Creator == "" || counter == 0 {
+	if beatMap.Name+beatMap.Artist+beatMap.Creator == "" || (counter == 0 && !headersOnly) {
 		return errors.New("corrupted file")
 	}
 
@@ -260,7 +309,7 @@ func ParseTimingPointsAndPauses(beatMap *BeatMap) {
 		return
 	}
 
-	file, err := os.Open(filepath.Join(settings.General.GetSongsDir(), beatMap.Dir, beatMap.File))
+	file, err := os.Open(beatMap.GetFilePath())
 	if err != nil {
 		panic(err)
 	}
@@ -301,7 +350,7 @@ func ParseTimingPointsAndPauses(beatMap *BeatMap) {
 }
 
 func ParseObjects(beatMap *BeatMap, diffCalcOnly, parseColors bool) {
-	file, err := os.Open(filepath.Join(settings.General.GetSongsDir(), beatMap.Dir, beatMap.File))
+	file, err := os.Open(beatMap.GetFilePath())
 	if err != nil {
 		panic(err)
 	}
@@ -353,42 +402,18 @@ func ParseObjects(beatMap *BeatMap, diffCalcOnly, parseColors bool) {
 		skin.FinishBeatmapColors()
 	}
 
-	num := 0
-	comboNumber := 1
-	comboSet := 0
-	comboSetHax := 0
-	forceNewCombo := false
+	calculateCombos(beatMap)
 
-	for i, iO := range beatMap.HitObjects {
-		if iO.GetType() == objects.SPINNER {
-			forceNewCombo = true
-		} else if iO.IsNewCombo() || forceNewCombo {
-			iO.SetNewCombo(true)
-			comboNumber = 1
-			comboSet++
-			comboSetHax += int(iO.GetColorOffset()) + 1
-
-			forceNewCombo = false
-		}
-
-		if iO.IsNewCombo() && i > 0 {
-			beatMap.HitObjects[i-1].SetLastInCombo(true)
-		}
-
-		iO.SetID(int64(num))
-		iO.SetComboNumber(int64(comboNumber))
-		iO.SetComboSet(int64(comboSet))
-		iO.SetComboSetHax(int64(comboSetHax))
-		iO.SetStackLeniency(beatMap.StackLeniency)
-
-		comboNumber++
-		num++
-	}
+	beatMap.Diff.Mods.RunHooks(difficulty.PreTimingHooks, beatMap, diffCalcOnly)
 
 	for _, obj := range beatMap.HitObjects {
 		obj.SetTiming(beatMap.Timings, beatMap.Version, diffCalcOnly)
 	}
 
+	beatMap.Diff.Mods.RunHooks(difficulty.PostTimingHooks, beatMap, diffCalcOnly)
+
+	beatMap.setupRateChange()
+
 	if settings.Objects.StackEnabled || settings.KNOCKOUT || settings.PLAY || diffCalcOnly {
 		beatMap.CalculateStackLeniency(beatMap.Diff)
 	}
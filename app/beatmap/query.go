@@ -0,0 +1,414 @@
+package beatmap
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type queryOperator int
+
+const (
+	opEqual        = queryOperator(iota) // "=" or ":", text values only have to be contained in the field
+	opExact                              // "=="
+	opNotEqual                           // "!="
+	opLess                               // "<"
+	opLessEqual                          // "<="
+	opGreater                            // ">"
+	opGreaterEqual                       // ">="
+)
+
+// operators are ordered so two-character operators are checked first
+var operators = []struct {
+	text string
+	op   queryOperator
+}{
+	{"==", opExact},
+	{"!=", opNotEqual},
+	{"<=", opLessEqual},
+	{">=", opGreaterEqual},
+	{"=", opEqual},
+	{":", opEqual},
+	{"<", opLess},
+	{">", opGreater},
+}
+
+type keyType int
+
+const (
+	numberKey = keyType(iota)
+	lengthKey
+	textKey
+	statusKey
+	modeKey
+)
+
+type queryKey struct {
+	kind   keyType
+	number func(b *BeatMap) float64
+	text   func(b *BeatMap) []string
+}
+
+func numberField(f func(b *BeatMap) float64) *queryKey {
+	return &queryKey{kind: numberKey, number: f}
+}
+
+func textField(f func(b *BeatMap) []string) *queryKey {
+	return &queryKey{kind: textKey, text: f}
+}
+
+var queryKeys = map[string]*queryKey{
+	"stars":    numberField(func(b *BeatMap) float64 { return b.Stars }),
+	"ar":       numberField(func(b *BeatMap) float64 { return b.Diff.GetAR() }),
+	"od":       numberField(func(b *BeatMap) float64 { return b.Diff.GetOD() }),
+	"cs":       numberField(func(b *BeatMap) float64 { return b.Diff.GetCS() }),
+	"hp":       numberField(func(b *BeatMap) float64 { return b.Diff.GetHP() }),
+	"bpm":      numberField(func(b *BeatMap) float64 { return b.MaxBPM }),
+	"objects":  numberField(func(b *BeatMap) float64 { return float64(b.Circles + b.Sliders + b.Spinners) }),
+	"circles":  numberField(func(b *BeatMap) float64 { return float64(b.Circles) }),
+	"sliders":  numberField(func(b *BeatMap) float64 { return float64(b.Sliders) }),
+	"spinners": numberField(func(b *BeatMap) float64 { return float64(b.Spinners) }),
+	"played":   numberField(func(b *BeatMap) float64 { return float64(b.PlayCount) }),
+	"id":       numberField(func(b *BeatMap) float64 { return float64(b.ID) }),
+	"setid":    numberField(func(b *BeatMap) float64 { return float64(b.SetID) }),
+	"length":   {kind: lengthKey, number: func(b *BeatMap) float64 { return float64(b.Length) / 1000 }},
+
+	"artist":     textField(func(b *BeatMap) []string { return []string{b.Artist, b.ArtistUnicode} }),
+	"title":      textField(func(b *BeatMap) []string { return []string{b.Name, b.NameUnicode} }),
+	"difficulty": textField(func(b *BeatMap) []string { return []string{b.Difficulty} }),
+	"creator":    textField(func(b *BeatMap) []string { return []string{b.Creator} }),
+	"source":     textField(func(b *BeatMap) []string { return []string{b.Source} }),
+	"tags":       textField(func(b *BeatMap) []string { return []string{b.Tags} }),
+
+	"status": {kind: statusKey},
+	"mode":   {kind: modeKey},
+}
+
+var keyAliases = map[string]string{
+	"star":    "stars",
+	"sr":      "stars",
+	"drain":   "hp",
+	"plays":   "played",
+	"set":     "setid",
+	"diff":    "difficulty",
+	"version": "difficulty",
+	"mapper":  "creator",
+	"tag":     "tags",
+}
+
+var modeNames = []string{"osu", "taiko", "fruits", "mania"}
+
+// Query is a parsed beatmap filter like `stars>6 ar>=9.5 bpm>200 length<3:00 creator=abc status=ranked`.
+// Terms without an operator, or with an unknown key, are searched as plain text in map's artist, title, difficulty, creator, source, tags and ids.
+type Query struct {
+	filters []func(b *BeatMap) bool
+	words   []string
+
+	hasMode   bool
+	hasStatus bool
+}
+
+// ParseQuery parses a query string. Values containing spaces can be quoted, e.g. creator="Some Mapper"
+func ParseQuery(query string) (*Query, error) {
+	q := new(Query)
+
+	for _, term := range splitQuery(query) {
+		key, op, value, ok := splitTerm(term)
+
+		if !ok {
+			q.words = append(q.words, strings.ToLower(term))
+			continue
+		}
+
+		filter, err := newFilter(key, op, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query term \"%s\": %w", term, err)
+		}
+
+		q.filters = append(q.filters, filter)
+
+		q.hasMode = q.hasMode || key == "mode"
+		q.hasStatus = q.hasStatus || key == "status"
+	}
+
+	return q, nil
+}
+
+// HasMode returns true if the query filters maps by game mode
+func (q *Query) HasMode() bool {
+	return q.hasMode
+}
+
+// HasStatus reports whether the query filters by ranked status
+func (q *Query) HasStatus() bool {
+	return q.hasStatus
+}
+
+// NewTextQuery creates a query matching maps which contain the whole text, without parsing any filters
+func NewTextQuery(text string) *Query {
+	q := new(Query)
+
+	if text != "" {
+		q.words = []string{strings.ToLower(text)}
+	}
+
+	return q
+}
+
+// Matches checks whether beatmap satisfies all query terms
+func (q *Query) Matches(b *BeatMap) bool {
+	if len(q.words) == 0 {
+		return q.MatchesText(b, "")
+	}
+
+	return q.MatchesText(b, SearchText(b))
+}
+
+// MatchesText is Matches with beatmap's SearchText computed beforehand, useful when the same beatmaps are searched repeatedly
+func (q *Query) MatchesText(b *BeatMap, searchText string) bool {
+	for _, w := range q.words {
+		if !strings.Contains(searchText, w) {
+			return false
+		}
+	}
+
+	for _, f := range q.filters {
+		if !f(b) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SearchText returns lowercase text used to match plain words of a Query
+func SearchText(b *BeatMap) string {
+	return strings.ToLower(fmt.Sprintf("%s - %s [%s] by %s %d %d %s %s %s %s", b.Artist, b.Name, b.Difficulty, b.Creator, b.SetID, b.ID, b.ArtistUnicode, b.NameUnicode, b.Source, b.Tags))
+}
+
+func splitQuery(query string) (terms []string) {
+	var sb strings.Builder
+
+	quoted := false
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			if sb.Len() > 0 {
+				terms = append(terms, sb.String())
+				sb.Reset()
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	if sb.Len() > 0 {
+		terms = append(terms, sb.String())
+	}
+
+	return
+}
+
+// splitTerm splits "key<op>value" term, ok is false if term isn't a filter on a known key
+func splitTerm(term string) (key string, op queryOperator, value string, ok bool) {
+	i := strings.IndexAny(term, "=!<>:")
+	if i <= 0 {
+		return
+	}
+
+	key = strings.ToLower(term[:i])
+
+	if alias, ok1 := keyAliases[key]; ok1 {
+		key = alias
+	}
+
+	if _, ok1 := queryKeys[key]; !ok1 {
+		return
+	}
+
+	for _, o := range operators {
+		if strings.HasPrefix(term[i:], o.text) {
+			return key, o.op, term[i+len(o.text):], true
+		}
+	}
+
+	return
+}
+
+func newFilter(key string, op queryOperator, value string) (func(b *BeatMap) bool, error) {
+	qKey := queryKeys[key]
+
+	switch qKey.kind {
+	case numberKey, lengthKey:
+		parse := parseQueryNumber
+		if qKey.kind == lengthKey {
+			parse = parseQueryLength
+		}
+
+		target, tolerance, err := parse(value)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(b *BeatMap) bool {
+			v := qKey.number(b)
+
+			if key == "stars" && v < 0 { // not calculated yet
+				return false
+			}
+
+			return compareNumbers(v, op, target, tolerance)
+		}, nil
+	case textKey:
+		if op != opEqual && op != opExact && op != opNotEqual {
+			return nil, errors.New("text can be only compared with =, == and !=")
+		}
+
+		value = strings.ToLower(value)
+
+		return func(b *BeatMap) bool {
+			found := false
+
+			for _, t := range qKey.text(b) {
+				t = strings.ToLower(t)
+
+				if (op == opExact && t == value) || (op != opExact && strings.Contains(t, value)) {
+					found = true
+					break
+				}
+			}
+
+			return found != (op == opNotEqual)
+		}, nil
+	case statusKey:
+		if op != opEqual && op != opExact && op != opNotEqual {
+			return nil, errors.New("status can be only compared with =, == and !=")
+		}
+
+		status, err := ParseRankedStatus(value)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(b *BeatMap) bool {
+			return (b.Status == status) != (op == opNotEqual)
+		}, nil
+	default:
+		mode, err := parseMode(value)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(b *BeatMap) bool {
+			return compareNumbers(float64(b.Mode), op, float64(mode), 0)
+		}, nil
+	}
+}
+
+func compareNumbers(v float64, op queryOperator, target, tolerance float64) bool {
+	switch op {
+	case opLess:
+		return v < target
+	case opLessEqual:
+		return v <= target+tolerance
+	case opGreater:
+		return v > target
+	case opGreaterEqual:
+		return v >= target-tolerance
+	case opNotEqual:
+		return math.Abs(v-target) > tolerance
+	default:
+		return math.Abs(v-target) <= tolerance
+	}
+}
+
+// parseQueryNumber returns the value and half of its precision, so stars=6 matches 5.5-6.5 and ar=9.5 matches 9.45-9.55
+func parseQueryNumber(value string) (float64, float64, error) {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("\"%s\" is not a number", value)
+	}
+
+	decimals := 0
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		decimals = len(value) - i - 1
+	}
+
+	return v, 0.5 * math.Pow(10, -float64(decimals)), nil
+}
+
+// parseQueryLength parses length in seconds, accepting plain seconds (90), colon format (1:30, 1:02:03) or units (1m30s, 2h)
+func parseQueryLength(value string) (float64, float64, error) {
+	invalid := fmt.Errorf("\"%s\" is not a valid length", value)
+
+	if strings.Contains(value, ":") {
+		seconds := 0.0
+
+		for _, part := range strings.Split(value, ":") {
+			v, err := strconv.ParseUint(part, 10, 32)
+			if err != nil {
+				return 0, 0, invalid
+			}
+
+			seconds = seconds*60 + float64(v)
+		}
+
+		return seconds, 0.5, nil
+	}
+
+	if v, tolerance, err := parseQueryNumber(value); err == nil {
+		return v, tolerance, nil
+	}
+
+	units := map[byte]float64{'h': 3600, 's': 1, 'm': 60}
+
+	seconds, tolerance := 0.0, 0.0
+	start := 0
+
+	for i := 0; i < len(value); i++ {
+		scale, ok := units[value[i]]
+		if !ok {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(value[start:i], 64)
+		if err != nil {
+			return 0, 0, invalid
+		}
+
+		seconds += v * scale
+		tolerance = scale / 2
+		start = i + 1
+	}
+
+	if start == 0 || start != len(value) {
+		return 0, 0, invalid
+	}
+
+	return seconds, tolerance, nil
+}
+
+func parseMode(value string) (int64, error) {
+	value = strings.ToLower(value)
+
+	for i, name := range modeNames {
+		if value == name || value == strconv.Itoa(i) {
+			return int64(i), nil
+		}
+	}
+
+	switch value {
+	case "std", "standard":
+		return 0, nil
+	case "catch", "ctb":
+		return 2, nil
+	}
+
+	return 0, fmt.Errorf("unknown game mode: \"%s\"", value)
+}
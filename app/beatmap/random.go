@@ -0,0 +1,390 @@
+package beatmap
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/framework/math/math32"
+	"github.com/wieku/danser-go/framework/math/mutils"
+	"github.com/wieku/danser-go/framework/math/netrand"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"math"
+)
+
+//Original code by: https://github.com/ppy/osu/blob/master/osu.Game.Rulesets.Osu/Mods/OsuModRandom.cs
+//and https://github.com/ppy/osu/blob/master/osu.Game.Rulesets.Osu/Utils/OsuHitObjectGenerationUtils_Reposition.cs
+
+const (
+	rdMaxAngleSharpness = 10.0
+	rdDefAngleSharpness = 7.0
+
+	// rdPrecedingToShift is the number of preceding circles moved along with an object that was pushed back into the playfield
+	rdPrecedingToShift = 10
+)
+
+var playfieldDiagonal = vector.NewVec2f(playfieldWidth, playfieldHeight).Len()
+
+type objectPositionInfo struct {
+	object objects.IHitObject
+
+	// relativeAngle is the angle between the direction from previous object and the direction to this object
+	relativeAngle float32
+
+	distanceFromPrevious float32
+
+	// rotation is slider's rotation relative to the direction from previous object
+	rotation float32
+}
+
+type workingObject struct {
+	info *objectPositionInfo
+
+	positionModified    vector.Vector2f
+	endPositionModified vector.Vector2f
+}
+
+type randomizer struct {
+	beatMap *BeatMap
+	rng     *netrand.Random
+	radius  float32
+
+	angleSharpness float32
+}
+
+// applyRandom reshuffles positions of objects with a seeded generator, the same way osu!lazer's Random mod does
+func applyRandom(beatMap *BeatMap) {
+	if len(beatMap.HitObjects) == 0 {
+		return
+	}
+
+	conf, ok := difficulty.GetModConfig[difficulty.RandomSettings](beatMap.Diff)
+	if !ok {
+		conf = difficulty.NewRandomSettings()
+	}
+
+	rd := &randomizer{
+		beatMap:        beatMap,
+		rng:            netrand.New(int32(conf.Seed)),
+		radius:         float32(beatMap.Diff.CircleRadiusL),
+		angleSharpness: float32(conf.AngleSharpness),
+	}
+
+	infos := generatePositionInfos(beatMap.HitObjects)
+
+	// Offsets the angles of all hit objects in a "section" by the same amount
+	sectionOffset := float32(0)
+
+	// Whether the angles are positive or negative (clockwise or counter-clockwise flow)
+	flowDirection := false
+
+	for i, info := range infos {
+		if rd.shouldStartNewSection(infos, i) {
+			sectionOffset = rd.getRandomOffset(0.0008)
+			flowDirection = !flowDirection
+		}
+
+		if slider, ok := info.object.(*objects.Slider); ok && rd.rng.NextDouble() < 0.5 {
+			flipSliderInPlaceHorizontally(slider)
+		}
+
+		if i == 0 {
+			info.distanceFromPrevious = float32(rd.rng.NextDouble() * playfieldHeight / 2)
+			info.relativeAngle = float32(rd.rng.NextDouble()*2*math.Pi - math.Pi)
+
+			continue
+		}
+
+		// Offsets only the angle of the current hit object if a flow change occurs
+		flowChangeOffset := float32(0)
+
+		// Offsets only the angle of the current hit object
+		oneTimeOffset := rd.getRandomOffset(0.002)
+
+		if rd.shouldApplyFlowChange(infos, i) {
+			flowChangeOffset = rd.getRandomOffset(0.002)
+			flowDirection = !flowDirection
+		}
+
+		// sectionOffset and oneTimeOffset should mainly affect patterns with large spacing, flowChangeOffset should mainly affect streams
+		totalOffset := (sectionOffset+oneTimeOffset)*info.distanceFromPrevious + flowChangeOffset*(playfieldDiagonal-info.distanceFromPrevious)
+
+		info.relativeAngle = rd.getRelativeTargetAngle(info.distanceFromPrevious, totalOffset, flowDirection)
+	}
+
+	rd.repositionObjects(infos)
+}
+
+func generatePositionInfos(hitObjects []objects.IHitObject) []*objectPositionInfo {
+	infos := make([]*objectPositionInfo, 0, len(hitObjects))
+
+	previousPosition := playfieldCentre
+	previousAngle := float32(0)
+
+	for _, obj := range hitObjects {
+		relativePosition := obj.GetStartPosition().Sub(previousPosition)
+		absoluteAngle := relativePosition.AngleR()
+
+		info := &objectPositionInfo{
+			object:               obj,
+			relativeAngle:        absoluteAngle - previousAngle,
+			distanceFromPrevious: relativePosition.Len(),
+		}
+
+		if slider, ok := obj.(*objects.Slider); ok {
+			absoluteRotation := getSliderRotation(slider)
+			info.rotation = absoluteRotation - absoluteAngle
+			absoluteAngle = absoluteRotation
+		}
+
+		infos = append(infos, info)
+
+		previousPosition = getObjectEndPosition(obj)
+		previousAngle = absoluteAngle
+	}
+
+	return infos
+}
+
+func (rd *randomizer) getRandomOffset(stdDev float32) float32 {
+	// Range: [0.625, 1.75], higher angle sharpness gives lower multiplier
+	customMultiplier := (1.5*rdMaxAngleSharpness - rd.angleSharpness) / (1.5*rdMaxAngleSharpness - rdDefAngleSharpness)
+
+	return randomGaussian(rd.rng, 0, stdDev*customMultiplier)
+}
+
+func (rd *randomizer) getRelativeTargetAngle(targetDistance, offset float32, flowDirection bool) float32 {
+	// Range: [0.1, 1]
+	angleSharpness := rd.angleSharpness / rdMaxAngleSharpness
+	// Range: [0, 0.9]
+	angleWideness := 1 - angleSharpness
+
+	// Range: [-60, 30]
+	customOffsetX := angleSharpness*100 - 70
+	// Range: [-0.075, 0.15]
+	customOffsetY := angleWideness*0.25 - 0.075
+
+	targetDistance += customOffsetX
+
+	angle := float32(2.16/(1+200*math.Exp(0.036*float64(targetDistance-310+customOffsetX))) + 0.5)
+	angle += offset + customOffsetY
+
+	relativeAngle := math32.Pi - angle
+
+	if flowDirection {
+		return -relativeAngle
+	}
+
+	return relativeAngle
+}
+
+// previousStartedCombo checks whether previous object started a combo, excluding new-combo-spam and 1-2 combos
+func previousStartedCombo(infos []*objectPositionInfo, i int) bool {
+	return getObjectBase(infos[max(0, i-2)].object).ComboNumber-1 > 1 && infos[i-1].object.IsNewCombo()
+}
+
+func (rd *randomizer) shouldApplyFlowChange(infos []*objectPositionInfo, i int) bool {
+	return previousStartedCombo(infos, i) && rd.rng.NextDouble() < float64(float32(0.6))
+}
+
+func (rd *randomizer) shouldStartNewSection(infos []*objectPositionInfo, i int) bool {
+	if i == 0 {
+		return true
+	}
+
+	startedCombo := previousStartedCombo(infos, i)
+	onDownbeat := isObjectOnBeat(rd.beatMap, infos[i-1].object, true)
+	onBeat := isObjectOnBeat(rd.beatMap, infos[i-1].object, false)
+
+	return (startedCombo && rd.rng.NextDouble() < float64(float32(0.6))) ||
+		onDownbeat ||
+		(onBeat && rd.rng.NextDouble() < float64(float32(0.4)))
+}
+
+// repositionObjects places objects according to their position infos
+func (rd *randomizer) repositionObjects(infos []*objectPositionInfo) {
+	working := make([]*workingObject, len(infos))
+
+	for i, info := range infos {
+		working[i] = &workingObject{
+			info:                info,
+			positionModified:    info.object.GetStartPosition(),
+			endPositionModified: getObjectEndPosition(info.object),
+		}
+	}
+
+	var previous *workingObject
+
+	for i, current := range working {
+		if current.info.object.GetType() == objects.SPINNER {
+			previous = current
+			continue
+		}
+
+		var beforePrevious *workingObject
+		if i > 1 {
+			beforePrevious = working[i-2]
+		}
+
+		rd.computeModifiedPosition(current, previous, beforePrevious)
+
+		// Move hit objects back into the playfield if they are outside of it
+		var shift vector.Vector2f
+
+		if slider, ok := current.info.object.(*objects.Slider); ok {
+			shift = rd.clampSliderToPlayfield(current, slider)
+		} else {
+			shift = rd.clampCircleToPlayfield(current)
+		}
+
+		if shift != vector.NewVec2f(0, 0) {
+			var toBeMoved []objects.IHitObject
+
+			for j := i - 1; j >= i-rdPrecedingToShift && j >= 0; j-- {
+				// only shift hit circles
+				if working[j].info.object.GetType() != objects.CIRCLE {
+					break
+				}
+
+				toBeMoved = append(toBeMoved, working[j].info.object)
+			}
+
+			rd.applyDecreasingShift(toBeMoved, shift)
+		}
+
+		previous = current
+	}
+}
+
+func (rd *randomizer) computeModifiedPosition(current, previous, beforePrevious *workingObject) {
+	previousAbsoluteAngle := float32(0)
+
+	if previous != nil {
+		if slider, ok := previous.info.object.(*objects.Slider); ok {
+			previousAbsoluteAngle = getSliderRotation(slider)
+		} else {
+			earliestPosition := playfieldCentre
+			if beforePrevious != nil {
+				earliestPosition = getObjectEndPosition(beforePrevious.info.object)
+			}
+
+			previousAbsoluteAngle = previous.info.object.GetStartPosition().Sub(earliestPosition).AngleR()
+		}
+	}
+
+	absoluteAngle := previousAbsoluteAngle + current.info.relativeAngle
+
+	posRelativeToPrev := vector.NewVec2f(
+		current.info.distanceFromPrevious*math32.Cos(absoluteAngle),
+		current.info.distanceFromPrevious*math32.Sin(absoluteAngle),
+	)
+
+	lastEndPosition := playfieldCentre
+	if previous != nil {
+		lastEndPosition = previous.endPositionModified
+	}
+
+	posRelativeToPrev = rotateAwayFromEdge(lastEndPosition, posRelativeToPrev, 0.5)
+
+	current.positionModified = lastEndPosition.Add(posRelativeToPrev)
+
+	slider, ok := current.info.object.(*objects.Slider)
+	if !ok {
+		return
+	}
+
+	absoluteAngle = posRelativeToPrev.AngleR()
+
+	centreOfMassOriginal := calculateCentreOfMass(slider)
+	centreOfMassModified := rotateVector(centreOfMassOriginal, current.info.rotation+absoluteAngle-getSliderRotation(slider))
+	centreOfMassModified = rotateAwayFromEdge(current.positionModified, centreOfMassModified, 0.5)
+
+	relativeRotation := centreOfMassModified.AngleR() - centreOfMassOriginal.AngleR()
+
+	if math32.Abs(relativeRotation) > 1e-3 {
+		rotateSlider(slider, relativeRotation)
+	}
+}
+
+func (rd *randomizer) clampCircleToPlayfield(current *workingObject) vector.Vector2f {
+	previousPosition := current.positionModified
+
+	current.positionModified = clampToPlayfieldWithPadding(current.positionModified, rd.radius)
+	current.endPositionModified = current.positionModified
+
+	setObjectPosition(current.info.object, current.positionModified)
+
+	return current.positionModified.Sub(previousPosition)
+}
+
+func (rd *randomizer) clampSliderToPlayfield(current *workingObject, slider *objects.Slider) vector.Vector2f {
+	minX, minY := math32.Inf(1), math32.Inf(1)
+	maxX, maxY := math32.Inf(-1), math32.Inf(-1)
+
+	// The slider rotation might make it impossible to fit the slider into the playfield,
+	// so slider's dimensions are subtracted from the playfield's dimensions to get bounds of slider's position
+	for _, line := range slider.GetCurve().GetLines() {
+		for _, point := range []vector.Vector2f{line.Point1, line.Point2} {
+			relative := point.Sub(slider.StartPosRaw)
+
+			minX, maxX = min(minX, relative.X), max(maxX, relative.X)
+			minY, maxY = min(minY, relative.Y), max(maxY, relative.Y)
+		}
+	}
+
+	left, right := -(minX - rd.radius), playfieldWidth-(maxX+rd.radius)
+	top, bottom := -(minY - rd.radius), playfieldHeight-(maxY+rd.radius)
+
+	previousPosition := current.positionModified
+
+	// If the slider is larger than the playfield, at least make sure that the head circle is inside the playfield
+	var newX, newY float32
+
+	if right < left {
+		newX = mutils.Clamp(left, 0, playfieldWidth)
+	} else {
+		newX = mutils.Clamp(previousPosition.X, left, right)
+	}
+
+	if bottom < top {
+		newY = mutils.Clamp(top, 0, playfieldHeight)
+	} else {
+		newY = mutils.Clamp(previousPosition.Y, top, bottom)
+	}
+
+	current.positionModified = vector.NewVec2f(newX, newY)
+
+	setObjectPosition(slider, current.positionModified)
+
+	current.endPositionModified = getObjectEndPosition(slider)
+
+	return current.positionModified.Sub(previousPosition)
+}
+
+// applyDecreasingShift moves the first object by a vector slightly smaller than shift and the last one by a vector slightly larger than zero
+func (rd *randomizer) applyDecreasingShift(hitObjects []objects.IHitObject, shift vector.Vector2f) {
+	for i, obj := range hitObjects {
+		position := obj.GetStartPosition().Add(shift.Scl(float32(len(hitObjects)-i) / float32(len(hitObjects)+1)))
+
+		setObjectPosition(obj, clampToPlayfieldWithPadding(position, rd.radius))
+	}
+}
+
+func calculateCentreOfMass(slider *objects.Slider) vector.Vector2f {
+	const sampleStep = 50.0
+
+	pathDistance := slider.GetPixelLength()
+
+	// just sample the start and end positions if the slider is too short
+	if pathDistance <= sampleStep {
+		return sliderPathPositionAt(slider, 1).Scl(0.5)
+	}
+
+	count := 0
+	sum := vector.NewVec2f(0, 0)
+
+	for i := 0.0; i < pathDistance; i += sampleStep {
+		sum = sum.Add(sliderPathPositionAt(slider, i/pathDistance))
+		count++
+	}
+
+	return sum.Scl(1 / float32(count))
+}
@@ -0,0 +1,72 @@
+package beatmap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RankedStatus is beatmap's online status. Values match the ones used in osu!.db
+type RankedStatus int
+
+const (
+	StatusUnknown RankedStatus = iota
+	StatusUnsubmitted
+	StatusPending // osu! doesn't differentiate between pending, WIP and graveyard
+	_
+	StatusRanked
+	StatusApproved
+	StatusQualified
+	StatusLoved
+)
+
+var statusNames = map[RankedStatus]string{
+	StatusUnknown:     "unknown",
+	StatusUnsubmitted: "unsubmitted",
+	StatusPending:     "pending",
+	StatusRanked:      "ranked",
+	StatusApproved:    "approved",
+	StatusQualified:   "qualified",
+	StatusLoved:       "loved",
+}
+
+var statusAliases = map[string]RankedStatus{
+	"wip":       StatusPending,
+	"graveyard": StatusPending,
+}
+
+func (s RankedStatus) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+
+	return statusNames[StatusUnknown]
+}
+
+// ParseRankedStatus accepts status names, their unique prefixes and some aliases, e.g. "r", "rank" and "ranked" all mean StatusRanked
+func ParseRankedStatus(value string) (RankedStatus, error) {
+	value = strings.ToLower(value)
+
+	if s, ok := statusAliases[value]; ok {
+		return s, nil
+	}
+
+	found := StatusUnknown
+	matches := 0
+
+	for s, name := range statusNames {
+		if name == value {
+			return s, nil
+		}
+
+		if value != "" && strings.HasPrefix(name, value) {
+			found = s
+			matches++
+		}
+	}
+
+	if matches != 1 {
+		return StatusUnknown, fmt.Errorf("unknown ranked status: \"%s\"", value)
+	}
+
+	return found, nil
+}
@@ -0,0 +1,345 @@
+package beatmap
+
+import (
+	"github.com/wieku/danser-go/app/audio"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/framework/math/math32"
+	"github.com/wieku/danser-go/framework/math/netrand"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"math"
+	"slices"
+)
+
+//Original code by: https://github.com/ppy/osu/blob/master/osu.Game.Rulesets.Osu/Mods/OsuModTargetPractice.cs
+
+const (
+	// tpMaxBaseDistance is the jump distance for circles in the last combo
+	tpMaxBaseDistance = 333.0
+
+	// tpDistanceCap is the maximum allowed jump distance after multipliers are applied
+	tpDistanceCap = 380.0
+
+	// tpEdgeRotationMultiplier is the extent of rotation towards playfield centre when a circle is near the edge
+	tpEdgeRotationMultiplier = 0.75
+
+	// tpOverlapCheckCount is the number of recent circles to check for overlap
+	tpOverlapCheckCount = 5
+
+	// tpTimingPrecision is the acceptable difference for timing comparisons
+	tpTimingPrecision = 1.0
+)
+
+type targetCircle struct {
+	circle     *objects.Circle
+	comboIndex int
+}
+
+// applyTargetPractice replaces beatmap's objects with circles placed on every beat, the same way osu!lazer's Target Practice mod does.
+// Objects have to have their timings set already.
+func applyTargetPractice(beatMap *BeatMap, diffCalcOnly bool) {
+	if len(beatMap.HitObjects) == 0 {
+		return
+	}
+
+	conf, ok := difficulty.GetModConfig[difficulty.TargetPracticeSettings](beatMap.Diff)
+	if !ok {
+		conf = difficulty.NewTargetPracticeSettings()
+	}
+
+	original := beatMap.HitObjects
+
+	beats := generateBeats(beatMap, original)
+
+	circles := make([]*targetCircle, 0, len(beats))
+
+	for _, beat := range beats {
+		sample, hitSound := getSamplesAtTime(original, beat)
+
+		circles = append(circles, &targetCircle{
+			circle: objects.NewGeneratedCircle(playfieldCentre, beat, sample, hitSound),
+		})
+	}
+
+	fixComboInfo(original, circles)
+
+	randomizeCirclePositions(beatMap, circles, netrand.New(int32(conf.Seed)))
+
+	beatMap.HitObjects = make([]objects.IHitObject, len(circles))
+
+	for i, c := range circles {
+		beatMap.HitObjects[i] = c.circle
+	}
+
+	calculateCombos(beatMap)
+
+	for _, obj := range beatMap.HitObjects {
+		obj.SetTiming(beatMap.Timings, beatMap.Version, diffCalcOnly)
+	}
+}
+
+func almostBigger(a, b float64) bool {
+	return a > b-tpTimingPrecision
+}
+
+func definitelyBigger(a, b float64) bool {
+	return a-tpTimingPrecision > b
+}
+
+func almostEquals(a, b float64) bool {
+	return math.Abs(a-b) <= tpTimingPrecision
+}
+
+func getLazerEndTime(obj objects.IHitObject) float64 {
+	if slider, ok := obj.(*objects.Slider); ok {
+		return slider.EndTimeLazer
+	}
+
+	return obj.GetEndTime()
+}
+
+func generateBeats(beatMap *BeatMap, original []objects.IHitObject) []float64 {
+	startTime := original[0].GetStartTime()
+	endTime := getLazerEndTime(original[len(original)-1])
+
+	points := beatMap.Timings.GetOriginalPoints()
+
+	var beats []float64
+
+	for i, point := range points {
+		// Ignore timing points after endTime
+		if definitelyBigger(point.Time, endTime) {
+			continue
+		}
+
+		nextTime := math.Inf(1)
+		if i < len(points)-1 {
+			nextTime = points[i+1].Time
+		}
+
+		beatLength := point.GetBaseBeatLength()
+		if !(beatLength > 0) {
+			continue
+		}
+
+		// Generate beats as long as the timing point is active
+		for j := 0; ; j++ {
+			currentTime := point.Time + float64(j)*beatLength
+
+			if definitelyBigger(currentTime, endTime) || currentTime >= nextTime {
+				break
+			}
+
+			beat := math.Floor(currentTime)
+
+			// Remove beats before startTime and during breaks
+			if almostBigger(beat, startTime) && !isInsideBreakPeriod(beatMap, original, beat) {
+				beats = append(beats, beat)
+			}
+		}
+	}
+
+	// Remove beats that are too close to the next one (e.g. due to timing point changes)
+	for i := len(beats) - 2; i >= 0; i-- {
+		beat := beats[i]
+
+		if !definitelyBigger(beats[i+1]-beat, beatMap.Timings.GetOriginalPointAt(beat).GetBaseBeatLength()/2) {
+			beats = slices.Delete(beats, i, i+1)
+		}
+	}
+
+	return beats
+}
+
+// isInsideBreakPeriod checks if the time is inside a break. Unlike break's end time, the period ends strictly at the first object after the break.
+func isInsideBreakPeriod(beatMap *BeatMap, original []objects.IHitObject, time float64) bool {
+	for _, pause := range beatMap.Pauses {
+		index := slices.IndexFunc(original, func(obj objects.IHitObject) bool {
+			return almostBigger(obj.GetStartTime(), pause.EndTime)
+		})
+
+		if index == -1 {
+			continue
+		}
+
+		if almostBigger(time, pause.StartTime) && definitelyBigger(original[index].GetStartTime(), time) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getSamplesAtTime returns samples of an original object or slider's node at the given time.
+// If there are none, normal sample of the closest original object is used.
+func getSamplesAtTime(original []objects.IHitObject, time float64) (sample int, hitSound audio.HitSoundInfo) {
+	for _, obj := range original {
+		if almostEquals(time, obj.GetStartTime()) {
+			return getObjectSamples(obj, 0)
+		}
+
+		slider, ok := obj.(*objects.Slider)
+		if !ok || !almostBigger(time, slider.StartTime) || !almostBigger(slider.EndTimeLazer, time) {
+			continue
+		}
+
+		spanDuration := (slider.EndTimeLazer - slider.StartTime) / float64(slider.RepeatCount)
+		spanProgress := (time - slider.StartTime) / spanDuration
+		nodeIndex := math.Round(spanProgress)
+
+		if almostEquals(nodeIndex, spanProgress) {
+			return getObjectSamples(slider, int(nodeIndex))
+		}
+	}
+
+	_, hitSound = getObjectSamples(getClosestObject(original, time), 0)
+
+	return 0, hitSound
+}
+
+func getObjectSamples(obj objects.IHitObject, nodeIndex int) (sample int, hitSound audio.HitSoundInfo) {
+	switch o := obj.(type) {
+	case *objects.Circle:
+		return o.GetSample(), o.BasicHitSound
+	case *objects.Spinner:
+		return o.GetSample(), o.BasicHitSound
+	case *objects.Slider:
+		hitSound = o.BasicHitSound
+		sample, hitSound.SampleSet, hitSound.AdditionSet = o.GetEdgeSample(min(nodeIndex, o.RepeatCount))
+
+		return sample, hitSound
+	}
+
+	return
+}
+
+// getClosestObject returns the closest preceding or succeeding object, whichever is closer in time
+func getClosestObject(original []objects.IHitObject, time float64) objects.IHitObject {
+	precedingIndex := -1
+
+	for i, obj := range original {
+		if obj.GetStartTime() < time {
+			precedingIndex = i
+		}
+	}
+
+	if precedingIndex == -1 {
+		return original[0]
+	}
+
+	if precedingIndex == len(original)-1 {
+		return original[precedingIndex]
+	}
+
+	if original[precedingIndex+1].GetStartTime()-time < time-original[precedingIndex].GetStartTime() {
+		return original[precedingIndex+1]
+	}
+
+	return original[precedingIndex]
+}
+
+// fixComboInfo copies combos from original objects at the same time or the closest preceding ones.
+// Combo indices are then made continuous, because the original map can start and end a combo in between beats.
+func fixComboInfo(original []objects.IHitObject, circles []*targetCircle) {
+	lastComboSet := int64(-1)
+	comboIndex := -1
+
+	for _, c := range circles {
+		comboSet := int64(0)
+
+		for i := len(original) - 1; i >= 0; i-- {
+			if almostBigger(c.circle.StartTime, original[i].GetStartTime()) {
+				comboSet = original[i].GetComboSet()
+				break
+			}
+		}
+
+		if comboSet != lastComboSet {
+			lastComboSet = comboSet
+			comboIndex++
+
+			c.circle.NewCombo = true
+		}
+
+		c.comboIndex = comboIndex
+	}
+}
+
+func randomizeCirclePositions(beatMap *BeatMap, circles []*targetCircle, rng *netrand.Random) {
+	if len(circles) == 0 {
+		return
+	}
+
+	nextSingle := func(maxV float32) float32 {
+		return float32(rng.NextDouble() * float64(maxV))
+	}
+
+	twoPi := math32.Pi * 2
+
+	radius := float32(beatMap.Diff.CircleRadiusL)
+
+	direction := twoPi * nextSingle(1)
+	maxComboIndex := circles[len(circles)-1].comboIndex
+
+	for i, c := range circles {
+		lastPos := playfieldCentre
+		if i > 0 {
+			lastPos = circles[i-1].circle.StartPosRaw
+		}
+
+		distance := radius
+		if maxComboIndex > 0 {
+			distance = radius + float32(c.comboIndex)*(tpMaxBaseDistance-radius)/float32(maxComboIndex)
+		}
+
+		if c.circle.NewCombo {
+			distance *= 1.5
+		}
+
+		if beatMap.Timings.GetPointAt(c.circle.StartTime + 1).Kiai {
+			distance *= 1.2
+		}
+
+		distance = min(tpDistanceCap, distance)
+
+		// Attempt to place the circle at a place that does not overlap with previous ones
+		preceding := circles[max(0, i-tpOverlapCheckCount):i]
+
+		for tryCount := 0; ; {
+			if tryCount > 0 {
+				direction = twoPi * nextSingle(1)
+			}
+
+			relativePos := vector.NewVec2f(distance*math32.Cos(direction), distance*math32.Sin(direction))
+
+			// Rotate the new circle away from playfield border
+			relativePos = rotateAwayFromEdge(lastPos, relativePos, tpEdgeRotationMultiplier)
+			direction = relativePos.AngleR()
+
+			setObjectPosition(c.circle, clampToPlayfieldWithPadding(lastPos.Add(relativePos), radius))
+
+			tryCount++
+			if tryCount%10 == 0 {
+				distance *= 0.9
+			}
+
+			if distance < radius*2 || !checkForOverlap(preceding, c.circle, radius) {
+				break
+			}
+		}
+
+		isLastInCombo := i == len(circles)-1 || circles[i+1].circle.NewCombo
+
+		if isLastInCombo {
+			direction = twoPi * nextSingle(1)
+		} else {
+			direction += distance / tpDistanceCap * (nextSingle(1)*twoPi - math32.Pi)
+		}
+	}
+}
+
+func checkForOverlap(toCheck []*targetCircle, target *objects.Circle, radius float32) bool {
+	return slices.ContainsFunc(toCheck, func(c *targetCircle) bool {
+		return c.circle.StartPosRaw.Dst(target.StartPosRaw) < radius*2
+	})
+}
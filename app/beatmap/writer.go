@@ -0,0 +1,231 @@
+package beatmap
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/wieku/danser-go/app/audio"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/framework/math/curves"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var curveTypes = map[curves.CType]string{
+	curves.CLine:    "L",
+	curves.CBezier:  "B",
+	curves.CCirArc:  "P",
+	curves.CCatmull: "C",
+}
+
+var sampleSetNames = map[int]string{
+	1: "Normal",
+	2: "Soft",
+	3: "Drum",
+}
+
+// WriteBeatMap writes the beatmap in .osu (v14) format. Timing points and objects have to be already parsed.
+// Mods applied to beatmap's difficulty are baked in: times are scaled by the rate, objects are flipped by Hard Rock or Mirror,
+// and AR, OD, CS, HP are written as they're perceived while playing, so the result played without mods feels the same.
+// Storyboards, videos and combo colours are not written.
+func WriteBeatMap(w io.Writer, beatMap *BeatMap) error {
+	diff := beatMap.Diff
+
+	speed := diff.GetSpeed()
+
+	if diff.CheckModActive(difficulty.WindUp, difficulty.WindDown, difficulty.AdaptiveSpeed) {
+		return fmt.Errorf("rate changing during playback (%s) can't be written to a beatmap", diff.GetModString())
+	}
+
+	bw := bufio.NewWriter(w)
+
+	scaleTime := func(time float64) float64 {
+		return time / speed
+	}
+
+	// hit objects' times have to be integers in stable
+	objectTime := func(time float64) int64 {
+		return int64(math.Round(scaleTime(time)))
+	}
+
+	fmt.Fprint(bw, "osu file format v14\r\n")
+
+	fmt.Fprint(bw, "\r\n[General]\r\n")
+	fmt.Fprintf(bw, "AudioFilename: %s\r\n", beatMap.Audio)
+	fmt.Fprint(bw, "AudioLeadIn: 0\r\n")
+
+	previewTime := beatMap.PreviewTime
+	if previewTime > 0 {
+		previewTime = objectTime(float64(previewTime))
+	}
+
+	fmt.Fprintf(bw, "PreviewTime: %d\r\n", previewTime)
+	fmt.Fprint(bw, "Countdown: 0\r\n")
+
+	if name, ok := sampleSetNames[beatMap.Timings.BaseSet]; ok {
+		fmt.Fprintf(bw, "SampleSet: %s\r\n", name)
+	}
+
+	fmt.Fprintf(bw, "StackLeniency: %s\r\n", formatFloat(beatMap.StackLeniency))
+	fmt.Fprintf(bw, "Mode: %d\r\n", beatMap.Mode)
+
+	fmt.Fprint(bw, "\r\n[Metadata]\r\n")
+	fmt.Fprintf(bw, "Title:%s\r\n", beatMap.Name)
+	fmt.Fprintf(bw, "TitleUnicode:%s\r\n", beatMap.NameUnicode)
+	fmt.Fprintf(bw, "Artist:%s\r\n", beatMap.Artist)
+	fmt.Fprintf(bw, "ArtistUnicode:%s\r\n", beatMap.ArtistUnicode)
+	fmt.Fprintf(bw, "Creator:%s\r\n", beatMap.Creator)
+	fmt.Fprintf(bw, "Version:%s\r\n", beatMap.Difficulty)
+	fmt.Fprintf(bw, "Source:%s\r\n", beatMap.Source)
+	fmt.Fprintf(bw, "Tags:%s\r\n", beatMap.Tags)
+	fmt.Fprintf(bw, "BeatmapID:%d\r\n", beatMap.ID)
+	fmt.Fprintf(bw, "BeatmapSetID:%d\r\n", beatMap.SetID)
+
+	fmt.Fprint(bw, "\r\n[Difficulty]\r\n")
+	fmt.Fprintf(bw, "HPDrainRate:%s\r\n", formatDifficulty(diff.HPMod))
+	fmt.Fprintf(bw, "CircleSize:%s\r\n", formatDifficulty(diff.CSMod))
+	fmt.Fprintf(bw, "OverallDifficulty:%s\r\n", formatDifficulty(diff.ODReal))
+	fmt.Fprintf(bw, "ApproachRate:%s\r\n", formatDifficulty(diff.ARReal))
+	fmt.Fprintf(bw, "SliderMultiplier:%s\r\n", formatFloat(beatMap.Timings.SliderMult))
+	fmt.Fprintf(bw, "SliderTickRate:%s\r\n", formatFloat(beatMap.Timings.TickRate))
+
+	fmt.Fprint(bw, "\r\n[Events]\r\n")
+
+	if beatMap.Bg != "" {
+		fmt.Fprintf(bw, "0,0,\"%s\",0,0\r\n", beatMap.Bg)
+	}
+
+	for _, pause := range beatMap.Pauses {
+		fmt.Fprintf(bw, "2,%d,%d\r\n", objectTime(pause.StartTime), objectTime(pause.EndTime))
+	}
+
+	fmt.Fprint(bw, "\r\n[TimingPoints]\r\n")
+
+	for _, point := range beatMap.Timings.GetPoints() {
+		beatLength := point.GetRawBeatLength()
+
+		uninherited := 1
+		if point.Inherited {
+			uninherited = 0
+		} else {
+			beatLength /= speed
+		}
+
+		effects := 0
+		if point.Kiai {
+			effects |= 1
+		}
+
+		if point.OmitFirstBarLine {
+			effects |= 8
+		}
+
+		fmt.Fprintf(bw, "%s,%s,%d,%d,%d,%d,%d,%d\r\n",
+			formatFloat(scaleTime(point.Time)),
+			formatFloat(beatLength),
+			point.Signature,
+			point.SampleSet,
+			point.SampleIndex,
+			int(math.Round(point.SampleVolume*100)),
+			uninherited,
+			effects)
+	}
+
+	fmt.Fprint(bw, "\r\n[HitObjects]\r\n")
+
+	for _, obj := range beatMap.HitObjects {
+		base := getObjectBase(obj)
+		if base == nil {
+			continue
+		}
+
+		objType := obj.GetType()
+
+		if base.NewCombo {
+			objType |= objects.NEWCOMBO
+		}
+
+		objType |= objects.Type(base.ColorOffset << 4)
+
+		position := objects.FlipPosition(base.StartPosRaw, diff)
+
+		fmt.Fprintf(bw, "%s,%s,%d,%d,", formatPosition(position.X), formatPosition(position.Y), objectTime(base.StartTime), objType)
+
+		switch o := obj.(type) {
+		case *objects.Circle:
+			fmt.Fprintf(bw, "%d,%s\r\n", o.GetSample(), formatHitSample(base.BasicHitSound))
+		case *objects.Spinner:
+			fmt.Fprintf(bw, "%d,%d,%s\r\n", o.GetSample(), objectTime(o.GetEndTime()), formatHitSample(base.BasicHitSound))
+		case *objects.Slider:
+			edgeSounds := make([]string, o.RepeatCount+1)
+			edgeSets := make([]string, o.RepeatCount+1)
+
+			for i := range edgeSounds {
+				sample, sampleSet, additionSet := o.GetEdgeSample(i)
+
+				edgeSounds[i] = strconv.Itoa(sample)
+				edgeSets[i] = fmt.Sprintf("%d:%d", sampleSet, additionSet)
+			}
+
+			fmt.Fprintf(bw, "%d,%s,%d,%s,%s,%s,%s\r\n",
+				o.GetBaseSample(),
+				formatCurve(o.GetCurveDefs(), diff),
+				o.RepeatCount,
+				formatFloat(o.GetPixelLength()),
+				strings.Join(edgeSounds, "|"),
+				strings.Join(edgeSets, "|"),
+				formatHitSample(base.BasicHitSound))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// formatCurve writes slider's path skipping its start position. Segments after the first one start at the last point
+// of the previous segment, so that point is written once, after the type of the segment it begins.
+func formatCurve(defs []curves.CurveDef, diff *difficulty.Difficulty) string {
+	var sb strings.Builder
+
+	for i, def := range defs {
+		points := def.Points
+		if i == 0 {
+			points = points[1:]
+		}
+
+		if i < len(defs)-1 && len(points) > 0 {
+			points = points[:len(points)-1]
+		}
+
+		if i > 0 {
+			sb.WriteString("|")
+		}
+
+		sb.WriteString(curveTypes[def.CurveType])
+
+		for _, p := range points {
+			p = objects.FlipPosition(p, diff)
+			sb.WriteString("|" + formatPosition(p.X) + ":" + formatPosition(p.Y))
+		}
+	}
+
+	return sb.String()
+}
+
+func formatHitSample(info audio.HitSoundInfo) string {
+	return fmt.Sprintf("%d:%d:%d:%d:", info.SampleSet, info.AdditionSet, info.CustomIndex, int(math.Round(info.CustomVolume*100)))
+}
+
+func formatPosition(v float32) string {
+	return strconv.FormatFloat(float64(v), 'f', -1, 32)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatDifficulty rounds difficulty values to 2 decimal places, rate changes produce values like 9.666666
+func formatDifficulty(v float64) string {
+	return formatFloat(math.Round(v*100) / 100)
+}
@@ -23,6 +23,7 @@ type GenericController struct {
 	bMap       *beatmap.BeatMap
 	cursors    []*graphics.Cursor
 	schedulers []schedulers.Scheduler
+	recorders  []*ReplayRecorder
 }
 
 func NewGenericController() Controller {
@@ -164,6 +165,13 @@ func (controller *GenericController) InitCursors() {
 
 		controller.schedulers[i].Init(queues[i].hitObjects, controller.bMap.Diff, controller.cursors[i], spinners.GetMoverCtorByName(spinMover), true)
 	}
+
+	// In knockout mode recording is handled by ReplayController as it has access to the ruleset
+	if settings.Gameplay.SaveDanserReplays && !settings.KNOCKOUT {
+		for _, cursor := range controller.cursors {
+			controller.recorders = append(controller.recorders, NewReplayRecorder(controller.bMap, cursor, controller.bMap.Diff))
+		}
+	}
 }
 
 func (controller *GenericController) Update(time float64, delta float64) {
@@ -174,6 +182,19 @@ func (controller *GenericController) Update(time float64, delta float64) {
 		controller.cursors[i].LeftButton = controller.cursors[i].LeftKey || controller.cursors[i].LeftMouse
 		controller.cursors[i].RightButton = controller.cursors[i].RightKey || controller.cursors[i].RightMouse
 	}
+
+	if len(controller.recorders) > 0 {
+		objs := controller.bMap.HitObjects
+		ended := len(objs) == 0 || time >= objs[len(objs)-1].GetEndTime()
+
+		for _, recorder := range controller.recorders {
+			recorder.Update(time)
+
+			if ended {
+				recorder.Save(nil)
+			}
+		}
+	}
 }
 
 func (controller *GenericController) GetCursors() []*graphics.Cursor {
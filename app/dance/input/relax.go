@@ -63,3 +63,12 @@ func (processor *RelaxInputProcessor) Update(time float64) {
 		processor.wasLeft = !processor.wasLeft
 	}
 }
+
+// WasLeft returns whether the last click was made with the left button, used to restore the state when rewinding
+func (processor *RelaxInputProcessor) WasLeft() bool {
+	return processor.wasLeft
+}
+
+func (processor *RelaxInputProcessor) SetWasLeft(wasLeft bool) {
+	processor.wasLeft = wasLeft
+}
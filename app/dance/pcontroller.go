@@ -40,6 +40,8 @@ type PlayerController struct {
 
 	quickRestart     bool
 	quickRestartTime float64
+
+	recorder *ReplayRecorder
 }
 
 func NewPlayerController() Controller {
@@ -58,6 +60,10 @@ func (controller *PlayerController) InitCursors() {
 	controller.window = glfw.GetCurrentContext()
 	controller.ruleset = osu.NewOsuRuleset(controller.bMap, controller.cursors, []*difficulty.Difficulty{controller.bMap.Diff.Clone()})
 
+	if settings.Gameplay.SavePlayReplays {
+		controller.recorder = NewReplayRecorder(controller.bMap, controller.cursors[0], controller.bMap.Diff)
+	}
+
 	if !controller.bMap.Diff.CheckModActive(difficulty.Relax) {
 		input2.RegisterListener(controller.KeyEvent)
 	} else {
@@ -170,6 +176,16 @@ func (controller *PlayerController) Update(time float64, delta float64) {
 	controller.ruleset.UpdatePostFor(controller.cursors[0], int64(time), false)
 	controller.ruleset.Update(int64(time))
 
+	if controller.recorder != nil && !controller.recorder.IsSaved() {
+		controller.recorder.Update(time)
+		controller.recorder.UpdateLife(int64(time), controller.ruleset.GetHP(controller.cursors[0]))
+
+		if controller.ruleset.HasEnded() || controller.ruleset.HasFailed(controller.cursors[0]) {
+			score := controller.ruleset.GetScore(controller.cursors[0])
+			controller.recorder.Save(&score)
+		}
+	}
+
 	controller.lastTime = time
 
 	controller.cursors[0].Update(delta)
@@ -39,6 +39,7 @@ type RpData struct {
 	Grade     osu.Grade
 	scoreID   int64
 	ScoreTime time.Time
+	ReplayMD5 string
 }
 
 type subControl struct {
@@ -52,6 +53,7 @@ type subControl struct {
 	relaxController *input.RelaxInputProcessor
 	mouseController schedulers.Scheduler
 	diff            *difficulty.Difficulty
+	recorder        *ReplayRecorder
 
 	modifiedMods bool
 }
@@ -68,6 +70,9 @@ type ReplayController struct {
 	controllers []*subControl
 	ruleset     *osu.OsuRuleSet
 	lastTime    float64
+
+	snapshots        []*replaySnapshot
+	snapshotsEnabled bool
 }
 
 func NewReplayController() Controller {
@@ -79,11 +84,60 @@ func NewReplayController() Controller {
 func (controller *ReplayController) SetBeatMap(beatMap *beatmap.BeatMap) {
 	controller.bMap = beatMap
 
+	candidates, localReplay := loadCandidates(beatMap, rplpa.OSU)
+
+	hiddenMods := difficulty.ParseMods(settings.Knockout.HideMods)
+
+	for i, replay := range candidates {
+		log.Println(fmt.Sprintf("Loading replay for \"%s\":", replay.Username))
+
+		control := NewSubControl()
+
+		setupReplayDiff(control, beatMap, replay, localReplay)
+
+		if localReplay {
+			// Music has to follow player's Adaptive Speed as its rate depends on their hits
+			beatMap.Diff.LinkRate(control.diff)
+		}
+
+		loadFrames(control, replay.ReplayData)
+
+		mxCombo := replay.MaxCombo
+
+		control.newHandling = replay.OsuVersion >= 20190506 // This was when slider scoring was changed, so *I think* replay handling as well: https://osu.ppy.sh/home/changelog/cuttingedge/20190506
+		control.oldSpinners = replay.OsuVersion < 20190510  // This was when spinner scoring was changed: https://osu.ppy.sh/home/changelog/cuttingedge/20190510.2
+
+		controller.replays = append(controller.replays, RpData{replay.Username, replay.Username + string(rune(unicode.MaxRune-i)), control.diff.Mods.Difference(hiddenMods).String(), control.diff.Mods, 100, 0, int64(mxCombo), osu.NONE, replay.ScoreID, replay.Timestamp, replay.ReplayMD5})
+		controller.controllers = append(controller.controllers, control)
+
+		log.Println("\tExpected score:", replay.Score)
+		log.Println("\tReplay loaded!")
+	}
+
+	if !localReplay && (settings.Knockout.AddDanser || len(controller.controllers) == 0) {
+		control := NewSubControl()
+		control.diff = beatMap.Diff.Clone()
+
+		control.danceController = NewGenericController()
+		control.danceController.SetBeatMap(beatMap)
+
+		controller.replays = append([]RpData{{settings.Knockout.DanserName, settings.Knockout.DanserName, control.diff.GetModString(), control.diff.Mods, 100, 0, 0, osu.NONE, -1, time.Now(), ""}}, controller.replays...)
+		controller.controllers = append([]*subControl{control}, controller.controllers...)
+
+		if len(candidates) == 0 {
+			controller.bMap.Diff.AddMod(difficulty.Autoplay)
+		}
+	}
+
+	settings.PLAYERS = len(controller.replays)
+}
+
+// loadCandidates loads the replay passed with -replay or replays for knockout. Replays of other game modes are skipped.
+func loadCandidates(beatMap *beatmap.BeatMap, playMode int8) (candidates []*rplpa.Replay, localReplay bool) {
 	organizeReplays()
 
-	candidates := make([]*rplpa.Replay, 0)
+	candidates = make([]*rplpa.Replay, 0)
 
-	localReplay := false
 	if settings.REPLAY != "" {
 		log.Println("Loading: ", settings.REPLAY)
 
@@ -102,7 +156,7 @@ func (controller *ReplayController) SetBeatMap(beatMap *beatmap.BeatMap) {
 			localReplay = true
 		}
 	} else if settings.Knockout.MaxPlayers > 0 || (settings.KNOCKOUTREPLAYS != nil && len(settings.KNOCKOUTREPLAYS) > 0) { // ignore max player limit with new knockout
-		candidates = controller.getCandidates()
+		candidates = getCandidates(beatMap, playMode)
 	}
 
 	if !localReplay {
@@ -115,69 +169,43 @@ func (controller *ReplayController) SetBeatMap(beatMap *beatmap.BeatMap) {
 		}
 	}
 
-	displayedMods := ^difficulty.ParseMods(settings.Knockout.HideMods)
-
-	for i, replay := range candidates {
-		log.Println(fmt.Sprintf("Loading replay for \"%s\":", replay.Username))
-
-		control := NewSubControl()
-
-		control.diff = beatMap.Diff.Clone()
-		control.diff.SetMods(difficulty.None)
-
-		if replay.ScoreInfo != nil && replay.ScoreInfo.Mods != nil && len(replay.ScoreInfo.Mods) > 0 {
-			modsNew := make([]rplpa.ModInfo, 0, len(replay.ScoreInfo.Mods))
-
-			for _, mod := range replay.ScoreInfo.Mods {
-				modsNew = append(modsNew, *mod)
-			}
-
-			control.diff.SetMods2(modsNew)
-		} else {
-			control.diff.SetMods(difficulty.Modifier(replay.Mods))
-		}
-
-		if replay.OsuVersion >= 30000000 { // Lazer is 1000 years in the future
-			control.diff.Mods |= difficulty.Lazer
-		}
-
-		if localReplay && !beatMap.Diff.Equals(control.diff) {
-			control.diff.SetMods2(beatMap.Diff.ExportMods2())
-			control.modifiedMods = true
-		}
-
-		log.Println("\tMods:", control.diff.GetModString())
-
-		loadFrames(control, replay.ReplayData)
-
-		mxCombo := replay.MaxCombo
+	return
+}
 
-		control.newHandling = replay.OsuVersion >= 20190506 // This was when slider scoring was changed, so *I think* replay handling as well: https://osu.ppy.sh/home/changelog/cuttingedge/20190506
-		control.oldSpinners = replay.OsuVersion < 20190510  // This was when spinner scoring was changed: https://osu.ppy.sh/home/changelog/cuttingedge/20190510.2
+// setupReplayDiff creates subcontroller's difficulty with mods used in the replay
+func setupReplayDiff(control *subControl, beatMap *beatmap.BeatMap, replay *rplpa.Replay, localReplay bool) {
+	control.diff = beatMap.Diff.Clone()
+	control.diff.SetMods(difficulty.None)
 
-		controller.replays = append(controller.replays, RpData{replay.Username, replay.Username + string(rune(unicode.MaxRune-i)), (control.diff.Mods & displayedMods).String(), control.diff.Mods, 100, 0, int64(mxCombo), osu.NONE, replay.ScoreID, replay.Timestamp})
-		controller.controllers = append(controller.controllers, control)
+	if modsNew := getLazerMods(replay); len(modsNew) > 0 {
+		control.diff.SetMods2(modsNew)
+	} else {
+		control.diff.SetMods(difficulty.ModifierFromLegacy(replay.Mods))
+	}
 
-		log.Println("\tExpected score:", replay.Score)
-		log.Println("\tReplay loaded!")
+	if replay.OsuVersion >= 30000000 { // Lazer is 1000 years in the future
+		control.diff.Mods = control.diff.Mods.With(difficulty.Lazer)
 	}
 
-	if !localReplay && (settings.Knockout.AddDanser || len(controller.controllers) == 0) {
-		control := NewSubControl()
-		control.diff = beatMap.Diff.Clone()
+	if localReplay && !beatMap.Diff.Equals(control.diff) {
+		control.diff.SetMods2(beatMap.Diff.ExportMods2())
+		control.modifiedMods = true
+	}
 
-		control.danceController = NewGenericController()
-		control.danceController.SetBeatMap(beatMap)
+	log.Println("\tMods:", control.diff.GetModString())
+}
 
-		controller.replays = append([]RpData{{settings.Knockout.DanserName, settings.Knockout.DanserName, control.diff.GetModString(), control.diff.Mods, 100, 0, 0, osu.NONE, -1, time.Now()}}, controller.replays...)
-		controller.controllers = append([]*subControl{control}, controller.controllers...)
+// getLazerMods returns lazer style mods of the replay, nil if it has only legacy ones
+func getLazerMods(replay *rplpa.Replay) (mods []rplpa.ModInfo) {
+	if replay.ScoreInfo == nil {
+		return nil
+	}
 
-		if len(candidates) == 0 {
-			controller.bMap.Diff.AddMod(difficulty.Autoplay)
-		}
+	for _, mod := range replay.ScoreInfo.Mods {
+		mods = append(mods, *mod)
 	}
 
-	settings.PLAYERS = len(controller.replays)
+	return
 }
 
 func organizeReplays() {
@@ -217,7 +245,7 @@ func organizeReplays() {
 	}
 }
 
-func (controller *ReplayController) getCandidates() (candidates []*rplpa.Replay) {
+func getCandidates(beatMap *beatmap.BeatMap, playMode int8) (candidates []*rplpa.Replay) {
 	excludedMods := difficulty.ParseMods(settings.Knockout.ExcludeMods)
 
 	tryAddReplay := func(path string, modExclude bool) {
@@ -235,17 +263,27 @@ func (controller *ReplayController) getCandidates() (candidates []*rplpa.Replay)
 			return
 		}
 
-		if !strings.EqualFold(replayD.BeatmapMD5, controller.bMap.MD5) {
+		if !strings.EqualFold(replayD.BeatmapMD5, beatMap.MD5) {
 			log.Println("Incompatible maps, skipping", replayD.Username)
 			return
 		}
 
-		if !difficulty.Modifier(replayD.Mods).Compatible() || difficulty.Modifier(replayD.Mods).Active(difficulty.Target) {
+		if replayD.PlayMode != playMode {
+			log.Println("Excluding for different game mode:", replayD.Username)
+			return
+		}
+
+		if !difficulty.ModifierFromLegacy(replayD.Mods).Compatible() || difficulty.ModifierFromLegacy(replayD.Mods).Active(difficulty.Target) {
 			log.Println("Excluding for incompatible mods:", replayD.Username)
 			return
 		}
 
-		if (replayD.Mods&uint32(excludedMods)) > 0 && modExclude {
+		if difficulty.HasDrawnPositionMods(getLazerMods(replayD)) {
+			log.Println("Excluding for mods which danser can't judge (Magnetised, Repel, Wiggle):", replayD.Username)
+			return
+		}
+
+		if difficulty.ModifierFromLegacy(replayD.Mods).Intersects(excludedMods) && modExclude {
 			log.Println("Excluding for mods:", replayD.Username)
 			return
 		}
@@ -263,7 +301,7 @@ func (controller *ReplayController) getCandidates() (candidates []*rplpa.Replay)
 			tryAddReplay(r, false)
 		}
 	} else {
-		replayDir := filepath.Join(env.DataDir(), replaysMaster, controller.bMap.MD5)
+		replayDir := filepath.Join(env.DataDir(), replaysMaster, beatMap.MD5)
 
 		replayPaths, _ := files.SearchFiles(replayDir, "*.osr", 0)
 
@@ -294,11 +332,12 @@ func loadFrames(subController *subControl, frames []*rplpa.ReplayData) {
 	duration := 0
 
 	for _, frame := range frames {
+		duration += int(frame.Time)
+
 		if frame.Time >= 0 {
-			times = append(times, float64(frame.Time))
+			// Frame deltas are in beatmap time, so they are converted with the rate at the time of the frame
+			times = append(times, subController.diff.GetModifiedTimeAt(float64(duration), float64(frame.Time)))
 		}
-
-		duration += int(frame.Time)
 	}
 
 	sort.Float64s(times)
@@ -311,11 +350,9 @@ func loadFrames(subController *subControl, frames []*rplpa.ReplayData) {
 		meanFrameTime = (times[l/2] + times[l/2-1]) / 2
 	}
 
-	meanFrameTime = subController.diff.GetModifiedTime(meanFrameTime)
-
 	log.Println(fmt.Sprintf("\tMedian cv frametime: %.2fms", meanFrameTime))
 
-	if meanFrameTime <= 13 && !subController.diff.CheckModActive(difficulty.Autoplay|difficulty.Relax|difficulty.Relax2) {
+	if meanFrameTime <= 13 && !subController.diff.CheckModActive(difficulty.Autoplay, difficulty.Relax, difficulty.Relax2) {
 		log.Println("\tWARNING!!! THIS REPLAY WAS PROBABLY TIMEWARPED!!!")
 	}
 
@@ -343,6 +380,10 @@ func (controller *ReplayController) InitCursors() {
 			}
 
 			controller.cursors = append(controller.cursors, cursors...)
+
+			if settings.Gameplay.SaveDanserReplays {
+				c.recorder = NewReplayRecorder(controller.bMap, cursors[0], c.diff)
+			}
 		} else {
 			cursor := graphics.NewCursor()
 			cursor.Name = controller.replays[i].RawName
@@ -455,14 +496,32 @@ func (controller *ReplayController) updateMain(nTime float64) {
 		controller.ruleset.Update(int64(nTime))
 	}
 
+	for i, c := range controller.controllers {
+		if c.recorder == nil || c.recorder.IsSaved() {
+			continue
+		}
+
+		c.recorder.Update(nTime)
+		c.recorder.UpdateLife(int64(nTime), controller.ruleset.GetHP(controller.cursors[i]))
+
+		if controller.ruleset.HasEnded() {
+			score := controller.ruleset.GetScore(controller.cursors[i])
+			c.recorder.Save(&score)
+		}
+	}
+
 	controller.lastTime = nTime
+
+	if controller.snapshotsEnabled && controller.CanRewind() {
+		controller.takeSnapshot(nTime)
+	}
 }
 
 func (controller *ReplayController) processLazer(i int, c *subControl, nTime float64) {
 	wasUpdated := false
 
-	isRelax := (controller.replays[i].ModsV & difficulty.Relax) > 0
-	isAutopilot := (controller.replays[i].ModsV & difficulty.Relax2) > 0
+	isRelax := controller.replays[i].ModsV.Active(difficulty.Relax)
+	isAutopilot := controller.replays[i].ModsV.Active(difficulty.Relax2)
 
 	if isAutopilot {
 		c.mouseController.Update(nTime)
@@ -541,8 +600,8 @@ func (controller *ReplayController) processLazer(i int, c *subControl, nTime flo
 func (controller *ReplayController) processStable(i int, c *subControl, nTime float64) {
 	wasUpdated := false
 
-	isRelax := (controller.replays[i].ModsV & difficulty.Relax) > 0
-	isAutopilot := (controller.replays[i].ModsV & difficulty.Relax2) > 0
+	isRelax := controller.replays[i].ModsV.Active(difficulty.Relax)
+	isAutopilot := controller.replays[i].ModsV.Active(difficulty.Relax2)
 
 	if isAutopilot {
 		c.mouseController.Update(nTime)
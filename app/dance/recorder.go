@@ -0,0 +1,387 @@
+package dance
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/itchio/lzma"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/env"
+	"github.com/wieku/rplpa"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	savedReplays = "saved"
+
+	stableVersion = 20250122
+	lazerVersion  = 30000016
+
+	// Frames are written at least this often even if nothing changes, similarly to stable's 60Hz input polling
+	maxFrameInterval = 16
+
+	lifeBarInterval = 1000
+)
+
+// ReplayRecorder samples the state of a cursor and serializes it into a stable compatible .osr file
+type ReplayRecorder struct {
+	bMap   *beatmap.BeatMap
+	cursor *graphics.Cursor
+	diff   *difficulty.Difficulty
+
+	frames  []*rplpa.ReplayData
+	lifeBar []rplpa.LifeBarGraph
+
+	lastTime     int64
+	lastLifeTime int64
+	lastKeys     rplpa.KeyPressed
+	lastPosition [2]float32
+
+	saved bool
+}
+
+func NewReplayRecorder(bMap *beatmap.BeatMap, cursor *graphics.Cursor, diff *difficulty.Difficulty) *ReplayRecorder {
+	recorder := &ReplayRecorder{
+		bMap:         bMap,
+		cursor:       cursor,
+		diff:         diff,
+		lastTime:     -1,
+		lastLifeTime: math.MinInt64,
+	}
+
+	// stable writes these two frames at the beginning of every replay, first one is skipped during playback
+	recorder.frames = append(recorder.frames,
+		&rplpa.ReplayData{Time: 0, MouseX: 256, MouseY: -500, KeyPressed: &rplpa.KeyPressed{}},
+		&rplpa.ReplayData{Time: -1, MouseX: 256, MouseY: -500, KeyPressed: &rplpa.KeyPressed{}},
+	)
+
+	return recorder
+}
+
+// Update samples the cursor at the given time. Only one frame per millisecond is written.
+func (recorder *ReplayRecorder) Update(time float64) {
+	if recorder.saved || time < 0 {
+		return
+	}
+
+	iTime := int64(time)
+
+	if iTime <= recorder.lastTime {
+		return
+	}
+
+	keys := rplpa.KeyPressed{
+		LeftClick:  recorder.cursor.LeftButton,
+		RightClick: recorder.cursor.RightButton,
+		Key1:       recorder.cursor.LeftKey,
+		Key2:       recorder.cursor.RightKey,
+		Smoke:      recorder.cursor.SmokeKey,
+	}
+
+	position := [2]float32{recorder.cursor.RawPosition.X, recorder.cursor.RawPosition.Y}
+
+	if len(recorder.frames) > 2 && keys == recorder.lastKeys && position == recorder.lastPosition && iTime-recorder.lastTime < maxFrameInterval {
+		return
+	}
+
+	recorder.frames = append(recorder.frames, &rplpa.ReplayData{
+		Time:       float64(iTime - recorder.lastTime),
+		MouseX:     float64(position[0]),
+		MouseY:     float64(position[1]),
+		KeyPressed: &keys,
+	})
+
+	recorder.lastTime = iTime
+	recorder.lastKeys = keys
+	recorder.lastPosition = position
+}
+
+// UpdateLife adds a health point to the life bar graph, sampled every second
+func (recorder *ReplayRecorder) UpdateLife(time int64, hp float64) {
+	if recorder.saved || time-recorder.lastLifeTime < lifeBarInterval {
+		return
+	}
+
+	recorder.lifeBar = append(recorder.lifeBar, rplpa.LifeBarGraph{Time: int32(time), HP: float32(hp)})
+	recorder.lastLifeTime = time
+}
+
+func (recorder *ReplayRecorder) IsSaved() bool {
+	return recorder.saved
+}
+
+// Save writes the replay to danser's replays directory. score can be nil if no ruleset was judging the cursor.
+func (recorder *ReplayRecorder) Save(score *osu.Score) {
+	if recorder.saved {
+		return
+	}
+
+	recorder.saved = true
+
+	if len(recorder.frames) <= 2 {
+		log.Println("ReplayRecorder: No input was recorded, skipping...")
+		return
+	}
+
+	replay := recorder.createReplay(score)
+
+	data, err := encodeReplay(replay)
+	if err != nil {
+		log.Println("ReplayRecorder: Failed to encode replay:", err)
+		return
+	}
+
+	dir := filepath.Join(env.DataDir(), replaysMaster, savedReplays)
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		log.Println("ReplayRecorder: Failed to create replay directory:", err)
+		return
+	}
+
+	fileName := fmt.Sprintf("%s - %s - %s [%s] (%s).osr", replay.Username, recorder.bMap.Artist, recorder.bMap.Name, recorder.bMap.Difficulty, replay.Timestamp.Local().Format("2006-01-02_15-04-05"))
+	filePath := filepath.Join(dir, sanitizeFileName(fileName))
+
+	if err = os.WriteFile(filePath, data, 0644); err != nil {
+		log.Println("ReplayRecorder: Failed to save replay:", err)
+		return
+	}
+
+	log.Println("ReplayRecorder: Replay saved to:", filePath)
+}
+
+func (recorder *ReplayRecorder) createReplay(score *osu.Score) *rplpa.Replay {
+	isLazer := recorder.diff.CheckModActive(difficulty.Lazer)
+
+	replay := &rplpa.Replay{
+		PlayMode:     rplpa.OSU,
+		OsuVersion:   stableVersion,
+		BeatmapMD5:   recorder.bMap.MD5,
+		Username:     recorder.cursor.Name,
+		Mods:         recorder.diff.Mods.Legacy(),
+		LifebarGraph: recorder.lifeBar,
+		Timestamp:    time.Now().UTC(),
+		ReplayData:   recorder.frames,
+	}
+
+	if isLazer {
+		replay.OsuVersion = lazerVersion
+	}
+
+	if replay.Username == "" {
+		replay.Username = settings.Knockout.DanserName
+	}
+
+	if score != nil {
+		replay.Count300 = uint16(score.Count300)
+		replay.Count100 = uint16(score.Count100)
+		replay.Count50 = uint16(score.Count50)
+		replay.CountGeki = uint16(score.CountGeki)
+		replay.CountKatu = uint16(score.CountKatu)
+		replay.CountMiss = uint16(score.CountMiss)
+		replay.Score = int32(min(score.Score, math.MaxInt32))
+		replay.MaxCombo = uint16(score.Combo)
+		replay.Fullcombo = score.PerfectCombo
+	}
+
+	// Lazer mods, mod settings or custom rates can't be expressed with legacy bitmask
+	if isLazer || !recorder.diff.Mods.IsLegacy() || math.Abs(recorder.diff.Speed-recorder.diff.BaseModSpeed) > 0.001 {
+		scoreInfo := &rplpa.ScoreInfo{
+			Statistics:        make(map[rplpa.LazerHitResult]int64),
+			MaximumStatistics: make(map[rplpa.LazerHitResult]int64),
+		}
+
+		for _, mod := range recorder.diff.ExportMods2() {
+			if mod.Acronym == "LZ" {
+				continue
+			}
+
+			scoreInfo.Mods = append(scoreInfo.Mods, &mod)
+		}
+
+		if score != nil {
+			scoreInfo.Statistics[rplpa.LazerGreat] = int64(score.Count300)
+			scoreInfo.Statistics[rplpa.LazerOk] = int64(score.Count100)
+			scoreInfo.Statistics[rplpa.LazerMeh] = int64(score.Count50)
+			scoreInfo.Statistics[rplpa.LazerMiss] = int64(score.CountMiss)
+			scoreInfo.MaximumStatistics[rplpa.LazerGreat] = int64(score.Count300 + score.Count100 + score.Count50 + score.CountMiss)
+		}
+
+		replay.ScoreInfo = scoreInfo
+	}
+
+	replay.ReplayMD5 = replayHash(replay)
+
+	return replay
+}
+
+func replayHash(replay *rplpa.Replay) string {
+	hash := md5.Sum([]byte(fmt.Sprintf("%dp%do%do%dt%da%sr%de%ty%so%du%dTrue", replay.Count100+replay.Count300, replay.Count50, replay.CountGeki, replay.CountKatu, replay.CountMiss, replay.BeatmapMD5, replay.MaxCombo, replay.Fullcombo, replay.Username, replay.Score, replay.Mods)))
+	return hex.EncodeToString(hash[:])
+}
+
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < 32 {
+			return '_'
+		}
+
+		return r
+	}, name)
+}
+
+// encodeReplay serializes the replay to the .osr format. rplpa.WriteReplay is not used because stable expects integer frame times and lazer needs the ScoreInfo block.
+func encodeReplay(replay *rplpa.Replay) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+
+	le := binary.LittleEndian
+
+	writeString := func(s string) {
+		if s == "" {
+			buf.WriteByte(0)
+			return
+		}
+
+		buf.WriteByte(11)
+
+		length := uint(len(s))
+		for {
+			b := byte(length & 0x7F)
+			length >>= 7
+
+			if length != 0 {
+				b |= 0x80
+			}
+
+			buf.WriteByte(b)
+
+			if length == 0 {
+				break
+			}
+		}
+
+		buf.WriteString(s)
+	}
+
+	_ = binary.Write(buf, le, replay.PlayMode)
+	_ = binary.Write(buf, le, replay.OsuVersion)
+	writeString(replay.BeatmapMD5)
+	writeString(replay.Username)
+	writeString(replay.ReplayMD5)
+	_ = binary.Write(buf, le, replay.Count300)
+	_ = binary.Write(buf, le, replay.Count100)
+	_ = binary.Write(buf, le, replay.Count50)
+	_ = binary.Write(buf, le, replay.CountGeki)
+	_ = binary.Write(buf, le, replay.CountKatu)
+	_ = binary.Write(buf, le, replay.CountMiss)
+	_ = binary.Write(buf, le, replay.Score)
+	_ = binary.Write(buf, le, replay.MaxCombo)
+	_ = binary.Write(buf, le, replay.Fullcombo)
+	_ = binary.Write(buf, le, replay.Mods)
+
+	lifeBar := &strings.Builder{}
+
+	for _, l := range replay.LifebarGraph {
+		lifeBar.WriteString(fmt.Sprintf("%d|%s,", l.Time, strconv.FormatFloat(float64(l.HP), 'f', -1, 32)))
+	}
+
+	writeString(lifeBar.String())
+
+	// .NET ticks, time.Duration can't be used because it overflows after ~292 years
+	base := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	ticks := (replay.Timestamp.Unix()-base)*10000000 + int64(replay.Timestamp.Nanosecond()/100)
+
+	_ = binary.Write(buf, le, ticks)
+
+	frames, err := compress(func(sb *strings.Builder) {
+		for _, frame := range replay.ReplayData {
+			keys := 0
+
+			if frame.KeyPressed.LeftClick {
+				keys |= rplpa.LEFTCLICK
+			}
+
+			if frame.KeyPressed.RightClick {
+				keys |= rplpa.RIGHTCLICK
+			}
+
+			if frame.KeyPressed.Key1 {
+				keys |= rplpa.KEY1
+			}
+
+			if frame.KeyPressed.Key2 {
+				keys |= rplpa.KEY2
+			}
+
+			if frame.KeyPressed.Smoke {
+				keys |= rplpa.SMOKE
+			}
+
+			sb.WriteString(fmt.Sprintf("%d|%s|%s|%d,", int64(frame.Time), formatCoordinate(frame.MouseX), formatCoordinate(frame.MouseY), keys))
+		}
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("compressing frames: %w", err)
+	}
+
+	_ = binary.Write(buf, le, int32(len(frames)))
+	buf.Write(frames)
+
+	_ = binary.Write(buf, le, replay.ScoreID)
+
+	if replay.ScoreInfo != nil {
+		info, err := json.Marshal(replay.ScoreInfo)
+		if err != nil {
+			return nil, fmt.Errorf("serializing score info: %w", err)
+		}
+
+		compressedInfo, err := compress(func(sb *strings.Builder) {
+			sb.Write(info)
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("compressing score info: %w", err)
+		}
+
+		_ = binary.Write(buf, le, int32(len(compressedInfo)))
+		buf.Write(compressedInfo)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func formatCoordinate(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 32)
+}
+
+func compress(write func(sb *strings.Builder)) ([]byte, error) {
+	sb := &strings.Builder{}
+	write(sb)
+
+	out := bytes.NewBuffer(make([]byte, 0, sb.Len()/4))
+
+	wr := lzma.NewWriter(out)
+
+	if _, err := wr.Write([]byte(sb.String())); err != nil {
+		return nil, err
+	}
+
+	if err := wr.Close(); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
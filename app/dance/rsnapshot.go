@@ -0,0 +1,150 @@
+package dance
+
+import (
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/framework/math/vector"
+)
+
+const (
+	snapshotInterval = 5000.0
+
+	// rewindMargin makes sure that objects visible at the seek target are simulated from the beginning after rewinding
+	rewindMargin = 3000.0
+)
+
+type cursorSnapshot struct {
+	leftButton, rightButton bool
+	leftKey, rightKey       bool
+	leftMouse, rightMouse   bool
+	smokeKey                bool
+	isReplayFrame           bool
+
+	lastFrameTime    int64
+	currentFrameTime int64
+
+	position vector.Vector2f
+}
+
+type controlSnapshot struct {
+	replayIndex int
+	replayTime  float64
+	lastTime    int64
+	relaxLeft   bool
+
+	cursor cursorSnapshot
+}
+
+type replaySnapshot struct {
+	time     float64
+	ruleset  *osu.Snapshot
+	controls []controlSnapshot
+}
+
+// CanRewind returns false if any of the players is driven by a stateful mover or recorded, as their state can't be restored
+func (controller *ReplayController) CanRewind() bool {
+	for _, c := range controller.controllers {
+		if c.danceController != nil || c.mouseController != nil || c.recorder != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// EnableSnapshots makes the controller take snapshots needed by Rewind. It's left disabled when seeking isn't possible,
+// e.g. while recording, as snapshots of long maps cost a lot of memory.
+func (controller *ReplayController) EnableSnapshots() {
+	controller.snapshotsEnabled = true
+}
+
+func (controller *ReplayController) takeSnapshot(nTime float64) {
+	if len(controller.snapshots) > 0 && nTime-controller.snapshots[len(controller.snapshots)-1].time < snapshotInterval {
+		return
+	}
+
+	snapshot := &replaySnapshot{
+		time:     nTime,
+		ruleset:  controller.ruleset.Snapshot(int64(nTime)),
+		controls: make([]controlSnapshot, len(controller.controllers)),
+	}
+
+	for i, c := range controller.controllers {
+		cursor := controller.cursors[i]
+
+		snapshot.controls[i] = controlSnapshot{
+			replayIndex: c.replayIndex,
+			replayTime:  c.replayTime,
+			lastTime:    c.lastTime,
+			cursor: cursorSnapshot{
+				leftButton:       cursor.LeftButton,
+				rightButton:      cursor.RightButton,
+				leftKey:          cursor.LeftKey,
+				rightKey:         cursor.RightKey,
+				leftMouse:        cursor.LeftMouse,
+				rightMouse:       cursor.RightMouse,
+				smokeKey:         cursor.SmokeKey,
+				isReplayFrame:    cursor.IsReplayFrame,
+				lastFrameTime:    cursor.LastFrameTime,
+				currentFrameTime: cursor.CurrentFrameTime,
+				position:         cursor.RawPosition,
+			},
+		}
+
+		if c.relaxController != nil {
+			snapshot.controls[i].relaxLeft = c.relaxController.WasLeft()
+		}
+	}
+
+	controller.snapshots = append(controller.snapshots, snapshot)
+}
+
+// Rewind restores the latest snapshot taken well before the given time and returns the time it was taken at.
+// Playback has to be simulated again from that time with Update calls.
+func (controller *ReplayController) Rewind(time float64) float64 {
+	if len(controller.snapshots) == 0 {
+		return controller.lastTime
+	}
+
+	index := 0
+
+	for i, s := range controller.snapshots {
+		if s.time <= time-rewindMargin {
+			index = i
+		}
+	}
+
+	snapshot := controller.snapshots[index]
+	controller.snapshots = controller.snapshots[:index+1]
+
+	controller.ruleset.Restore(snapshot.ruleset)
+
+	for i, c := range controller.controllers {
+		state := snapshot.controls[i]
+
+		c.replayIndex = state.replayIndex
+		c.replayTime = state.replayTime
+		c.lastTime = state.lastTime
+
+		if c.relaxController != nil {
+			c.relaxController.SetWasLeft(state.relaxLeft)
+		}
+
+		cursor := controller.cursors[i]
+
+		cursor.LeftButton = state.cursor.leftButton
+		cursor.RightButton = state.cursor.rightButton
+		cursor.LeftKey = state.cursor.leftKey
+		cursor.RightKey = state.cursor.rightKey
+		cursor.LeftMouse = state.cursor.leftMouse
+		cursor.RightMouse = state.cursor.rightMouse
+		cursor.SmokeKey = state.cursor.smokeKey
+		cursor.IsReplayFrame = state.cursor.isReplayFrame
+		cursor.LastFrameTime = state.cursor.lastFrameTime
+		cursor.CurrentFrameTime = state.cursor.currentFrameTime
+		cursor.SetPos(state.cursor.position)
+	}
+
+	controller.lastTime = snapshot.time
+
+	return snapshot.time
+}
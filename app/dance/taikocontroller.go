@@ -0,0 +1,325 @@
+package dance
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/rulesets/taiko"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/env"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"github.com/wieku/rplpa"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+	"unicode"
+)
+
+// autoReleaseTime is the time autoplay holds the keys for
+const autoReleaseTime = 15
+
+// TaikoController replays osu!taiko plays. Players without replays are driven by generated autoplay frames.
+type TaikoController struct {
+	bMap        *beatmap.BeatMap
+	replays     []RpData
+	cursors     []*graphics.Cursor
+	controllers []*subControl
+	inputs      []taiko.Input
+	ruleset     *taiko.TaikoRuleSet
+	lastTime    float64
+}
+
+func NewTaikoController() Controller {
+	_ = os.MkdirAll(filepath.Join(env.DataDir(), replaysMaster), 0755)
+
+	return &TaikoController{lastTime: -200}
+}
+
+func (controller *TaikoController) SetBeatMap(beatMap *beatmap.BeatMap) {
+	controller.bMap = beatMap
+
+	var candidates []*rplpa.Replay
+
+	localReplay := false
+
+	if settings.KNOCKOUT {
+		candidates, localReplay = loadCandidates(beatMap, rplpa.TAIKO)
+	}
+
+	hiddenMods := difficulty.ParseMods(settings.Knockout.HideMods)
+
+	for i, replay := range candidates {
+		log.Println(fmt.Sprintf("Loading replay for \"%s\":", replay.Username))
+
+		control := NewSubControl()
+
+		setupReplayDiff(control, beatMap, replay, localReplay)
+
+		loadFrames(control, replay.ReplayData)
+
+		controller.replays = append(controller.replays, RpData{replay.Username, replay.Username + string(rune(unicode.MaxRune-i)), control.diff.Mods.Difference(hiddenMods).String(), control.diff.Mods, 100, 0, int64(replay.MaxCombo), osu.NONE, replay.ScoreID, replay.Timestamp, replay.ReplayMD5})
+		controller.controllers = append(controller.controllers, control)
+
+		log.Println("\tExpected score:", replay.Score)
+		log.Println("\tExpected accuracy:", fmt.Sprintf("%.2f%%", taikoAccuracy(replay)))
+		log.Println("\tReplay loaded!")
+	}
+
+	if !localReplay && (settings.Knockout.AddDanser || len(controller.controllers) == 0) {
+		control := NewSubControl()
+		control.diff = beatMap.Diff.Clone()
+
+		controller.replays = append([]RpData{{settings.Knockout.DanserName, settings.Knockout.DanserName, control.diff.GetModString(), control.diff.Mods, 100, 0, 0, osu.NONE, -1, time.Now(), ""}}, controller.replays...)
+		controller.controllers = append([]*subControl{control}, controller.controllers...)
+
+		if len(candidates) == 0 {
+			controller.bMap.Diff.AddMod(difficulty.Autoplay)
+		}
+	}
+
+	controller.inputs = make([]taiko.Input, len(controller.controllers))
+
+	settings.PLAYERS = len(controller.replays)
+}
+
+// taikoAccuracy calculates the accuracy of the replay from its judgement counts, in percent
+func taikoAccuracy(replay *rplpa.Replay) float64 {
+	total := float64(replay.Count300) + float64(replay.Count100) + float64(replay.CountMiss)
+	if total == 0 {
+		return 100
+	}
+
+	return (float64(replay.Count300) + float64(replay.Count100)*0.5) / total * 100
+}
+
+func (controller *TaikoController) InitCursors() {
+	var diffs []*difficulty.Difficulty
+
+	for i, c := range controller.controllers {
+		cursor := graphics.NewCursor()
+		cursor.SetPos(vector.NewVec2f(256, 192))
+
+		if c.frames == nil {
+			cursor.Name = controller.replays[i].Name
+			cursor.ScoreID = -1
+			cursor.ScoreTime = time.Now()
+			cursor.IsPlayer = true
+			cursor.IsAutoplay = true
+		} else {
+			cursor.Name = controller.replays[i].RawName
+			cursor.ScoreID = controller.replays[i].scoreID
+			cursor.ScoreTime = controller.replays[i].ScoreTime
+			cursor.ModifiedMods = c.modifiedMods
+			cursor.IsReplay = true
+
+			c.replayTime += c.frames[0].Time
+			c.frames = c.frames[1:]
+		}
+
+		cursor.Update(0)
+
+		controller.cursors = append(controller.cursors, cursor)
+
+		diffs = append(diffs, c.diff)
+	}
+
+	controller.ruleset = taiko.NewTaikoRuleset(controller.bMap, controller.cursors, diffs)
+
+	for _, c := range controller.controllers {
+		if c.frames == nil {
+			c.frames = generateAutoFrames(controller.ruleset.GetObjects(), c.diff)
+		}
+	}
+}
+
+type autoPress struct {
+	time float64
+	keys []int
+}
+
+// generateAutoFrames creates replay frames hitting every object perfectly, keys of the same colour are alternated
+func generateAutoFrames(objects []*taiko.Object, diff *difficulty.Difficulty) []*rplpa.ReplayData {
+	presses := make([]autoPress, 0, len(objects))
+
+	rightDon, rightKat := false, false
+
+	nextDon := func() int {
+		rightDon = !rightDon
+
+		if rightDon {
+			return taiko.RightDon
+		}
+
+		return taiko.LeftDon
+	}
+
+	nextKat := func() int {
+		rightKat = !rightKat
+
+		if rightKat {
+			return taiko.RightKat
+		}
+
+		return taiko.LeftKat
+	}
+
+	for _, o := range objects {
+		switch o.Type {
+		case taiko.Hit:
+			switch {
+			case o.Big && o.Kat:
+				presses = append(presses, autoPress{o.StartTime, []int{taiko.LeftKat, taiko.RightKat}})
+			case o.Big:
+				presses = append(presses, autoPress{o.StartTime, []int{taiko.LeftDon, taiko.RightDon}})
+			case o.Kat:
+				presses = append(presses, autoPress{o.StartTime, []int{nextKat()}})
+			default:
+				presses = append(presses, autoPress{o.StartTime, []int{nextDon()}})
+			}
+		case taiko.DrumRoll:
+			for _, tick := range o.Ticks {
+				presses = append(presses, autoPress{tick, []int{nextDon()}})
+			}
+		case taiko.Swell:
+			required := o.GetRequiredHits(diff.ODMod)
+			spacing := (o.EndTime - o.StartTime) / float64(required+1)
+
+			for j := 0; j < required; j++ {
+				var key int
+
+				if j%2 == 0 {
+					key = nextDon()
+				} else {
+					key = nextKat()
+				}
+
+				presses = append(presses, autoPress{o.StartTime + spacing*float64(j+1), []int{key}})
+			}
+		}
+	}
+
+	slices.SortStableFunc(presses, func(a, b autoPress) int {
+		return cmp.Compare(a.time, b.time)
+	})
+
+	frames := make([]*rplpa.ReplayData, 0, len(presses)*2)
+
+	lastTime := 0.0
+
+	addFrame := func(time float64, keys []int) {
+		var input taiko.Input
+
+		for _, k := range keys {
+			input[k] = true
+		}
+
+		frames = append(frames, &rplpa.ReplayData{
+			Time:   time - lastTime,
+			MouseX: 256,
+			MouseY: 192,
+			KeyPressed: &rplpa.KeyPressed{
+				LeftClick:  input[taiko.LeftDon],
+				Key1:       input[taiko.RightDon],
+				RightClick: input[taiko.LeftKat],
+				Key2:       input[taiko.RightKat],
+			},
+		})
+
+		lastTime = time
+	}
+
+	for i, press := range presses {
+		pressTime := max(math.Floor(press.time), lastTime)
+
+		addFrame(pressTime, press.keys)
+
+		releaseTime := pressTime + autoReleaseTime
+		if i < len(presses)-1 {
+			releaseTime = min(releaseTime, math.Floor((pressTime+math.Floor(presses[i+1].time))/2))
+		}
+
+		addFrame(max(releaseTime, pressTime+1), nil)
+	}
+
+	return frames
+}
+
+func (controller *TaikoController) Update(time float64, _ float64) {
+	numSkipped := int(time) - int(controller.lastTime) - 1
+
+	if numSkipped >= 1 {
+		for nTime := numSkipped; nTime >= 1; nTime-- {
+			controller.updateMain(time - float64(nTime))
+		}
+	}
+
+	controller.updateMain(time)
+
+	for i := range controller.controllers {
+		sc := controller.ruleset.GetScore(controller.cursors[i])
+		controller.replays[i].Accuracy = sc.Accuracy
+		controller.replays[i].Combo = sc.Combo
+		controller.replays[i].Grade = sc.Grade
+	}
+}
+
+func (controller *TaikoController) updateMain(nTime float64) {
+	controller.bMap.Timings.Update(nTime)
+
+	for i, c := range controller.controllers {
+		if c.replayIndex < len(c.frames) {
+			for c.replayIndex < len(c.frames) && c.replayTime+c.frames[c.replayIndex].Time <= math.Floor(nTime) {
+				frame := c.frames[c.replayIndex]
+				c.replayTime += frame.Time
+
+				controller.inputs[i] = taiko.Input{
+					taiko.LeftDon:  frame.KeyPressed.LeftClick,
+					taiko.RightDon: frame.KeyPressed.Key1,
+					taiko.LeftKat:  frame.KeyPressed.RightClick,
+					taiko.RightKat: frame.KeyPressed.Key2,
+				}
+
+				controller.ruleset.UpdateInput(controller.cursors[i], int64(c.replayTime), controller.inputs[i])
+
+				c.replayIndex++
+			}
+		} else {
+			controller.inputs[i] = taiko.Input{}
+
+			controller.ruleset.UpdateInput(controller.cursors[i], int64(nTime), controller.inputs[i])
+		}
+	}
+
+	if int64(nTime) != int64(controller.lastTime) {
+		controller.ruleset.Update(int64(nTime))
+	}
+
+	controller.lastTime = nTime
+}
+
+func (controller *TaikoController) GetCursors() []*graphics.Cursor {
+	return controller.cursors
+}
+
+func (controller *TaikoController) GetReplays() []RpData {
+	return controller.replays
+}
+
+func (controller *TaikoController) GetRuleset() *taiko.TaikoRuleSet {
+	return controller.ruleset
+}
+
+func (controller *TaikoController) GetBeatMap() *beatmap.BeatMap {
+	return controller.bMap
+}
+
+// GetInput returns currently pressed keys of the player
+func (controller *TaikoController) GetInput(player int) taiko.Input {
+	return controller.inputs[player]
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"log"
+	"slices"
+	"strings"
+)
+
+type Collection struct {
+	Name string
+
+	hashes map[string]struct{}
+}
+
+// Contains checks whether beatmap with given MD5 hash belongs to the collection
+func (c *Collection) Contains(md5 string) bool {
+	_, ok := c.hashes[strings.ToLower(md5)]
+	return ok
+}
+
+func (c *Collection) Len() int {
+	return len(c.hashes)
+}
+
+var collections []*Collection
+
+// GetCollections returns collections loaded during last LoadBeatmaps call sorted by name
+func GetCollections() []*Collection {
+	return collections
+}
+
+func loadCollectionsFromDatabase() []*Collection {
+	res, err := dbFile.Query("SELECT name, md5 FROM collections")
+	if err != nil {
+		log.Println("DatabaseManager: Failed to load collections:", err)
+		return nil
+	}
+
+	defer res.Close()
+
+	byName := make(map[string]*Collection)
+
+	for res.Next() {
+		var name, md5 string
+
+		if err = res.Scan(&name, &md5); err != nil {
+			log.Println(err)
+			continue
+		}
+
+		c, ok := byName[name]
+		if !ok {
+			c = &Collection{
+				Name:   name,
+				hashes: make(map[string]struct{}),
+			}
+
+			byName[name] = c
+		}
+
+		c.hashes[strings.ToLower(md5)] = struct{}{}
+	}
+
+	result := make([]*Collection, 0, len(byName))
+
+	for _, c := range byName {
+		result = append(result, c)
+	}
+
+	slices.SortFunc(result, func(a, b *Collection) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	return result
+}
@@ -0,0 +1,175 @@
+package database
+
+import (
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/lazer"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/goroutines"
+	"github.com/wieku/danser-go/framework/util"
+	"log"
+	"strings"
+)
+
+var lazerModes = map[string]int64{
+	"osu":    0,
+	"taiko":  1,
+	"fruits": 2,
+	"mania":  3,
+}
+
+type lazerCandidate struct {
+	location mapLocation
+	set      *lazer.BeatmapSet
+	bMap     *lazer.Beatmap
+}
+
+// importLazerMaps synchronizes beatmaps from lazer's library with the database. Files stay in lazer's file store, only metadata is cached.
+func importLazerMaps(importListener ImportListener) {
+	const workers = 4
+
+	lazer.Reload()
+
+	mapsInDB := getLazerMD5s()
+
+	var candidates []lazerCandidate
+
+	if lib := lazer.GetLibrary(); lib != nil {
+		for _, set := range lib.BeatmapSets {
+			for _, bMap := range set.Beatmaps {
+				if _, ok := lazerModes[bMap.Ruleset.ShortName]; !ok || bMap.Hidden {
+					continue
+				}
+
+				file := set.GetBeatmapFile(bMap)
+				if file == "" {
+					continue
+				}
+
+				candidates = append(candidates, lazerCandidate{
+					location: mapLocation{
+						dir:  lazer.GetSetDir(set),
+						file: file,
+					},
+					set:  set,
+					bMap: bMap,
+				})
+			}
+		}
+	}
+
+	var toImport []lazerCandidate
+	var toRemove []mapLocation
+
+	for _, candidate := range candidates {
+		if md5, ok := mapsInDB[candidate.location]; ok {
+			delete(mapsInDB, candidate.location)
+
+			if strings.EqualFold(md5, candidate.bMap.MD5Hash) {
+				continue
+			}
+
+			toRemove = append(toRemove, candidate.location)
+		}
+
+		toImport = append(toImport, candidate)
+	}
+
+	for location := range mapsInDB {
+		toRemove = append(toRemove, location)
+	}
+
+	if len(toRemove) > 0 {
+		log.Println("DatabaseManager: Removing", len(toRemove), "outdated osu!lazer beatmaps from database...")
+
+		removeBeatmaps(toRemove)
+	}
+
+	if len(toImport) == 0 {
+		return
+	}
+
+	log.Println("DatabaseManager: Starting import of", len(toImport), "osu!lazer maps...")
+
+	trySendStatus(importListener, Import, 0, len(toImport))
+
+	receive := make(chan *beatmap.BeatMap, workers)
+
+	goroutines.Run(func() {
+		util.BalanceChan(workers, toImport, receive, func(candidate lazerCandidate) (*beatmap.BeatMap, bool) {
+			defer func() {
+				if err := recover(); err != nil {
+					log.Println("DatabaseManager: Failed to load osu!lazer beatmap \"", candidate.location.file, "\":", err)
+				}
+			}()
+
+			bMap := beatmap.NewBeatMap()
+			bMap.Dir = candidate.location.dir
+			bMap.File = candidate.location.file
+
+			if err := beatmap.ParseBeatMap(bMap); err != nil {
+				log.Println("DatabaseManager: Failed to import osu!lazer beatmap:", candidate.location.file, "Error:", err)
+				return nil, false
+			}
+
+			bMap.MD5 = strings.ToLower(candidate.bMap.MD5Hash)
+			bMap.LastModified = candidate.set.DateAdded.UnixNano() / 1000000
+			bMap.TimeAdded = bMap.LastModified
+
+			if settings.General.VerboseImportLogs {
+				log.Println("DatabaseManager: Imported from osu!lazer:", candidate.location.file)
+			}
+
+			return bMap, true
+		})
+
+		close(receive)
+	})
+
+	var numImported int
+	var imported []*beatmap.BeatMap
+
+	for bMap := range receive {
+		numImported++
+		trySendStatus(importListener, Import, numImported, len(toImport))
+
+		imported = append(imported, bMap)
+
+		if len(imported) >= 1000 {
+			insertBeatmaps(imported)
+
+			imported = imported[:0]
+		}
+	}
+
+	if len(imported) > 0 {
+		insertBeatmaps(imported)
+	}
+
+	trySendStatus(importListener, Finished, 100, 100)
+
+	log.Println("DatabaseManager: Imported", numImported, "osu!lazer beatmaps.")
+}
+
+func getLazerMD5s() map[mapLocation]string {
+	res, err := dbFile.Query("SELECT dir, file, md5 FROM beatmaps WHERE dir LIKE ?", lazer.DirPrefix+"%")
+	if err != nil {
+		panic(err)
+	}
+
+	defer res.Close()
+
+	maps := make(map[mapLocation]string)
+
+	for res.Next() {
+		var dir, file, md5 string
+
+		res.Scan(&dir, &file, &md5)
+
+		maps[mapLocation{
+			dir:  dir,
+			file: file,
+		}] = md5
+	}
+
+	return maps
+}
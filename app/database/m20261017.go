@@ -0,0 +1,27 @@
+package database
+
+import (
+	"github.com/wieku/danser-go/app/beatmap"
+)
+
+type M20261017 struct{}
+
+func (m *M20261017) RequiredSections() []string {
+	return nil
+}
+
+func (m *M20261017) FieldsToMigrate() []string {
+	return nil
+}
+
+func (m *M20261017) GetValues(_ *beatmap.BeatMap) []interface{} {
+	return nil
+}
+
+func (m *M20261017) Date() int {
+	return 20261017
+}
+
+func (m *M20261017) GetMigrationStmts() string {
+	return "CREATE TABLE IF NOT EXISTS collections (name TEXT, md5 TEXT); CREATE INDEX IF NOT EXISTS cidx ON collections (name);"
+}
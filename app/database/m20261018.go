@@ -0,0 +1,27 @@
+package database
+
+import (
+	"github.com/wieku/danser-go/app/beatmap"
+)
+
+type M20261018 struct{}
+
+func (m *M20261018) RequiredSections() []string {
+	return nil
+}
+
+func (m *M20261018) FieldsToMigrate() []string {
+	return nil
+}
+
+func (m *M20261018) GetValues(_ *beatmap.BeatMap) []interface{} {
+	return nil
+}
+
+func (m *M20261018) Date() int {
+	return 20261018
+}
+
+func (m *M20261018) GetMigrationStmts() string {
+	return scoresSchema
+}
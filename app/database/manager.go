@@ -7,6 +7,7 @@ import (
 	"fmt"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/lazer"
 	"github.com/wieku/danser-go/app/rulesets/osu/performance/pp250306"
 	"github.com/wieku/danser-go/app/settings"
 	"github.com/wieku/danser-go/app/utils"
@@ -27,7 +28,7 @@ import (
 
 var dbFile *sql.DB
 
-const databaseVersion = 20220622
+const databaseVersion = 20261019
 
 var currentPreVersion = databaseVersion
 var currentSchemaPreVersion = databaseVersion
@@ -74,6 +75,9 @@ func Init() error {
 		&M20210423{},
 		&M20220605{},
 		&M20220622{},
+		&M20261017{},
+		&M20261018{},
+		&M20261019{},
 	}
 
 	dbFile, err = sql.Open("sqlite3", filepath.Join(env.DataDir(), "danser.db"))
@@ -82,10 +86,12 @@ func Init() error {
 	}
 
 	_, err = dbFile.Exec(`
-		CREATE TABLE IF NOT EXISTS beatmaps (dir TEXT, file TEXT, lastModified INTEGER, title TEXT, titleUnicode TEXT, artist TEXT, artistUnicode TEXT, creator TEXT, version TEXT, source TEXT, tags TEXT, cs REAL, ar REAL, sliderMultiplier REAL, sliderTickRate REAL, audioFile TEXT, previewTime INTEGER, sampleSet INTEGER, stackLeniency REAL, mode INTEGER, bg TEXT, md5 TEXT, dateAdded INTEGER, playCount INTEGER, lastPlayed INTEGER, hpdrain REAL, od REAL, stars REAL DEFAULT -1, bpmMin REAL, bpmMax REAL, circles INTEGER, sliders INTEGER, spinners INTEGER, endTime INTEGER, setID INTEGER, mapID INTEGER, starsVersion INTEGER DEFAULT 0, localOffset INTEGER DEFAULT 0);
+		CREATE TABLE IF NOT EXISTS beatmaps (dir TEXT, file TEXT, lastModified INTEGER, title TEXT, titleUnicode TEXT, artist TEXT, artistUnicode TEXT, creator TEXT, version TEXT, source TEXT, tags TEXT, cs REAL, ar REAL, sliderMultiplier REAL, sliderTickRate REAL, audioFile TEXT, previewTime INTEGER, sampleSet INTEGER, stackLeniency REAL, mode INTEGER, bg TEXT, md5 TEXT, dateAdded INTEGER, playCount INTEGER, lastPlayed INTEGER, hpdrain REAL, od REAL, stars REAL DEFAULT -1, bpmMin REAL, bpmMax REAL, circles INTEGER, sliders INTEGER, spinners INTEGER, endTime INTEGER, setID INTEGER, mapID INTEGER, starsVersion INTEGER DEFAULT 0, localOffset INTEGER DEFAULT 0, status INTEGER DEFAULT 0);
 		CREATE INDEX IF NOT EXISTS idx ON beatmaps (dir, file);
 		CREATE TABLE IF NOT EXISTS info (key TEXT NOT NULL UNIQUE, value TEXT);
-	`)
+		CREATE TABLE IF NOT EXISTS collections (name TEXT, md5 TEXT);
+		CREATE INDEX IF NOT EXISTS cidx ON collections (name);
+	` + scoresSchema)
 
 	if err != nil {
 		return err
@@ -165,23 +171,39 @@ func LoadBeatmaps(skipDatabaseCheck bool, importListener ImportListener) []*beat
 		unpackedMaps = unpackMaps()
 	}
 
-	importMaps(skipDatabaseCheck, unpackedMaps, importListener)
+	var stableMaps map[mapLocation]*stableBeatmap
+	if settings.General.ImportOsuDatabase {
+		stableMaps = loadStableMaps()
+		importCollections()
+	}
+
+	importMaps(skipDatabaseCheck, unpackedMaps, stableMaps, importListener)
+
+	importLazerMaps(importListener)
 
 	log.Println("DatabaseManager: Loading beatmaps from database...")
 
 	allMaps := loadBeatmapsFromDatabase()
 
-	stdMaps := make([]*beatmap.BeatMap, 0, len(allMaps)/2)
+	updateStatuses(allMaps, collectStatuses(stableMaps))
+
+	statusesKnown = slices.ContainsFunc(allMaps, func(b *beatmap.BeatMap) bool {
+		return b.Status != beatmap.StatusUnknown
+	})
+
+	collections = loadCollectionsFromDatabase()
+
+	supportedMaps := make([]*beatmap.BeatMap, 0, len(allMaps)/2)
 
 	for _, b := range allMaps {
-		if b.Mode == 0 {
-			stdMaps = append(stdMaps, b)
+		if b.Mode == 0 || b.Mode == 1 { // osu!standard and osu!taiko
+			supportedMaps = append(supportedMaps, b)
 		}
 	}
 
-	log.Println("DatabaseManager: Loaded", len(stdMaps), "total.")
+	log.Println("DatabaseManager: Loaded", len(supportedMaps), "total.")
 
-	return stdMaps
+	return supportedMaps
 }
 
 func unpackMaps() (dirs []string) {
@@ -217,7 +239,7 @@ const (
 	Finished
 )
 
-func importMaps(skipDatabaseCheck bool, mustCheckDirs []string, importListener ImportListener) {
+func importMaps(skipDatabaseCheck bool, mustCheckDirs []string, stableMaps map[mapLocation]*stableBeatmap, importListener ImportListener) {
 	const workers = 4
 
 	cachedFolders, mapsInDB := getLastModified()
@@ -353,6 +375,18 @@ func importMaps(skipDatabaseCheck bool, mustCheckDirs []string, importListener I
 				log.Println("DatabaseManager: Importing:", partialPath)
 			}
 
+			if entry, ok := stableMaps[candidate]; ok {
+				if bMap := importFromStable(file, candidate, entry); bMap != nil {
+					if settings.General.VerboseImportLogs {
+						log.Println("DatabaseManager: Imported from osu!.db:", partialPath)
+					}
+
+					return bMap, true
+				}
+
+				file.Seek(0, 0)
+			}
+
 			if bMap := beatmap.ParseBeatMapFile(file); bMap != nil {
 				stat, _ := file.Stat()
 				bMap.LastModified = stat.ModTime().UnixNano() / 1000000
@@ -681,7 +715,7 @@ func insertBeatmaps(bMaps []*beatmap.BeatMap) {
 
 	if err == nil {
 		var st *sql.Stmt
-		st, err = tx.Prepare("INSERT INTO beatmaps VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		st, err = tx.Prepare("INSERT INTO beatmaps VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 
 		if err == nil {
 			for _, bMap := range bMaps {
@@ -724,6 +758,7 @@ func insertBeatmaps(bMaps []*beatmap.BeatMap) {
 					bMap.ID,
 					bMap.StarsVersion,
 					bMap.LocalOffset,
+					bMap.Status,
 				)
 
 				if err1 != nil {
@@ -792,6 +827,7 @@ func loadBeatmapsFromDatabase() []*beatmap.BeatMap {
 			&beatMap.ID,
 			&beatMap.StarsVersion,
 			&beatMap.LocalOffset,
+			&beatMap.Status,
 		)
 
 		beatMap.Diff.SetCS(mutils.Clamp(cs, 0, 10))
@@ -818,6 +854,10 @@ func getLastModified() (map[string]uint8, map[mapLocation]int64) {
 
 		res.Scan(&dir, &file, &lastModified)
 
+		if lazer.IsLazerDir(dir) { // osu!lazer beatmaps are handled by importLazerMaps
+			continue
+		}
+
 		dirs[dir] = 1
 
 		mod[mapLocation{
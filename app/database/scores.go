@@ -0,0 +1,204 @@
+package database
+
+import (
+	"database/sql"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/framework/env"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// scoresSchema creates the score history table, used both for new databases and by M20261018 for existing ones
+const scoresSchema = "CREATE TABLE IF NOT EXISTS scores (player TEXT, md5 TEXT, mods TEXT, modString TEXT, score INTEGER, maxCombo INTEGER, count300 INTEGER, countGeki INTEGER, count100 INTEGER, countKatu INTEGER, count50 INTEGER, countMiss INTEGER, accuracy REAL, pp REAL, date INTEGER); CREATE UNIQUE INDEX IF NOT EXISTS sidx ON scores (md5, player, date);"
+
+const scoreColumns = "player, md5, mods, modString, score, maxCombo, count300, countGeki, count100, countKatu, count50, countMiss, accuracy, pp, date"
+
+// Score is a result of a completed play or replay kept in local score history
+type Score struct {
+	Player    string
+	MD5       string
+	Mods      difficulty.Modifier
+	ModString string
+
+	Score     int64
+	MaxCombo  uint
+	Count300  uint
+	CountGeki uint
+	Count100  uint
+	CountKatu uint
+	Count50   uint
+	CountMiss uint
+
+	Accuracy float64
+	PP       float64
+
+	Date time.Time
+}
+
+// SaveScores adds scores to local score history. Scores already in the history (same beatmap, player and date) are skipped, so rendering the same replay again doesn't duplicate it
+func SaveScores(scores []*Score) {
+	if len(scores) == 0 {
+		return
+	}
+
+	withDatabase(func(db *sql.DB) {
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("DatabaseManager: Failed to save scores:", err)
+			return
+		}
+
+		st, err := tx.Prepare("INSERT OR IGNORE INTO scores (" + scoreColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		if err != nil {
+			log.Println("DatabaseManager: Failed to save scores:", err)
+			tx.Rollback()
+
+			return
+		}
+
+		saved := int64(0)
+
+		for _, s := range scores {
+			res, err1 := st.Exec(
+				s.Player,
+				strings.ToLower(s.MD5),
+				encodeMods(s.Mods),
+				s.ModString,
+				s.Score,
+				s.MaxCombo,
+				s.Count300,
+				s.CountGeki,
+				s.Count100,
+				s.CountKatu,
+				s.Count50,
+				s.CountMiss,
+				s.Accuracy,
+				s.PP,
+				s.Date.UnixMilli())
+
+			if err1 != nil {
+				log.Println(err1)
+				continue
+			}
+
+			if n, err2 := res.RowsAffected(); err2 == nil {
+				saved += n
+			}
+		}
+
+		st.Close()
+
+		if err = tx.Commit(); err != nil {
+			log.Println("DatabaseManager: Failed to save scores:", err)
+			return
+		}
+
+		log.Println("DatabaseManager: Saved", saved, "new score(s) to local score history")
+	})
+}
+
+// GetScores returns local scores on beatmap with given MD5 hash sorted by score
+func GetScores(md5 string) []*Score {
+	return queryScores("SELECT "+scoreColumns+" FROM scores WHERE md5 = ? ORDER BY score DESC", strings.ToLower(md5))
+}
+
+// GetPersonalBests returns the highest local score on each played beatmap, keyed by beatmap's MD5 hash
+func GetPersonalBests() map[string]*Score {
+	// SQLite fills bare columns from the row holding MAX() value
+	scores := queryScores("SELECT player, md5, mods, modString, MAX(score), maxCombo, count300, countGeki, count100, countKatu, count50, countMiss, accuracy, pp, date FROM scores GROUP BY md5")
+
+	bests := make(map[string]*Score, len(scores))
+
+	for _, s := range scores {
+		bests[s.MD5] = s
+	}
+
+	return bests
+}
+
+func queryScores(query string, args ...any) (scores []*Score) {
+	withDatabase(func(db *sql.DB) {
+		res, err := db.Query(query, args...)
+		if err != nil {
+			log.Println("DatabaseManager: Failed to load scores:", err)
+			return
+		}
+
+		defer res.Close()
+
+		for res.Next() {
+			s := new(Score)
+
+			var mods string
+			var date int64
+
+			err = res.Scan(
+				&s.Player,
+				&s.MD5,
+				&mods,
+				&s.ModString,
+				&s.Score,
+				&s.MaxCombo,
+				&s.Count300,
+				&s.CountGeki,
+				&s.Count100,
+				&s.CountKatu,
+				&s.Count50,
+				&s.CountMiss,
+				&s.Accuracy,
+				&s.PP,
+				&date)
+
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+
+			s.Mods = decodeMods(mods)
+			s.Date = time.UnixMilli(date)
+
+			scores = append(scores, s)
+		}
+	})
+
+	return
+}
+
+// encodeMods stores mods as comma separated acronyms, so they don't depend on mod registration order or stable's bitmask
+func encodeMods(mods difficulty.Modifier) string {
+	var acronyms []string
+
+	for _, mod := range mods.List() {
+		acronyms = append(acronyms, string(mod))
+	}
+
+	return strings.Join(acronyms, ",")
+}
+
+func decodeMods(mods string) (m difficulty.Modifier) {
+	for _, acronym := range strings.Split(mods, ",") {
+		m = m.Union(difficulty.ParseFromAcronym(acronym))
+	}
+
+	return
+}
+
+// withDatabase runs f with the open database, or opens it just for that call if it has been already closed (e.g. during gameplay)
+func withDatabase(f func(db *sql.DB)) {
+	if dbFile != nil {
+		f(dbFile)
+		return
+	}
+
+	db, err := sql.Open("sqlite3", filepath.Join(env.DataDir(), "danser.db"))
+	if err != nil {
+		log.Println("DatabaseManager: Failed to open database:", err)
+		return
+	}
+
+	defer db.Close()
+
+	f(db)
+}
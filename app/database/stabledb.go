@@ -0,0 +1,376 @@
+package database
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// .NET ticks of unix epoch
+const ticksEpoch = 621355968000000000
+
+type stableBeatmap struct {
+	Artist        string
+	ArtistUnicode string
+	Title         string
+	TitleUnicode  string
+	Creator       string
+	Version       string
+	AudioFile     string
+	MD5           string
+	File          string
+	Folder        string
+	Source        string
+	Tags          string
+
+	Status beatmap.RankedStatus
+
+	Circles  int
+	Sliders  int
+	Spinners int
+
+	// LastModified is in unix milliseconds
+	LastModified int64
+
+	AR, CS, HP, OD   float64
+	SliderMultiplier float64
+	StackLeniency    float64
+
+	// Stars is nomod osu!standard star rating, -1 if osu! didn't calculate it
+	Stars float64
+
+	TotalTime   int64
+	PreviewTime int64
+
+	MinBPM, MaxBPM float64
+
+	ID    int64
+	SetID int64
+	Mode  int64
+}
+
+type stableDB struct {
+	Version  int32
+	Beatmaps []*stableBeatmap
+}
+
+type stableCollection struct {
+	Name   string
+	Hashes []string
+}
+
+type stableReader struct {
+	reader *bufio.Reader
+	err    error
+}
+
+func newStableReader(r io.Reader) *stableReader {
+	return &stableReader{reader: bufio.NewReaderSize(r, 1024*1024)}
+}
+
+func (r *stableReader) read(data any) {
+	if r.err != nil {
+		return
+	}
+
+	r.err = binary.Read(r.reader, binary.LittleEndian, data)
+}
+
+func (r *stableReader) readByte() (v uint8) {
+	r.read(&v)
+	return
+}
+
+func (r *stableReader) readBool() bool {
+	return r.readByte() != 0
+}
+
+func (r *stableReader) readInt16() (v int16) {
+	r.read(&v)
+	return
+}
+
+func (r *stableReader) readInt32() (v int32) {
+	r.read(&v)
+	return
+}
+
+func (r *stableReader) readInt64() (v int64) {
+	r.read(&v)
+	return
+}
+
+func (r *stableReader) readFloat32() (v float32) {
+	r.read(&v)
+	return
+}
+
+func (r *stableReader) readFloat64() (v float64) {
+	r.read(&v)
+	return
+}
+
+func (r *stableReader) skip(n int) {
+	if r.err != nil {
+		return
+	}
+
+	_, r.err = r.reader.Discard(n)
+}
+
+func (r *stableReader) readString() string {
+	switch r.readByte() {
+	case 0x00:
+		return ""
+	case 0x0b:
+	default:
+		if r.err == nil {
+			r.err = errors.New("invalid string marker")
+		}
+
+		return ""
+	}
+
+	var length uint64
+	var shift uint
+
+	for r.err == nil {
+		b := r.readByte()
+
+		length |= uint64(b&0x7f) << shift
+
+		if b&0x80 == 0 {
+			break
+		}
+
+		shift += 7
+	}
+
+	if r.err != nil {
+		return ""
+	}
+
+	buf := make([]byte, length)
+
+	_, r.err = io.ReadFull(r.reader, buf)
+
+	return string(buf)
+}
+
+// readStarRatings reads mods -> star rating dictionary and returns nomod value
+func (r *stableReader) readStarRatings(version int32) float64 {
+	stars := -1.0
+
+	count := int(r.readInt32())
+
+	for i := 0; i < count && r.err == nil; i++ {
+		r.skip(1)
+		mods := r.readInt32()
+		r.skip(1)
+
+		var value float64
+
+		if version >= 20250107 {
+			value = float64(r.readFloat32())
+		} else {
+			value = r.readFloat64()
+		}
+
+		if mods == 0 {
+			stars = value
+		}
+	}
+
+	return stars
+}
+
+func (r *stableReader) readDifficultyValue(version int32) float64 {
+	if version < 20140609 {
+		return float64(r.readByte())
+	}
+
+	return float64(r.readFloat32())
+}
+
+func ticksToMillis(ticks int64) int64 {
+	return (ticks - ticksEpoch) / 10000
+}
+
+// readOsuDB parses osu!stable's osu!.db
+func readOsuDB(path string) (*stableDB, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	r := newStableReader(file)
+
+	db := &stableDB{}
+
+	db.Version = r.readInt32()
+
+	r.readInt32()  // folder count
+	r.readBool()   // account unlocked
+	r.readInt64()  // unlock date
+	r.readString() // player name
+
+	count := int(r.readInt32())
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	db.Beatmaps = make([]*stableBeatmap, 0, max(count, 0))
+
+	for i := 0; i < count && r.err == nil; i++ {
+		db.Beatmaps = append(db.Beatmaps, readStableBeatmap(r, db.Version))
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to read beatmap entry: %w", r.err)
+	}
+
+	return db, nil
+}
+
+func readStableBeatmap(r *stableReader, version int32) *stableBeatmap {
+	bMap := &stableBeatmap{
+		MinBPM: math.Inf(0),
+	}
+
+	if version < 20191106 {
+		r.readInt32() // entry size
+	}
+
+	bMap.Artist = r.readString()
+	bMap.ArtistUnicode = r.readString()
+	bMap.Title = r.readString()
+	bMap.TitleUnicode = r.readString()
+	bMap.Creator = r.readString()
+	bMap.Version = r.readString()
+	bMap.AudioFile = r.readString()
+	bMap.MD5 = r.readString()
+	bMap.File = r.readString()
+
+	bMap.Status = beatmap.RankedStatus(r.readByte())
+
+	bMap.Circles = int(r.readInt16())
+	bMap.Sliders = int(r.readInt16())
+	bMap.Spinners = int(r.readInt16())
+
+	bMap.LastModified = ticksToMillis(r.readInt64())
+
+	bMap.AR = r.readDifficultyValue(version)
+	bMap.CS = r.readDifficultyValue(version)
+	bMap.HP = r.readDifficultyValue(version)
+	bMap.OD = r.readDifficultyValue(version)
+
+	bMap.SliderMultiplier = r.readFloat64()
+
+	bMap.Stars = -1
+
+	if version >= 20140609 {
+		bMap.Stars = r.readStarRatings(version)
+
+		for i := 0; i < 3; i++ { // taiko, catch, mania
+			r.readStarRatings(version)
+		}
+	}
+
+	r.readInt32() // drain time
+
+	bMap.TotalTime = int64(r.readInt32())
+	bMap.PreviewTime = int64(r.readInt32())
+
+	points := int(r.readInt32())
+
+	for i := 0; i < points && r.err == nil; i++ {
+		beatLength := r.readFloat64()
+		r.readFloat64() // offset
+		uninherited := r.readBool()
+
+		if uninherited && !math.IsNaN(beatLength) && beatLength > 0 {
+			bpm := 60000 / beatLength
+			bMap.MinBPM = min(bMap.MinBPM, bpm)
+			bMap.MaxBPM = max(bMap.MaxBPM, bpm)
+		}
+	}
+
+	bMap.ID = int64(r.readInt32())
+	bMap.SetID = int64(r.readInt32())
+
+	r.readInt32() // thread id
+	r.skip(4)     // grades
+	r.readInt16() // local offset
+
+	bMap.StackLeniency = float64(r.readFloat32())
+	bMap.Mode = int64(r.readByte())
+	bMap.Source = r.readString()
+	bMap.Tags = r.readString()
+
+	r.readInt16()  // online offset
+	r.readString() // title font
+	r.readBool()   // unplayed
+	r.readInt64()  // last played
+	r.readBool()   // osz2
+
+	bMap.Folder = filepath.ToSlash(r.readString())
+
+	r.readInt64() // last checked against online repository
+	r.skip(5)     // ignore sounds, ignore skin, disable storyboard, disable video, visual override
+
+	if version < 20140609 {
+		r.readInt16()
+	}
+
+	r.readInt32() // last modification time (unused)
+	r.readByte()  // mania scroll speed
+
+	return bMap
+}
+
+// readCollectionDB parses osu!stable's collection.db
+func readCollectionDB(path string) ([]*stableCollection, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	r := newStableReader(file)
+
+	r.readInt32() // version
+
+	count := int(r.readInt32())
+
+	result := make([]*stableCollection, 0, max(count, 0))
+
+	for i := 0; i < count && r.err == nil; i++ {
+		collection := &stableCollection{
+			Name: r.readString(),
+		}
+
+		hashes := int(r.readInt32())
+
+		for j := 0; j < hashes && r.err == nil; j++ {
+			collection.Hashes = append(collection.Hashes, r.readString())
+		}
+
+		result = append(result, collection)
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to read collection: %w", r.err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/settings"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// getStableDir returns osu!stable's installation directory, it's assumed that Songs directory is located there
+func getStableDir() string {
+	return filepath.Dir(songsDir)
+}
+
+func loadStableMaps() map[mapLocation]*stableBeatmap {
+	dbPath := filepath.Join(getStableDir(), "osu!.db")
+
+	if _, err := os.Stat(dbPath); err != nil {
+		log.Println("DatabaseManager: osu!.db not found in", getStableDir())
+		return nil
+	}
+
+	log.Println("DatabaseManager: Reading osu!.db...")
+
+	db, err := readOsuDB(dbPath)
+	if err != nil {
+		log.Println("DatabaseManager: Failed to read osu!.db:", err)
+		return nil
+	}
+
+	stableMaps := make(map[mapLocation]*stableBeatmap, len(db.Beatmaps))
+
+	for _, b := range db.Beatmaps {
+		if b.Folder == "" || b.File == "" {
+			continue
+		}
+
+		stableMaps[mapLocation{
+			dir:  b.Folder,
+			file: b.File,
+		}] = b
+	}
+
+	log.Println("DatabaseManager: Found", len(stableMaps), "beatmaps in osu!.db, version:", db.Version)
+
+	return stableMaps
+}
+
+// importFromStable creates beatmap using data cached by osu!stable. Only headers of .osu file are parsed, returns nil if file changed since osu! cached it.
+func importFromStable(file *os.File, location mapLocation, entry *stableBeatmap) *beatmap.BeatMap {
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil
+	}
+
+	md5Str := hex.EncodeToString(hash.Sum(nil))
+
+	if !strings.EqualFold(md5Str, entry.MD5) {
+		return nil
+	}
+
+	bMap := beatmap.NewBeatMap()
+	bMap.Dir = location.dir
+	bMap.File = location.file
+
+	if err := beatmap.ParseBeatMapHeaders(bMap); err != nil {
+		return nil
+	}
+
+	bMap.MD5 = md5Str
+	bMap.Circles = entry.Circles
+	bMap.Sliders = entry.Sliders
+	bMap.Spinners = entry.Spinners
+	bMap.Length = int(entry.TotalTime)
+
+	if bMap.ID <= 0 {
+		bMap.ID = entry.ID
+	}
+
+	if bMap.SetID <= 0 {
+		bMap.SetID = entry.SetID
+	}
+
+	if entry.MaxBPM > 0 {
+		bMap.MinBPM = entry.MinBPM
+		bMap.MaxBPM = entry.MaxBPM
+	}
+
+	// osu!'s star rating is only shown until danser calculates its own, StarsVersion stays at 0 so it's always recalculated
+	if bMap.Mode == 0 && entry.Stars >= 0 {
+		bMap.Stars = entry.Stars
+	}
+
+	stat, _ := file.Stat()
+	bMap.LastModified = stat.ModTime().UnixNano() / 1000000
+	bMap.TimeAdded = time.Now().UnixNano() / 1000000
+
+	return bMap
+}
+
+// importCollections replaces stored collections with the ones found in collection.db
+func importCollections() {
+	dbPath := filepath.Join(getStableDir(), "collection.db")
+
+	if _, err := os.Stat(dbPath); err != nil {
+		return
+	}
+
+	stableCollections, err := readCollectionDB(dbPath)
+	if err != nil {
+		log.Println("DatabaseManager: Failed to read collection.db:", err)
+		return
+	}
+
+	tx, err := dbFile.Begin()
+	if err != nil {
+		panic(err)
+	}
+
+	if _, err = tx.Exec("DELETE FROM collections"); err != nil {
+		panic(err)
+	}
+
+	st, err := tx.Prepare("INSERT INTO collections VALUES (?, ?)")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, c := range stableCollections {
+		for _, hash := range c.Hashes {
+			if _, err1 := st.Exec(c.Name, strings.ToLower(hash)); err1 != nil {
+				log.Println(err1)
+			}
+		}
+	}
+
+	if err = st.Close(); err != nil {
+		panic(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	log.Println("DatabaseManager: Imported", len(stableCollections), "collections from collection.db")
+
+	if settings.General.VerboseImportLogs {
+		for _, c := range stableCollections {
+			log.Println("DatabaseManager: Collection:", c.Name, "-", len(c.Hashes), "beatmaps")
+		}
+	}
+}
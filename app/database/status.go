@@ -0,0 +1,114 @@
+package database
+
+import (
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/lazer"
+	"log"
+	"strings"
+)
+
+// lazerStatuses maps lazer's BeatmapOnlineStatus to osu!.db values
+var lazerStatuses = map[int]beatmap.RankedStatus{
+	-4: beatmap.StatusUnknown, // locally modified
+	-3: beatmap.StatusUnsubmitted,
+	-2: beatmap.StatusPending, // graveyard
+	-1: beatmap.StatusPending, // WIP
+	0:  beatmap.StatusPending,
+	1:  beatmap.StatusRanked,
+	2:  beatmap.StatusApproved,
+	3:  beatmap.StatusQualified,
+	4:  beatmap.StatusLoved,
+}
+
+// statusesKnown is true if any beatmap loaded by the last LoadBeatmaps call has a known ranked status
+var statusesKnown bool
+
+var statusWarningShown bool
+
+// CheckStatusFilter logs a warning if the query filters by ranked status while statuses of all beatmaps are unknown.
+// Statuses are read only from osu!stable's osu!.db (General.ImportOsuDatabase) and osu!lazer's library, so with a plain Songs folder such filter can't match anything
+func CheckStatusFilter(query *beatmap.Query) {
+	if query == nil || !query.HasStatus() || statusesKnown || statusWarningShown {
+		return
+	}
+
+	statusWarningShown = true
+
+	log.Println("DatabaseManager: WARNING: Query filters by ranked status, but statuses of all beatmaps are unknown. They are imported only from osu!.db (General.ImportOsuDatabase) or osu!lazer's library, so status filters will match only status=unknown")
+}
+
+// collectStatuses gathers ranked statuses known to osu!stable and osu!lazer, keyed by lowercase MD5 hash
+func collectStatuses(stableMaps map[mapLocation]*stableBeatmap) map[string]beatmap.RankedStatus {
+	statuses := make(map[string]beatmap.RankedStatus)
+
+	for _, entry := range stableMaps {
+		if entry.Status != beatmap.StatusUnknown {
+			statuses[strings.ToLower(entry.MD5)] = entry.Status
+		}
+	}
+
+	if lib := lazer.GetLibrary(); lib != nil {
+		for _, set := range lib.BeatmapSets {
+			for _, bMap := range set.Beatmaps {
+				if bMap.Status == nil {
+					continue
+				}
+
+				if status := lazerStatuses[*bMap.Status]; status != beatmap.StatusUnknown {
+					statuses[strings.ToLower(bMap.MD5Hash)] = status
+				}
+			}
+		}
+	}
+
+	return statuses
+}
+
+// updateStatuses applies known ranked statuses to loaded beatmaps and saves the changed ones to the database
+func updateStatuses(bMaps []*beatmap.BeatMap, statuses map[string]beatmap.RankedStatus) {
+	if len(statuses) == 0 {
+		return
+	}
+
+	var changed []*beatmap.BeatMap
+
+	for _, bMap := range bMaps {
+		if status, ok := statuses[strings.ToLower(bMap.MD5)]; ok && status != bMap.Status {
+			bMap.Status = status
+			changed = append(changed, bMap)
+		}
+	}
+
+	if len(changed) == 0 {
+		return
+	}
+
+	tx, err := dbFile.Begin()
+	if err != nil {
+		log.Println("DatabaseManager: Failed to update ranked statuses:", err)
+		return
+	}
+
+	st, err := tx.Prepare("UPDATE beatmaps SET status = ? WHERE dir = ? AND file = ?")
+	if err != nil {
+		log.Println("DatabaseManager: Failed to update ranked statuses:", err)
+		tx.Rollback()
+
+		return
+	}
+
+	for _, bMap := range changed {
+		if _, err1 := st.Exec(bMap.Status, bMap.Dir, bMap.File); err1 != nil {
+			log.Println(err1)
+		}
+	}
+
+	st.Close()
+
+	if err = tx.Commit(); err != nil {
+		log.Println("DatabaseManager: Failed to update ranked statuses:", err)
+		return
+	}
+
+	log.Println("DatabaseManager: Updated ranked status of", len(changed), "beatmaps")
+}
@@ -12,6 +12,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -27,7 +28,55 @@ var audioPool chan []byte
 var audioWriteQueue chan []byte
 var endSyncAudio *sync.WaitGroup
 
-func startAudio(audioFPS float64) {
+// audioRunning is true from the start of audio ffmpeg process until its write queue is closed
+var audioRunning bool
+
+// stemBuffers hold the mix followed by stems while they're interleaved into one multichannel buffer, nil if stems are not saved
+var stemBuffers [][]byte
+
+// stemNames are used as filter labels and file names of stems
+var stemNames = [bass.StemCount]string{
+	bass.StemMusic:      "music",
+	bass.StemHitsounds:  "hitsounds",
+	bass.StemStoryboard: "storyboard",
+	bass.StemUI:         "ui",
+}
+
+// stemTitles are titles of stems saved as extra audio tracks
+var stemTitles = [bass.StemCount]string{
+	bass.StemMusic:      "Music",
+	bass.StemHitsounds:  "Hitsounds",
+	bass.StemStoryboard: "Storyboard",
+	bass.StemUI:         "UI",
+}
+
+// initAudio prepares buffers used by all parts of the recording
+func initAudio(audioFPS float64) {
+	bass.SetStemsEnabled(settings.Recording.HasAudioStems())
+
+	audioBufSize := bass.GetMixerRequiredBufferSize(1 / audioFPS)
+
+	stemBuffers = nil
+
+	if settings.Recording.HasAudioStems() {
+		stemBuffers = make([][]byte, 1+bass.StemCount)
+
+		for i := range stemBuffers {
+			stemBuffers[i] = make([]byte, audioBufSize)
+		}
+
+		audioBufSize *= len(stemBuffers)
+	}
+
+	audioPool = make(chan []byte, MaxAudioBuffers)
+
+	for i := 0; i < MaxAudioBuffers; i++ {
+		audioPool <- make([]byte, audioBufSize)
+	}
+}
+
+// startAudio starts ffmpeg process saving audio of the current part of the recording
+func startAudio() {
 	inputName := "-"
 
 	if runtime.GOOS != "windows" {
@@ -46,33 +95,23 @@ func startAudio(audioFPS float64) {
 		"-f", "f32le",
 		"-acodec", "pcm_f32le",
 		"-ar", "48000",
-		"-ac", "2",
+		"-ac", strconv.Itoa(getAudioChannels()),
 		"-i", inputName,
 
 		"-nostats", //hide audio encoding statistics because video ones are more important
 		"-vn",
-	}
 
-	audioFilters := strings.TrimSpace(settings.Recording.AudioFilters)
-	if len(audioFilters) > 0 {
-		options = append(options, "-af", audioFilters)
+		// parts are encoded once they're joined, lossy codecs would leave gaps at the boundaries
+		"-c:a", "pcm_f32le",
+		getAudioPath(),
 	}
 
-	options = append(options, "-c:a", settings.Recording.AudioCodec, "-strict", "-2")
-
-	encOptions, err := settings.Recording.GetAudioOptions().GenerateFFmpegArgs()
-	if err != nil {
-		panic(fmt.Sprintf("encoder \"%s\": %s", settings.Recording.AudioCodec, err))
-	} else if encOptions != nil {
-		options = append(options, encOptions...)
-	}
-
-	options = append(options, filepath.Join(settings.Recording.GetOutputDir(), output+"_temp", "audio."+settings.Recording.Container))
-
 	log.Println("Running ffmpeg with options:", options)
 
 	cmdAudio = exec.Command(ffmpegExec, options...)
 
+	var err error
+
 	if runtime.GOOS == "windows" {
 		audioPipe, err = cmdAudio.StdinPipe()
 		if err != nil {
@@ -90,15 +129,8 @@ func startAudio(audioFPS float64) {
 		panic(fmt.Sprintf("ffmpeg's audio process failed to start! Please check if audio parameters are entered correctly or audio codec is supported by provided container. Error: %s", err))
 	}
 
-	audioBufSize := bass.GetMixerRequiredBufferSize(1 / audioFPS)
-
-	audioPool = make(chan []byte, MaxAudioBuffers)
-
-	for i := 0; i < MaxAudioBuffers; i++ {
-		audioPool <- make([]byte, audioBufSize)
-	}
-
 	audioWriteQueue = make(chan []byte, MaxAudioBuffers)
+	audioRunning = true
 
 	endSyncAudio = &sync.WaitGroup{}
 	endSyncAudio.Add(1)
@@ -106,6 +138,11 @@ func startAudio(audioFPS float64) {
 	goroutines.RunOS(func() {
 		for data := range audioWriteQueue {
 			if _, err := audioPipe.Write(data); err != nil {
+				if aborted.Load() { // the pipe was closed by Abort
+					audioPool <- data
+					continue
+				}
+
 				panic(fmt.Sprintf("ffmpeg's audio process finished abruptly! Please check if you have enough storage or audio parameters are entered correctly. Error: %s", err))
 			}
 
@@ -116,9 +153,40 @@ func startAudio(audioFPS float64) {
 	})
 }
 
+// getAudioChannels returns the number of channels of intermediate audio files, stems are saved as extra channel pairs after the mix
+func getAudioChannels() int {
+	if settings.Recording.HasAudioStems() {
+		return 2 * (1 + bass.StemCount)
+	}
+
+	return 2
+}
+
+// getAudioEncoderOptions returns filters, codec and codec options of the audio in the final video.
+// If stems are saved, filters are applied by the graph from getStemsFilter instead.
+func getAudioEncoderOptions() (options []string) {
+	audioFilters := strings.TrimSpace(settings.Recording.AudioFilters)
+	if len(audioFilters) > 0 && !settings.Recording.HasAudioStems() {
+		options = append(options, "-af", audioFilters)
+	}
+
+	options = append(options, "-c:a", settings.Recording.AudioCodec, "-strict", "-2")
+
+	encOptions, err := settings.Recording.GetAudioOptions().GenerateFFmpegArgs()
+	if err != nil {
+		panic(fmt.Sprintf("encoder \"%s\": %s", settings.Recording.AudioCodec, err))
+	} else if encOptions != nil {
+		options = append(options, encOptions...)
+	}
+
+	return
+}
+
 func stopAudio() {
 	log.Println("Audio finished! Stopping audio pipe...")
 
+	audioRunning = false
+
 	close(audioWriteQueue)
 
 	endSyncAudio.Wait()
@@ -132,10 +200,125 @@ func stopAudio() {
 	log.Println("Audio process finished.")
 }
 
+// getStemsFilter returns a filter graph which splits the multichannel audio of the input into the mix labeled [mix]
+// and, if withStems is set, stems labeled with their names. Audio filters are applied to each of them.
+func getStemsFilter(input int, withStems bool) string {
+	labels := []string{"mix"}
+	if withStems {
+		labels = append(labels, stemNames[:]...)
+	}
+
+	audioFilters := strings.TrimSpace(settings.Recording.AudioFilters)
+
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("[%d:a]asplit=%d", input, len(labels)))
+
+	for i := range labels {
+		sb.WriteString(fmt.Sprintf("[s%d]", i))
+	}
+
+	for i, label := range labels {
+		sb.WriteString(fmt.Sprintf(";[s%d]pan=stereo|c0=c%d|c1=c%d", i, 2*i, 2*i+1))
+
+		if len(audioFilters) > 0 {
+			sb.WriteString("," + audioFilters)
+		}
+
+		sb.WriteString("[" + label + "]")
+	}
+
+	return sb.String()
+}
+
+// getStemTracksOptions returns options adding stems from the graph of getStemsFilter as extra audio tracks after the mix
+func getStemTracksOptions() (options []string) {
+	options = append(options, "-metadata:s:a:0", "title=Mix")
+
+	for i, name := range stemNames {
+		options = append(options, "-map", "["+name+"]", fmt.Sprintf("-metadata:s:a:%d", i+1), "title="+stemTitles[i])
+	}
+
+	return
+}
+
+// getStemFilesOptions returns output options saving stems from the graph of getStemsFilter to separate files.
+// They have to be placed before options of the main output.
+func getStemFilesOptions() (options []string) {
+	codec := "pcm_s16le"
+	if settings.Recording.AudioStems == "flac" {
+		codec = "flac"
+	}
+
+	for _, name := range stemNames {
+		options = append(options, "-map", "["+name+"]", "-c:a", codec, getStemPath(name))
+	}
+
+	return
+}
+
+// getStemPath returns the path of the stem file, it's saved next to the video or inside the directory of an image sequence
+func getStemPath(name string) string {
+	ext := "." + settings.Recording.AudioStems
+
+	if settings.Recording.IsImageSequence() {
+		return filepath.Join(GetOutputPath(), name+ext)
+	}
+
+	return GetOutputPath() + "_" + name + ext
+}
+
+// killAudio stops audio ffmpeg process without waiting for queued samples
+func killAudio() {
+	if !audioRunning {
+		return
+	}
+
+	audioRunning = false
+
+	_ = cmdAudio.Process.Kill()
+
+	_ = audioPipe.Close()
+
+	close(audioWriteQueue)
+
+	endSyncAudio.Wait()
+
+	_ = cmdAudio.Wait()
+}
+
 func PushAudio() {
 	data := <-audioPool
 
-	bass.ProcessMixer(data)
+	processAudio(data)
 
 	audioWriteQueue <- data
 }
+
+// DiscardAudio advances the mixer by the same amount as PushAudio, but the samples are thrown away
+func DiscardAudio() {
+	data := <-audioPool
+
+	processAudio(data)
+
+	audioPool <- data
+}
+
+// processAudio fills the buffer with the next chunk of audio. If stems are saved, stereo frames of the mix
+// and stems are interleaved, so they're stored as one multichannel stream.
+func processAudio(data []byte) {
+	if stemBuffers == nil {
+		bass.ProcessMixer(data)
+		return
+	}
+
+	bass.ProcessMixer(stemBuffers[0], stemBuffers[1:]...)
+
+	const frameSize = 8 // 2 channels of 32-bit floats
+
+	for i := 0; i < len(stemBuffers[0]); i += frameSize {
+		for j, buffer := range stemBuffers {
+			copy(data[i*len(stemBuffers)+j*frameSize:], buffer[i:i+frameSize])
+		}
+	}
+}
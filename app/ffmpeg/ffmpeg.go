@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"github.com/wieku/danser-go/app/settings"
 	"github.com/wieku/danser-go/framework/files"
+	"io/fs"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,6 +18,17 @@ var ffmpegExec string
 
 var output string
 
+// PartLength is the length (in ms) of parts every recording is split into, they're joined into the final video when it's finished.
+// Finished parts are kept after a crash or interruption, so running the same recording again (with the same or no -out)
+// continues from the first missing one.
+const PartLength = 60000.0
+
+// part is the index of the currently recorded part
+var part int
+
+// aborted tells writers that ffmpeg processes were killed by Abort, so failed writes are expected
+var aborted atomic.Bool
+
 // check used encoders exist
 func preCheck() {
 	var err error
@@ -71,11 +84,50 @@ func preCheck() {
 	if !afound {
 		panic(fmt.Sprintf("Audio codec %q does not exist", acodec))
 	}
+
+	if settings.Recording.IsImageSequence() {
+		if settings.Recording.AudioStems == "tracks" {
+			panic("Image sequences can't hold extra audio tracks, save stems as WAV or FLAC files")
+		}
+
+		return
+	}
+
+	container := settings.Recording.Container
+
+	// audio is encoded after the whole recording, so it's better to fail early
+	if container == "webm" && (acodec == "aac" || acodec == "libmp3lame" || acodec == "flac") {
+		panic(fmt.Sprintf("Audio codec %q can't be saved in webm container, use OPUS", acodec))
+	}
+
+	// encoders would silently drop the alpha channel
+	if settings.Recording.IsTransparent() {
+		if vcodec == "prores_ks" && !settings.Recording.ProResSettings.Is444() {
+			panic("Transparency needs ProRes 4444 or 4444 XQ profile")
+		}
+
+		if vcodec == "libvpx-vp9" && container != "webm" && container != "mkv" {
+			panic("Transparent VP9 video needs webm or mkv container")
+		}
+	}
 }
 
-func StartFFmpeg(fps, _w, _h int, audioFPS float64, _output string) {
+// StartFFmpeg starts encoding the recording. job identifies settings, beatmap and replays of the recording, if it matches
+// the manifest of an interrupted recording with the same output, finished parts are kept. Without an output name, the generated
+// name of an interrupted recording of the same job is reused. Returns the time (relative to the start of the recording) from which
+// the recording has to continue, it's 0 if nothing was recorded yet.
+func StartFFmpeg(fps, _w, _h int, audioFPS float64, _output, job string) float64 {
 	preCheck()
 
+	aborted.Store(false)
+
+	// audio is encoded at the end, invalid options should be reported before recording starts
+	getAudioEncoderOptions()
+
+	if strings.TrimSpace(_output) == "" && segment == 0 {
+		_output = findInterruptedOutput(job)
+	}
+
 	if strings.TrimSpace(_output) == "" {
 		_output = "danser_" + time.Now().Format("2006-01-02_15-04-05")
 	}
@@ -84,42 +136,383 @@ func StartFFmpeg(fps, _w, _h int, audioFPS float64, _output string) {
 
 	log.Println("Starting encoding!")
 
-	_ = os.RemoveAll(filepath.Join(settings.Recording.GetOutputDir(), output+"_temp"))
+	resumeTime := 0.0
 
-	err := os.MkdirAll(filepath.Join(settings.Recording.GetOutputDir(), output+"_temp"), 0755)
-	if err != nil && !os.IsExist(err) {
-		panic(err)
+	if segment > 0 {
+		// segments share the directory, it's prepared by the parent process
+		if err := os.MkdirAll(getTempDir(), 0755); err != nil {
+			panic(err)
+		}
+	} else {
+		part = loadManifest(job)
+		resumeTime = float64(part) * PartLength
+	}
+
+	initVideo(fps, _w, _h)
+	initAudio(audioFPS)
+
+	startVideo()
+	startAudio()
+
+	return resumeTime
+}
+
+// SetTime tells the time of the recorded frame relative to the start of the recording, a new part is started if it's past the
+// current one. It has to be called before the first frame of each output frame (there may be more with motion blur).
+func SetTime(time float64) {
+	if segment > 0 {
+		return
+	}
+
+	if next := max(0, int(time/PartLength)); next > part {
+		finishPart()
+
+		part = next
+
+		startVideo()
+		startAudio()
+	}
+}
+
+// GetOutputPath returns the path of the final video without an extension
+func GetOutputPath() string {
+	return filepath.Join(settings.Recording.GetOutputDir(), output)
+}
+
+// GetResultPath returns the path of the final video, or the directory with images if an image sequence is recorded.
+// If layers are recorded separately, it's the video of the first one.
+func GetResultPath() string {
+	if settings.Recording.IsImageSequence() {
+		return GetOutputPath()
+	}
+
+	return getFinalPath(getStreamNames()[0])
+}
+
+// GetResultFiles returns paths of all files of the finished recording: videos of all layers and separate audio stems,
+// or images and audio files if an image sequence is recorded
+func GetResultFiles() (result []string) {
+	if settings.Recording.IsImageSequence() {
+		_ = filepath.WalkDir(GetOutputPath(), func(path string, d fs.DirEntry, err error) error {
+			if err == nil && d.Type().IsRegular() {
+				result = append(result, path)
+			}
+
+			return nil
+		})
+
+		return
+	}
+
+	for _, name := range getStreamNames() {
+		result = append(result, getFinalPath(name))
+	}
+
+	if settings.Recording.HasAudioStems() && settings.Recording.AudioStems != "tracks" {
+		for _, name := range stemNames {
+			result = append(result, getStemPath(name))
+		}
+	}
+
+	return
+}
+
+// getFinalPath returns the path of the final video of the stream with given name, or the directory with its images
+func getFinalPath(name string) string {
+	if settings.Recording.IsImageSequence() {
+		return filepath.Join(GetOutputPath(), name)
+	}
+
+	return GetOutputPath() + getStreamSuffix(name) + "." + settings.Recording.Container
+}
+
+// getStreamNames returns names of video streams of the recording, there's one stream with an empty name unless layers are separate
+func getStreamNames() []string {
+	if settings.Recording.IsLayered() {
+		return layerNames
+	}
+
+	return []string{""}
+}
+
+func getStreamSuffix(name string) string {
+	if name == "" {
+		return ""
 	}
 
-	startVideo(fps, _w, _h)
-	startAudio(audioFPS)
+	return "_" + name
 }
 
 func StopFFmpeg() {
 	log.Println("Finishing rendering...")
 
+	if segment > 0 {
+		stopVideo()
+		stopAudio()
+		disposeVideo()
+
+		log.Println("Ffmpeg finished.")
+
+		finishSegment()
+
+		return
+	}
+
+	finishPart()
+	disposeVideo()
+
+	log.Println("Ffmpeg finished.")
+
+	join(getTempDir(), currentManifest.Parts)
+
+	cleanup()
+}
+
+// Abort kills ffmpeg processes of a recording that failed. Parts finished so far are kept, so the recording can be resumed later.
+func Abort() {
+	if streams == nil && !audioRunning {
+		return
+	}
+
+	log.Println("Aborting rendering...")
+
+	aborted.Store(true)
+
+	for _, stream := range streams {
+		stream.kill()
+	}
+
+	killAudio()
+	disposeVideo()
+
+	log.Println("Ffmpeg processes killed.")
+}
+
+// finishPart waits for ffmpeg to save the current part and adds it to the manifest
+func finishPart() {
 	stopVideo()
 	stopAudio()
 
-	log.Println("Ffmpeg finished.")
+	currentManifest.Parts = append(currentManifest.Parts, part)
+	currentManifest.save()
+}
 
-	combine()
+// getTempDir returns the directory of intermediate video and audio files
+func getTempDir() string {
+	if segment > 0 {
+		return getSegmentDir(output)
+	}
+
+	return filepath.Join(settings.Recording.GetOutputDir(), output+"_temp")
 }
 
-func combine() {
+func getVideoPath(name string) string {
+	index := part
+	if segment > 0 {
+		index = segment
+	}
+
+	path := filepath.Join(getTempDir(), getVideoName(index, name))
+
+	if settings.Recording.IsImageSequence() { // image2 muxer replaces the pattern with frame number
+		path = filepath.Join(path, "%06d"+getImageExtension())
+	}
+
+	return path
+}
+
+func getAudioPath() string {
+	if segment > 0 {
+		return filepath.Join(getTempDir(), getAudioName(segment))
+	}
+
+	return filepath.Join(getTempDir(), getAudioName(part))
+}
+
+// getVideoName returns the name of intermediate video file of the stream, or the directory with its frames if an image sequence is recorded
+func getVideoName(index int, name string) string {
+	if settings.Recording.IsImageSequence() {
+		return fmt.Sprintf("video_%03d%s", index, getStreamSuffix(name))
+	}
+
+	return fmt.Sprintf("video_%03d%s.%s", index, getStreamSuffix(name), settings.Recording.Container)
+}
+
+// getImageExtension returns the extension of images in a sequence, image encoders are named after their formats
+func getImageExtension() string {
+	return "." + strings.ToLower(settings.Recording.Encoder)
+}
+
+// getAudioName returns the name of intermediate audio file, it's uncompressed so matroska is used regardless of the container
+func getAudioName(index int) string {
+	return fmt.Sprintf("audio_%03d.mka", index)
+}
+
+// join concatenates intermediate files with given indices into the final video. Video is copied, audio is encoded in one go.
+// If layers are recorded separately, each gets its own video with the same audio. Audio stems are separated from the mix here as well.
+func join(dir string, indices []int) {
+	if settings.Recording.IsImageSequence() {
+		joinImages(dir, indices)
+		return
+	}
+
+	var audioList strings.Builder
+
+	names := getStreamNames()
+	videoLists := make([]strings.Builder, len(names))
+
+	for _, i := range indices {
+		// all streams get the same frames
+		if _, err := os.Stat(filepath.Join(dir, getVideoName(i, names[0]))); err != nil {
+			log.Println(fmt.Sprintf("Part %d has no frames, skipping...", i))
+			continue
+		}
+
+		for j, name := range names {
+			videoLists[j].WriteString(fmt.Sprintf("file '%s'\n", getVideoName(i, name)))
+		}
+
+		audioList.WriteString(fmt.Sprintf("file '%s'\n", getAudioName(i)))
+	}
+
+	audioListPath := filepath.Join(dir, "audio.txt")
+
+	if err := os.WriteFile(audioListPath, []byte(audioList.String()), 0644); err != nil {
+		panic(err)
+	}
+
+	for j, name := range names {
+		videoListPath := filepath.Join(dir, "video"+getStreamSuffix(name)+".txt")
+
+		if err := os.WriteFile(videoListPath, []byte(videoLists[j].String()), 0644); err != nil {
+			panic(err)
+		}
+
+		options := []string{
+			"-y",
+			"-f", "concat", "-safe", "0", "-i", videoListPath,
+			"-f", "concat", "-safe", "0", "-i", audioListPath,
+		}
+
+		if settings.Recording.HasAudioStems() {
+			tracks := settings.Recording.AudioStems == "tracks"
+
+			// stem files are the same for all layers, they're saved along with the first one
+			stemFiles := !tracks && j == 0
+
+			options = append(options, "-filter_complex", getStemsFilter(1, tracks || stemFiles))
+
+			if stemFiles {
+				options = append(options, getStemFilesOptions()...)
+			}
+
+			options = append(options, "-map", "0:v", "-map", "[mix]")
+
+			if tracks {
+				options = append(options, getStemTracksOptions()...)
+			}
+		} else {
+			options = append(options, "-map", "0:v", "-map", "1:a")
+		}
+
+		options = append(options, "-c:v", "copy")
+		options = append(options, getAudioEncoderOptions()...)
+
+		compose(options, getFinalPath(name))
+	}
+}
+
+// joinImages moves frames of intermediate image sequences with given indices into one directory numbered continuously,
+// or into a subdirectory per layer if layers are recorded separately. Audio is saved next to them as wav, as the sequence
+// has no container to hold it.
+func joinImages(dir string, indices []int) {
+	_ = os.RemoveAll(GetOutputPath())
+
+	names := getStreamNames()
+
+	for _, name := range names {
+		if err := os.MkdirAll(getFinalPath(name), 0755); err != nil {
+			panic(err)
+		}
+	}
+
+	log.Println("Moving frames to:", GetOutputPath())
+
+	var audioList strings.Builder
+
+	frames := make([]int, len(names))
+
+	for _, i := range indices {
+		partFrames := 0
+
+		for j, name := range names {
+			partFrames = moveFrames(filepath.Join(dir, getVideoName(i, name)), getFinalPath(name), &frames[j])
+		}
+
+		if partFrames == 0 {
+			log.Println(fmt.Sprintf("Part %d has no frames, skipping...", i))
+			continue
+		}
+
+		audioList.WriteString(fmt.Sprintf("file '%s'\n", getAudioName(i)))
+	}
+
+	audioListPath := filepath.Join(dir, "audio.txt")
+
+	if err := os.WriteFile(audioListPath, []byte(audioList.String()), 0644); err != nil {
+		panic(err)
+	}
+
 	options := []string{
 		"-y",
-		"-i", filepath.Join(settings.Recording.GetOutputDir(), output+"_temp", "video."+settings.Recording.Container),
-		"-i", filepath.Join(settings.Recording.GetOutputDir(), output+"_temp", "audio."+settings.Recording.Container),
-		"-c:v", "copy",
-		"-c:a", "copy", "-strict", "-2",
+		"-f", "concat", "-safe", "0", "-i", audioListPath,
 	}
 
-	if settings.Recording.Container == "mp4" {
-		options = append(options, "-movflags", "+faststart")
+	if settings.Recording.HasAudioStems() {
+		options = append(options, "-filter_complex", getStemsFilter(0, true))
+		options = append(options, getStemFilesOptions()...)
+		options = append(options, "-map", "[mix]")
+	} else if audioFilters := strings.TrimSpace(settings.Recording.AudioFilters); len(audioFilters) > 0 {
+		options = append(options, "-af", audioFilters)
 	}
 
-	finalOutputPath := filepath.Join(settings.Recording.GetOutputDir(), output+"."+settings.Recording.Container)
+	options = append(options, "-c:a", "pcm_s16le")
+
+	compose(options, filepath.Join(GetOutputPath(), "audio.wav"))
+}
+
+// moveFrames moves images from the part directory to the final one, numbering them from the given counter. Returns the number of moved frames.
+func moveFrames(partDir, finalDir string, counter *int) int {
+	// entries are sorted by name, frame numbers are zero-padded so it's also the order of frames
+	entries, err := os.ReadDir(partDir)
+	if err != nil {
+		return 0
+	}
+
+	moved := 0
+
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != getImageExtension() {
+			continue
+		}
+
+		*counter++
+
+		if err = os.Rename(filepath.Join(partDir, entry.Name()), filepath.Join(finalDir, fmt.Sprintf("%06d%s", *counter, getImageExtension()))); err != nil {
+			panic(err)
+		}
+
+		moved++
+	}
+
+	return moved
+}
+
+// compose runs ffmpeg which joins intermediate files into the final file at the given path
+func compose(options []string, finalOutputPath string) {
+	if filepath.Ext(finalOutputPath) == ".mp4" {
+		options = append(options, "-movflags", "+faststart")
+	}
 
 	options = append(options, finalOutputPath)
 
@@ -139,17 +532,15 @@ func combine() {
 			panic(fmt.Sprintf("ffmpeg finished abruptly! Please check if you have enough storage. Error: %s", err))
 		} else {
 			log.Println("Finished!")
-			log.Println("Video is available at:", finalOutputPath)
+			log.Println("Video is available at:", GetResultPath())
 		}
 	}
-
-	cleanup()
 }
 
 func cleanup() {
 	log.Println("Cleaning up intermediate files...")
 
-	_ = os.RemoveAll(filepath.Join(settings.Recording.GetOutputDir(), output+"_temp"))
+	_ = os.RemoveAll(getTempDir())
 
 	log.Println("Finished.")
 }
@@ -0,0 +1,138 @@
+package ffmpeg
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/wieku/danser-go/app/settings"
+)
+
+const manifestName = "manifest.json"
+
+// manifest lists finished parts of the recording, it's kept in the temp directory to resume an interrupted recording
+type manifest struct {
+	Job        string  `json:"job"`
+	PartLength float64 `json:"partLength"`
+	Parts      []int   `json:"parts"`
+}
+
+var currentManifest *manifest
+
+// loadManifest reads the manifest left by a previous recording with the same output and keeps its parts if it was the same job,
+// otherwise the temp directory is cleared. Returns the index of the first part that has to be recorded.
+func loadManifest(job string) int {
+	currentManifest = &manifest{
+		Job:        job,
+		PartLength: PartLength,
+	}
+
+	dir := getTempDir()
+
+	var previous manifest
+
+	if data, err := os.ReadFile(filepath.Join(dir, manifestName)); err == nil && json.Unmarshal(data, &previous) == nil &&
+		previous.Job == job && previous.PartLength == PartLength {
+		// ffmpeg may have died before the part was saved even if it's listed
+		for _, i := range previous.Parts {
+			if !isPartSaved(i) {
+				break
+			}
+
+			currentManifest.Parts = append(currentManifest.Parts, i)
+		}
+	}
+
+	if len(currentManifest.Parts) > 0 {
+		log.Println(fmt.Sprintf("Found %d finished parts of an interrupted recording, resuming...", len(currentManifest.Parts)))
+
+		currentManifest.save()
+
+		return currentManifest.Parts[len(currentManifest.Parts)-1] + 1
+	}
+
+	_ = os.RemoveAll(dir)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		panic(err)
+	}
+
+	currentManifest.save()
+
+	return 0
+}
+
+// findInterruptedOutput returns the generated name (danser_<date>) of an interrupted recording of the same job, so recordings
+// started without an output name can be resumed too. If there are more, the newest one is picked. Returns an empty string if there's none.
+func findInterruptedOutput(job string) string {
+	entries, err := os.ReadDir(settings.Recording.GetOutputDir())
+	if err != nil {
+		return ""
+	}
+
+	// generated names contain the date, so the newest recording is the last one
+	for _, entry := range slices.Backward(entries) {
+		name, ok := strings.CutSuffix(entry.Name(), "_temp")
+		if !ok || !entry.IsDir() || !strings.HasPrefix(name, "danser_") {
+			continue
+		}
+
+		var previous manifest
+
+		if data, err1 := os.ReadFile(filepath.Join(settings.Recording.GetOutputDir(), entry.Name(), manifestName)); err1 == nil && json.Unmarshal(data, &previous) == nil &&
+			previous.Job == job && previous.PartLength == PartLength && len(previous.Parts) > 0 {
+			return name
+		}
+	}
+
+	return ""
+}
+
+func isPartSaved(index int) bool {
+	names := []string{getAudioName(index)}
+
+	for _, name := range getStreamNames() {
+		names = append(names, getVideoName(index, name))
+	}
+
+	for _, name := range names {
+		path := filepath.Join(getTempDir(), name)
+
+		stat, err := os.Stat(path)
+		if err != nil {
+			return false
+		}
+
+		if stat.IsDir() { // frames of an image sequence
+			if entries, err1 := os.ReadDir(path); err1 != nil || len(entries) == 0 {
+				return false
+			}
+		} else if stat.Size() == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// save writes the manifest to a temporary file first, so it's not left half-written by a crash
+func (m *manifest) save() {
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		panic(err)
+	}
+
+	path := filepath.Join(getTempDir(), manifestName)
+
+	if err = os.WriteFile(path+".tmp", data, 0644); err != nil {
+		panic(err)
+	}
+
+	if err = os.Rename(path+".tmp", path); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,56 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/framework/files"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ChangeAudioSpeed encodes input audio file to output with its speed changed. Pitch follows the speed if adjustPitch is true (like in Nightcore),
+// otherwise it stays the same. Output format is chosen by ffmpeg from the output's extension.
+func ChangeAudioSpeed(input, output string, speed float64, adjustPitch bool) error {
+	ffmpegExec, err := files.GetCommandExec("ffmpeg", "ffmpeg")
+	if err != nil {
+		return fmt.Errorf("ffmpeg not found! Please make sure it's installed in danser directory or in PATH. Follow download instructions at https://github.com/Wieku/danser-go/wiki/FFmpeg")
+	}
+
+	var filters []string
+
+	if adjustPitch {
+		filters = append(filters, "aresample=48000", "asetrate="+strconv.FormatFloat(48000*speed, 'f', -1, 64), "aresample=48000")
+	} else {
+		// atempo accepts only values between 0.5 and 2 in older versions of ffmpeg, so bigger changes have to be chained
+		tempo := speed
+
+		for ; tempo > 2; tempo /= 2 {
+			filters = append(filters, "atempo=2")
+		}
+
+		for ; tempo < 0.5; tempo /= 0.5 {
+			filters = append(filters, "atempo=0.5")
+		}
+
+		filters = append(filters, "atempo="+strconv.FormatFloat(tempo, 'f', -1, 64))
+	}
+
+	options := []string{
+		"-y",
+		"-nostats",
+		"-i", input,
+		"-vn",
+		"-map_metadata", "-1",
+		"-af", strings.Join(filters, ","),
+		output,
+	}
+
+	log.Println("Running ffmpeg with options:", options)
+
+	if out, err := exec.Command(ffmpegExec, options...).CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg failed to change audio speed: %w\n%s", err, out)
+	}
+
+	return nil
+}
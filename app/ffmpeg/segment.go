@@ -0,0 +1,69 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/settings"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// segment is the 1-based index of the part of a recording split between processes, 0 if the whole recording is done by this one
+var segment int
+
+// SetSegment makes the recording produce intermediate files of one segment instead of the final video.
+// Segments are joined by ConcatSegments in the parent process.
+func SetSegment(index int) {
+	segment = index
+}
+
+// PrepareSegments checks ffmpeg and creates an empty directory for segments of the recording with the given name
+func PrepareSegments(name string) {
+	preCheck()
+
+	output = name
+
+	dir := getSegmentDir(name)
+
+	_ = os.RemoveAll(dir)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		panic(err)
+	}
+}
+
+// ConcatSegments joins segments rendered by child processes into the final video
+func ConcatSegments(count int) {
+	indices := make([]int, count)
+	for i := range indices {
+		indices[i] = i + 1
+	}
+
+	join(getSegmentDir(output), indices)
+
+	log.Println("Cleaning up segments...")
+
+	_ = os.RemoveAll(getSegmentDir(output))
+
+	log.Println("Finished.")
+}
+
+// finishSegment removes files of a segment that didn't get any frames, so they're skipped by ConcatSegments
+func finishSegment() {
+	if outputFrames > 0 {
+		log.Println(fmt.Sprintf("Segment %d finished.", segment))
+		return
+	}
+
+	log.Println(fmt.Sprintf("Segment %d has no frames, removing its files...", segment))
+
+	for _, name := range getStreamNames() {
+		_ = os.RemoveAll(filepath.Join(getTempDir(), getVideoName(segment, name)))
+	}
+
+	_ = os.Remove(getAudioPath())
+}
+
+func getSegmentDir(name string) string {
+	return filepath.Join(settings.Recording.GetOutputDir(), name+"_segments")
+}
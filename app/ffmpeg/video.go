@@ -8,6 +8,7 @@ import (
 	"github.com/wieku/danser-go/framework/files"
 	"github.com/wieku/danser-go/framework/frame"
 	"github.com/wieku/danser-go/framework/goroutines"
+	"github.com/wieku/danser-go/framework/graphics/buffer"
 	"github.com/wieku/danser-go/framework/graphics/effects"
 	"github.com/wieku/danser-go/framework/graphics/texture"
 	"github.com/wieku/danser-go/framework/util/pixconv"
@@ -17,6 +18,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -25,21 +27,42 @@ import (
 
 const MaxVideoBuffers = 10
 
-var cmdVideo *exec.Cmd
+// videoStream is one video file of the recording, each layer gets its own when they're recorded separately
+type videoStream struct {
+	// name is added to names of files of the stream, it's empty if there's only one
+	name string
 
-var videoPipe io.WriteCloser
+	cmd *exec.Cmd
 
-var videoWriteQueue chan *PBO
-var endSyncVideo *sync.WaitGroup
+	// running is true from the start of ffmpeg process until its write queue is closed
+	running bool
 
-var videoError string
-var videoErrorWait *sync.WaitGroup
+	pipe io.WriteCloser
 
-var freePBOPool chan *PBO
+	writeQueue chan *PBO
+	endSync    *sync.WaitGroup
 
-var frameReadQueue = make([]*PBO, 0)
+	err     string
+	logPipe *os.File
+	errWait *sync.WaitGroup
 
-var blend *effects.Blend
+	pboPool  chan *PBO
+	pboQueue []*PBO
+
+	blend             *effects.Blend
+	rgbToYuvConverter *effects.RGBYUV
+
+	// layerFBO holds the layer drawn by the player until the frame is made, nil if there's only one stream
+	layerFBO *buffer.Framebuffer
+}
+
+var streams []*videoStream
+
+// layerNames are file suffixes of separately recorded layers, in the order of states.Layer
+var layerNames = []string{"background", "playfield", "hud"}
+
+// currentLayer is the index of the stream which layer is being drawn, -1 if none is bound
+var currentLayer = -1
 
 var w, h int
 
@@ -47,6 +70,22 @@ var limiter *frame.Limiter
 
 var parsedFormat pixconv.PixFmt
 
+// options of the video ffmpeg process, they're the same for each part of the recording
+var videoInputOptions, videoOutputOptions []string
+
+var videoEncoder string
+
+// alpha tells whether frames are read with the alpha channel
+var alpha bool
+
+// rgbOutputFormats lists output pixel formats (without and with alpha) of encoders which always get RGB frames from danser
+var rgbOutputFormats = map[string][2]string{
+	"prores_ks":  {"yuv444p10le", "yuva444p10le"},
+	"libvpx-vp9": {"yuv420p", "yuva420p"},
+	"png":        {"rgb24", "rgba"},
+	"exr":        {"gbrpf32le", "gbrapf32le"},
+}
+
 type PBO struct {
 	handle     uint32
 	memPointer unsafe.Pointer
@@ -63,7 +102,9 @@ func createPBO(format pixconv.PixFmt) *PBO {
 
 	glSize := w * h * 3
 
-	if pbo.convFormat == pixconv.I420 || pbo.convFormat == pixconv.NV12 {
+	if alpha {
+		glSize = w * h * 4
+	} else if pbo.convFormat == pixconv.I420 || pbo.convFormat == pixconv.NV12 {
 		glSize = w * h * 3 / 2
 	}
 
@@ -77,9 +118,13 @@ func createPBO(format pixconv.PixFmt) *PBO {
 	return pbo
 }
 
-var rgbToYuvConverter *effects.RGBYUV
+func (pbo *PBO) dispose() {
+	gl.UnmapNamedBuffer(pbo.handle)
+	gl.DeleteBuffers(1, &pbo.handle)
+}
 
-func startVideo(fps, _w, _h int) {
+// initVideo prepares ffmpeg options and buffers used by all parts of the recording
+func initVideo(fps, _w, _h int) {
 	w, h = _w, _h
 
 	if settings.Recording.MotionBlur.Enabled {
@@ -89,50 +134,62 @@ func startVideo(fps, _w, _h int) {
 	encoder := strings.ToLower(settings.Recording.Encoder)
 	outputFormat := strings.ToLower(settings.Recording.PixelFormat)
 
+	alpha = settings.Recording.IsTransparent()
+
+	rgbFormats, rgbOnly := rgbOutputFormats[encoder]
+
 	if strings.HasSuffix(encoder, "_qsv") { // qsv works best with nv12 format
 		outputFormat = "nv12"
 	} else if encoder == "libsvtav1" {
 		outputFormat = "yuv420p"
+	} else if rgbOnly {
+		outputFormat = rgbFormats[0]
+
+		if alpha {
+			outputFormat = rgbFormats[1]
+		} else if encoder == "prores_ks" && !settings.Recording.ProResSettings.Is444() {
+			outputFormat = "yuv422p10le"
+		}
 	}
 
 	parsedFormat = pixconv.ARGB
 
-	switch outputFormat {
-	case "yuv420p":
-		parsedFormat = pixconv.I420
-	case "yuv444p":
-		parsedFormat = pixconv.I444
-	case "nv12":
-		parsedFormat = pixconv.NV12
+	if !rgbOnly {
+		switch outputFormat {
+		case "yuv420p":
+			parsedFormat = pixconv.I420
+		case "yuv444p":
+			parsedFormat = pixconv.I444
+		case "nv12":
+			parsedFormat = pixconv.NV12
+		}
 	}
 
 	var filters []string
 
 	inputPixFmt := "rgb24"
+	if alpha {
+		inputPixFmt = "rgba"
+	}
+
 	if parsedFormat != pixconv.ARGB {
 		inputPixFmt = outputFormat
 	} else {
 		filters = append(filters, "vflip")
 	}
 
+	if alpha { // frames are drawn with premultiplied alpha, encoders expect straight one
+		filters = append(filters, "unpremultiply=inplace=1")
+	}
+
 	videoFilters := strings.TrimSpace(settings.Recording.Filters)
 	if len(videoFilters) > 0 {
 		filters = append(filters, videoFilters)
 	}
 
-	inputName := "-"
-
-	if runtime.GOOS != "windows" {
-		pipe, err := files.NewNamedPipe("")
-		if err != nil {
-			panic(err)
-		}
-
-		inputName = pipe.Name()
-		videoPipe = pipe
-	}
+	videoEncoder = encoder
 
-	options := []string{
+	videoInputOptions = []string{
 		"-y",  //(optional) overwrite output file if it exists
 		"-an", // no audio
 		"-f", "rawvideo",
@@ -142,8 +199,8 @@ func startVideo(fps, _w, _h int) {
 		"-r", strconv.Itoa(fps), //frames per second
 	}
 
-	if inputPixFmt != "rgb24" {
-		options = append(options,
+	if parsedFormat != pixconv.ARGB {
+		videoInputOptions = append(videoInputOptions,
 			"-color_range", "1",
 			"-colorspace", "1",
 			"-color_trc", "1",
@@ -151,42 +208,133 @@ func startVideo(fps, _w, _h int) {
 		)
 	}
 
-	options = append(options,
-		"-i", inputName, //The input comes from a videoPipe
-	)
+	videoOutputOptions = nil
 
 	if len(filters) > 0 {
-		options = append(options, "-vf", strings.Join(filters, ","))
+		videoOutputOptions = append(videoOutputOptions, "-vf", strings.Join(filters, ","))
 	}
 
-	options = append(options,
-		"-c:v", encoder,
-		"-color_range", "1",
-		"-colorspace", "1",
-		"-color_trc", "1",
-		"-color_primaries", "1",
-		"-movflags", "+write_colr",
-	)
+	videoOutputOptions = append(videoOutputOptions, "-c:v", encoder)
+
+	// images are stored in RGB, colorspace tags are meant for YUV video
+	if !settings.Recording.IsImageSequence() {
+		videoOutputOptions = append(videoOutputOptions,
+			"-color_range", "1",
+			"-colorspace", "1",
+			"-color_trc", "1",
+			"-color_primaries", "1",
+			"-movflags", "+write_colr",
+		)
+	}
 
 	if parsedFormat == pixconv.ARGB {
-		options = append(options, "-pix_fmt", outputFormat)
+		videoOutputOptions = append(videoOutputOptions, "-pix_fmt", outputFormat)
 	}
 
 	encOptions, err := settings.Recording.GetEncoderOptions().GenerateFFmpegArgs()
 	if err != nil {
 		panic(fmt.Sprintf("encoder \"%s\": %s", encoder, err))
 	} else if encOptions != nil {
-		options = append(options, encOptions...)
+		videoOutputOptions = append(videoOutputOptions, encOptions...)
+	}
+
+	frameNumber = -1
+	outputFrames = 0
+	currentLayer = -1
+
+	names := getStreamNames()
+
+	streams = make([]*videoStream, len(names))
+
+	goroutines.CallMain(func() {
+		for i, name := range names {
+			streams[i] = newVideoStream(name)
+		}
+	})
+
+	limiter = frame.NewLimiter(settings.Recording.EncodingFPSCap)
+}
+
+// newVideoStream creates buffers and effects of a stream, has to be called from main thread
+func newVideoStream(name string) *videoStream {
+	stream := &videoStream{
+		name:    name,
+		pboPool: make(chan *PBO, MaxVideoBuffers),
+	}
+
+	if parsedFormat != pixconv.ARGB {
+		stream.rgbToYuvConverter = effects.NewRGBYUV(w, h, parsedFormat != pixconv.I444 && parsedFormat != pixconv.I422)
+	}
+
+	for i := 0; i < MaxVideoBuffers; i++ {
+		stream.pboPool <- createPBO(parsedFormat)
+	}
+
+	if settings.Recording.MotionBlur.Enabled {
+		bFrames := settings.Recording.MotionBlur.BlendFrames
+
+		if alpha {
+			stream.blend = effects.NewBlendFormat(w, h, bFrames, calculateWeights(bFrames), texture.RGBA)
+		} else {
+			stream.blend = effects.NewBlend(w, h, bFrames, calculateWeights(bFrames))
+		}
+	}
+
+	if name != "" {
+		stream.layerFBO = buffer.NewFrame(w, h, false, false)
+	}
+
+	return stream
+}
+
+// startVideo starts ffmpeg processes encoding the current part of the recording
+func startVideo() {
+	for _, stream := range streams {
+		stream.start()
 	}
+}
 
-	options = append(options, filepath.Join(settings.Recording.GetOutputDir(), output+"_temp", "video."+settings.Recording.Container))
+func (stream *videoStream) start() {
+	inputName := "-"
+
+	if runtime.GOOS != "windows" {
+		pipe, err := files.NewNamedPipe("")
+		if err != nil {
+			panic(err)
+		}
+
+		inputName = pipe.Name()
+		stream.pipe = pipe
+	}
+
+	videoPath := getVideoPath(stream.name)
+
+	options := append(slices.Clone(videoInputOptions),
+		"-i", inputName, //The input comes from a videoPipe
+	)
+
+	options = append(options, videoOutputOptions...)
+	options = append(options, videoPath)
+
+	if settings.Recording.IsImageSequence() {
+		// frames of a restarted part may be left from the interrupted run
+		dir := filepath.Dir(videoPath)
+
+		_ = os.RemoveAll(dir)
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			panic(err)
+		}
+	}
 
 	log.Println("Running ffmpeg with options:", options)
 
-	cmdVideo = exec.Command(ffmpegExec, options...)
+	var err error
+
+	stream.cmd = exec.Command(ffmpegExec, options...)
 
 	if runtime.GOOS == "windows" {
-		videoPipe, err = cmdVideo.StdinPipe()
+		stream.pipe, err = stream.cmd.StdinPipe()
 		if err != nil {
 			panic(err)
 		}
@@ -197,6 +345,8 @@ func startVideo(fps, _w, _h int) {
 		panic(err)
 	}
 
+	stream.logPipe = oFile
+
 	outList := []io.Writer{oFile}
 	errList := []io.Writer{oFile}
 
@@ -205,37 +355,21 @@ func startVideo(fps, _w, _h int) {
 		errList = append(errList, os.Stderr)
 	}
 
-	cmdVideo.Stdout = io.MultiWriter(outList...)
-	cmdVideo.Stderr = io.MultiWriter(errList...)
+	stream.cmd.Stdout = io.MultiWriter(outList...)
+	stream.cmd.Stderr = io.MultiWriter(errList...)
 
-	err = cmdVideo.Start()
+	err = stream.cmd.Start()
 	if err != nil {
 		panic(fmt.Sprintf("ffmpeg's video process failed to start! Please check if video parameters are entered correctly or video codec is supported by provided container. Error: %s", err))
 	}
 
-	freePBOPool = make(chan *PBO, MaxVideoBuffers)
-
-	goroutines.CallMain(func() {
-		if parsedFormat != pixconv.ARGB {
-			rgbToYuvConverter = effects.NewRGBYUV(w, h, parsedFormat != pixconv.I444 && parsedFormat != pixconv.I422)
-		}
-
-		for i := 0; i < MaxVideoBuffers; i++ {
-			freePBOPool <- createPBO(parsedFormat)
-		}
-
-		if settings.Recording.MotionBlur.Enabled {
-			bFrames := settings.Recording.MotionBlur.BlendFrames
-			blend = effects.NewBlend(w, h, bFrames, calculateWeights(bFrames))
-		}
-	})
+	stream.writeQueue = make(chan *PBO, MaxVideoBuffers)
+	stream.running = true
 
-	videoWriteQueue = make(chan *PBO, MaxVideoBuffers)
+	stream.err = ""
 
-	limiter = frame.NewLimiter(settings.Recording.EncodingFPSCap)
-
-	videoErrorWait = &sync.WaitGroup{}
-	videoErrorWait.Add(1)
+	stream.errWait = &sync.WaitGroup{}
+	stream.errWait.Add(1)
 
 	goroutines.Run(func() {
 		sc := bufio.NewScanner(rFile)
@@ -260,98 +394,243 @@ func startVideo(fps, _w, _h int) {
 					strings.Contains(lineLower, "no capable devices found") ||
 					strings.Contains(lineLower, "does not support") {
 
-					videoError = encoder + ": " + cutLine
+					stream.err = videoEncoder + ": " + cutLine
 
 					oFile.Close()
 				}
 			}
 		}
 
-		videoErrorWait.Done()
+		stream.errWait.Done()
 	})
 
-	endSyncVideo = &sync.WaitGroup{}
-	endSyncVideo.Add(1)
+	stream.endSync = &sync.WaitGroup{}
+	stream.endSync.Add(1)
 
 	goroutines.RunOS(func() {
-		for pbo := range videoWriteQueue {
-			if _, err2 := videoPipe.Write(pbo.data); err2 != nil {
+		for pbo := range stream.writeQueue {
+			if _, err2 := stream.pipe.Write(pbo.data); err2 != nil {
+				if aborted.Load() { // the pipe was closed by Abort
+					stream.pboPool <- pbo
+					continue
+				}
+
 				errorMsg := err2.Error()
 
-				videoErrorWait.Wait()
+				stream.errWait.Wait()
 
-				if videoError != "" {
-					errorMsg = videoError
+				if stream.err != "" {
+					errorMsg = stream.err
 				}
 
 				panic(fmt.Sprintf("ffmpeg's video process finished abruptly! Please check if you have enough storage or video parameters are entered correctly. Error: %s", errorMsg))
 			}
 
-			freePBOPool <- pbo
+			stream.pboPool <- pbo
 		}
 
-		endSyncVideo.Done()
+		stream.endSync.Done()
 	})
 }
 
 func stopVideo() {
+	for _, stream := range streams {
+		stream.stop()
+	}
+}
+
+func (stream *videoStream) stop() {
 	log.Println("Waiting for video to finish writing...")
 
-	checkData(true, true)
+	stream.checkData(true, true)
 
-	close(videoWriteQueue)
+	stream.running = false
 
-	endSyncVideo.Wait()
+	close(stream.writeQueue)
+
+	stream.endSync.Wait()
 
 	log.Println("Finished! Stopping video pipe...")
 
-	_ = videoPipe.Close()
+	_ = stream.pipe.Close()
 
 	log.Println("Video pipe closed. Waiting for video ffmpeg process to finish...")
 
-	_ = cmdVideo.Wait()
+	_ = stream.cmd.Wait()
+
+	_ = stream.logPipe.Close()
 
 	log.Println("Video process finished.")
 }
 
+// kill stops ffmpeg process without waiting for queued frames, frames of the current part are lost
+func (stream *videoStream) kill() {
+	if !stream.running {
+		return
+	}
+
+	stream.running = false
+
+	_ = stream.cmd.Process.Kill()
+
+	// unblocks the writer, named pipe is also opened for reading by danser, so writes don't fail when ffmpeg is gone
+	_ = stream.pipe.Close()
+
+	close(stream.writeQueue)
+
+	stream.endSync.Wait()
+
+	_ = stream.cmd.Wait()
+
+	_ = stream.logPipe.Close()
+}
+
+// dispose frees buffers of the stream, it can't be used afterwards
+func (stream *videoStream) dispose() {
+	// frames still waiting for the GPU have their fences alive
+	queued := stream.pboQueue
+
+	var pooled []*PBO
+
+	for len(stream.pboPool) > 0 {
+		pooled = append(pooled, <-stream.pboPool)
+	}
+
+	stream.pboQueue = nil
+
+	goroutines.CallNonBlockMain(func() {
+		for _, pbo := range queued {
+			gl.DeleteSync(pbo.sync)
+			pbo.dispose()
+		}
+
+		for _, pbo := range pooled {
+			pbo.dispose()
+		}
+	})
+
+	if stream.layerFBO != nil {
+		stream.layerFBO.Dispose()
+	}
+}
+
+// disposeVideo frees buffers of all streams after the recording is finished or aborted
+func disposeVideo() {
+	for _, stream := range streams {
+		stream.dispose()
+	}
+
+	streams = nil
+}
+
 func PreFrame() {
-	if settings.Recording.MotionBlur.Enabled {
-		blend.Begin()
-	} else if rgbToYuvConverter != nil {
-		rgbToYuvConverter.Begin()
+	if settings.Recording.IsLayered() {
+		for _, stream := range streams {
+			stream.layerFBO.ClearColor(0, 0, 0, 0)
+		}
+
+		return
+	}
+
+	streams[0].preFrame()
+}
+
+func (stream *videoStream) preFrame() {
+	if stream.blend != nil {
+		stream.blend.Begin()
+	} else if stream.rgbToYuvConverter != nil {
+		stream.rgbToYuvConverter.Begin()
+	}
+}
+
+// SwitchLayer makes following draw calls go to the framebuffer of the layer with given index, it's used only when layers are
+// recorded separately. The last layer stays bound until the frame is made.
+func SwitchLayer(index int) {
+	if currentLayer == index {
+		return
 	}
+
+	if currentLayer >= 0 {
+		streams[currentLayer].layerFBO.Unbind()
+	}
+
+	currentLayer = index
+
+	streams[currentLayer].layerFBO.Bind()
+}
+
+// finishLayers unbinds the last drawn layer
+func finishLayers() {
+	if currentLayer >= 0 {
+		streams[currentLayer].layerFBO.Unbind()
+	}
+
+	currentLayer = -1
+}
+
+// copyLayer passes the drawn layer to the stream, in the same way as the whole frame is drawn when there's only one stream
+func (stream *videoStream) copyLayer() {
+	stream.preFrame()
+
+	var target int32
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &target)
+
+	gl.BlitNamedFramebuffer(stream.layerFBO.GetID(), uint32(target), 0, 0, int32(w), int32(h), 0, 0, int32(w), int32(h), gl.COLOR_BUFFER_BIT, gl.NEAREST)
 }
 
 var frameNumber = int64(-1)
 
+// outputFrames counts frames sent to ffmpeg
+var outputFrames = int64(0)
+
 func MakeFrame() {
 	frameNumber++
 
-	if settings.Recording.MotionBlur.Enabled {
-		blend.End()
+	finishLayers()
+
+	for _, stream := range streams {
+		if stream.layerFBO != nil {
+			stream.copyLayer()
+		}
+
+		stream.makeFrame()
+	}
+
+	if settings.Recording.MotionBlur.Enabled && frameNumber%int64(settings.Recording.MotionBlur.OversampleMultiplier) != 0 {
+		return
+	}
+
+	outputFrames++
+
+	limiter.Sync()
+}
+
+func (stream *videoStream) makeFrame() {
+	if stream.blend != nil {
+		stream.blend.End()
 
 		if frameNumber%int64(settings.Recording.MotionBlur.OversampleMultiplier) != 0 {
 			return
 		}
 
-		if rgbToYuvConverter != nil {
-			rgbToYuvConverter.Begin()
+		if stream.rgbToYuvConverter != nil {
+			stream.rgbToYuvConverter.Begin()
 		}
 
-		blend.Blend()
+		stream.blend.Blend()
 	}
 
 	var yuvFull, yuvHalf []texture.Texture
 
-	if rgbToYuvConverter != nil {
-		rgbToYuvConverter.End()
+	if stream.rgbToYuvConverter != nil {
+		stream.rgbToYuvConverter.End()
 
-		yuvFull, yuvHalf = rgbToYuvConverter.Draw()
+		yuvFull, yuvHalf = stream.rgbToYuvConverter.Draw()
 	}
 
-	checkData(len(freePBOPool) == 0, false) // Force wait for at least one frame to be retrieved if pbo pool is empty
+	stream.checkData(len(stream.pboPool) == 0, false) // Force wait for at least one frame to be retrieved if pbo pool is empty
 
-	pbo := <-freePBOPool // Wait for free PBO
+	pbo := <-stream.pboPool // Wait for free PBO
 
 	//gl.MemoryBarrier(gl.PIXEL_BUFFER_BARRIER_BIT)
 
@@ -372,6 +651,8 @@ func MakeFrame() {
 		gl.GetTextureSubImage(yuvFull[0].GetID(), 0, 0, 0, 0, int32(w), int32(h), 1, gl.RED, gl.UNSIGNED_BYTE, int32(w*h), gl.Ptr(nil))
 		gl.GetTextureSubImage(yuvFull[1].GetID(), 0, 0, 0, 0, int32(w), int32(h), 1, gl.RED, gl.UNSIGNED_BYTE, int32(w*h), gl.PtrOffset(w*h))
 		gl.GetTextureSubImage(yuvFull[2].GetID(), 0, 0, 0, 0, int32(w), int32(h), 1, gl.RED, gl.UNSIGNED_BYTE, int32(w*h), gl.PtrOffset(w*h*2))
+	} else if alpha {
+		gl.ReadPixels(0, 0, int32(w), int32(h), uint32(gl.RGBA), gl.UNSIGNED_BYTE, gl.Ptr(nil))
 	} else {
 		gl.ReadPixels(0, 0, int32(w), int32(h), uint32(gl.RGB), gl.UNSIGNED_BYTE, gl.Ptr(nil))
 	}
@@ -380,16 +661,34 @@ func MakeFrame() {
 
 	gl.Flush()
 
-	frameReadQueue = append(frameReadQueue, pbo)
+	stream.pboQueue = append(stream.pboQueue, pbo)
+
+	stream.checkData(false, false)
+}
 
-	checkData(false, false)
+// DiscardFrame finishes the frame started by PreFrame without sending it to ffmpeg.
+// Motion blur still accumulates it, so the following frames are blended the same way as in a continuous recording.
+func DiscardFrame() {
+	frameNumber++
 
-	limiter.Sync()
+	finishLayers()
+
+	for _, stream := range streams {
+		if stream.layerFBO != nil {
+			stream.copyLayer()
+		}
+
+		if stream.blend != nil {
+			stream.blend.End()
+		} else if stream.rgbToYuvConverter != nil {
+			stream.rgbToYuvConverter.End()
+		}
+	}
 }
 
-func checkData(waitForFirst, waitForAll bool) { // I tried to do that on another thread, but it needs another opengl context and creates other funky problems
-	for i := 0; len(frameReadQueue) > 0; i++ {
-		pbo := frameReadQueue[0]
+func (stream *videoStream) checkData(waitForFirst, waitForAll bool) { // I tried to do that on another thread, but it needs another opengl context and creates other funky problems
+	for i := 0; len(stream.pboQueue) > 0; i++ {
+		pbo := stream.pboQueue[0]
 
 		status := int32(gl.SIGNALED)
 
@@ -411,8 +710,8 @@ func checkData(waitForFirst, waitForAll bool) { // I tried to do that on another
 
 		gl.DeleteSync(pbo.sync)
 
-		frameReadQueue = frameReadQueue[1:]
+		stream.pboQueue = stream.pboQueue[1:]
 
-		videoWriteQueue <- pbo
+		stream.writeQueue <- pbo
 	}
 }
@@ -59,6 +59,17 @@ func initCursor() {
 	osuRect = Camera.GetWorldRect()
 }
 
+// headlessRenderer is used when there's no GL context, cursor only has to track its position for the ruleset
+type headlessRenderer struct{}
+
+func (headlessRenderer) SetPosition(_ vector.Vector2f) {}
+
+func (headlessRenderer) Update(_ float64) {}
+
+func (headlessRenderer) UpdateRenderer() {}
+
+func (headlessRenderer) DrawM(_, _ float64, _ *batch.QuadBatch, _ color2.Color, _ color2.Color) {}
+
 type Cursor struct {
 	scale *animation.Glider
 
@@ -103,19 +114,24 @@ type Cursor struct {
 
 	rippleContainer *sprite.Manager
 	time            float64
+
+	// alpha is controlled by mods hiding the cursor, e.g. No Scope
+	alpha float64
 }
 
 func NewCursor() *Cursor {
-	if cursorFbo == nil {
+	if cursorFbo == nil && !settings.HEADLESS {
 		initCursor()
 	}
 
-	cursor := &Cursor{Position: vector.NewVec2f(256, -500)}
+	cursor := &Cursor{Position: vector.NewVec2f(256, -500), alpha: 1}
 	cursor.scale = animation.NewGlider(1.0)
 
 	cursor.lastSetting = settings.Skin.Cursor.UseSkinCursor
 
-	if cursor.lastSetting {
+	if settings.HEADLESS {
+		cursor.renderer = headlessRenderer{}
+	} else if cursor.lastSetting {
 		cursor.renderer = newOsuRenderer()
 	} else {
 		cursor.renderer = newDanserRenderer()
@@ -143,7 +159,7 @@ func (cursor *Cursor) SetPos(pt vector.Vector2f) {
 		tmp.X = 512 - tmp.X
 	}
 
-	if settings.Cursor.BounceOnEdges && settings.DIVIDES <= 2 {
+	if settings.Cursor.BounceOnEdges && settings.DIVIDES <= 2 && !settings.HEADLESS {
 		tmp.X -= osuRect.MinX
 		tmp.Y -= osuRect.MinY
 		tmp.X = math32.Mod(tmp.X, 2*(osuRect.MaxX-osuRect.MinX))
@@ -292,7 +308,7 @@ func (cursor *Cursor) smokeBrighten() {
 func (cursor *Cursor) UpdateRenderer() {
 	newSettings := settings.Skin.Cursor.UseSkinCursor
 
-	if newSettings != cursor.lastSetting {
+	if newSettings != cursor.lastSetting && !settings.HEADLESS {
 		cursor.lastSetting = newSettings
 		if cursor.lastSetting {
 			cursor.renderer = newOsuRenderer()
@@ -333,6 +349,11 @@ func (cursor *Cursor) Draw(scale float64, batch *batch.QuadBatch, color color2.C
 	cursor.DrawM(scale, batch, color, color)
 }
 
+// SetAlpha sets the visibility of the cursor itself, smoke and ripples are not affected
+func (cursor *Cursor) SetAlpha(alpha float64) {
+	cursor.alpha = alpha
+}
+
 func (cursor *Cursor) DrawM(scale float64, batch *batch.QuadBatch, color color2.Color, colorGlow color2.Color) {
 	if cursor.rippleContainer.GetNumProcessed() > 0 || cursor.smokeContainer.GetNumProcessed() > 0 {
 		batch.Begin()
@@ -358,6 +379,9 @@ func (cursor *Cursor) DrawM(scale float64, batch *batch.QuadBatch, color color2.
 		cursorFbo.ClearColor(0.0, 0.0, 0.0, 0.0)
 	}
 
+	color.A *= float32(cursor.alpha)
+	colorGlow.A *= float32(cursor.alpha)
+
 	cursor.renderer.DrawM(scale, cursor.scale.GetValue(), batch, color, colorGlow)
 
 	if useAdditive {
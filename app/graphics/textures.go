@@ -28,6 +28,10 @@ var Hit50 *texture.TextureRegion
 var Hit100 *texture.TextureRegion
 
 func LoadTextures() {
+	if Atlas != nil {
+		return
+	}
+
 	Atlas = texture.NewTextureAtlas(2048, 4)
 	Atlas.Bind(16)
 
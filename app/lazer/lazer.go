@@ -0,0 +1,307 @@
+// Package lazer provides experimental access to osu!lazer's library. Lazer stores all files in a hashed store (files/<h[0]>/<h[0:2]>/<hash>)
+// indexed by client.realm. Realm's binary format is not readable here, so the database has to be exported to client.json
+// (same directory as client.realm) with tools/lazer-export, which keeps lazer's Realm object and property names:
+//
+//	{
+//		"BeatmapSets": [{"ID", "OnlineID", "DateAdded", "DeletePending", "Files": [{"Filename", "File": {"Hash"}}], "Beatmaps": [{"ID", "Hash", "MD5Hash", "Hidden", "Status", "Ruleset": {"ShortName"}}]}],
+//		"Skins": [{"ID", "Name", "Creator", "DeletePending", "Files": [...]}],
+//		"Scores": [{"ID", "Date", "DeletePending", "User": {"Username"}, "BeatmapInfo": {"MD5Hash"}, "Files": [...]}]
+//	}
+package lazer
+
+import (
+	"encoding/json"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/files"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DirPrefix marks beatmap directories and skin names backed by lazer's file store. Colon can't appear in stable's directory names on Windows.
+const DirPrefix = "lazer:"
+
+const snapshotName = "client.json"
+
+type File struct {
+	Hash string `json:"Hash"`
+}
+
+type NamedFile struct {
+	Filename string `json:"Filename"`
+	File     File   `json:"File"`
+}
+
+type Ruleset struct {
+	ShortName string `json:"ShortName"`
+}
+
+type Beatmap struct {
+	ID      string  `json:"ID"`
+	Hash    string  `json:"Hash"`
+	MD5Hash string  `json:"MD5Hash"`
+	Hidden  bool    `json:"Hidden"`
+	Ruleset Ruleset `json:"Ruleset"`
+
+	// Status is lazer's BeatmapOnlineStatus, nil if it wasn't exported
+	Status *int `json:"Status"`
+}
+
+type BeatmapSet struct {
+	ID            string       `json:"ID"`
+	OnlineID      int64        `json:"OnlineID"`
+	DateAdded     time.Time    `json:"DateAdded"`
+	DeletePending bool         `json:"DeletePending"`
+	Files         []*NamedFile `json:"Files"`
+	Beatmaps      []*Beatmap   `json:"Beatmaps"`
+}
+
+// GetBeatmapFile returns .osu file name of given difficulty
+func (set *BeatmapSet) GetBeatmapFile(bMap *Beatmap) string {
+	for _, f := range set.Files {
+		if strings.EqualFold(f.File.Hash, bMap.Hash) {
+			return f.Filename
+		}
+	}
+
+	return ""
+}
+
+type Skin struct {
+	ID            string       `json:"ID"`
+	Name          string       `json:"Name"`
+	Creator       string       `json:"Creator"`
+	DeletePending bool         `json:"DeletePending"`
+	Files         []*NamedFile `json:"Files"`
+}
+
+type User struct {
+	Username string `json:"Username"`
+}
+
+type ScoreBeatmap struct {
+	MD5Hash string `json:"MD5Hash"`
+}
+
+type Score struct {
+	ID            string       `json:"ID"`
+	Date          time.Time    `json:"Date"`
+	DeletePending bool         `json:"DeletePending"`
+	User          User         `json:"User"`
+	BeatmapInfo   ScoreBeatmap `json:"BeatmapInfo"`
+	Files         []*NamedFile `json:"Files"`
+}
+
+type Replay struct {
+	Path       string
+	Player     string
+	BeatmapMD5 string
+	Date       time.Time
+}
+
+type Library struct {
+	BeatmapSets []*BeatmapSet `json:"BeatmapSets"`
+	Skins       []*Skin       `json:"Skins"`
+	Scores      []*Score      `json:"Scores"`
+
+	dir string
+
+	sets     map[string]*BeatmapSet
+	setFiles map[string]*files.FileMap
+	skins    map[string]*Skin
+}
+
+var library *Library
+var loaded bool
+var mutex sync.Mutex
+
+func init() {
+	settings.LazerSkinOptions = GetSkinNames
+}
+
+// GetLibrary returns lazer's library, it's loaded on first call. Returns nil if lazer support is disabled or snapshot couldn't be read.
+func GetLibrary() *Library {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if !loaded {
+		library = load(settings.General.GetLazerDir())
+		loaded = true
+	}
+
+	return library
+}
+
+// Reload forces the snapshot to be read again on next GetLibrary call
+func Reload() {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	library = nil
+	loaded = false
+}
+
+func load(dir string) *Library {
+	if dir == "" {
+		return nil
+	}
+
+	snapshotPath := filepath.Join(dir, snapshotName)
+
+	data, err := os.ReadFile(snapshotPath)
+	if err != nil {
+		if _, err2 := os.Stat(filepath.Join(dir, "client.realm")); err2 == nil {
+			log.Println("LazerLibrary: client.realm can't be read directly, please export it with tools/lazer-export to:", snapshotPath)
+		} else {
+			log.Println("LazerLibrary: Failed to read lazer's library:", err)
+		}
+
+		return nil
+	}
+
+	lib := &Library{
+		dir:      dir,
+		sets:     make(map[string]*BeatmapSet),
+		setFiles: make(map[string]*files.FileMap),
+		skins:    make(map[string]*Skin),
+	}
+
+	if err = json.Unmarshal(data, lib); err != nil {
+		log.Println("LazerLibrary: Failed to parse", snapshotPath+":", err)
+		return nil
+	}
+
+	lib.BeatmapSets = slices.DeleteFunc(lib.BeatmapSets, func(set *BeatmapSet) bool { return set.DeletePending })
+	lib.Skins = slices.DeleteFunc(lib.Skins, func(skin *Skin) bool { return skin.DeletePending })
+	lib.Scores = slices.DeleteFunc(lib.Scores, func(score *Score) bool { return score.DeletePending })
+
+	for _, set := range lib.BeatmapSets {
+		lib.sets[set.ID] = set
+	}
+
+	for _, skin := range lib.Skins {
+		lib.skins[skin.Name] = skin
+	}
+
+	log.Println("LazerLibrary: Loaded", len(lib.BeatmapSets), "beatmap sets,", len(lib.Skins), "skins and", len(lib.Scores), "scores")
+
+	return lib
+}
+
+// GetFilePath resolves a hash to the location in lazer's file store
+func (lib *Library) GetFilePath(hash string) string {
+	hash = strings.ToLower(hash)
+
+	if len(hash) < 2 {
+		return ""
+	}
+
+	return filepath.Join(lib.dir, "files", hash[:1], hash[:2], hash)
+}
+
+func (lib *Library) newFileMap(namedFiles []*NamedFile) *files.FileMap {
+	entries := make(map[string]string, len(namedFiles))
+
+	for _, f := range namedFiles {
+		entries[f.Filename] = lib.GetFilePath(f.File.Hash)
+	}
+
+	return files.NewFileMapFromEntries(entries)
+}
+
+// GetSetDir returns beatmap directory used by danser for given set
+func GetSetDir(set *BeatmapSet) string {
+	return DirPrefix + set.ID
+}
+
+func IsLazerDir(dir string) bool {
+	return strings.HasPrefix(dir, DirPrefix)
+}
+
+// GetSetFileMap returns file map of beatmap set identified by directory returned by GetSetDir
+func GetSetFileMap(dir string) (*files.FileMap, error) {
+	lib := GetLibrary()
+	if lib == nil {
+		return nil, os.ErrNotExist
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if fileMap, ok := lib.setFiles[dir]; ok {
+		return fileMap, nil
+	}
+
+	set, ok := lib.sets[strings.TrimPrefix(dir, DirPrefix)]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	fileMap := lib.newFileMap(set.Files)
+
+	lib.setFiles[dir] = fileMap
+
+	return fileMap, nil
+}
+
+// GetSkinFileMap returns file map of a skin, name has to be prefixed with DirPrefix
+func GetSkinFileMap(name string) (*files.FileMap, error) {
+	lib := GetLibrary()
+	if lib == nil {
+		return nil, os.ErrNotExist
+	}
+
+	skin, ok := lib.skins[strings.TrimPrefix(name, DirPrefix)]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	return lib.newFileMap(skin.Files), nil
+}
+
+// GetSkinNames returns names of lazer's skins prefixed with DirPrefix
+func GetSkinNames() (names []string) {
+	lib := GetLibrary()
+	if lib == nil {
+		return
+	}
+
+	for _, skin := range lib.Skins {
+		names = append(names, DirPrefix+skin.Name)
+	}
+
+	slices.SortFunc(names, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+
+	return
+}
+
+// GetReplays returns all scores that have a replay file
+func GetReplays() (replays []*Replay) {
+	lib := GetLibrary()
+	if lib == nil {
+		return
+	}
+
+	for _, score := range lib.Scores {
+		for _, f := range score.Files {
+			if strings.HasSuffix(strings.ToLower(f.Filename), ".osr") {
+				replays = append(replays, &Replay{
+					Path:       lib.GetFilePath(f.File.Hash),
+					Player:     score.User.Username,
+					BeatmapMD5: score.BeatmapInfo.MD5Hash,
+					Date:       score.Date,
+				})
+
+				break
+			}
+		}
+	}
+
+	return
+}
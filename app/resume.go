@@ -0,0 +1,68 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/wieku/danser-go/app/dance"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/states"
+	"github.com/wieku/danser-go/build"
+	"hash"
+	"io"
+	"os"
+)
+
+// getJobFingerprint returns a hash of everything that changes the recording of the player, an interrupted recording
+// is resumed only if it matches. Knockout players are hashed after they're selected, so classic knockout picking up
+// a different set of replays from the replays directory doesn't resume into mismatched parts.
+func getJobFingerprint(p *states.Player) string {
+	h := sha256.New()
+
+	beatMap := p.GetBeatMap()
+
+	mods, err := json.Marshal(beatMap.Diff.ExportMods2())
+	if err != nil {
+		panic(err)
+	}
+
+	config, err := json.Marshal(settings.GetFormat())
+	if err != nil {
+		panic(err)
+	}
+
+	_, _ = fmt.Fprintln(h, build.VERSION, beatMap.MD5, string(mods), string(config))
+
+	_, _ = fmt.Fprintln(h, settings.PLAY, settings.KNOCKOUT, settings.SKIP, settings.START, settings.END, settings.SPEED,
+		settings.PITCH, settings.DIVIDES, settings.TAG, settings.LOCALOFFSET)
+
+	replays := settings.KNOCKOUTREPLAYS
+	if settings.REPLAY != "" {
+		replays = []string{settings.REPLAY}
+	}
+
+	for _, replay := range replays {
+		hashFile(h, replay)
+	}
+
+	if controller, ok := p.GetController().(interface{ GetReplays() []dance.RpData }); ok {
+		for _, r := range controller.GetReplays() {
+			_, _ = fmt.Fprintln(h, r.RawName, r.ModsV.String(), r.ReplayMD5)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func hashFile(h hash.Hash, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		_, _ = fmt.Fprintln(h, path)
+		return
+	}
+
+	defer file.Close()
+
+	_, _ = io.Copy(h, file)
+}
@@ -43,6 +43,9 @@ type IHealthProcessor interface {
 	GetHealth() float64
 
 	GetDrainRate() float64
+
+	saveState() any
+	restoreState(state any)
 }
 
 type FailListener func()
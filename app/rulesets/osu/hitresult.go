@@ -3,6 +3,7 @@ package osu
 import (
 	"github.com/wieku/danser-go/app/beatmap/difficulty"
 	"github.com/wieku/danser-go/framework/math/vector"
+	"strings"
 )
 
 type HitResult int64
@@ -42,6 +43,46 @@ const (
 	RawHits     = SliderHits | SpinnerHits
 )
 
+var hitResultNames = []struct {
+	result HitResult
+	name   string
+}{
+	{SliderMiss, "SliderMiss"},
+	{Miss, "Miss"},
+	{Hit50, "Hit50"},
+	{Hit100, "Hit100"},
+	{Hit300, "Hit300"},
+	{SliderStart, "SliderStart"},
+	{SliderPoint, "SliderPoint"},
+	{SliderRepeat, "SliderRepeat"},
+	{LegacySliderEnd, "LegacySliderEnd"},
+	{SliderEnd, "SliderEnd"},
+	{SliderFinish, "SliderFinish"},
+	{SpinnerSpin, "SpinnerSpin"},
+	{SpinnerPoints, "SpinnerPoints"},
+	{SpinnerBonus, "SpinnerBonus"},
+	{MuAddition, "MuAddition"},
+	{KatuAddition, "KatuAddition"},
+	{GekiAddition, "GekiAddition"},
+	{PositionalMiss, "PositionalMiss"},
+}
+
+func (r HitResult) String() string {
+	if r == Ignore {
+		return "Ignore"
+	}
+
+	var names []string
+
+	for _, n := range hitResultNames {
+		if r&n.result > 0 {
+			names = append(names, n.name)
+		}
+	}
+
+	return strings.Join(names, "|")
+}
+
 func (r HitResult) IsBonus() bool {
 	v := r & (^Additions)
 
@@ -167,3 +208,16 @@ func createJudgementResultF(result HitResult, maxResult HitResult, comboResult C
 
 	return jResult
 }
+
+func (c ComboResult) String() string {
+	switch c {
+	case Reset:
+		return "Reset"
+	case Hold:
+		return "Hold"
+	case Increase:
+		return "Increase"
+	}
+
+	return "Unknown"
+}
@@ -0,0 +1,70 @@
+package osu
+
+import (
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"math"
+)
+
+// period is a time range in which Alternate and Single Tap don't block any presses
+type period struct {
+	start, end float64
+}
+
+// initInputBlocking calculates periods outside gameplay (before the first object and during breaks) for Alternate and Single Tap
+func initInputBlocking(beatMap *beatmap.BeatMap, player *difficultyPlayer) {
+	if !player.diff.CheckModActive(difficulty.Alternate, difficulty.SingleTap) || len(beatMap.HitObjects) == 0 {
+		return
+	}
+
+	validJudgementTime := func(startTime float64) float64 {
+		return startTime - player.diff.Hit50U
+	}
+
+	player.nonGameplayPeriods = append(player.nonGameplayPeriods, period{math.Inf(-1), validJudgementTime(beatMap.HitObjects[0].GetStartTime()) - 1})
+
+	for _, pause := range beatMap.Pauses {
+		for _, obj := range beatMap.HitObjects {
+			if obj.GetStartTime() >= pause.EndTime {
+				player.nonGameplayPeriods = append(player.nonGameplayPeriods, period{pause.StartTime, validJudgementTime(obj.GetStartTime()) - 1})
+				break
+			}
+		}
+	}
+}
+
+// blockInvalidPresses discards new presses that are not allowed by Alternate or Single Tap
+func blockInvalidPresses(player *difficultyPlayer, time int64) {
+	if len(player.nonGameplayPeriods) == 0 {
+		return
+	}
+
+	for _, p := range player.nonGameplayPeriods {
+		if float64(time) >= p.start && float64(time) <= p.end {
+			player.lastAcceptedAction = Buttons(0)
+			return
+		}
+	}
+
+	if player.leftCond && !isValidAction(player, Left) {
+		player.leftCond = false
+	}
+
+	if player.rightCond && !isValidAction(player, Right) {
+		player.rightCond = false
+	}
+}
+
+func isValidAction(player *difficultyPlayer, action Buttons) bool {
+	valid := player.lastAcceptedAction != action
+
+	if player.diff.CheckModActive(difficulty.SingleTap) {
+		valid = player.lastAcceptedAction == Buttons(0) || player.lastAcceptedAction == action
+	}
+
+	if valid {
+		player.lastAcceptedAction = action
+	}
+
+	return valid
+}
@@ -0,0 +1,173 @@
+package osu
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/wieku/danser-go/app/graphics"
+	"os"
+	"strconv"
+)
+
+type ExportedJudgement struct {
+	Player      string  `json:"player"`
+	Time        int64   `json:"time"`
+	Object      int64   `json:"object"`
+	ObjectType  string  `json:"object_type"`
+	ObjectTime  int64   `json:"object_time"`
+	HitError    *int64  `json:"hit_error,omitempty"`
+	Result      string  `json:"result"`
+	ComboResult string  `json:"combo_result"`
+	Combo       uint    `json:"combo"`
+	X           float32 `json:"x"`
+	Y           float32 `json:"y"`
+}
+
+type ExportedClick struct {
+	Player     string  `json:"player"`
+	Time       int64   `json:"time"`
+	X          float32 `json:"x"`
+	Y          float32 `json:"y"`
+	LeftMouse  string  `json:"left_mouse"`
+	RightMouse string  `json:"right_mouse"`
+	LeftKey    string  `json:"left_key"`
+	RightKey   string  `json:"right_key"`
+	Smoke      string  `json:"smoke"`
+}
+
+// JudgementExporter collects every judgement and click event processed by OsuRuleSet so they can be saved for external analysis
+type JudgementExporter struct {
+	Judgements []ExportedJudgement `json:"judgements"`
+	Clicks     []ExportedClick     `json:"clicks"`
+}
+
+func NewJudgementExporter() *JudgementExporter {
+	return &JudgementExporter{
+		Judgements: make([]ExportedJudgement, 0),
+		Clicks:     make([]ExportedClick, 0),
+	}
+}
+
+func (exporter *JudgementExporter) addJudgement(cursor *graphics.Cursor, result JudgementResult, score Score) {
+	judgement := ExportedJudgement{
+		Player:      cursor.Name,
+		Time:        result.Time,
+		Object:      result.Number,
+		Result:      result.HitResult.String(),
+		ComboResult: result.ComboResult.String(),
+		Combo:       score.CurrentCombo,
+		X:           result.Position.X,
+		Y:           result.Position.Y,
+	}
+
+	if result.object != nil {
+		judgement.ObjectTime = int64(result.object.GetObject().GetStartTime())
+
+		hasHitError := false
+
+		switch result.object.(type) {
+		case *Circle:
+			judgement.ObjectType = "circle"
+			hasHitError = result.HitResult&BaseHits > 0
+		case *Slider:
+			judgement.ObjectType = "slider"
+			hasHitError = result.HitResult&SliderStart > 0
+		case *Spinner:
+			judgement.ObjectType = "spinner"
+		}
+
+		if hasHitError {
+			hitError := result.Time - judgement.ObjectTime
+			judgement.HitError = &hitError
+		}
+	}
+
+	exporter.Judgements = append(exporter.Judgements, judgement)
+}
+
+func (exporter *JudgementExporter) addClick(cursor *graphics.Cursor, time int64, leftMouse, rightMouse, leftKb, rightKb, smoke ButtonAction) {
+	exporter.Clicks = append(exporter.Clicks, ExportedClick{
+		Player:     cursor.Name,
+		Time:       time,
+		X:          cursor.Position.X,
+		Y:          cursor.Position.Y,
+		LeftMouse:  leftMouse.String(),
+		RightMouse: rightMouse.String(),
+		LeftKey:    leftKb.String(),
+		RightKey:   rightKb.String(),
+		Smoke:      smoke.String(),
+	})
+}
+
+// Save writes collected events to basePath with format specific suffix. JSON format produces a single file, CSV format produces separate files for judgements and clicks.
+func (exporter *JudgementExporter) Save(basePath, format string) ([]string, error) {
+	switch format {
+	case "json":
+		path := basePath + ".judgements.json"
+
+		data, err := json.MarshalIndent(exporter, "", "\t")
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{path}, os.WriteFile(path, data, 0644)
+	case "csv":
+		judgementsPath := basePath + ".judgements.csv"
+
+		err := writeCSV(judgementsPath, []string{"player", "time", "object", "object_type", "object_time", "hit_error", "result", "combo_result", "combo", "x", "y"}, len(exporter.Judgements), func(i int) []string {
+			j := exporter.Judgements[i]
+
+			hitError := ""
+			if j.HitError != nil {
+				hitError = strconv.FormatInt(*j.HitError, 10)
+			}
+
+			return []string{j.Player, strconv.FormatInt(j.Time, 10), strconv.FormatInt(j.Object, 10), j.ObjectType, strconv.FormatInt(j.ObjectTime, 10), hitError, j.Result, j.ComboResult, strconv.FormatUint(uint64(j.Combo), 10), formatFloat(j.X), formatFloat(j.Y)}
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		clicksPath := basePath + ".clicks.csv"
+
+		err = writeCSV(clicksPath, []string{"player", "time", "x", "y", "left_mouse", "right_mouse", "left_key", "right_key", "smoke"}, len(exporter.Clicks), func(i int) []string {
+			c := exporter.Clicks[i]
+
+			return []string{c.Player, strconv.FormatInt(c.Time, 10), formatFloat(c.X), formatFloat(c.Y), c.LeftMouse, c.RightMouse, c.LeftKey, c.RightKey, c.Smoke}
+		})
+
+		return []string{judgementsPath, clicksPath}, err
+	}
+
+	return nil, fmt.Errorf("unknown export format: %s", format)
+}
+
+func writeCSV(path string, header []string, count int, row func(i int) []string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	if err = writer.Write(header); err != nil {
+		return err
+	}
+
+	for i := 0; i < count; i++ {
+		if err = writer.Write(row(i)); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func formatFloat(v float32) string {
+	return strconv.FormatFloat(float64(v), 'f', -1, 32)
+}
@@ -2,32 +2,32 @@ package api
 
 type Attributes struct {
 	// Total Star rating, visible on osu!'s beatmap page
-	Total float64
+	Total float64 `json:"total"`
 
 	// Aim stars, needed for Performance Points (aka PP) calculations
-	Aim          float64
-	AimNoSliders float64
+	Aim          float64 `json:"aim"`
+	AimNoSliders float64 `json:"aim_no_sliders"`
 
 	// Speed stars, needed for Performance Points (aka PP) calculations
-	Speed float64
+	Speed float64 `json:"speed"`
 
-	SpeedNoteCount float64
+	SpeedNoteCount float64 `json:"speed_note_count"`
 
-	AimDifficultStrainCount   float64
-	AimDifficultSliderCount   float64
-	SpeedDifficultStrainCount float64
+	AimDifficultStrainCount   float64 `json:"aim_difficult_strain_count"`
+	AimDifficultSliderCount   float64 `json:"aim_difficult_slider_count"`
+	SpeedDifficultStrainCount float64 `json:"speed_difficult_strain_count"`
 
 	// Flashlight stars, needed for Performance Points (aka PP) calculations
-	Flashlight float64
+	Flashlight float64 `json:"flashlight"`
 
 	// SliderFactor is a ratio of Aim calculated without sliders to Aim with them
-	SliderFactor float64
+	SliderFactor float64 `json:"slider_factor"`
 
-	ObjectCount int
-	Circles     int
-	Sliders     int
-	Spinners    int
-	MaxCombo    int
+	ObjectCount int `json:"object_count"`
+	Circles     int `json:"circles"`
+	Sliders     int `json:"sliders"`
+	Spinners    int `json:"spinners"`
+	MaxCombo    int `json:"max_combo"`
 }
 
 // StrainPeaks contains peaks of Aim, Speed and Flashlight skills, as well as peaks passed through star rating formula
@@ -128,7 +128,7 @@ func (diffCalc *DifficultyCalculator) CalculateSingle(objects []objects.IHitObje
 
 // CalculateStep calculates successive star ratings for every part of a beatmap
 func (diffCalc *DifficultyCalculator) CalculateStep(objects []objects.IHitObject, diff *difficulty.Difficulty) []api.Attributes {
-	modString := (diff.Mods & difficulty.DifficultyAdjustMask).String()
+	modString := diff.Mods.Intersect(difficulty.DifficultyAdjustMask).String()
 	if modString == "" {
 		modString = "NM"
 	}
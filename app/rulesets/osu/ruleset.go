@@ -36,6 +36,21 @@ const (
 	Released
 )
 
+func (action ButtonAction) String() string {
+	switch action {
+	case Resting:
+		return "Resting"
+	case Clicked:
+		return "Clicked"
+	case Pressed:
+		return "Pressed"
+	case Released:
+		return "Released"
+	}
+
+	return "Unknown"
+}
+
 type buttonState struct {
 	Left, Right bool
 }
@@ -55,6 +70,9 @@ type HitObject interface {
 	GetFadeTime() int64
 	GetNumber() int64
 	GetObject() objects.IHitObject
+
+	saveState() any
+	restoreState(state any)
 }
 
 type difficultyPlayer struct {
@@ -81,6 +99,9 @@ type difficultyPlayer struct {
 	lzLegacyNotelock bool
 	lzNoSliderAcc    bool
 	lzLegacySound    bool
+
+	nonGameplayPeriods []period
+	lastAcceptedAction Buttons
 }
 
 type subSet struct {
@@ -111,6 +132,8 @@ type endListener func(time int64, number int64)
 
 type failListener func(cursor *graphics.Cursor)
 
+type finishListener func()
+
 type OsuRuleSet struct {
 	beatMap *beatmap.BeatMap
 	cursors map[*graphics.Cursor]*subSet
@@ -119,12 +142,17 @@ type OsuRuleSet struct {
 
 	oppDiffs map[string][]api.Attributes
 
-	queue         []HitObject
-	processed     []HitObject
-	hitListener   hitListener
-	endListener   endListener
-	failListener  failListener
-	clickListener clickListener
+	queue          []HitObject
+	processed      []HitObject
+	hitListener    hitListener
+	endListener    endListener
+	failListener   failListener
+	finishListener finishListener
+	clickListener  clickListener
+
+	exporter *JudgementExporter
+
+	initialStates map[HitObject]any
 }
 
 func NewOsuRuleset(beatMap *beatmap.BeatMap, cursors []*graphics.Cursor, diffs []*difficulty.Difficulty) *OsuRuleSet {
@@ -147,8 +175,8 @@ func NewOsuRuleset(beatMap *beatmap.BeatMap, cursors []*graphics.Cursor, diffs [
 
 		beatMap.CalculateStackLeniency(diff) // Calculate additional stack indexes for DA/EZ/HR/whatever that changes Preempt
 
-		diff.Mods = diff.Mods | (beatMap.Diff.Mods & difficulty.ScoreV2) // if beatmap has ScoreV2 mod, force it for all players
-		diff.Mods = diff.Mods | (beatMap.Diff.Mods & difficulty.Lazer)   // same for Lazer
+		diff.Mods = diff.Mods.Union(beatMap.Diff.Mods.Intersect(difficulty.NewModifier(difficulty.ScoreV2))) // if beatmap has ScoreV2 mod, force it for all players
+		diff.Mods = diff.Mods.Union(beatMap.Diff.Mods.Intersect(difficulty.NewModifier(difficulty.Lazer)))   // same for Lazer
 
 		if !diff.CheckModActive(difficulty.Lazer) {
 			nonLazerReplays = true
@@ -161,6 +189,8 @@ func NewOsuRuleset(beatMap *beatmap.BeatMap, cursors []*graphics.Cursor, diffs [
 		player := &difficultyPlayer{cursor: cursor, diff: diff, maskedModString: diff.GetModStringMasked()}
 		diffPlayers = append(diffPlayers, player)
 
+		initInputBlocking(beatMap, player)
+
 		lzLegacyHP := false
 
 		if diff.CheckModActive(difficulty.Classic) {
@@ -319,6 +349,7 @@ func (set *OsuRuleSet) Update(time int64) {
 	}
 
 	for _, subSet := range set.cursors {
+		subSet.player.diff.UpdateRate(float64(time))
 		subSet.hp.Update(time)
 	}
 
@@ -326,6 +357,10 @@ func (set *OsuRuleSet) Update(time int64) {
 		set.printEndTable()
 
 		set.ended = true
+
+		if set.finishListener != nil {
+			set.finishListener()
+		}
 	}
 }
 
@@ -374,11 +409,13 @@ func (set *OsuRuleSet) UpdateClickFor(cursor *graphics.Cursor, time int64) {
 	player.alreadyStolen = false
 
 	if player.cursor.IsReplayFrame || player.cursor.IsPlayer {
-		set.processButtonChangesForEvent(player)
+		set.processButtonChangesForEvent(player, time)
 
 		player.leftCond = !player.buttons.Left && player.cursor.LeftButton
 		player.rightCond = !player.buttons.Right && player.cursor.RightButton
 
+		blockInvalidPresses(player, time)
+
 		player.leftCondE = player.leftCond
 		player.rightCondE = player.rightCond
 
@@ -413,15 +450,21 @@ func (set *OsuRuleSet) UpdateClickFor(cursor *graphics.Cursor, time int64) {
 	}
 }
 
-func (set *OsuRuleSet) processButtonChangesForEvent(player *difficultyPlayer) {
+func (set *OsuRuleSet) processButtonChangesForEvent(player *difficultyPlayer, time int64) {
 	mLAction := set.processButtonActionType(&player.lastMouseState.Left, player.cursor.LeftMouse)
 	mRAction := set.processButtonActionType(&player.lastMouseState.Right, player.cursor.RightMouse)
 	kLAction := set.processButtonActionType(&player.lastKbState.Left, player.cursor.LeftKey)
 	kRAction := set.processButtonActionType(&player.lastKbState.Right, player.cursor.RightKey)
 	smokeAction := set.processButtonActionType(&player.lastSmokeState, player.cursor.SmokeKey)
 
-	if (mLAction|mRAction|kLAction|kRAction|smokeAction)&(Clicked|Released) > 0 && set.clickListener != nil {
-		set.clickListener(player.cursor, mLAction, mRAction, kLAction, kRAction, smokeAction)
+	if (mLAction|mRAction|kLAction|kRAction|smokeAction)&(Clicked|Released) > 0 {
+		if set.clickListener != nil {
+			set.clickListener(player.cursor, mLAction, mRAction, kLAction, kRAction, smokeAction)
+		}
+
+		if set.exporter != nil {
+			set.exporter.addClick(player.cursor, time, mLAction, mRAction, kLAction, kRAction, smokeAction)
+		}
 	}
 }
 
@@ -494,7 +537,12 @@ func (set *OsuRuleSet) SendResult(cursor *graphics.Cursor, judgementResult Judge
 		return
 	}
 
-	if (subSet.player.diff.Mods.Active(difficulty.SuddenDeath|difficulty.Perfect) && judgementResult.ComboResult == Reset) ||
+	// Adaptive Speed reacts only to judgements of circles and slider heads that have hit windows
+	if judgementResult.MaxResult == Hit300 && !judgementResult.fromSliderFinish && judgementResult.object != nil {
+		subSet.player.diff.AddRateResult(judgementResult.object.GetObject().GetStartTime(), float64(judgementResult.Time), judgementResult.HitResult&BaseHits > 0)
+	}
+
+	if (subSet.player.diff.Mods.Active(difficulty.SuddenDeath, difficulty.Perfect) && judgementResult.ComboResult == Reset) ||
 		(subSet.player.diff.Mods.Active(difficulty.Perfect) && (judgementResult.HitResult&BaseHitsM > 0 && judgementResult.HitResult&BaseHitsM != Hit300)) {
 		if judgementResult.HitResult&BaseHitsM > 0 {
 			judgementResult.HitResult = Miss
@@ -520,6 +568,11 @@ func (set *OsuRuleSet) SendResult(cursor *graphics.Cursor, judgementResult Judge
 	subSet.score.Combo = max(subSet.score.CurrentCombo, subSet.score.Combo)
 	subSet.score.Accuracy = subSet.scoreProcessor.GetAccuracy()
 
+	if (subSet.player.diff.Mods.Active(difficulty.Target) && judgementResult.HitResult == Miss) ||
+		(subSet.player.diff.Mods.Active(difficulty.AccuracyChallenge) && judgementResult.HitResult.AffectsAccLZ() && set.failsAccuracyChallenge(subSet)) {
+		subSet.sdpfFail = true
+	}
+
 	subSet.score.CalculateGrade(subSet.player.diff.Mods)
 
 	diff := set.GetCurrentDiffAttribs(cursor)
@@ -540,6 +593,10 @@ func (set *OsuRuleSet) SendResult(cursor *graphics.Cursor, judgementResult Judge
 		set.hitListener(cursor, judgementResult, *subSet.score)
 	}
 
+	if set.exporter != nil {
+		set.exporter.addJudgement(cursor, judgementResult, *subSet.score)
+	}
+
 	if len(set.cursors) == 1 && judgementResult.HitResult != SliderFinish && !settings.RECORD {
 		log.Println(fmt.Sprintf(
 			"Got: %3d, Combo: %4d, Max Combo: %4d, Score: %9d, Acc: %6.2f%%, 300: %4d, 100: %3d, 50: %2d, miss: %2d, from: %d, at: %d, pos: %.0fx%.0f, pp: %.2f",
@@ -561,6 +618,22 @@ func (set *OsuRuleSet) SendResult(cursor *graphics.Cursor, judgementResult Judge
 	}
 }
 
+// failsAccuracyChallenge checks if player's accuracy dropped below Accuracy Challenge's minimum
+func (set *OsuRuleSet) failsAccuracyChallenge(subSet *subSet) bool {
+	conf, ok := difficulty.GetModConfig[difficulty.AccuracyChallengeSettings](subSet.player.diff)
+	if !ok {
+		conf = difficulty.NewAccuracyChallengeSettings()
+	}
+
+	accuracy := subSet.score.Accuracy
+
+	if sc, ok := subSet.scoreProcessor.(*scoreV3Processor); ok && conf.AccuracyJudgeMode == difficulty.AccuracyMaxAchievable {
+		accuracy = sc.GetMaxAchievableAccuracy()
+	}
+
+	return accuracy < conf.MinimumAccuracy
+}
+
 func (set *OsuRuleSet) processGekiKatu(sSet *subSet, judgementResult *JudgementResult) {
 	switch judgementResult.HitResult {
 	case Hit100:
@@ -744,7 +817,7 @@ func (set *OsuRuleSet) failInternal(player *difficultyPlayer) {
 		return
 	}
 
-	if !subSet.forceFail && player.diff.CheckModActive(difficulty.NoFail|difficulty.Relax|difficulty.Relax2) {
+	if !subSet.forceFail && player.diff.CheckModActive(difficulty.NoFail, difficulty.Relax, difficulty.Relax2) {
 		return
 	}
 
@@ -786,10 +859,19 @@ func (set *OsuRuleSet) SetEndListener(listener endListener) {
 	set.endListener = listener
 }
 
+func (set *OsuRuleSet) SetExporter(exporter *JudgementExporter) {
+	set.exporter = exporter
+}
+
 func (set *OsuRuleSet) SetFailListener(listener failListener) {
 	set.failListener = listener
 }
 
+// SetFinishListener sets a listener called once, after all objects have been judged
+func (set *OsuRuleSet) SetFinishListener(listener finishListener) {
+	set.finishListener = listener
+}
+
 func (set *OsuRuleSet) GetFCPP(cursor *graphics.Cursor) api.PPv2Results {
 	subSet := set.cursors[cursor]
 
@@ -876,3 +958,11 @@ func (set *OsuRuleSet) GetProcessed() []HitObject {
 func (set *OsuRuleSet) GetBeatMap() *beatmap.BeatMap {
 	return set.beatMap
 }
+
+func (set *OsuRuleSet) HasEnded() bool {
+	return set.ended
+}
+
+func (set *OsuRuleSet) HasFailed(cursor *graphics.Cursor) bool {
+	return set.cursors[cursor].failed
+}
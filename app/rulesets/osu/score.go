@@ -13,6 +13,9 @@ type scoreProcessor interface {
 	GetScore() int64
 	GetCombo() int64
 	GetAccuracy() float64
+
+	saveState() any
+	restoreState(state any)
 }
 
 type Score struct {
@@ -88,13 +91,13 @@ func (s *Score) AddResult(result JudgementResult) {
 func (s *Score) CalculateGrade(mods difficulty.Modifier) {
 	var baseGrade Grade
 
-	if mods&(difficulty.Lazer) > 0 {
+	if mods.Active(difficulty.Lazer) {
 		baseGrade = s.gradeV2()
 	} else {
 		baseGrade = s.gradeV1()
 	}
 
-	if mods&(difficulty.Hidden|difficulty.Flashlight) > 0 {
+	if mods.Active(difficulty.Hidden, difficulty.Flashlight) {
 		switch baseGrade {
 		case S:
 			baseGrade = SH
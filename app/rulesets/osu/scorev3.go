@@ -19,8 +19,9 @@ type scoreV3Processor struct {
 	comboPart    float64
 	comboPartMax float64
 
-	accPart    int64
-	accPartMax int64
+	accPart      int64
+	accPartMax   int64
+	accPartTotal int64
 
 	hits          int64
 	maxHits       int64
@@ -78,6 +79,7 @@ func (s *scoreV3Processor) Init(beatMap *beatmap.BeatMap, player *difficultyPlay
 
 	s.comboPartMax = s.comboPart
 	s.maxHits = s.hits
+	s.accPartTotal = s.accPartMax
 
 	s.combo = 0
 	s.hits = 0
@@ -150,3 +152,12 @@ func (s *scoreV3Processor) GetCombo() int64 {
 func (s *scoreV3Processor) GetAccuracy() float64 {
 	return s.accuracy
 }
+
+// GetMaxAchievableAccuracy returns the accuracy the player would end with if all remaining judgements were perfect
+func (s *scoreV3Processor) GetMaxAchievableAccuracy() float64 {
+	if s.accPartTotal == 0 {
+		return 1
+	}
+
+	return float64(s.accPart+s.accPartTotal-s.accPartMax) / float64(s.accPartTotal)
+}
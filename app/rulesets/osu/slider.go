@@ -306,6 +306,10 @@ func (slider *Slider) UpdateFor(player *difficultyPlayer, time int64, processSli
 			}
 
 			state.sliding = false
+
+			if lzMod && player.diff.CheckModActive(difficulty.StrictTracking) {
+				slider.missTailStrict(player, state, time, sliderPosition)
+			}
 		}
 	}
 
@@ -377,6 +381,12 @@ func (slider *Slider) processTicksLazer(player *difficultyPlayer, state *sliders
 	for index := state.scored + state.missed; index < pointsPassed; index++ {
 		point := state.points[index]
 
+		// Strict Tracking's tail has been already missed when tracking was lost
+		if point.judged {
+			state.missed++
+			continue
+		}
+
 		scoreGiven := Ignore
 		combo := Reset
 
@@ -398,7 +408,7 @@ func (slider *Slider) processTicksLazer(player *difficultyPlayer, state *sliders
 
 			scoreGiven = SliderMiss
 
-			if point.scoreGiven&(SliderEnd|LegacySliderEnd) > 0 {
+			if point.scoreGiven&(SliderEnd|LegacySliderEnd) > 0 && !player.diff.CheckModActive(difficulty.StrictTracking) {
 				combo = Hold
 			}
 		}
@@ -409,6 +419,19 @@ func (slider *Slider) processTicksLazer(player *difficultyPlayer, state *sliders
 	}
 }
 
+// missTailStrict misses slider's tail immediately after tracking is lost, used by Strict Tracking
+func (slider *Slider) missTailStrict(player *difficultyPlayer, state *sliderstate, time int64, sliderPosition vector.Vector2f) {
+	tail := &state.points[len(state.points)-1]
+
+	if tail.judged {
+		return
+	}
+
+	tail.judged = true
+
+	slider.ruleSet.SendResult(player.cursor, createJudgementResult(SliderMiss, tail.scoreGiven, Reset, time, sliderPosition, slider))
+}
+
 func (slider *Slider) UpdatePostFor(player *difficultyPlayer, time int64, processSliderEndsAhead bool) bool {
 	state := slider.state[player]
 
@@ -0,0 +1,207 @@
+package osu
+
+import (
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"slices"
+)
+
+type subSetSnapshot struct {
+	set            subSet
+	score          Score
+	hp             any
+	scoreProcessor any
+	rate           any
+}
+
+// Snapshot holds the state of the ruleset at a given time, used to rewind the playback
+type Snapshot struct {
+	Time int64
+
+	ended bool
+
+	queue     []HitObject
+	processed []HitObject
+
+	objects map[HitObject]any
+	players map[*difficultyPlayer]difficultyPlayer
+	subSets map[*graphics.Cursor]subSetSnapshot
+}
+
+// Snapshot saves the current state of the ruleset. Objects that are still waiting in the queue are restored to their initial state, so only active ones are stored.
+func (set *OsuRuleSet) Snapshot(time int64) *Snapshot {
+	if set.initialStates == nil {
+		set.initialStates = make(map[HitObject]any, len(set.queue))
+
+		for _, o := range set.queue {
+			set.initialStates[o] = o.saveState()
+		}
+	}
+
+	snapshot := &Snapshot{
+		Time:      time,
+		ended:     set.ended,
+		queue:     slices.Clone(set.queue),
+		processed: slices.Clone(set.processed),
+		objects:   make(map[HitObject]any, len(set.processed)),
+		players:   make(map[*difficultyPlayer]difficultyPlayer, len(set.cursors)),
+		subSets:   make(map[*graphics.Cursor]subSetSnapshot, len(set.cursors)),
+	}
+
+	for _, o := range set.processed {
+		snapshot.objects[o] = o.saveState()
+	}
+
+	for cursor, subSet := range set.cursors {
+		snapshot.players[subSet.player] = *subSet.player
+
+		snapshot.subSets[cursor] = subSetSnapshot{
+			set:            *subSet,
+			score:          *subSet.score,
+			hp:             subSet.hp.saveState(),
+			scoreProcessor: subSet.scoreProcessor.saveState(),
+			rate:           subSet.player.diff.SaveRateState(),
+		}
+	}
+
+	return snapshot
+}
+
+// Restore brings the ruleset back to the state saved in the snapshot
+func (set *OsuRuleSet) Restore(snapshot *Snapshot) {
+	set.ended = snapshot.ended
+
+	set.queue = slices.Clone(snapshot.queue)
+	set.processed = slices.Clone(snapshot.processed)
+
+	for _, o := range set.queue {
+		o.restoreState(set.initialStates[o])
+	}
+
+	for _, o := range set.processed {
+		o.restoreState(snapshot.objects[o])
+	}
+
+	for cursor, subSet := range set.cursors {
+		*subSet.player = snapshot.players[subSet.player]
+
+		state := snapshot.subSets[cursor]
+
+		*subSet = state.set
+		*subSet.score = state.score
+		subSet.hp.restoreState(state.hp)
+		subSet.scoreProcessor.restoreState(state.scoreProcessor)
+		subSet.player.diff.RestoreRateState(state.rate)
+	}
+}
+
+func (circle *Circle) saveState() any {
+	state := make(map[*difficultyPlayer]objstate, len(circle.state))
+
+	for p, s := range circle.state {
+		state[p] = *s
+	}
+
+	return state
+}
+
+func (circle *Circle) restoreState(state any) {
+	for p, s := range state.(map[*difficultyPlayer]objstate) {
+		*circle.state[p] = s
+	}
+}
+
+type sliderSnapshot struct {
+	states map[*difficultyPlayer]sliderstate
+
+	lastSliderTime   int64
+	sliderPosition   vector.Vector2f
+	sliderPositionLZ vector.Vector2f
+}
+
+func (slider *Slider) saveState() any {
+	state := sliderSnapshot{
+		states:           make(map[*difficultyPlayer]sliderstate, len(slider.state)),
+		lastSliderTime:   slider.lastSliderTime,
+		sliderPosition:   slider.sliderPosition,
+		sliderPositionLZ: slider.sliderPositionLZ,
+	}
+
+	for p, s := range slider.state {
+		sCopy := *s
+		sCopy.points = slices.Clone(s.points)
+
+		state.states[p] = sCopy
+	}
+
+	return state
+}
+
+func (slider *Slider) restoreState(state any) {
+	sState := state.(sliderSnapshot)
+
+	slider.lastSliderTime = sState.lastSliderTime
+	slider.sliderPosition = sState.sliderPosition
+	slider.sliderPositionLZ = sState.sliderPositionLZ
+
+	for p, s := range sState.states {
+		*slider.state[p] = s
+		slider.state[p].points = slices.Clone(s.points)
+	}
+}
+
+func (spinner *Spinner) saveState() any {
+	state := make(map[*difficultyPlayer]spinnerstate, len(spinner.state))
+
+	for p, s := range spinner.state {
+		state[p] = *s
+	}
+
+	return state
+}
+
+func (spinner *Spinner) restoreState(state any) {
+	for p, s := range state.(map[*difficultyPlayer]spinnerstate) {
+		*spinner.state[p] = s
+	}
+}
+
+func (hp *HealthProcessor) saveState() any {
+	return *hp
+}
+
+func (hp *HealthProcessor) restoreState(state any) {
+	*hp = state.(HealthProcessor)
+}
+
+func (hp *HealthProcessorV2) saveState() any {
+	return *hp
+}
+
+func (hp *HealthProcessorV2) restoreState(state any) {
+	*hp = state.(HealthProcessorV2)
+}
+
+func (s *scoreV1Processor) saveState() any {
+	return *s
+}
+
+func (s *scoreV1Processor) restoreState(state any) {
+	*s = state.(scoreV1Processor)
+}
+
+func (s *scoreV2Processor) saveState() any {
+	return *s
+}
+
+func (s *scoreV2Processor) restoreState(state any) {
+	*s = state.(scoreV2Processor)
+}
+
+func (s *scoreV3Processor) saveState() any {
+	return *s
+}
+
+func (s *scoreV3Processor) restoreState(state any) {
+	*s = state.(scoreV3Processor)
+}
@@ -118,7 +118,7 @@ func (spinner *Spinner) processStable(player *difficultyPlayer, time int64) {
 	}
 
 	if player.cursor.IsReplayFrame && time > int64(spinner.hitSpinner.GetStartTime()) && time < int64(spinner.hitSpinner.GetEndTime()) {
-		maxAccelThisFrame := player.diff.GetModifiedTime(spinner.maxAcceleration * timeDiff)
+		maxAccelThisFrame := player.diff.GetModifiedTimeAt(float64(time), spinner.maxAcceleration*timeDiff)
 
 		if player.diff.CheckModActive(difficulty.SpunOut) || player.diff.CheckModActive(difficulty.Relax2) {
 			state.currentVelocity = 0.03
@@ -185,9 +185,9 @@ func (spinner *Spinner) processStable(player *difficultyPlayer, time int64) {
 			}
 
 			if math.Abs(angleDiff) < math.Pi {
-				if player.diff.GetModifiedTime(state.frameVariance) > FrameTime*1.04 {
+				if player.diff.GetModifiedTimeAt(float64(time), state.frameVariance) > FrameTime*1.04 {
 					if timeDiff > 0 {
-						state.theoreticalVelocity = angleDiff / player.diff.GetModifiedTime(timeDiff)
+						state.theoreticalVelocity = angleDiff / player.diff.GetModifiedTimeAt(float64(time), timeDiff)
 					} else {
 						state.theoreticalVelocity = 0
 					}
@@ -207,7 +207,7 @@ func (spinner *Spinner) processStable(player *difficultyPlayer, time int64) {
 		state.rotationCountF += float32(math.Abs(float64(float32(rotationAddition)) / math.Pi))
 
 		if len(spinner.players) == 1 {
-			spinner.hitSpinner.SetRotation(player.diff.GetModifiedTime(state.rotationCountFD))
+			spinner.hitSpinner.SetRotation(player.diff.GetModifiedTimeAt(float64(time), state.rotationCountFD))
 			spinner.hitSpinner.SetRPM(state.rpm)
 			spinner.hitSpinner.UpdateCompletion(float64(state.rotationCountF) / float64(state.requirement))
 		}
@@ -268,7 +268,7 @@ func (spinner *Spinner) processLazer(player *difficultyPlayer, time int64) {
 		var deltaRPM float32 = 0
 
 		if player.gameDownState || player.diff.CheckModActive(difficulty.Relax) {
-			delta *= float32(player.diff.GetSpeed())
+			delta *= float32(player.diff.GetSpeedAt(float64(time)))
 
 			if delta != 0 {
 				state.totalAccumulatedRotation += delta
@@ -302,7 +302,7 @@ func (spinner *Spinner) processLazer(player *difficultyPlayer, time int64) {
 
 		spinning := mutils.Abs(state.rotationCountF-state.rotationCountFPrev) > 10
 
-		state.rotationCountFPrev = mutils.Lerp(state.rotationCountFPrev, state.rotationCountF, 1-math32.Pow(0.99, float32(player.diff.GetModifiedTime(timeDiff))))
+		state.rotationCountFPrev = mutils.Lerp(state.rotationCountFPrev, state.rotationCountF, 1-math32.Pow(0.99, float32(player.diff.GetModifiedTimeAt(float64(time), timeDiff))))
 
 		if len(spinner.players) == 1 {
 			if spinning {
@@ -0,0 +1,137 @@
+package taiko
+
+import (
+	"github.com/wieku/danser-go/app/audio"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"log"
+	"sort"
+)
+
+const (
+	sampleWhistle = 2
+	sampleFinish  = 4
+	sampleClap    = 8
+
+	// legacyVelocityMultiplier is osu!stable's taiko scroll speed multiplier
+	legacyVelocityMultiplier = 1.4
+)
+
+// ConvertBeatMap creates taiko objects from parsed hit objects. Works for both osu!taiko maps and osu!standard maps.
+// Like in osu!stable, sliders of converted maps that are too short for a drum roll are split into hits.
+func ConvertBeatMap(beatMap *beatmap.BeatMap) []*Object {
+	log.Println("Converting beatmap to osu!taiko...")
+
+	converted := make([]*Object, 0, len(beatMap.HitObjects))
+
+	for _, o := range beatMap.HitObjects {
+		switch obj := o.(type) {
+		case *objects.Circle:
+			converted = append(converted, newHit(beatMap, obj.GetStartTime(), obj.GetSample(), obj.BasicHitSound))
+		case *objects.Slider:
+			converted = append(converted, convertSlider(beatMap, obj)...)
+		case *objects.Spinner:
+			converted = append(converted, &Object{
+				Type:      Swell,
+				StartTime: obj.GetStartTime(),
+				EndTime:   obj.GetEndTime(),
+				Velocity:  getVelocity(beatMap, obj.GetStartTime()),
+				sample:    obj.GetSample(),
+				hitSound:  obj.BasicHitSound,
+				timings:   beatMap.Timings,
+			})
+		}
+	}
+
+	sort.SliceStable(converted, func(i, j int) bool {
+		return converted[i].StartTime < converted[j].StartTime
+	})
+
+	for i, o := range converted {
+		o.Number = int64(i)
+	}
+
+	log.Println("Converted", len(beatMap.HitObjects), "objects to", len(converted), "osu!taiko objects")
+
+	return converted
+}
+
+func newHit(beatMap *beatmap.BeatMap, time float64, sample int, hitSound audio.HitSoundInfo) *Object {
+	return &Object{
+		Type:      Hit,
+		StartTime: time,
+		EndTime:   time,
+		Kat:       sample&(sampleWhistle|sampleClap) > 0,
+		Big:       sample&sampleFinish > 0,
+		Velocity:  getVelocity(beatMap, time),
+		sample:    sample,
+		hitSound:  hitSound,
+		timings:   beatMap.Timings,
+	}
+}
+
+func convertSlider(beatMap *beatmap.BeatMap, slider *objects.Slider) []*Object {
+	startTime := slider.GetStartTime()
+	duration := slider.GetEndTime() - startTime
+	spans := max(1, slider.RepeatCount)
+
+	// osu!stable uses speed adjusted beat length only in old beatmaps
+	beatLength := slider.TPoint.GetBeatLength()
+	if beatMap.Version >= 8 {
+		beatLength = slider.TPoint.GetBaseBeatLength()
+	}
+
+	tickSpacing := min(beatLength/beatMap.Timings.TickRate, duration/float64(spans))
+
+	if beatMap.Mode != 1 && tickSpacing > 0 && duration < 2*beatLength {
+		hits := make([]*Object, 0)
+
+		for i, t := 0, startTime; t <= startTime+duration+tickSpacing/8; t += tickSpacing {
+			sample, sampleSet, additionSet := slider.GetEdgeSample(i)
+
+			hitSound := slider.BasicHitSound
+
+			if sampleSet != 0 {
+				hitSound.SampleSet = sampleSet
+			}
+
+			hitSound.AdditionSet = additionSet
+
+			hits = append(hits, newHit(beatMap, t, sample, hitSound))
+
+			i = (i + 1) % (spans + 1)
+		}
+
+		return hits
+	}
+
+	roll := &Object{
+		Type:      DrumRoll,
+		StartTime: startTime,
+		EndTime:   startTime + duration,
+		Big:       slider.GetBaseSample()&sampleFinish > 0,
+		Velocity:  getVelocity(beatMap, startTime),
+		sample:    slider.GetBaseSample(),
+		hitSound:  slider.BasicHitSound,
+		timings:   beatMap.Timings,
+	}
+
+	tickRate := 4.0
+	if beatMap.Timings.TickRate == 3 {
+		tickRate = 3
+	}
+
+	roll.TickSpacing = slider.TPoint.GetBaseBeatLength() / tickRate
+
+	if roll.TickSpacing > 0 {
+		for t := roll.StartTime; t < roll.EndTime+roll.TickSpacing/2; t += roll.TickSpacing {
+			roll.Ticks = append(roll.Ticks, t)
+		}
+	}
+
+	return []*Object{roll}
+}
+
+func getVelocity(beatMap *beatmap.BeatMap, time float64) float64 {
+	return legacyVelocityMultiplier * 100 * beatMap.Timings.SliderMult / beatMap.Timings.GetPointAt(time).GetBeatLength()
+}
@@ -0,0 +1,58 @@
+package taiko
+
+type HitResult uint8
+
+const (
+	None = HitResult(iota)
+	Great
+	Ok
+	Miss
+	StrongBonus
+	DrumRollTick
+	SwellTick
+)
+
+func (result HitResult) String() string {
+	switch result {
+	case Great:
+		return "Great"
+	case Ok:
+		return "Ok"
+	case Miss:
+		return "Miss"
+	case StrongBonus:
+		return "StrongBonus"
+	case DrumRollTick:
+		return "DrumRollTick"
+	case SwellTick:
+		return "SwellTick"
+	}
+
+	return "None"
+}
+
+// ScoreValue returns base score of the judgement
+func (result HitResult) ScoreValue() int64 {
+	switch result {
+	case Great, DrumRollTick, SwellTick:
+		return 300
+	case Ok:
+		return 150
+	}
+
+	return 0
+}
+
+// IsJudgement returns true for results that count towards accuracy
+func (result HitResult) IsJudgement() bool {
+	return result == Great || result == Ok || result == Miss
+}
+
+type JudgementResult struct {
+	Time      int64
+	Number    int64
+	HitResult HitResult
+
+	// ComboBreak is true when the judgement reset player's combo
+	ComboBreak bool
+}
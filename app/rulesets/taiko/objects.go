@@ -0,0 +1,89 @@
+package taiko
+
+import (
+	"github.com/wieku/danser-go/app/audio"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+)
+
+type ObjectType uint8
+
+const (
+	Hit = ObjectType(iota)
+	DrumRoll
+	Swell
+)
+
+func (t ObjectType) String() string {
+	switch t {
+	case Hit:
+		return "Hit"
+	case DrumRoll:
+		return "DrumRoll"
+	case Swell:
+		return "Swell"
+	}
+
+	return "Unknown"
+}
+
+type Object struct {
+	Type ObjectType
+
+	// Number is the index of the object in converted object list
+	Number int64
+
+	StartTime float64
+	EndTime   float64
+
+	// Kat marks rim hits, don otherwise
+	Kat bool
+
+	// Big marks strong hits and drum rolls which give bonus score when hit with both keys
+	Big bool
+
+	// Velocity is the scrolling speed in osu!pixels per millisecond
+	Velocity float64
+
+	// TickSpacing is the distance between drum roll ticks
+	TickSpacing float64
+	Ticks       []float64
+
+	sample   int
+	hitSound audio.HitSoundInfo
+	timings  *objects.Timings
+}
+
+// GetRequiredHits returns the number of hits needed to complete a swell
+func (object *Object) GetRequiredHits(od float64) int {
+	hitMultiplier := difficulty.DifficultyRate(od, 3, 5, 7.5) * 1.65
+
+	return max(1, int((object.EndTime-object.StartTime)/1000*hitMultiplier))
+}
+
+func (object *Object) isKiai(time float64) bool {
+	return object.timings.GetPointAt(time).Kiai
+}
+
+func (object *Object) playSound() {
+	point := object.timings.GetPointAt(object.StartTime)
+
+	index := object.hitSound.CustomIndex
+	sampleSet := object.hitSound.SampleSet
+
+	if index == 0 {
+		index = point.SampleIndex
+	}
+
+	if sampleSet == 0 {
+		sampleSet = point.SampleSet
+	}
+
+	audio.PlaySample(sampleSet, object.hitSound.AdditionSet, object.sample, index, point.SampleVolume, object.Number, 256)
+}
+
+func (object *Object) playTick(time float64) {
+	point := object.timings.GetPointAt(time)
+
+	audio.PlaySample(point.SampleSet, 0, 0, point.SampleIndex, point.SampleVolume, object.Number, 256)
+}
@@ -0,0 +1,428 @@
+package taiko
+
+import (
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/graphics"
+	"log"
+	"math"
+)
+
+// strongHitWindow is the maximum time between two hits of a big note to get a strong bonus
+const strongHitWindow = 30
+
+const (
+	LeftDon = iota
+	RightDon
+	LeftKat
+	RightKat
+)
+
+// Input holds the state of taiko's four keys
+type Input [4]bool
+
+type hitWindows struct {
+	great float64
+	ok    float64
+	miss  float64
+}
+
+func newHitWindows(od float64) hitWindows {
+	return hitWindows{
+		great: math.Floor(difficulty.DifficultyRate(od, 50, 35, 20)),
+		ok:    math.Floor(difficulty.DifficultyRate(od, 120, 80, 50)),
+		miss:  math.Floor(difficulty.DifficultyRate(od, 135, 95, 70)),
+	}
+}
+
+type objectState struct {
+	done   bool
+	result HitResult
+
+	hitTime int64
+	hitKey  int
+
+	// scoreValue is the score given by the hit, a strong bonus gives it once more
+	scoreValue int64
+
+	tickIndex int
+
+	swellHits    int
+	swellLastKat bool
+}
+
+type taikoPlayer struct {
+	cursor  *graphics.Cursor
+	diff    *difficulty.Difficulty
+	windows hitWindows
+
+	states    []objectState
+	processed int
+
+	// lastHit is the index of the last hit big note, -1 if there's none waiting for a strong bonus
+	lastHit int
+
+	score Score
+
+	hp               float64
+	hpMultiplier     float64
+	hpMissMultiplier float64
+
+	lastInput Input
+}
+
+type hitListener func(cursor *graphics.Cursor, judgementResult JudgementResult, score Score)
+
+type TaikoRuleSet struct {
+	beatMap *beatmap.BeatMap
+	objects []*Object
+
+	players []*taikoPlayer
+	cursors map[*graphics.Cursor]*taikoPlayer
+
+	soundIndex    int
+	audioDisabled bool
+
+	ended bool
+
+	hitListener hitListener
+}
+
+func NewTaikoRuleset(beatMap *beatmap.BeatMap, cursors []*graphics.Cursor, diffs []*difficulty.Difficulty) *TaikoRuleSet {
+	log.Println("Creating osu!taiko ruleset...")
+
+	ruleset := new(TaikoRuleSet)
+	ruleset.beatMap = beatMap
+	ruleset.objects = ConvertBeatMap(beatMap)
+	ruleset.cursors = make(map[*graphics.Cursor]*taikoPlayer)
+
+	hitCount := 0
+
+	for _, o := range ruleset.objects {
+		if o.Type == Hit {
+			hitCount++
+		}
+	}
+
+	for i, cursor := range cursors {
+		diff := diffs[i]
+
+		player := &taikoPlayer{
+			cursor:           cursor,
+			diff:             diff,
+			windows:          newHitWindows(diff.ODMod),
+			states:           make([]objectState, len(ruleset.objects)),
+			lastHit:          -1,
+			hpMultiplier:     1 / (float64(max(1, hitCount)) * difficulty.DifficultyRate(diff.HPMod, 0.5, 0.75, 0.98)),
+			hpMissMultiplier: difficulty.DifficultyRate(diff.HPMod, 0.0018, 0.0075, 0.0120),
+		}
+
+		player.score.init(beatMap, diff)
+
+		ruleset.players = append(ruleset.players, player)
+		ruleset.cursors[cursor] = player
+	}
+
+	return ruleset
+}
+
+// UpdateInput registers key presses of the player
+func (ruleset *TaikoRuleSet) UpdateInput(cursor *graphics.Cursor, time int64, input Input) {
+	player := ruleset.cursors[cursor]
+
+	ruleset.updatePlayer(player, time)
+
+	for key, pressed := range input {
+		if pressed && !player.lastInput[key] {
+			ruleset.press(player, time, key)
+		}
+	}
+
+	player.lastInput = input
+}
+
+func (ruleset *TaikoRuleSet) Update(time int64) {
+	for _, player := range ruleset.players {
+		ruleset.updatePlayer(player, time)
+	}
+
+	// With multiple players, hits are heard at their time regardless of judgements
+	if len(ruleset.players) > 1 {
+		for ; ruleset.soundIndex < len(ruleset.objects) && ruleset.objects[ruleset.soundIndex].StartTime <= float64(time); ruleset.soundIndex++ {
+			if o := ruleset.objects[ruleset.soundIndex]; o.Type == Hit && !ruleset.audioDisabled {
+				o.playSound()
+			}
+		}
+	}
+
+	if !ruleset.ended {
+		ended := true
+
+		for _, player := range ruleset.players {
+			if player.processed < len(ruleset.objects) {
+				ended = false
+				break
+			}
+		}
+
+		if ended {
+			ruleset.ended = true
+
+			for _, player := range ruleset.players {
+				log.Println("Player:", player.cursor.Name, "Score:", player.score.Score, "Accuracy:", player.score.Accuracy*100, "Max combo:", player.score.MaxCombo,
+					"Great:", player.score.CountGreat, "Ok:", player.score.CountOk, "Miss:", player.score.CountMiss)
+			}
+		}
+	}
+}
+
+// updatePlayer judges objects that can't be hit anymore
+func (ruleset *TaikoRuleSet) updatePlayer(player *taikoPlayer, time int64) {
+	t := float64(time)
+
+	for player.processed < len(ruleset.objects) {
+		o := ruleset.objects[player.processed]
+		state := &player.states[player.processed]
+
+		if !state.done {
+			switch o.Type {
+			case Hit:
+				if t > o.StartTime+player.windows.miss {
+					ruleset.judge(player, player.processed, int64(o.StartTime+player.windows.miss), Miss)
+				}
+			case DrumRoll:
+				if t > o.EndTime+o.TickSpacing/2 {
+					state.done = true
+				}
+			case Swell:
+				if t > o.EndTime {
+					state.done = true
+				}
+			}
+		}
+
+		if !state.done {
+			break
+		}
+
+		player.processed++
+	}
+}
+
+func (ruleset *TaikoRuleSet) press(player *taikoPlayer, time int64, key int) {
+	t := float64(time)
+	kat := key == LeftKat || key == RightKat
+	relax := player.diff.CheckModActive(difficulty.Relax)
+
+	if player.lastHit >= 0 {
+		o := ruleset.objects[player.lastHit]
+		state := &player.states[player.lastHit]
+
+		if time-state.hitTime > strongHitWindow {
+			player.lastHit = -1
+		} else if key != state.hitKey && (kat == o.Kat || relax) {
+			player.lastHit = -1
+
+			player.score.addStrongBonus(state.scoreValue)
+
+			ruleset.sendResult(player, o.Number, time, StrongBonus, false)
+
+			return
+		}
+	}
+
+	for i := player.processed; i < len(ruleset.objects); i++ {
+		o := ruleset.objects[i]
+		state := &player.states[i]
+
+		if state.done {
+			continue
+		}
+
+		switch o.Type {
+		case Hit:
+			if t < o.StartTime-player.windows.miss {
+				return
+			}
+
+			result := Miss
+
+			if kat == o.Kat || relax {
+				switch diff := math.Abs(t - o.StartTime); {
+				case diff <= player.windows.great:
+					result = Great
+				case diff <= player.windows.ok:
+					result = Ok
+				}
+			}
+
+			state.hitTime = time
+			state.hitKey = key
+
+			ruleset.judge(player, i, time, result)
+
+			if result != Miss {
+				if o.Big {
+					player.lastHit = i
+				}
+
+				if ruleset.shouldPlaySounds(player) {
+					o.playSound()
+				}
+			}
+
+			return
+		case DrumRoll:
+			if t < o.StartTime-o.TickSpacing/2 {
+				return
+			}
+
+			if ruleset.hitTick(player, o, state, t) {
+				return
+			}
+		case Swell:
+			if t < o.StartTime {
+				return
+			}
+
+			if t <= o.EndTime {
+				if relax {
+					kat = !state.swellLastKat
+				}
+
+				ruleset.hitSwell(player, o, state, t, kat)
+
+				return
+			}
+		}
+	}
+}
+
+func (ruleset *TaikoRuleSet) hitTick(player *taikoPlayer, o *Object, state *objectState, t float64) bool {
+	for state.tickIndex < len(o.Ticks) && o.Ticks[state.tickIndex]+o.TickSpacing/2 < t {
+		state.tickIndex++
+	}
+
+	if state.tickIndex >= len(o.Ticks) || math.Abs(o.Ticks[state.tickIndex]-t) > o.TickSpacing/2 {
+		return false
+	}
+
+	state.tickIndex++
+
+	player.score.addResult(DrumRollTick, o.Big, o.isKiai(t))
+
+	ruleset.sendResult(player, o.Number, int64(t), DrumRollTick, false)
+
+	if ruleset.shouldPlaySounds(player) {
+		o.playTick(t)
+	}
+
+	return true
+}
+
+// hitSwell counts swell hits, don and kat have to be alternated
+func (ruleset *TaikoRuleSet) hitSwell(player *taikoPlayer, o *Object, state *objectState, t float64, kat bool) {
+	if state.swellHits > 0 && state.swellLastKat == kat {
+		return
+	}
+
+	state.swellHits++
+	state.swellLastKat = kat
+
+	player.score.addResult(SwellTick, false, false)
+
+	ruleset.sendResult(player, o.Number, int64(t), SwellTick, false)
+
+	if ruleset.shouldPlaySounds(player) {
+		o.playTick(t)
+	}
+
+	if state.swellHits >= o.GetRequiredHits(player.diff.ODMod) {
+		state.done = true
+		state.result = Great
+
+		player.score.addSwellBonus(o.isKiai(o.EndTime))
+
+		if ruleset.shouldPlaySounds(player) {
+			o.playSound()
+		}
+	}
+}
+
+func (ruleset *TaikoRuleSet) judge(player *taikoPlayer, index int, time int64, result HitResult) {
+	state := &player.states[index]
+	state.done = true
+	state.result = result
+
+	o := ruleset.objects[index]
+
+	state.scoreValue = player.score.addResult(result, false, o.isKiai(o.StartTime))
+
+	switch result {
+	case Great:
+		player.hp += 1 * player.hpMultiplier
+	case Ok:
+		player.hp += 0.5 * player.hpMultiplier
+	case Miss:
+		player.hp -= player.hpMissMultiplier
+	}
+
+	player.hp = min(max(player.hp, 0), 1)
+
+	ruleset.sendResult(player, o.Number, time, result, result == Miss)
+}
+
+func (ruleset *TaikoRuleSet) sendResult(player *taikoPlayer, number, time int64, result HitResult, comboBreak bool) {
+	if ruleset.hitListener != nil {
+		ruleset.hitListener(player.cursor, JudgementResult{
+			Time:       time,
+			Number:     number,
+			HitResult:  result,
+			ComboBreak: comboBreak,
+		}, player.score)
+	}
+}
+
+func (ruleset *TaikoRuleSet) shouldPlaySounds(player *taikoPlayer) bool {
+	return len(ruleset.players) == 1 && !ruleset.audioDisabled && player == ruleset.players[0]
+}
+
+func (ruleset *TaikoRuleSet) SetListener(listener hitListener) {
+	ruleset.hitListener = listener
+}
+
+func (ruleset *TaikoRuleSet) DisableAudioSubmission(value bool) {
+	ruleset.audioDisabled = value
+}
+
+func (ruleset *TaikoRuleSet) GetObjects() []*Object {
+	return ruleset.objects
+}
+
+// GetResult returns the judgement of the object, None if it wasn't judged yet. Completed swells return Great.
+func (ruleset *TaikoRuleSet) GetResult(cursor *graphics.Cursor, number int64) HitResult {
+	return ruleset.cursors[cursor].states[number].result
+}
+
+// GetSwellProgress returns the number of hits and hits required to complete the swell
+func (ruleset *TaikoRuleSet) GetSwellProgress(cursor *graphics.Cursor, number int64) (hits, required int) {
+	player := ruleset.cursors[cursor]
+
+	return player.states[number].swellHits, ruleset.objects[number].GetRequiredHits(player.diff.ODMod)
+}
+
+func (ruleset *TaikoRuleSet) GetScore(cursor *graphics.Cursor) Score {
+	return ruleset.cursors[cursor].score
+}
+
+func (ruleset *TaikoRuleSet) GetHP(cursor *graphics.Cursor) float64 {
+	return ruleset.cursors[cursor].hp
+}
+
+func (ruleset *TaikoRuleSet) GetBeatMap() *beatmap.BeatMap {
+	return ruleset.beatMap
+}
+
+func (ruleset *TaikoRuleSet) HasEnded() bool {
+	return ruleset.ended
+}
@@ -0,0 +1,156 @@
+package taiko
+
+import (
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/framework/math/mutils"
+	"math"
+)
+
+// Score tracks judgements, combo, accuracy and score like osu!stable does (ScoreV1)
+type Score struct {
+	Score    int64
+	Combo    int64
+	MaxCombo int64
+
+	CountGreat int64
+	CountOk    int64
+	CountMiss  int64
+
+	DrumRollTicks int64
+	SwellTicks    int64
+
+	Accuracy float64
+	Grade    osu.Grade
+
+	mods                 difficulty.Modifier
+	difficultyMultiplier int64
+	modMultiplier        float64
+}
+
+// init calculates stable's score multipliers. Difficulty multiplier comes from unconverted beatmap's difficulty settings and object density.
+func (score *Score) init(beatMap *beatmap.BeatMap, diff *difficulty.Difficulty) {
+	score.mods = diff.Mods
+	score.modMultiplier = diff.GetScoreMultiplier()
+
+	score.updateAccuracy()
+
+	if len(beatMap.HitObjects) == 0 {
+		return
+	}
+
+	pauses := int64(0)
+	for _, p := range beatMap.Pauses {
+		pauses += int64(math.Round(p.GetEndTime())) - int64(math.Round(p.GetStartTime()))
+	}
+
+	drainTime := float32((int64(math.Round(beatMap.HitObjects[len(beatMap.HitObjects)-1].GetStartTime())) - int64(math.Round(beatMap.HitObjects[0].GetStartTime())) - pauses) / 1000)
+
+	density := mutils.Clamp(float32(len(beatMap.HitObjects))/drainTime*8, 0, 16)
+
+	score.difficultyMultiplier = int64(math.RoundToEven((float64(float32(beatMap.Diff.GetHP())) + float64(float32(beatMap.Diff.GetOD())) + float64(float32(beatMap.Diff.GetCS())) + float64(density)) / 38 * 5))
+}
+
+// addResult updates the score with a judgement and returns the score it gave. Hits get a combo bonus,
+// hits and drum roll ticks are worth 20% more in kiai and ticks of big drum rolls give 20% more.
+func (score *Score) addResult(result HitResult, big, kiai bool) int64 {
+	value := result.ScoreValue()
+
+	switch result {
+	case Great, Ok:
+		value = score.withComboBonus(value, kiai)
+	case DrumRollTick:
+		value = withKiai(value, kiai)
+
+		if big {
+			value += value / 5
+		}
+	}
+
+	switch result {
+	case Great:
+		score.CountGreat++
+	case Ok:
+		score.CountOk++
+	case Miss:
+		score.CountMiss++
+	case DrumRollTick:
+		score.DrumRollTicks++
+	case SwellTick:
+		score.SwellTicks++
+	}
+
+	if result == Miss {
+		score.Combo = 0
+	} else if result == Great || result == Ok {
+		score.Combo++
+		score.MaxCombo = max(score.MaxCombo, score.Combo)
+	}
+
+	score.Score += value
+
+	score.updateAccuracy()
+
+	return value
+}
+
+// addSwellBonus adds the bonus for completing a swell, it has a combo bonus but doesn't increase combo
+func (score *Score) addSwellBonus(kiai bool) {
+	score.Score += score.withComboBonus(Great.ScoreValue(), kiai)
+}
+
+// addStrongBonus adds the score given by a big hit once more, used when it was hit with both keys
+func (score *Score) addStrongBonus(value int64) {
+	score.Score += value
+}
+
+// withComboBonus adds stable's combo bonus to the value of a hit, the bonus grows every 10 combo up to 100 combo
+func (score *Score) withComboBonus(value int64, kiai bool) int64 {
+	value += int64(float64(value/35*2)*float64(score.difficultyMultiplier+1)*score.modMultiplier) * (min(100, score.Combo) / 10)
+
+	return withKiai(value, kiai)
+}
+
+func withKiai(value int64, kiai bool) int64 {
+	if kiai {
+		return int64(float32(value) * 1.2)
+	}
+
+	return value
+}
+
+func (score *Score) updateAccuracy() {
+	total := score.CountGreat + score.CountOk + score.CountMiss
+
+	score.Accuracy = 1
+
+	if total > 0 {
+		score.Accuracy = (float64(score.CountGreat) + float64(score.CountOk)*0.5) / float64(total)
+	}
+
+	hidden := score.mods.Active(difficulty.Hidden) || score.mods.Active(difficulty.Flashlight)
+
+	switch {
+	case score.Accuracy == 1:
+		score.Grade = osu.SS
+
+		if hidden {
+			score.Grade = osu.SSH
+		}
+	case score.Accuracy >= 0.95:
+		score.Grade = osu.S
+
+		if hidden {
+			score.Grade = osu.SH
+		}
+	case score.Accuracy >= 0.9:
+		score.Grade = osu.A
+	case score.Accuracy >= 0.8:
+		score.Grade = osu.B
+	case score.Accuracy >= 0.7:
+		score.Grade = osu.C
+	default:
+		score.Grade = osu.D
+	}
+}
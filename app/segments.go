@@ -0,0 +1,158 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/ffmpeg"
+	"github.com/wieku/danser-go/framework/goroutines"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// segmentPreRoll is how long (in ms) frames are drawn without being recorded before the start of a segment or a resumed recording,
+// so effects depending on previous frames (motion blur, cursor trails, particles) look the same as in a continuous recording
+const segmentPreRoll = 2000.0
+
+// segmentIndex is the 1-based index of the segment recorded by this process, 0 if the whole map is recorded
+var segmentIndex int
+var segmentCount int
+
+// getLogName returns the name of the log file. Flags are not parsed yet when logging starts, but child processes
+// of a segmented recording always get -segment=k as a separate argument, so they don't overwrite the parent's log.
+func getLogName() string {
+	for _, arg := range os.Args[1:] {
+		if index, ok := strings.CutPrefix(arg, "-segment="); ok {
+			return "danser-segment-" + index
+		}
+	}
+
+	return "danser"
+}
+
+// getSegmentRange returns the part of the timeline recorded by this process, relative to the start of recording
+func getSegmentRange(runningTime float64) (float64, float64) {
+	if segmentIndex == 0 {
+		return 0, runningTime
+	}
+
+	return runningTime * float64(segmentIndex-1) / float64(segmentCount), runningTime * float64(segmentIndex) / float64(segmentCount)
+}
+
+// renderSegments records the beatmap in parallel by splitting it into segments rendered by child danser processes.
+// Each child plays the map from the beginning to have the same state at the segment start, but draws only its part.
+func renderSegments(beatMap *beatmap.BeatMap, count int) {
+	if strings.TrimSpace(output) == "" {
+		output = "danser_" + time.Now().Format("2006-01-02_15-04-05")
+	}
+
+	ffmpeg.PrepareSegments(output)
+
+	executable, err := os.Executable()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to find danser executable: %s", err))
+	}
+
+	log.Println(fmt.Sprintf("Recording %d segments in parallel...", count))
+
+	startTime := time.Now()
+
+	commands := make([]*exec.Cmd, count)
+	outputs := make([]io.Reader, count)
+
+	for i := range commands {
+		// later flags override earlier ones, so the beatmap found here is used even if it was picked randomly
+		args := append(slices.Clone(os.Args[1:]),
+			"-out="+output,
+			"-id=-1",
+			"-md5="+beatMap.MD5,
+			"-nodbcheck",
+			"-noupdatecheck",
+			"-segment="+strconv.Itoa(i+1),
+		)
+
+		cmd := exec.Command(executable, args...)
+
+		stdout, err1 := cmd.StdoutPipe()
+		if err1 != nil {
+			panic(err1)
+		}
+
+		cmd.Stderr = cmd.Stdout
+
+		if err1 = cmd.Start(); err1 != nil {
+			killSegments(commands[:i])
+			panic(fmt.Sprintf("Failed to start segment %d: %s", i+1, err1))
+		}
+
+		commands[i] = cmd
+		outputs[i] = stdout
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+
+	failed := 0
+	var failErr error
+
+	for i, cmd := range commands {
+		wg.Add(1)
+
+		goroutines.Run(func() {
+			defer wg.Done()
+
+			forwardSegmentLog(i+1, outputs[i], &mutex)
+
+			if err1 := cmd.Wait(); err1 != nil {
+				mutex.Lock()
+
+				// other segments are killed after the first failure, their errors are not important
+				if failed == 0 {
+					failed = i + 1
+					failErr = err1
+
+					killSegments(commands)
+				}
+
+				mutex.Unlock()
+			}
+		})
+	}
+
+	wg.Wait()
+
+	if failed > 0 {
+		panic(fmt.Sprintf("Segment %d failed: %s. Check danser-segment-%d.log for details", failed, failErr, failed))
+	}
+
+	log.Println(fmt.Sprintf("All segments finished in %s, joining...", time.Since(startTime).Round(time.Second)))
+
+	ffmpeg.ConcatSegments(count)
+}
+
+// forwardSegmentLog writes child's output to the log, lines are prefixed with segment number
+func forwardSegmentLog(index int, r io.Reader, mutex *sync.Mutex) {
+	prefix := fmt.Sprintf("[Segment %d] ", index)
+
+	sc := bufio.NewScanner(r)
+
+	for sc.Scan() {
+		mutex.Lock()
+		_, _ = io.WriteString(log.Writer(), prefix+sc.Text()+"\n")
+		mutex.Unlock()
+	}
+}
+
+// killSegments stops all segments, a failed one makes the recording useless. Killing already finished ones has no effect.
+func killSegments(commands []*exec.Cmd) {
+	for _, cmd := range commands {
+		_ = cmd.Process.Kill()
+	}
+}
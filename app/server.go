@@ -0,0 +1,597 @@
+package app
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/env"
+	"github.com/wieku/danser-go/framework/goroutines"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Render server exposes a local HTTP API for recording replays:
+//
+//	POST /jobs              - queues a job. Multipart form with optional "replay" file and "md5", "id", "mods", "settings", "sPatch", "skin" fields
+//	GET  /jobs              - lists all jobs
+//	GET  /jobs/{id}         - returns job's status
+//	GET  /jobs/{id}/events  - streams job's status as server-sent events until it finishes
+//	GET  /jobs/{id}/result  - downloads the recorded video, or a zip archive of all result files if there are more of them
+//	                          (separately recorded layers, image sequences, audio stems saved as files)
+//
+// Jobs are recorded one after another by the jobRunner.
+//
+// The API has no authentication, so requests are restricted: "settings" has to name an existing settings file, "skin" an
+// installed skin and "sPatch" may only change sections listed in patchableSections, without fields pointing to files.
+
+const (
+	maxQueuedJobs   = 1000
+	maxFinishedJobs = 100
+	maxUploadSize   = 32 << 20
+)
+
+// patchableSections are settings sections which clients can change with "sPatch". Sections like General, Recording
+// or Debug are left out, as they control paths and encoder options.
+var patchableSections = []string{"Audio", "Gameplay", "Skin", "Cursor", "Objects", "Playfield", "CursorDance", "Knockout"}
+
+type jobStatus string
+
+const (
+	jobQueued   = jobStatus("queued")
+	jobRunning  = jobStatus("running")
+	jobFinished = jobStatus("finished")
+	jobFailed   = jobStatus("failed")
+)
+
+type jobState struct {
+	ID     string    `json:"id"`
+	Status jobStatus `json:"status"`
+
+	// Position is a place in the queue, 1 means that the job will be recorded next
+	Position int `json:"position,omitempty"`
+
+	Progress int     `json:"progress"`
+	Speed    float64 `json:"speed"`
+	ETA      int     `json:"eta"`
+
+	Error string `json:"error,omitempty"`
+
+	Submitted time.Time `json:"submitted"`
+}
+
+type serverJob struct {
+	state  jobState
+	job    *renderJob
+	output string
+	files  []string
+
+	// changed is closed and replaced on every state change
+	changed chan struct{}
+}
+
+type renderServer struct {
+	addr      string
+	defaults  *renderJob
+	uploadDir string
+
+	mutex sync.Mutex
+	jobs  map[string]*serverJob
+	order []*serverJob
+	queue chan *serverJob
+}
+
+var server *renderServer
+
+func newRenderServer(addr string, defaults *renderJob) *renderServer {
+	return &renderServer{
+		addr:      addr,
+		defaults:  defaults,
+		uploadDir: filepath.Join(env.DataDir(), "server"),
+		jobs:      make(map[string]*serverJob),
+		queue:     make(chan *serverJob, maxQueuedJobs),
+	}
+}
+
+func (server *renderServer) start() {
+	if err := os.MkdirAll(server.uploadDir, 0755); err != nil {
+		panic(err)
+	}
+
+	listener, err := net.Listen("tcp", server.addr)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to start render server: %s", err))
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /jobs", server.submit)
+	mux.HandleFunc("GET /jobs", server.list)
+	mux.HandleFunc("GET /jobs/{id}", server.status)
+	mux.HandleFunc("GET /jobs/{id}/events", server.events)
+	mux.HandleFunc("GET /jobs/{id}/result", server.result)
+
+	goroutines.Run(func() {
+		if err := http.Serve(listener, mux); err != nil {
+			log.Println("RenderServer: Server stopped:", err)
+		}
+	})
+
+	log.Println("RenderServer: Listening on", "http://"+listener.Addr().String())
+
+	if addr, ok := listener.Addr().(*net.TCPAddr); ok && !addr.IP.IsLoopback() {
+		log.Println("RenderServer: WARNING! Server is reachable from other machines and has no authentication, anyone on the network can queue recordings. Bind to 127.0.0.1 unless it's behind a trusted proxy or firewall.")
+	}
+}
+
+// process records queued jobs, never returns
+func (server *renderServer) process(runner *jobRunner) {
+	for sJob := range server.queue {
+		log.Println("RenderServer: Starting job", sJob.state.ID)
+
+		server.update(sJob, func(sJob *serverJob) {
+			sJob.state.Status = jobRunning
+		})
+
+		progressListener = func(progress int, speed float64, eta int) {
+			server.update(sJob, func(sJob *serverJob) {
+				sJob.state.Progress = progress
+				sJob.state.Speed = speed
+				sJob.state.ETA = eta
+			})
+		}
+
+		result := runner.run(sJob.job)
+
+		progressListener = nil
+
+		if sJob.job.Replay != "" {
+			_ = os.Remove(sJob.job.Replay)
+		}
+
+		server.update(sJob, func(sJob *serverJob) {
+			if result.err != nil {
+				sJob.state.Status = jobFailed
+				sJob.state.Error = result.err.Error()
+			} else {
+				sJob.state.Status = jobFinished
+				sJob.state.Progress = 100
+				sJob.state.ETA = 0
+				sJob.output = result.output
+				sJob.files = result.files
+			}
+
+			server.pruneJobs()
+		})
+
+		if result.err != nil {
+			log.Println("RenderServer: Job", sJob.state.ID, "failed:", result.err)
+		} else {
+			log.Println("RenderServer: Job", sJob.state.ID, "finished:", result.output)
+		}
+	}
+}
+
+func (server *renderServer) update(sJob *serverJob, f func(sJob *serverJob)) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	f(sJob)
+
+	close(sJob.changed)
+	sJob.changed = make(chan struct{})
+}
+
+// pruneJobs forgets the oldest finished and failed jobs above maxFinishedJobs, has to be called with locked mutex
+func (server *renderServer) pruneJobs() {
+	done := 0
+
+	for _, sJob := range server.order {
+		if sJob.state.Status == jobFinished || sJob.state.Status == jobFailed {
+			done++
+		}
+	}
+
+	if done <= maxFinishedJobs {
+		return
+	}
+
+	server.order = slices.DeleteFunc(server.order, func(sJob *serverJob) bool {
+		if done > maxFinishedJobs && (sJob.state.Status == jobFinished || sJob.state.Status == jobFailed) {
+			delete(server.jobs, sJob.state.ID)
+			done--
+
+			return true
+		}
+
+		return false
+	})
+}
+
+// getState returns a copy of job's state, has to be called with locked mutex
+func (server *renderServer) getState(sJob *serverJob) jobState {
+	state := sJob.state
+
+	if state.Status == jobQueued {
+		for _, j := range server.order {
+			if j.state.Status == jobQueued {
+				state.Position++
+			}
+
+			if j == sJob {
+				break
+			}
+		}
+	}
+
+	return state
+}
+
+func (server *renderServer) getJob(id string) (state jobState, sJob *serverJob, changed chan struct{}) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	sJob = server.jobs[id]
+	if sJob == nil {
+		return
+	}
+
+	return server.getState(sJob), sJob, sJob.changed
+}
+
+func (server *renderServer) submit(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+
+	if err := r.ParseMultipartForm(maxUploadSize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse the form: %s", err))
+		return
+	}
+
+	job := &renderJob{
+		MD5:      r.FormValue("md5"),
+		Settings: r.FormValue("settings"),
+		SPatch:   r.FormValue("sPatch"),
+		Mods:     r.FormValue("mods"),
+		Skin:     r.FormValue("skin"),
+	}
+
+	if err := checkRemoteJob(job); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if idS := r.FormValue("id"); idS != "" {
+		var err error
+
+		if job.ID, err = strconv.ParseInt(idS, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid beatmap id")
+			return
+		}
+	}
+
+	id := newJobID()
+
+	job.Out = "danser_" + id
+
+	if file, _, err := r.FormFile("replay"); err == nil {
+		defer file.Close()
+
+		job.Replay = filepath.Join(server.uploadDir, id+".osr")
+
+		if err = saveUpload(job.Replay, file); err != nil {
+			_ = os.Remove(job.Replay)
+
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to save the replay: %s", err))
+			return
+		}
+	} else if !errors.Is(err, http.ErrMissingFile) && !errors.Is(err, http.ErrNotMultipart) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Failed to read the replay: %s", err))
+		return
+	}
+
+	job.fillDefaults(server.defaults)
+
+	if err := job.validate(); err != nil {
+		if job.Replay != "" {
+			_ = os.Remove(job.Replay)
+		}
+
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sJob := &serverJob{
+		state: jobState{
+			ID:        id,
+			Status:    jobQueued,
+			Submitted: time.Now(),
+		},
+		job:     job,
+		changed: make(chan struct{}),
+	}
+
+	server.mutex.Lock()
+
+	if len(server.queue) == cap(server.queue) {
+		server.mutex.Unlock()
+
+		if job.Replay != "" {
+			_ = os.Remove(job.Replay)
+		}
+
+		writeError(w, http.StatusServiceUnavailable, "Queue is full")
+		return
+	}
+
+	server.jobs[id] = sJob
+	server.order = append(server.order, sJob)
+	server.queue <- sJob
+
+	state := server.getState(sJob)
+
+	server.mutex.Unlock()
+
+	log.Println("RenderServer: Queued job", id+":", job.getName())
+
+	writeJSON(w, http.StatusAccepted, state)
+}
+
+func (server *renderServer) list(w http.ResponseWriter, _ *http.Request) {
+	server.mutex.Lock()
+
+	states := make([]jobState, 0, len(server.order))
+
+	for _, sJob := range server.order {
+		states = append(states, server.getState(sJob))
+	}
+
+	server.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, states)
+}
+
+func (server *renderServer) status(w http.ResponseWriter, r *http.Request) {
+	state, sJob, _ := server.getJob(r.PathValue("id"))
+	if sJob == nil {
+		writeError(w, http.StatusNotFound, "Job not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, state)
+}
+
+func (server *renderServer) events(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	state, sJob, changed := server.getJob(id)
+	if sJob == nil {
+		writeError(w, http.StatusNotFound, "Job not found")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	for {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return
+		}
+
+		if _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", state.Status, data); err != nil {
+			return
+		}
+
+		flusher.Flush()
+
+		if state.Status == jobFinished || state.Status == jobFailed {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+
+		state, _, changed = server.getJob(id)
+	}
+}
+
+func (server *renderServer) result(w http.ResponseWriter, r *http.Request) {
+	state, sJob, _ := server.getJob(r.PathValue("id"))
+	if sJob == nil {
+		writeError(w, http.StatusNotFound, "Job not found")
+		return
+	}
+
+	if state.Status != jobFinished {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Job is %s", state.Status))
+		return
+	}
+
+	server.mutex.Lock()
+	output, files := sJob.output, sJob.files
+	server.mutex.Unlock()
+
+	if len(files) != 1 {
+		serveArchive(w, output, files)
+		return
+	}
+
+	file, err := os.Open(files[0])
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Result not found")
+		return
+	}
+
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil || !stat.Mode().IsRegular() {
+		writeError(w, http.StatusNotFound, "Result not found")
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", stat.Name()))
+
+	http.ServeContent(w, r, stat.Name(), stat.ModTime(), file)
+}
+
+// serveArchive sends result files as a zip archive, paths in it are relative to the directory of output. Files are stored
+// without compression, as videos and images are compressed already.
+func serveArchive(w http.ResponseWriter, output string, files []string) {
+	if len(files) == 0 {
+		writeError(w, http.StatusNotFound, "Result not found")
+		return
+	}
+
+	baseDir := filepath.Dir(output)
+
+	// output is a directory if an image sequence was recorded, its name has no extension
+	name := filepath.Base(output)
+	if stat, err := os.Stat(output); err == nil && !stat.IsDir() {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	name += ".zip"
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+
+	archive := zip.NewWriter(w)
+
+	for _, path := range files {
+		if err := addToArchive(archive, baseDir, path); err != nil {
+			// headers are already sent, the client gets a truncated archive
+			log.Println("RenderServer: Failed to send results:", err)
+			return
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		log.Println("RenderServer: Failed to send results:", err)
+	}
+}
+
+func addToArchive(archive *zip.Writer, baseDir, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if !stat.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a file", path)
+	}
+
+	header, err := zip.FileInfoHeader(stat)
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(baseDir, path)
+	if err != nil {
+		return err
+	}
+
+	header.Name = filepath.ToSlash(rel)
+	header.Method = zip.Store
+
+	writer, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(writer, file)
+
+	return err
+}
+
+// checkRemoteJob rejects fields sent by clients which could touch files outside danser's control,
+// server defaults are trusted and are checked only by renderJob.validate
+func checkRemoteJob(job *renderJob) error {
+	// clients can pick only settings placed directly in the settings directory
+	if strings.ContainsAny(job.Settings, `/\`) || job.Settings == "." || job.Settings == ".." {
+		return fmt.Errorf("settings \"%s\" can't contain a path", job.Settings)
+	}
+
+	if strings.TrimSpace(job.SPatch) != "" {
+		if err := settings.CheckPatch(job.SPatch, patchableSections); err != nil {
+			return err
+		}
+	}
+
+	if job.Skin != "" {
+		var skins []string
+
+		goroutines.CallMain(func() {
+			skins = settings.DefaultsFactory.SkinOptions()
+		})
+
+		if !slices.Contains(skins, job.Skin) {
+			return fmt.Errorf("skin \"%s\" doesn't exist", job.Skin)
+		}
+	}
+
+	return nil
+}
+
+func newJobID() string {
+	data := make([]byte, 8)
+
+	if _, err := rand.Read(data); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+
+	return hex.EncodeToString(data)
+}
+
+func saveUpload(path string, src io.Reader) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	_, err = io.Copy(file, src)
+
+	return err
+}
+
+func writeJSON(w http.ResponseWriter, code int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"error": message})
+}
@@ -243,6 +243,8 @@ func initGameplay() *gameplay {
 		IgnoreFailsInReplays:    false,
 		PPVersion:               "latest",
 		LazerClassicScore:       false,
+		SavePlayReplays:         true,
+		SaveDanserReplays:       false,
 	}
 }
 
@@ -273,6 +275,8 @@ type gameplay struct {
 	IgnoreFailsInReplays    bool
 	PPVersion               string `liveedit:"false" label:"PP counter version" combo:"211112|2021 pp rework (First Xexxar),220930|2022 pp rework,241007|2024 pp rework,latest|2025 Q1 update (latest)"`
 	LazerClassicScore       bool   `label:"Use \"Classic\" score for osu!lazer plays"`
+	SavePlayReplays         bool   `label:"Save replays of -play sessions" liveedit:"false"`
+	SaveDanserReplays       bool   `label:"Save replays of cursordance/autoplay runs" liveedit:"false"`
 }
 
 type boundaries struct {
@@ -375,7 +379,7 @@ type hitCounter struct {
 
 type scoreBoard struct {
 	*hudElementOffset
-	Mode           string `combo:"Normal,Country,Friends" tooltip:"Country and Friends modes require osu!supporter and Authorization Code API Mode!"`
+	Mode           string `combo:"Normal,Country,Friends,Local" tooltip:"Country and Friends modes require osu!supporter and Authorization Code API Mode!\nLocal mode shows scores from danser's local score history"`
 	ModsOnly       bool   `label:"Show mod leaderboard"`
 	AlignRight     bool   `label:"Align to the right" label:"Simulates the second team of osu! multiplayer"`
 	HideOthers     bool
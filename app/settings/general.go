@@ -27,6 +27,12 @@ type general struct {
 	// Directory that contains osu! skins
 	OsuSkinsDir string `long:"true" label:"osu! Skins directory" path:"Select osu! Skins directory"`
 
+	// Whether beatmaps, skins and replays should be loaded from osu!lazer's file store. Experimental, client.realm has to be exported with tools/lazer-export first.
+	ExperimentalLazerImport bool `label:"Import osu!lazer library (experimental)" tooltip:"Beatmaps, skins and replays are loaded from osu!lazer's file store without copying them.\nclient.realm can't be read directly, it has to be exported to client.json with tools/lazer-export after each change of lazer's library."`
+
+	// Directory that contains osu!lazer's data (files directory and client.json snapshot of client.realm)
+	OsuLazerDir string `long:"true" label:"osu!lazer data directory" path:"Select osu!lazer data directory" showif:"ExperimentalLazerImport=true" tooltip:"Directory containing client.realm, client.json and files directory"`
+
 	// Directory that contains osu! replays
 	OsuReplaysDir string `long:"true" label:"osu! Replays directory" path:"Select osu! Replays directory" tooltip:"Don't use replays directory inside danser's directory!"`
 
@@ -36,11 +42,15 @@ type general struct {
 	// Whether danser should unpack .osz files in Songs folder, osu! may complain about it
 	UnpackOszFiles bool
 
+	// Whether beatmap metadata and collections should be read from osu!.db and collection.db located next to osu! Songs directory
+	ImportOsuDatabase bool `label:"Import osu!.db and collection.db" tooltip:"Speeds up the import of new beatmaps and makes osu! collections available in song select. Files are read from the parent directory of osu! Songs directory"`
+
 	// Whether import details should be shown. If false, only failures will be logged.
 	VerboseImportLogs bool
 
 	songsDir   *string
 	skinsDir   *string
+	lazerDir   *string
 	replaysDir *string
 }
 
@@ -72,6 +82,25 @@ func (g *general) GetSkinsDir() string {
 	return *g.skinsDir
 }
 
+// GetLazerDir returns absolute path to osu!lazer's data directory or empty string if lazer import is disabled
+func (g *general) GetLazerDir() string {
+	if g.lazerDir == nil {
+		dir := ""
+
+		if g.ExperimentalLazerImport && g.OsuLazerDir != "" {
+			dir = filepath.Join(env.DataDir(), g.OsuLazerDir)
+
+			if filepath.IsAbs(g.OsuLazerDir) {
+				dir = g.OsuLazerDir
+			}
+		}
+
+		g.lazerDir = &dir
+	}
+
+	return *g.lazerDir
+}
+
 func (g *general) GetReplaysDir() string {
 	if g.replaysDir == nil {
 		dir := filepath.Join(env.DataDir(), g.OsuReplaysDir)
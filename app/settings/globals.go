@@ -9,12 +9,14 @@ var START = 0.0
 var END = math.Inf(1)
 var KNOCKOUT = false
 var KNOCKOUTREPLAYS []string = nil
+var TAIKO = false
 var PLAYERS = 1
 var DIVIDES = 1
 var SPEED = 1.0
 var PITCH = 1.0
 var TAG = 1
 var RECORD = false
+var HEADLESS = false
 var REPLAY = ""
 var LOCALOFFSET = 0
 var PerfGraph = false
@@ -9,6 +9,12 @@ func initInput() *input {
 		RestartKey:           "`",
 		SmokeKey:             "C",
 		ScreenshotKey:        "F2",
+		PauseKey:             "P",
+		SeekBackwardKey:      "LEFT",
+		SeekForwardKey:       "RIGHT",
+		FrameBackwardKey:     ",",
+		FrameForwardKey:      ".",
+		SeekStep:             5000,
 		MouseButtonsDisabled: true,
 		MouseHighPrecision:   false,
 		MouseSensitivity:     1,
@@ -21,6 +27,12 @@ type input struct {
 	RestartKey           string  `key:"true"`
 	SmokeKey             string  `key:"true"`
 	ScreenshotKey        string  `key:"true"`
+	PauseKey             string  `key:"true" tooltip:"Pauses or resumes replay playback"`
+	SeekBackwardKey      string  `key:"true"`
+	SeekForwardKey       string  `key:"true"`
+	FrameBackwardKey     string  `key:"true" tooltip:"Steps one frame back while paused"`
+	FrameForwardKey      string  `key:"true" tooltip:"Steps one frame forward while paused"`
+	SeekStep             float64 `label:"Seek step" min:"1000" max:"30000" format:"%.0fms"`
 	MouseButtonsDisabled bool    `label:"Disable mouse buttons"`
 	MouseHighPrecision   bool    `label:"Mouse raw input"`
 	MouseSensitivity     float64 `label:"Raw input sensitivity" min:"0.4" max:"6"`
@@ -10,6 +10,8 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"time"
 )
@@ -194,3 +196,128 @@ func migrateSettings() {
 		}
 	}
 }
+
+// Exists returns whether a settings file with given name exists, empty name means default settings which are always available.
+// Names can point to subdirectories (e.g. b/abc), but not outside the settings directory.
+func Exists(name string) bool {
+	if name == "" {
+		return true
+	}
+
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return false
+	}
+
+	stat, err := os.Stat(filepath.Join(env.ConfigDir(), name+".json"))
+
+	return err == nil && !stat.IsDir()
+}
+
+// CheckPatch returns an error if the patch changes something outside given top-level sections, or a field that points to local
+// files or directories (path, file and comboSrc tags), so patches from untrusted sources can't read or write arbitrary files.
+// Section names are matched case-insensitively like json.Unmarshal does.
+func CheckPatch(patch string, sections []string) error {
+	var values map[string]json.RawMessage
+
+	if err := json.Unmarshal([]byte(patch), &values); err != nil {
+		return fmt.Errorf("settings patch has to be a JSON object: %s", err)
+	}
+
+	configType := reflect.TypeOf(Config{})
+
+	for key, value := range values {
+		if !slices.ContainsFunc(sections, func(s string) bool { return strings.EqualFold(s, key) }) {
+			return fmt.Errorf("settings section \"%s\" can't be patched", key)
+		}
+
+		field, ok := findJSONField(configType, key)
+		if !ok {
+			continue
+		}
+
+		if err := checkPatchValue(field.Type, value, field.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkPatchValue(fType reflect.Type, value json.RawMessage, path string) error {
+	for fType.Kind() == reflect.Pointer {
+		fType = fType.Elem()
+	}
+
+	switch fType.Kind() {
+	case reflect.Struct:
+		var values map[string]json.RawMessage
+		if json.Unmarshal(value, &values) != nil {
+			return nil // not an object, Unmarshal will fail later
+		}
+
+		for key, fValue := range values {
+			field, ok := findJSONField(fType, key)
+			if !ok {
+				continue
+			}
+
+			fPath := path + "." + field.Name
+
+			if _, ok = field.Tag.Lookup("path"); ok {
+				return fmt.Errorf("settings field \"%s\" can't be patched", fPath)
+			}
+
+			if _, ok = field.Tag.Lookup("file"); ok {
+				return fmt.Errorf("settings field \"%s\" can't be patched", fPath)
+			}
+
+			if _, ok = field.Tag.Lookup("comboSrc"); ok {
+				return fmt.Errorf("settings field \"%s\" can't be patched", fPath)
+			}
+
+			if err := checkPatchValue(field.Type, fValue, fPath); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		var values []json.RawMessage
+		if json.Unmarshal(value, &values) != nil {
+			return nil
+		}
+
+		for i, v := range values {
+			if err := checkPatchValue(fType.Elem(), v, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// findJSONField finds exported field of the struct which would be set by given JSON key
+func findJSONField(sType reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < sType.NumField(); i++ {
+		field := sType.Field(i)
+
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			if tagName, _, _ := strings.Cut(tag, ","); tagName == "-" {
+				continue
+			} else if tagName != "" {
+				name = tagName
+			}
+		}
+
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
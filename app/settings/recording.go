@@ -13,6 +13,11 @@ import (
 
 var Recording = initRecording()
 
+// alphaEncoders can store transparency, they get RGBA frames when Transparent is enabled
+var alphaEncoders = []string{"prores_ks", "libvpx-vp9", "png", "exr"}
+
+var imageEncoders = []string{"png", "exr"}
+
 func initRecording() *recording {
 	return &recording{
 		FrameWidth:     1920,
@@ -103,12 +108,34 @@ func initRecording() *recording {
 			Preset:            "high_quality",
 			AdditionalOptions: "",
 		},
+		ProResSettings: &proresSettings{
+			Profile:           "4444",
+			AdditionalOptions: "",
+		},
+		VP9Settings: &vp9Settings{
+			RateControl:       "crf",
+			Bitrate:           "10M",
+			CRF:               24,
+			Speed:             2,
+			AdditionalOptions: "",
+		},
+		PNGSettings: &pngSettings{
+			CompressionLevel:  3,
+			AdditionalOptions: "",
+		},
+		EXRSettings: &exrSettings{
+			Compression:       "zip16",
+			Precision:         "half",
+			AdditionalOptions: "",
+		},
 		CustomSettings: &custom{
 			CustomOptions: "",
 		},
-		PixelFormat: "yuv420p",
-		Filters:     "",
-		AudioCodec:  "aac",
+		Transparent:    false,
+		SeparateLayers: false,
+		PixelFormat:    "yuv420p",
+		Filters:        "",
+		AudioCodec:     "aac",
 		AACSettings: &aacSettings{
 			Bitrate:           "192k",
 			AdditionalOptions: "",
@@ -130,10 +157,12 @@ func initRecording() *recording {
 		CustomAudioSettings: &custom{
 			CustomOptions: "",
 		},
-		AudioFilters:   "",
-		OutputDir:      "videos",
-		Container:      "mp4",
-		ShowFFmpegLogs: true,
+		AudioFilters:     "",
+		AudioStems:       "none",
+		OutputDir:        "videos",
+		Container:        "mp4",
+		ShowFFmpegLogs:   true,
+		ExportJudgements: "none",
 		MotionBlur: &motionblur{
 			Enabled:              false,
 			OversampleMultiplier: 16,
@@ -150,7 +179,7 @@ type recording struct {
 	FrameHeight         int                `min:"1" max:"17280"`
 	FPS                 int                `label:"FPS (PLEASE READ TOOLTIP)" string:"true" min:"1" max:"10727" tooltip:"IMPORTANT: If you plan to have a \"high fps\" video, use Motion Blur below instead of setting FPS to absurd numbers. Setting the value too high will result in a broken video!"`
 	EncodingFPSCap      int                `string:"true" min:"0" max:"10727" label:"Max Encoding FPS (Speed)" tooltip:"Limits the speed at which danser renders the video. If FPS is set to 60 and this option to 30, then it means 2 minute map will take at least 4 minutes to render"`
-	Encoder             string             `combo:"libx264|Software x264 (AVC),libx265|Software x265 (HEVC),libsvtav1|Software AV1,h264_nvenc|NVIDIA NVENC H.264 (AVC),hevc_nvenc|NVIDIA NVENC H.265 (HEVC),av1_nvenc|NVIDIA NVENC AV1,h264_qsv|Intel QuickSync H.264 (AVC),hevc_qsv|Intel QuickSync H.265 (HEVC),h264_amf|AMD AMF H.264 (AVC),hevc_amf|AMD AMF H.265 (HEVC),av1_amf|AMD AMF AV1" comboSrc:"EncoderOptions"`
+	Encoder             string             `combo:"libx264|Software x264 (AVC),libx265|Software x265 (HEVC),libsvtav1|Software AV1,h264_nvenc|NVIDIA NVENC H.264 (AVC),hevc_nvenc|NVIDIA NVENC H.265 (HEVC),av1_nvenc|NVIDIA NVENC AV1,h264_qsv|Intel QuickSync H.264 (AVC),hevc_qsv|Intel QuickSync H.265 (HEVC),h264_amf|AMD AMF H.264 (AVC),hevc_amf|AMD AMF H.265 (HEVC),av1_amf|AMD AMF AV1,prores_ks|Apple ProRes,libvpx-vp9|Software VP9,png|PNG Image Sequence,exr|OpenEXR Image Sequence" comboSrc:"EncoderOptions"`
 	X264Settings        *x264Settings      `json:"libx264" label:"Software x264 (AVC) Settings" showif:"Encoder=libx264"`
 	X265Settings        *x265Settings      `json:"libx265" label:"Software x265 (HEVC) Settings" showif:"Encoder=libx265"`
 	AV1Settings         *av1Settings       `json:"libsvtav1" label:"Software AV1 Settings" showif:"Encoder=libsvtav1"`
@@ -162,8 +191,14 @@ type recording struct {
 	H264AmfSettings     *h264AmfSettings   `json:"h264_amf" label:"AMD AMF H.264 (AVC) Settings" showif:"Encoder=h264_amf"`
 	HEVCAmfSettings     *hevcAmfSettings   `json:"hevc_amf" label:"AMD AMF H.265 (HEVC) Settings" showif:"Encoder=hevc_amf"`
 	AV1AmfSettings      *av1AmfSettings    `json:"av1_amf" label:"AMD AMF AV1 Settings" showif:"Encoder=av1_amf"`
+	ProResSettings      *proresSettings    `json:"prores_ks" label:"Apple ProRes Settings" showif:"Encoder=prores_ks"`
+	VP9Settings         *vp9Settings       `json:"libvpx-vp9" label:"Software VP9 Settings" showif:"Encoder=libvpx-vp9"`
+	PNGSettings         *pngSettings       `json:"png" label:"PNG Image Sequence Settings" showif:"Encoder=png"`
+	EXRSettings         *exrSettings       `json:"exr" label:"OpenEXR Image Sequence Settings" showif:"Encoder=exr"`
 	CustomSettings      *custom            `json:"custom" label:"Custom Encoder Settings" showif:"Encoder=!"`
-	PixelFormat         string             `combo:"yuv420p|I420,yuv444p|I444,nv12|NV12" showif:"Encoder=!h264_qsv,!hevc_qsv,!libsvtav1"`
+	Transparent         bool               `label:"Transparent Background" showif:"Encoder=prores_ks,libvpx-vp9,png,exr" tooltip:"Records only the playfield and HUD with an alpha channel, without background, dim and blur.\nProRes needs a 4444 profile, VP9 needs webm or mkv container"`
+	SeparateLayers      bool               `showif:"Encoder=prores_ks,libvpx-vp9,png,exr" tooltip:"Records background with storyboard, playfield with cursors, and HUD to separate files with an alpha channel and the same audio.\nBloom is not applied"`
+	PixelFormat         string             `combo:"yuv420p|I420,yuv444p|I444,nv12|NV12" showif:"Encoder=!h264_qsv,!hevc_qsv,!libsvtav1,!prores_ks,!libvpx-vp9,!png,!exr"`
 	Filters             string             `label:"FFmpeg Video Filters"`
 	AudioCodec          string             `combo:"aac|AAC,libmp3lame|MP3,libopus|OPUS,flac|FLAC"`
 	AACSettings         *aacSettings       `json:"aac" label:"AAC Settings" showif:"AudioCodec=aac"`
@@ -172,11 +207,13 @@ type recording struct {
 	FLACSettings        *flacSettings      `json:"flac" label:"FLAC Settings" showif:"AudioCodec=flac"`
 	CustomAudioSettings *custom            `json:"customAudio" label:"Custom Audio Settings" showif:"AudioCodec=!"`
 	//AudioOptions        string             `label:"Audio Encoder Options"`
-	AudioFilters   string `label:"FFmpeg Audio Filters"`
-	OutputDir      string `path:"Select video output directory"`
-	Container      string `combo:"mp4,mkv"`
-	ShowFFmpegLogs bool
-	MotionBlur     *motionblur
+	AudioFilters     string `label:"FFmpeg Audio Filters"`
+	AudioStems       string `combo:"none|Disabled,tracks|Extra audio tracks,wav|Separate WAV files,flac|Separate FLAC files" tooltip:"Additionally saves music, hitsounds, storyboard samples and UI sounds separately, so they can be rebalanced after rendering.\nExtra audio tracks are not available for image sequences"`
+	OutputDir        string `path:"Select video output directory"`
+	Container        string `combo:"mp4,mkv,mov,webm" showif:"Encoder=!png,!exr"`
+	ShowFFmpegLogs   bool
+	ExportJudgements string `combo:"none|Disabled,csv|CSV,json|JSON" tooltip:"Saves every judgement and click processed by the ruleset next to the video"`
+	MotionBlur       *motionblur
 
 	outDir *string
 }
@@ -205,6 +242,14 @@ func (g *recording) GetEncoderOptions() EncoderOptions {
 		return g.HEVCAmfSettings
 	case "av1_amf":
 		return g.AV1AmfSettings
+	case "prores_ks":
+		return g.ProResSettings
+	case "libvpx-vp9":
+		return g.VP9Settings
+	case "png":
+		return g.PNGSettings
+	case "exr":
+		return g.EXRSettings
 	default:
 		return g.CustomSettings
 	}
@@ -225,6 +270,26 @@ func (g *recording) GetAudioOptions() EncoderOptions {
 	}
 }
 
+// IsTransparent returns whether frames are recorded with an alpha channel. Background is left out unless layers are separate.
+func (g *recording) IsTransparent() bool {
+	return (g.Transparent || g.SeparateLayers) && slices.Contains(alphaEncoders, strings.ToLower(g.Encoder))
+}
+
+// IsLayered returns whether background, playfield and HUD are recorded to separate files
+func (g *recording) IsLayered() bool {
+	return g.SeparateLayers && slices.Contains(alphaEncoders, strings.ToLower(g.Encoder))
+}
+
+// HasAudioStems returns whether music, hitsounds, storyboard samples and UI sounds are saved separately besides the mix
+func (g *recording) HasAudioStems() bool {
+	return g.AudioStems == "tracks" || g.AudioStems == "wav" || g.AudioStems == "flac"
+}
+
+// IsImageSequence returns whether frames are saved as separate images instead of a video
+func (g *recording) IsImageSequence() bool {
+	return slices.Contains(imageEncoders, strings.ToLower(g.Encoder))
+}
+
 func (g *recording) GetOutputDir() string {
 	if g.outDir == nil {
 		dir := filepath.Join(env.DataDir(), g.OutputDir)
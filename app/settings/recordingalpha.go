@@ -0,0 +1,125 @@
+package settings
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var proresProfiles = []string{
+	"proxy",
+	"lt",
+	"standard",
+	"hq",
+	"4444",
+	"4444xq",
+}
+
+var exrCompressions = []string{
+	"none",
+	"rle",
+	"zip1",
+	"zip16",
+}
+
+var exrPrecisions = []string{
+	"half",
+	"float",
+}
+
+type proresSettings struct {
+	Profile           string `combo:"proxy|Proxy,lt|LT,standard|Standard,hq|HQ,4444|4444,4444xq|4444 XQ" tooltip:"Only 4444 profiles can store transparency"`
+	AdditionalOptions string
+}
+
+func (s *proresSettings) GenerateFFmpegArgs() (ret []string, err error) {
+	if !slices.Contains(proresProfiles, s.Profile) {
+		return nil, fmt.Errorf("invalid profile: %s", s.Profile)
+	}
+
+	ret = append(ret, "-profile:v", s.Profile, "-vendor", "apl0")
+
+	ret = parseCustomOptions(ret, s.AdditionalOptions)
+
+	return
+}
+
+// Is444 returns whether the profile uses 4:4:4 chroma and can carry an alpha channel
+func (s *proresSettings) Is444() bool {
+	return strings.HasPrefix(s.Profile, "4444")
+}
+
+type vp9Settings struct {
+	RateControl       string `combo:"crf|Constant Rate Factor (CRF),vbr|VBR,cbr|CBR"`
+	Bitrate           string `showif:"RateControl=vbr,cbr"`
+	CRF               int    `string:"true" min:"0" max:"63" showif:"RateControl=crf"`
+	Speed             int    `combo:"0|0 (slowest),1,2,3,4,5|5 (fastest)"`
+	AdditionalOptions string
+}
+
+func (s *vp9Settings) GenerateFFmpegArgs() (ret []string, err error) {
+	switch strings.ToLower(s.RateControl) {
+	case "vbr":
+		ret = append(ret, "-b:v", s.Bitrate)
+	case "cbr":
+		ret = append(ret, "-b:v", s.Bitrate, "-minrate", s.Bitrate, "-maxrate", s.Bitrate)
+	case "crf":
+		if s.CRF < 0 || s.CRF > 63 {
+			return nil, fmt.Errorf("CRF parameter out of range [0-63]")
+		}
+
+		ret = append(ret, "-crf", strconv.Itoa(s.CRF), "-b:v", "0")
+	default:
+		return nil, fmt.Errorf("invalid rate control value: %s", s.RateControl)
+	}
+
+	if s.Speed < 0 || s.Speed > 5 {
+		return nil, fmt.Errorf("Speed out of range [0-5]")
+	}
+
+	ret = append(ret, "-deadline", "good", "-cpu-used", strconv.Itoa(s.Speed), "-row-mt", "1")
+
+	ret = parseCustomOptions(ret, s.AdditionalOptions)
+
+	return
+}
+
+type pngSettings struct {
+	CompressionLevel  int `combo:"0|0 (Biggest size),1,2,3,4,5,6,7,8,9|9 (Smallest size)" tooltip:"Higher levels make the recording slower"`
+	AdditionalOptions string
+}
+
+func (s *pngSettings) GenerateFFmpegArgs() (ret []string, err error) {
+	if s.CompressionLevel < 0 || s.CompressionLevel > 9 {
+		return nil, fmt.Errorf("CompressionLevel out of range [0-9]")
+	}
+
+	ret = append(ret, "-compression_level", strconv.Itoa(s.CompressionLevel))
+
+	ret = parseCustomOptions(ret, s.AdditionalOptions)
+
+	return
+}
+
+type exrSettings struct {
+	Compression       string `combo:"none|None,rle|RLE,zip1|ZIP (1 line),zip16|ZIP (16 lines)"`
+	Precision         string `combo:"half|Half (16-bit),float|Float (32-bit)"`
+	AdditionalOptions string
+}
+
+func (s *exrSettings) GenerateFFmpegArgs() (ret []string, err error) {
+	if !slices.Contains(exrCompressions, s.Compression) {
+		return nil, fmt.Errorf("invalid compression: %s", s.Compression)
+	}
+
+	if !slices.Contains(exrPrecisions, s.Precision) {
+		return nil, fmt.Errorf("invalid precision: %s", s.Precision)
+	}
+
+	ret = append(ret, "-compression", s.Compression, "-format", s.Precision)
+
+	ret = parseCustomOptions(ret, s.AdditionalOptions)
+
+	return
+}
@@ -3,6 +3,7 @@ package settings
 import (
 	"io/ioutil"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 )
@@ -38,6 +39,9 @@ type skin struct {
 var skinPath string
 var skinCache []string
 
+// LazerSkinOptions lists skins available in osu!lazer's library, set by lazer package to avoid import cycle
+var LazerSkinOptions func() []string
+
 func (d *defaultsFactory) SkinOptions() []string {
 	if General.GetSkinsDir() != skinPath {
 		skinPath = General.GetSkinsDir()
@@ -60,6 +64,12 @@ func (d *defaultsFactory) SkinOptions() []string {
 		skinCache = append([]string{"default"}, skinCache...)
 	}
 
+	if LazerSkinOptions != nil {
+		if lazerSkins := LazerSkinOptions(); len(lazerSkins) > 0 {
+			return append(slices.Clone(skinCache), lazerSkins...)
+		}
+	}
+
 	return skinCache
 }
 
@@ -2,6 +2,7 @@ package skin
 
 import (
 	"fmt"
+	"github.com/wieku/danser-go/app/lazer"
 	"github.com/wieku/danser-go/app/settings"
 	"github.com/wieku/danser-go/framework/assets"
 	"github.com/wieku/danser-go/framework/bass"
@@ -37,6 +38,9 @@ var textureLock = &sync.Mutex{}
 
 var atlas *texture.TextureAtlas
 
+// singleTextures are textures too big for the atlas, they're disposed along with it
+var singleTextures []*texture.TextureSingle
+
 var animationCache = make(map[string][]*texture.TextureRegion)
 
 var skinCache = make(map[string]*texture.TextureRegion)
@@ -83,7 +87,7 @@ func checkInit() {
 		log.Println("SkinManager: Loading fallback skin:", FallbackSkin)
 
 		var err error
-		fallbackPathCache, err = files.NewFileMap(filepath.Join(settings.General.GetSkinsDir(), FallbackSkin))
+		fallbackPathCache, err = getSkinFileMap(FallbackSkin)
 
 		if err != nil {
 			log.Println("SkinManager:", FallbackSkin, "does not exist, falling back to default...")
@@ -106,7 +110,7 @@ func tryLoadSkin(name, fallbackName string) {
 	log.Println("SkinManager: Loading skin:", name)
 
 	var err error
-	skinPathCache, err = files.NewFileMap(filepath.Join(settings.General.GetSkinsDir(), name))
+	skinPathCache, err = getSkinFileMap(name)
 
 	if err != nil {
 		log.Println(fmt.Sprintf("SkinManager: %s does not exist, falling back to %s...", name, fallbackName))
@@ -122,6 +126,49 @@ func tryLoadSkin(name, fallbackName string) {
 	}
 }
 
+// Reset unloads current skin and clears all caches, skin set in settings will be loaded on next access
+func Reset() {
+	fontLock.Lock()
+	soundLock.Lock()
+	textureLock.Lock()
+
+	defer fontLock.Unlock()
+	defer soundLock.Unlock()
+	defer textureLock.Unlock()
+
+	info = nil
+
+	if atlas != nil {
+		atlas.Dispose()
+		atlas = nil
+	}
+
+	for _, tx := range singleTextures {
+		tx.Dispose()
+	}
+
+	singleTextures = nil
+
+	skinPathCache = nil
+	fallbackPathCache = nil
+
+	clear(animationCache)
+	clear(skinCache)
+	clear(fallbackCache)
+	clear(defaultCache)
+	clear(sourceCache)
+	clear(fontCache)
+	clear(sampleCache)
+}
+
+func getSkinFileMap(name string) (*files.FileMap, error) {
+	if lazer.IsLazerDir(name) {
+		return lazer.GetSkinFileMap(name)
+	}
+
+	return files.NewFileMap(filepath.Join(settings.General.GetSkinsDir(), name))
+}
+
 func GetInfo() *SkinInfo {
 	checkInit()
 	return info
@@ -369,7 +416,10 @@ func loadTexture(name string, source Source) *texture.TextureRegion {
 		region.Height = float32(image.Height / 2)
 	}
 
-	if region != nil {
+	if region != nil && settings.HEADLESS {
+		// There's no GL context to upload to, dimensions are enough for headless mode
+		image.Dispose()
+	} else if region != nil {
 		// Upload this texture in GL thread
 		goroutines.CallNonBlockMain(func() {
 			checkAtlas()
@@ -390,6 +440,8 @@ func loadTexture(name string, source Source) *texture.TextureRegion {
 				tx := texture.NewTextureSingle(image.Width, image.Height, mipmaps)
 				tx.SetData(0, 0, image.Width, image.Height, image.Data)
 
+				singleTextures = append(singleTextures, tx)
+
 				reg := tx.GetRegion()
 				rg = &reg
 
@@ -493,6 +545,8 @@ func AddBeatmapColor(data []string) {
 }
 
 func FinishBeatmapColors() {
+	beatmapColors = nil
+
 	if len(beatmapColorsI) > 0 {
 		sort.SliceStable(beatmapColorsI, func(i, j int) bool {
 			return beatmapColorsI[i].index <= beatmapColorsI[j].index
@@ -504,6 +558,8 @@ func FinishBeatmapColors() {
 			beatmapColors = append(beatmapColors, c.color)
 		}
 	}
+
+	beatmapColorsI = nil
 }
 
 func GetColors() []color.Color {
@@ -3,6 +3,7 @@ package common
 import (
 	"github.com/EdlinOrg/prominentcolor"
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/wieku/danser-go/app/audio"
 	"github.com/wieku/danser-go/app/beatmap"
 	"github.com/wieku/danser-go/app/graphics/gui/drawables"
 	"github.com/wieku/danser-go/app/settings"
@@ -21,7 +22,6 @@ import (
 	"github.com/wieku/danser-go/framework/math/vector"
 	"log"
 	"math"
-	"path/filepath"
 )
 
 type Background struct {
@@ -33,6 +33,9 @@ type Background struct {
 	storyboard *storyboard.Storyboard
 	triangles  *drawables.Triangles
 
+	// hitsoundListener is the id of the listener driving storyboard's triggers, 0 if there's none
+	hitsoundListener int
+
 	blur           *effects.BlurEffect
 	blurVal        float64
 	blurredTexture texture.Texture
@@ -78,7 +81,7 @@ func NewBackground(loadDefault bool) *Background {
 
 func (bg *Background) SetBeatmap(beatMap *beatmap.BeatMap, loadDefault, loadStoryboards bool) {
 	bgLoadFunc := func() {
-		image, err := texture.NewPixmapFileString(filepath.Join(settings.General.GetSongsDir(), beatMap.Dir, beatMap.Bg))
+		image, err := texture.NewPixmapFileString(beatMap.GetPath(beatMap.Bg))
 		if err != nil && loadDefault {
 			image, err = assets.GetPixmap("assets/textures/background-1.png")
 			if err != nil {
@@ -110,14 +113,44 @@ func (bg *Background) SetBeatmap(beatMap *beatmap.BeatMap, loadDefault, loadStor
 	}
 
 	if loadStoryboards {
+		bg.removeStoryboard()
+
 		bg.storyboard = storyboard.NewStoryboard(beatMap)
 
 		if bg.storyboard == nil {
 			log.Println("Storyboard not found!")
+		} else if bg.storyboard.HasTriggers() {
+			sb := bg.storyboard
+
+			bg.hitsoundListener = audio.AddHitsoundListener(func(sampleSet, additionSet, hitsound, index int) {
+				sb.OnHitSound(bg.lastTime, sampleSet, additionSet, hitsound, index)
+			})
 		}
 	}
 }
 
+// Dispose frees background's texture and storyboard and removes storyboard's trigger listener
+func (bg *Background) Dispose() {
+	bg.removeStoryboard()
+
+	if bg.background != nil {
+		bg.background.Dispose()
+		bg.background = nil
+	}
+}
+
+func (bg *Background) removeStoryboard() {
+	if bg.hitsoundListener > 0 {
+		audio.RemoveHitsoundListener(bg.hitsoundListener)
+		bg.hitsoundListener = 0
+	}
+
+	if bg.storyboard != nil {
+		bg.storyboard.Dispose()
+		bg.storyboard = nil
+	}
+}
+
 func (bg *Background) SetTrack(track bass.ITrack) {
 	bg.triangles.SetTrack(track)
 }
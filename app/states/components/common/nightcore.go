@@ -34,6 +34,13 @@ func NewNightcoreProcessor() *NightcoreProcessor {
 
 	proc.Divisor = nightCoreDivisor
 
+	// nightcore beat accompanies the music
+	for _, sample := range []*bass.Sample{proc.hatSample, proc.clapSample, proc.kickSample, proc.finishSample} {
+		if sample != nil {
+			sample.SetStem(bass.StemMusic)
+		}
+	}
+
 	return proc
 }
 
@@ -0,0 +1,94 @@
+package common
+
+import (
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/framework/math/mutils"
+	"math"
+)
+
+//Original code by: https://github.com/ppy/osu/blob/master/osu.Game.Rulesets.Osu/Mods/OsuModNoScope.cs
+
+const (
+	noScopeTransitionDuration = 100.0
+	noScopeMinAlpha           = 0.0001
+)
+
+type spinnerPeriod struct {
+	start, end float64
+}
+
+// NoScope hides the cursor as the combo grows, it's shown again during breaks and spinners
+type NoScope struct {
+	hiddenComboCount int
+	gameplayStart    float64
+	spinners         []spinnerPeriod
+
+	alpha    float64
+	lastTime float64
+}
+
+func NewNoScope(beatMap *beatmap.BeatMap) *NoScope {
+	conf, ok := difficulty.GetModConfig[difficulty.NoScopeSettings](beatMap.Diff)
+	if !ok {
+		conf = difficulty.NewNoScopeSettings()
+	}
+
+	noScope := &NoScope{
+		hiddenComboCount: conf.HiddenComboCount,
+		gameplayStart:    math.Inf(-1),
+		alpha:            1,
+		lastTime:         math.NaN(),
+	}
+
+	if len(beatMap.HitObjects) > 0 {
+		noScope.gameplayStart = beatMap.HitObjects[0].GetStartTime() - beatMap.Diff.Preempt
+	}
+
+	for _, obj := range beatMap.HitObjects {
+		if obj.GetType() == objects.SPINNER {
+			noScope.spinners = append(noScope.spinners, spinnerPeriod{obj.GetStartTime() - noScopeTransitionDuration, obj.GetEndTime()})
+		}
+	}
+
+	return noScope
+}
+
+func (noScope *NoScope) Update(time float64, combo int64, inBreak bool) {
+	target := noScope.getComboAlpha(combo)
+
+	if inBreak || time < noScope.gameplayStart || noScope.isInSpinner(time) {
+		target = 1
+	}
+
+	if math.IsNaN(noScope.lastTime) || time < noScope.lastTime {
+		noScope.alpha = target
+	} else {
+		noScope.alpha = mutils.Lerp(noScope.alpha, target, mutils.Clamp((time-noScope.lastTime)/noScopeTransitionDuration, 0, 1))
+	}
+
+	noScope.lastTime = time
+}
+
+func (noScope *NoScope) getComboAlpha(combo int64) float64 {
+	if noScope.hiddenComboCount <= 0 {
+		return noScopeMinAlpha
+	}
+
+	return max(noScopeMinAlpha, 1-float64(combo)/float64(noScope.hiddenComboCount))
+}
+
+func (noScope *NoScope) isInSpinner(time float64) bool {
+	for _, p := range noScope.spinners {
+		if time >= p.start && time <= p.end {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (noScope *NoScope) GetAlpha() float64 {
+	return noScope.alpha
+}
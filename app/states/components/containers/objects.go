@@ -5,6 +5,7 @@ import (
 	"github.com/wieku/danser-go/app/beatmap"
 	"github.com/wieku/danser-go/app/beatmap/difficulty"
 	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/graphics"
 	"github.com/wieku/danser-go/app/graphics/sliderrenderer"
 	"github.com/wieku/danser-go/app/settings"
 	"github.com/wieku/danser-go/app/skin"
@@ -16,6 +17,7 @@ import (
 	"github.com/wieku/danser-go/framework/profiler"
 	"log"
 	"math"
+	"slices"
 	"sort"
 )
 
@@ -24,6 +26,18 @@ type renderableProxy struct {
 	isSliderBody bool
 	depth        float64
 	endTime      float64
+
+	// model is applied on top of the camera when fun mods transform the object
+	model       mgl32.Mat4
+	transformed bool
+}
+
+func (proxy *renderableProxy) getCamera(camera mgl32.Mat4) mgl32.Mat4 {
+	if !proxy.transformed {
+		return camera
+	}
+
+	return camera.Mul4(proxy.model)
 }
 
 type HitObjectContainer struct {
@@ -33,6 +47,8 @@ type HitObjectContainer struct {
 	spriteManager  *sprite.Manager
 	lastTime       float64
 	countProcessed int
+
+	transformer *objectTransformer
 }
 
 func NewHitObjectContainer(beatMap *beatmap.BeatMap) *HitObjectContainer {
@@ -47,11 +63,41 @@ func NewHitObjectContainer(beatMap *beatmap.BeatMap) *HitObjectContainer {
 
 	container.createFollowPoints()
 
+	if beatMap.Diff.CheckModActive(transformMods...) {
+		container.transformer = newObjectTransformer(beatMap.Diff)
+	}
+
 	log.Println("Container created.")
 
 	return container
 }
 
+// Rewind rebuilds the render queue so objects and follow points visible at given time are shown again.
+// Has to be called from the main thread.
+func (container *HitObjectContainer) Rewind(time float64) {
+	container.objectQueue = container.beatMap.GetObjectsCopy()
+	container.renderables = make([]*renderableProxy, 0)
+	container.spriteManager = sprite.NewManager()
+	container.countProcessed = 0
+
+	if container.transformer != nil {
+		container.transformer.reset()
+	}
+
+	container.createFollowPoints()
+
+	container.objectQueue = slices.DeleteFunc(container.objectQueue, func(o objects.IHitObject) bool {
+		return o.GetEndTime()+float64(container.beatMap.Diff.Hit50)+difficulty.HitFadeOut <= time
+	})
+}
+
+// SetCursor sets the cursor that Magnetised and Repel move objects in relation to
+func (container *HitObjectContainer) SetCursor(cursor *graphics.Cursor) {
+	if container.transformer != nil {
+		container.transformer.cursor = cursor
+	}
+}
+
 func (container *HitObjectContainer) createFollowPoints() {
 	const (
 		preEmpt  = 800.0
@@ -135,8 +181,8 @@ func (container *HitObjectContainer) Update(time float64) {
 func (container *HitObjectContainer) preProcessQueue(time float64) {
 	if len(container.objectQueue) > 0 {
 		for i := 0; i < len(container.objectQueue); i++ {
-			if p := container.objectQueue[i]; p.GetStartTime()-max(15000, container.beatMap.Diff.Preempt) <= time {
-				if p := container.objectQueue[i]; p.GetStartTime()-math.Floor(container.beatMap.Diff.Preempt) <= time {
+			if p := container.objectQueue[i]; p.GetStartTime()-max(15000, container.beatMap.Diff.Preempt)-getExtraPreempt(p) <= time {
+				if p := container.objectQueue[i]; p.GetStartTime()-math.Floor(container.beatMap.Diff.Preempt)-getExtraPreempt(p) <= time {
 					if _, ok := p.(*objects.Spinner); ok {
 						container.addProxy(&renderableProxy{
 							renderable:   p.(objects.Renderable),
@@ -181,6 +227,10 @@ func (container *HitObjectContainer) Draw(batch *batch.QuadBatch, baseCamera mgl
 
 	container.preProcessQueue(time)
 
+	if container.transformer != nil {
+		container.transformer.update(time, container.renderables)
+	}
+
 	if settings.Playfield.DrawObjects {
 		objectColors := settings.Objects.Colors.Color.GetColors(divides, float64(scale), float64(alpha))
 		borderColors := objectColors
@@ -242,7 +292,7 @@ func (container *HitObjectContainer) Draw(batch *batch.QuadBatch, baseCamera mgl
 						}
 
 						slidersRendered = true
-						s.DrawBody(time, objectColors[j], bodyColors[j], borderColors[j], borderColors[ind], cameras[j], scale)
+						s.DrawBody(time, objectColors[j], bodyColors[j], borderColors[j], borderColors[ind], container.renderables[i].getCamera(cameras[j]), scale)
 					}
 				}
 			}
@@ -280,6 +330,7 @@ func (container *HitObjectContainer) Draw(batch *batch.QuadBatch, baseCamera mgl
 
 					_, sp := container.renderables[i].renderable.(*objects.Spinner)
 					if !sp || j == 0 {
+						batch.SetCamera(proxy.getCamera(cameras[j]))
 						proxy.renderable.Draw(time, objectColors[j], batch)
 					}
 				} else if !settings.Objects.Sliders.SliderMerge {
@@ -293,7 +344,7 @@ func (container *HitObjectContainer) Draw(batch *batch.QuadBatch, baseCamera mgl
 					}
 
 					slidersRendered = true
-					proxy.renderable.(*objects.Slider).DrawBody(time, objectColors[j], bodyColors[j], borderColors[j], borderColors[ind], cameras[j], scale)
+					proxy.renderable.(*objects.Slider).DrawBody(time, objectColors[j], bodyColors[j], borderColors[j], borderColors[ind], proxy.getCamera(cameras[j]), scale)
 				}
 
 				if proxy.endTime <= time {
@@ -322,6 +373,7 @@ func (container *HitObjectContainer) Draw(batch *batch.QuadBatch, baseCamera mgl
 
 				for i := len(container.renderables) - 1; i >= 0; i-- {
 					if s := container.renderables[i]; !s.isSliderBody {
+						batch.SetCamera(s.getCamera(cameras[j]))
 						s.renderable.DrawApproach(time, objectColors[j], batch)
 					}
 				}
@@ -0,0 +1,359 @@
+package containers
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/framework/math/animation/easing"
+	"github.com/wieku/danser-go/framework/math/mutils"
+	"github.com/wieku/danser-go/framework/math/netrand"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"math"
+	"sort"
+)
+
+//Original code by: https://github.com/ppy/osu/tree/master/osu.Game.Rulesets.Osu/Mods
+
+// transformMods are mods that change how objects are drawn without changing their gameplay positions
+var transformMods = []difficulty.Mod{difficulty.Transform, difficulty.Wiggle, difficulty.SpinIn, difficulty.Grow, difficulty.Deflate,
+	difficulty.BarrelRoll, difficulty.Depth, difficulty.Magnetised, difficulty.Repel}
+
+const (
+	wiggleDuration = 100.0
+
+	// depthCameraZ is the distance of the camera from the playfield used by Depth
+	depthCameraZ = -200.0
+)
+
+var playfieldCentre = vector.NewVec2f(256, 192)
+
+// objectTransform is a visual transformation applied around object's stacked start position
+type objectTransform struct {
+	offset   vector.Vector2f
+	scale    vector.Vector2f
+	rotation float32
+}
+
+func newObjectTransform() objectTransform {
+	return objectTransform{scale: vector.NewVec2f(1, 1)}
+}
+
+func (transform objectTransform) matrix(origin vector.Vector2f) mgl32.Mat4 {
+	return mgl32.Translate3D(origin.X+transform.offset.X, origin.Y+transform.offset.Y, 0).
+		Mul4(mgl32.HomogRotate3DZ(transform.rotation)).
+		Mul4(mgl32.Scale3D(transform.scale.X, transform.scale.Y, 1)).
+		Mul4(mgl32.Translate3D(-origin.X, -origin.Y, 0))
+}
+
+type wiggleSegment struct {
+	startTime float64
+	from, to  vector.Vector2f
+}
+
+// objectTransformer calculates transformations of visible objects for osu!lazer's fun mods.
+// Those are visual only, judgements still use original positions of objects, so replays with mods that move objects are
+// not judged with them (see difficulty.DrawnPositionMods).
+type objectTransformer struct {
+	diff   *difficulty.Difficulty
+	cursor *graphics.Cursor
+
+	wiggles map[objects.IHitObject][]wiggleSegment
+
+	// offsets hold current positions of objects relative to their original ones, used by Magnetised and Repel
+	offsets  map[objects.IHitObject]vector.Vector2f
+	lastTime float64
+}
+
+func newObjectTransformer(diff *difficulty.Difficulty) *objectTransformer {
+	return &objectTransformer{
+		diff:     diff,
+		wiggles:  make(map[objects.IHitObject][]wiggleSegment),
+		offsets:  make(map[objects.IHitObject]vector.Vector2f),
+		lastTime: math.NaN(),
+	}
+}
+
+func (transformer *objectTransformer) reset() {
+	clear(transformer.offsets)
+	transformer.lastTime = math.NaN()
+}
+
+// update calculates model matrices of all renderables at given time
+func (transformer *objectTransformer) update(time float64, renderables []*renderableProxy) {
+	elapsed := 0.0
+	if !math.IsNaN(transformer.lastTime) {
+		elapsed = max(0, time-transformer.lastTime)
+	}
+
+	transformer.lastTime = time
+
+	// Slider's body has a separate proxy, stateful offsets have to be updated only once per object before matrices are calculated
+	for _, proxy := range renderables {
+		if obj, ok := proxy.renderable.(objects.IHitObject); ok && !proxy.isSliderBody && obj.GetType() != objects.SPINNER {
+			transformer.updateOffset(obj, time, elapsed)
+		}
+	}
+
+	for _, proxy := range renderables {
+		obj, ok := proxy.renderable.(objects.IHitObject)
+		if !ok || obj.GetType() == objects.SPINNER {
+			continue
+		}
+
+		origin := obj.GetStackedStartPositionMod(transformer.diff)
+
+		proxy.model = transformer.getTransform(obj, time).matrix(origin)
+		proxy.transformed = true
+	}
+}
+
+func (transformer *objectTransformer) getTransform(obj objects.IHitObject, time float64) objectTransform {
+	diff := transformer.diff
+
+	transform := newObjectTransform()
+
+	preempt := diff.Preempt + getExtraPreempt(obj)
+	appearTime := obj.GetStartTime() - preempt
+	progress := mutils.Clamp((time-appearTime)/preempt, 0, 1)
+
+	_, isSlider := obj.(*objects.Slider)
+
+	if diff.CheckModActive(difficulty.Transform) {
+		theta := float64(obj.GetID()) * diff.TimeFadeIn / 1000
+		appearDistance := (preempt - diff.TimeFadeIn) / 2
+
+		// -1 and +1 are there to prevent objects from appearing in the wrong position
+		moveProgress := mutils.Clamp((time-(appearTime-1))/(preempt+1), 0, 1)
+
+		distance := appearDistance * (1 - easing.InOutSine(moveProgress))
+
+		transform.offset = transform.offset.Add(vector.NewVec2d(math.Cos(theta), math.Sin(theta)).Scl(distance).Copy32())
+	}
+
+	if diff.CheckModActive(difficulty.Wiggle) {
+		transform.offset = transform.offset.Add(transformer.getWiggleOffset(obj, time))
+	}
+
+	if diff.CheckModActive(difficulty.Magnetised, difficulty.Repel) {
+		transform.offset = transform.offset.Add(transformer.offsets[obj])
+	}
+
+	if diff.CheckModActive(difficulty.SpinIn) {
+		eased := easing.InOutSine(progress)
+
+		if isSlider {
+			transform.scale = transform.scale.Scl(float32(eased))
+		} else {
+			transform.rotation += float32(2 * math.Pi * (1 - eased))
+			transform.scale = transform.scale.Mult(vector.NewVec2d(mutils.Lerp(2.0, 1.0, eased), eased).Copy32())
+		}
+	}
+
+	if conf, ok := difficulty.GetModConfig[difficulty.ScaleSettings](diff); ok && diff.CheckModActive(difficulty.Grow, difficulty.Deflate) {
+		transform.scale = transform.scale.Scl(float32(mutils.Lerp(conf.StartScale, 1.0, easing.OutSine(progress))))
+	}
+
+	if conf, ok := difficulty.GetModConfig[difficulty.DepthSettings](diff); ok && diff.CheckModActive(difficulty.Depth) {
+		var depth float64
+
+		if slider, ok1 := obj.(*objects.Slider); ok1 {
+			depth = getSliderDepth(slider, conf.MaxDepth, preempt, time)
+		} else {
+			depth = getObjectDepth(obj, conf.MaxDepth, preempt, time)
+		}
+
+		scale := float32(scaleForDepth(depth))
+
+		origin := obj.GetStackedStartPositionMod(diff)
+
+		transform.offset = transform.offset.Add(origin.Sub(playfieldCentre).Scl(scale - 1))
+		transform.scale = transform.scale.Scl(scale)
+	}
+
+	// Playfield is rotated by Barrel Roll, circles are rotated back to keep numbers readable
+	if diff.CheckModActive(difficulty.BarrelRoll) && !isSlider {
+		transform.rotation -= float32(BarrelRollRotation(diff, time))
+	}
+
+	return transform
+}
+
+// getWiggleOffset returns the offset caused by Wiggle, movements are generated once per object
+func (transformer *objectTransformer) getWiggleOffset(obj objects.IHitObject, time float64) vector.Vector2f {
+	segments, ok := transformer.wiggles[obj]
+	if !ok {
+		segments = transformer.generateWiggles(obj)
+		transformer.wiggles[obj] = segments
+	}
+
+	index := sort.Search(len(segments), func(i int) bool {
+		return segments[i].startTime > time
+	}) - 1
+
+	if index < 0 {
+		return vector.NewVec2f(0, 0)
+	}
+
+	segment := segments[index]
+
+	progress := float32(min(1, (time-segment.startTime)/wiggleDuration))
+
+	return segment.from.Lerp(segment.to, progress)
+}
+
+func (transformer *objectTransformer) generateWiggles(obj objects.IHitObject) []wiggleSegment {
+	strength := 1.0
+	if conf, ok := difficulty.GetModConfig[difficulty.WiggleSettings](transformer.diff); ok {
+		strength = conf.Strength
+	}
+
+	rng := netrand.New(int32(obj.GetStartTime()))
+
+	preempt := transformer.diff.Preempt + getExtraPreempt(obj)
+
+	var segments []wiggleSegment
+
+	last := vector.NewVec2f(0, 0)
+
+	wiggle := func(startTime float64) {
+		angle := rng.NextDouble() * 2 * math.Pi
+		distance := rng.NextDouble() * strength * 7
+
+		next := vector.NewVec2d(math.Cos(angle), math.Sin(angle)).Scl(distance).Copy32()
+
+		segments = append(segments, wiggleSegment{startTime, last, next})
+
+		last = next
+	}
+
+	for i := 0; i < int(preempt)/int(wiggleDuration); i++ {
+		wiggle(obj.GetStartTime() - preempt + float64(i)*wiggleDuration)
+	}
+
+	// Sliders keep wiggling for their duration
+	if slider, ok := obj.(*objects.Slider); ok {
+		for i := 0; i < int(slider.GetDuration()/wiggleDuration); i++ {
+			wiggle(slider.GetStartTime() + float64(i)*wiggleDuration)
+		}
+	}
+
+	return segments
+}
+
+// updateOffset eases the object towards (Magnetised) or away from (Repel) the cursor
+func (transformer *objectTransformer) updateOffset(obj objects.IHitObject, time, elapsed float64) {
+	if transformer.cursor == nil || elapsed <= 0 || !transformer.diff.CheckModActive(difficulty.Magnetised, difficulty.Repel) {
+		return
+	}
+
+	origin := obj.GetStackedStartPositionMod(transformer.diff)
+	current := origin.Add(transformer.offsets[obj])
+	cursorPos := transformer.cursor.Position
+
+	// After slider's head is hit, slider is moved so its ball follows the destination
+	ballOffset := vector.NewVec2f(0, 0)
+	if slider, ok := obj.(*objects.Slider); ok && time >= slider.GetStartTime() {
+		ballOffset = slider.GetStackedPositionAtMod(time, transformer.diff).Sub(origin)
+	}
+
+	var destination vector.Vector2f
+	var halfTime float64
+
+	if conf, ok := difficulty.GetModConfig[difficulty.MagnetisedSettings](transformer.diff); ok && transformer.diff.CheckModActive(difficulty.Magnetised) {
+		destination = cursorPos
+		halfTime = mutils.Lerp(3000.0, 40.0, conf.AttractionStrength)
+	} else if conf, ok := difficulty.GetModConfig[difficulty.RepelSettings](transformer.diff); ok && transformer.diff.CheckModActive(difficulty.Repel) {
+		destination = current.Scl(2).Sub(cursorPos)
+		destination = vector.NewVec2f(mutils.Clamp(destination.X, 0, 512), mutils.Clamp(destination.Y, 0, 384))
+		halfTime = float64(current.Dst(cursorPos)) / (0.04*conf.RepulsionStrength + 0.04)
+	} else {
+		return
+	}
+
+	destination = destination.Sub(ballOffset)
+
+	t := float32(1.0)
+	if halfTime > 0 {
+		t = float32(1 - math.Pow(0.5, elapsed/halfTime))
+	}
+
+	transformer.offsets[obj] = current.Lerp(destination, t).Sub(origin)
+}
+
+// BarrelRollRotation returns the rotation of the playfield in radians caused by Barrel Roll
+func BarrelRollRotation(diff *difficulty.Difficulty, time float64) float64 {
+	conf, ok := difficulty.GetModConfig[difficulty.BarrelRollSettings](diff)
+	if !ok || !diff.CheckModActive(difficulty.BarrelRoll) {
+		return 0
+	}
+
+	direction := 1.0
+	if conf.Direction == difficulty.RotationCounterclockwise {
+		direction = -1
+	}
+
+	return direction * 2 * math.Pi * time / 60000 * conf.SpinSpeed
+}
+
+func getExtraPreempt(obj objects.IHitObject) float64 {
+	switch o := obj.(type) {
+	case *objects.Circle:
+		return o.ExtraPreempt
+	case *objects.Slider:
+		return o.ExtraPreempt
+	}
+
+	return 0
+}
+
+func scaleForDepth(depth float64) float64 {
+	return -depthCameraZ / max(1, depth-depthCameraZ)
+}
+
+func depthForScale(scale float64) float64 {
+	return -depthCameraZ/scale + depthCameraZ
+}
+
+// getObjectDepth returns the depth of an object moving towards the camera with constant speed
+func getObjectDepth(obj objects.IHitObject, maxDepth, preempt, time float64) float64 {
+	speed := maxDepth / preempt
+	appearTime := obj.GetStartTime() - preempt
+
+	return maxDepth - (max(time, appearTime)-appearTime)*speed
+}
+
+// getSliderDepth returns the depth of a slider, long sliders slow down before their start, so they don't reach the camera too early
+func getSliderDepth(slider *objects.Slider, maxDepth, preempt, time float64) float64 {
+	minDepth := depthForScale(1.5)
+
+	baseSpeed := maxDepth / preempt
+	appearTime := slider.GetStartTime() - preempt
+
+	endDepth := maxDepth - (max(slider.GetEndTime(), appearTime)-appearTime)*baseSpeed
+	if endDepth > minDepth {
+		return getObjectDepth(slider, maxDepth, preempt, time)
+	}
+
+	offsetAfterStartTime := slider.GetDuration() + 500
+	slowSpeed := min(-minDepth/offsetAfterStartTime, baseSpeed)
+
+	decelerationTime := preempt * 0.2
+	decelerationDistance := decelerationTime * (baseSpeed + slowSpeed) * 0.5
+
+	switch {
+	case time < slider.GetStartTime()-decelerationTime:
+		fullDistance := decelerationDistance + baseSpeed*(preempt-decelerationTime)
+
+		return fullDistance - (max(time, appearTime)-appearTime)*baseSpeed
+	case time < slider.GetStartTime():
+		timeOffset := time - (slider.GetStartTime() - decelerationTime)
+		deceleration := (slowSpeed - baseSpeed) / decelerationTime
+
+		return decelerationDistance - (baseSpeed*timeOffset + deceleration*timeOffset*timeOffset*0.5)
+	default:
+		endTime := slider.GetStartTime() + offsetAfterStartTime
+
+		return -(min(time, endTime) - slider.GetStartTime()) * slowSpeed
+	}
+}
@@ -335,6 +335,42 @@ func (overlay *KnockoutOverlay) hitReceived(cursor *graphics.Cursor, judgementRe
 	}
 }
 
+// Rewind revives players that have broken after given time and syncs their stats with the ruleset
+func (overlay *KnockoutOverlay) Rewind(time float64) {
+	overlay.audioTime = time
+	overlay.deathBubbles = overlay.deathBubbles[:0]
+
+	for _, cursor := range overlay.controller.GetCursors() {
+		player := overlay.players[overlay.names[cursor]]
+
+		if player.hasBroken && float64(player.breakTime) > time {
+			player.hasBroken = false
+			player.breakTime = 0
+
+			overlay.alivePlayers++
+
+			player.fade.Reset()
+			player.fade.AddEvent(overlay.normalTime, overlay.normalTime+750, 1)
+
+			player.height.Reset()
+			player.height.SetEasing(easing.InQuad)
+			player.height.AddEvent(overlay.normalTime, overlay.normalTime+200, overlay.ScaledHeight*0.9*1.04/(51))
+		}
+
+		score := overlay.controller.GetRuleset().GetScore(cursor)
+
+		player.sCombo = int64(score.CurrentCombo)
+		player.score = score.Score
+		player.pp = score.PP.Total
+
+		player.scoreDisp.SetValue(float64(player.score), true)
+		player.ppDisp.SetValue(player.pp, true)
+		player.accDisp.SetValue(score.Accuracy*100, true)
+
+		player.displayHp = overlay.controller.GetRuleset().GetHP(cursor)
+	}
+}
+
 func (overlay *KnockoutOverlay) Update(time float64) {
 	if overlay.audioTime == 0 {
 		overlay.audioTime = time
@@ -17,4 +17,5 @@ type Overlay interface {
 	IsBroken(cursor *graphics.Cursor) bool
 	DisableAudioSubmission(b bool)
 	ShouldDrawHUDBeforeCursor() bool
+	Rewind(time float64)
 }
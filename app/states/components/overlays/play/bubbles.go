@@ -0,0 +1,78 @@
+package play
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/skin"
+	"github.com/wieku/danser-go/framework/graphics/batch"
+	"github.com/wieku/danser-go/framework/graphics/sprite"
+	"github.com/wieku/danser-go/framework/math/animation"
+	"github.com/wieku/danser-go/framework/math/animation/easing"
+	color2 "github.com/wieku/danser-go/framework/math/color"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"math"
+)
+
+//Original code by: https://github.com/ppy/osu/blob/master/osu.Game.Rulesets.Osu/Mods/OsuModBubbles.cs
+
+const bubbleAlpha = 0.5
+
+// Bubbles leaves a growing bubble in place of every judged object, used by Bubbles mod
+type Bubbles struct {
+	manager  *sprite.Manager
+	diff     *difficulty.Difficulty
+	lastTime float64
+}
+
+func NewBubbles(diff *difficulty.Difficulty) *Bubbles {
+	return &Bubbles{
+		manager: sprite.NewManager(),
+		diff:    diff,
+	}
+}
+
+// AddBubble spawns a bubble tinted with object's combo color, missed objects leave black bubbles
+func (bubbles *Bubbles) AddBubble(time int64, position vector.Vector2d, object objects.IHitObject, combo int64, hit bool) {
+	maxSize := min(1.75, 1.25+0.005*float64(combo))
+
+	startTime := float64(time)
+	duration := 1700 + math.Pow(bubbles.diff.Preempt*2, 1.07)
+	growEnd := startTime + duration*0.8
+	endTime := startTime + duration
+
+	color := color2.NewL(0)
+	if hit {
+		color = skin.GetColor(int(object.GetComboSet()), int(object.GetComboSetHax()), settings.Objects.Colors.Color.GetColors(1, 1, 1)[0])
+	}
+
+	bubble := sprite.NewSpriteSingle(skin.GetTexture("hitcircle-full"), startTime, position, vector.Centre)
+	bubble.SetColor(color)
+	bubble.SetAlpha(bubbleAlpha)
+	bubble.ShowForever(false)
+
+	bubble.AddTransform(animation.NewSingleTransform(animation.Scale, easing.Linear, startTime, growEnd, 1, maxSize))
+	bubble.AddTransform(animation.NewSingleTransform(animation.Scale, easing.OutQuint, growEnd, endTime, maxSize, maxSize*1.5))
+	bubble.AddTransform(animation.NewSingleTransform(animation.Fade, easing.OutCirc, growEnd, endTime, bubbleAlpha, 0))
+	bubble.AdjustTimesToTransformations()
+
+	bubbles.manager.Add(bubble)
+}
+
+func (bubbles *Bubbles) Update(time float64) {
+	bubbles.manager.Update(time)
+	bubbles.lastTime = time
+}
+
+func (bubbles *Bubbles) Draw(batch *batch.QuadBatch, alpha float64) {
+	batch.ResetTransform()
+	batch.SetColor(1, 1, 1, alpha)
+
+	scale := bubbles.diff.CircleRadius / 64
+	batch.SetScale(scale, scale)
+
+	bubbles.manager.Draw(bubbles.lastTime, batch)
+
+	batch.ResetTransform()
+	batch.SetColor(1, 1, 1, 1)
+}
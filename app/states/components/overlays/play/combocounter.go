@@ -102,6 +102,23 @@ func (counter *ComboCounter) Reset() {
 	counter.popCounter.SetText(fmt.Sprintf("%dx", counter.combo))
 }
 
+// SetCombo changes the combo without animations, used when playback is rewound
+func (counter *ComboCounter) SetCombo(combo int) {
+	counter.combo = combo
+	counter.nextTransfer = math.MaxFloat64
+
+	counter.updateMain(combo, false)
+	counter.popCounter.SetText(fmt.Sprintf("%dx", combo))
+
+	counter.mainCounter.ClearTransformationsOfType(animation.Fade)
+
+	if combo > 0 {
+		counter.mainCounter.SetAlpha(1)
+	} else {
+		counter.mainCounter.SetAlpha(0)
+	}
+}
+
 func (counter *ComboCounter) GetCombo() int {
 	return counter.combo
 }
@@ -77,8 +77,8 @@ func (h *StatHolder) SetMapStats(bMap *beatmap.BeatMap, pDiff *difficulty.Diffic
 	h.stats["modsA"] = pDiff.Mods.String()
 }
 
-func (h *StatHolder) UpdateBPM() {
-	h.stats["bpm"] = h.bMap.Timings.Current.GetBaseBPM() * h.diff.GetSpeed()
+func (h *StatHolder) UpdateBPM(time float64) {
+	h.stats["bpm"] = h.bMap.Timings.Current.GetBaseBPM() * h.diff.GetSpeedAt(time)
 }
 
 func (h *StatHolder) SetUsername(name string) {
@@ -155,7 +155,11 @@ func (h *StatHolder) AddClick(time float64) {
 func (h *StatHolder) UpdateTime(time float64) {
 	var count int64
 
-	earliestTimeValid := time - 1000*h.diff.GetSpeed()
+	speed := h.diff.GetSpeedAt(time)
+
+	h.stats["speed"] = speed
+
+	earliestTimeValid := time - 1000*speed
 
 	for i := len(h.clickTimes) - 1; i >= 0; i-- {
 		if earliestTimeValid > h.clickTimes[i] {
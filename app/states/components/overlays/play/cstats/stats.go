@@ -72,7 +72,7 @@ func (statDisplay *StatDisplay) GetStatHolder() *StatHolder {
 }
 
 func (statDisplay *StatDisplay) Update(audioTime, normalTime float64) {
-	statDisplay.statHolder.UpdateBPM()
+	statDisplay.statHolder.UpdateBPM(audioTime)
 	statDisplay.statHolder.UpdateTime(audioTime)
 
 	statDisplay.updateRolling(normalTime)
@@ -2,6 +2,8 @@ package play
 
 import (
 	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/database"
 	"github.com/wieku/danser-go/app/osuapi"
 	"github.com/wieku/danser-go/app/settings"
 	"github.com/wieku/danser-go/app/skin"
@@ -15,6 +17,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"time"
 )
 
 const spacing = 57.6
@@ -43,7 +46,7 @@ type ScoreBoard struct {
 	lazerScore bool
 }
 
-func NewScoreboard(beatMap *beatmap.BeatMap, lazerScore bool, omitID int64) *ScoreBoard {
+func NewScoreboard(beatMap *beatmap.BeatMap, lazerScore bool, omitID int64, omitName string, omitTime time.Time) *ScoreBoard {
 	board := &ScoreBoard{
 		first:            true,
 		explosionManager: sprite.NewManager(),
@@ -65,6 +68,12 @@ func NewScoreboard(beatMap *beatmap.BeatMap, lazerScore bool, omitID int64) *Sco
 		}
 	}
 
+	if settings.Gameplay.ScoreBoard.Mode == "Local" {
+		board.addScores(getLocalScores(beatMap, lazerScore, omitName, omitTime), false)
+
+		return board
+	}
+
 	var mods []string
 
 	mode := osuapi.NormalMode
@@ -104,23 +113,67 @@ func NewScoreboard(beatMap *beatmap.BeatMap, lazerScore bool, omitID int64) *Sco
 			return scores[i].LegacyTotalScore > scores[j].LegacyTotalScore
 		})
 
-		for i := 0; i < min(len(scores), 50); i++ {
-			s := scores[i]
+		board.addScores(scores, true)
+	}
+
+	return board
+}
+
+func (board *ScoreBoard) addScores(scores []osuapi.Score, loadAvatars bool) {
+	for i := 0; i < min(len(scores), 50); i++ {
+		s := scores[i]
 
-			entry := NewScoreboardEntry(s.User.Username, s, lazerScore, i+1, false)
+		entry := NewScoreboardEntry(s.User.Username, s, board.lazerScore, i+1, false)
 
-			if settings.Gameplay.ScoreBoard.ShowAvatars {
+		if settings.Gameplay.ScoreBoard.ShowAvatars {
+			if loadAvatars {
 				entry.LoadAvatarURL(s.User.AvatarURL)
+			} else {
+				entry.LoadDefaultAvatar()
 			}
+		}
 
-			board.scores = append(board.scores, entry)
-			board.displayScores = append(board.displayScores, entry)
+		board.scores = append(board.scores, entry)
+		board.displayScores = append(board.displayScores, entry)
+	}
+
+	log.Println("SCORES", len(board.scores))
+}
+
+// getLocalScores converts local score history to scoreboard entries. Scores set with a different scoring system (stable/lazer) are skipped
+func getLocalScores(beatMap *beatmap.BeatMap, lazerScore bool, omitName string, omitTime time.Time) (scores []osuapi.Score) {
+	for _, s := range database.GetScores(beatMap.MD5) {
+		if s.Mods.Active(difficulty.Lazer) != lazerScore {
+			continue
 		}
 
-		log.Println("SCORES", len(board.scores))
+		if settings.Gameplay.ScoreBoard.ModsOnly && s.Mods != beatMap.Diff.Mods {
+			continue
+		}
+
+		// Replay that is currently played may be already in the history
+		if s.Player == omitName && s.Date.UnixMilli() == omitTime.UnixMilli() {
+			continue
+		}
+
+		scores = append(scores, osuapi.Score{
+			Accuracy:          s.Accuracy,
+			MaxCombo:          int64(s.MaxCombo),
+			Score:             s.Score,
+			TotalScore:        s.Score,
+			ClassicTotalScore: s.Score,
+			LegacyTotalScore:  s.Score,
+			User: osuapi.User{
+				Username: s.Player,
+			},
+		})
 	}
 
-	return board
+	if len(scores) == 0 {
+		log.Println("Can't find local scores!")
+	}
+
+	return
 }
 
 func (board *ScoreBoard) AddPlayer(name string, autoPlay bool) {
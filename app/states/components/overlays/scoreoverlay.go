@@ -36,6 +36,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -88,6 +89,8 @@ type ScoreOverlay struct {
 	mods       *sprite.Manager
 	notFirst   bool
 	flashlight *common.Flashlight
+	noScope    *common.NoScope
+	bubbles    *play.Bubbles
 	delta      float64
 
 	entry         *play.ScoreBoard
@@ -133,15 +136,34 @@ type ScoreOverlay struct {
 	lazerScore bool
 
 	skipped bool
+
+	hitErrors []hitError
+	aimErrors []aimError
 }
 
 type keyInfo struct {
 	sprite *sprite.Sprite
 
 	baseColor, pressedColor color2.Color
+	name                    string
 	text                    string
 	count                   int
 	lastPress               float64
+	firstPress              float64
+	presses                 []float64
+}
+
+// hitError and aimError keep the error meter history, so meters can be rebuilt when playback is rewound
+type hitError struct {
+	time           float64
+	error          float64
+	positionalMiss bool
+}
+
+type aimError struct {
+	time             float64
+	position         vector.Vector2f
+	startPos, endPos *vector.Vector2f
 }
 
 func loadFonts() {
@@ -275,8 +297,10 @@ func NewScoreOverlay(ruleset *osu.OsuRuleSet, cursor *graphics.Cursor) *ScoreOve
 		kInfo := &keyInfo{
 			sprite:       key,
 			count:        -1,
+			name:         name,
 			text:         name,
 			lastPress:    -math.MaxFloat64,
+			firstPress:   math.MaxFloat64,
 			baseColor:    color2.NewLA(1, 0),
 			pressedColor: color2.NewIRGBA(255, 222, 0, 0),
 		}
@@ -330,7 +354,15 @@ func NewScoreOverlay(ruleset *osu.OsuRuleSet, cursor *graphics.Cursor) *ScoreOve
 		overlay.flashlight = common.NewFlashlight(overlay.ruleset.GetBeatMap())
 	}
 
-	overlay.entry = play.NewScoreboard(overlay.ruleset.GetBeatMap(), ruleset.GetPlayerDifficulty(overlay.cursor).CheckModActive(difficulty.Lazer), overlay.cursor.ScoreID)
+	if overlay.ruleset.GetBeatMap().Diff.Mods.Active(difficulty.NoScope) {
+		overlay.noScope = common.NewNoScope(overlay.ruleset.GetBeatMap())
+	}
+
+	if overlay.ruleset.GetBeatMap().Diff.Mods.Active(difficulty.Bubbles) {
+		overlay.bubbles = play.NewBubbles(overlay.ruleset.GetBeatMap().Diff)
+	}
+
+	overlay.entry = play.NewScoreboard(overlay.ruleset.GetBeatMap(), ruleset.GetPlayerDifficulty(overlay.cursor).CheckModActive(difficulty.Lazer), overlay.cursor.ScoreID, overlay.cursor.Name, overlay.cursor.ScoreTime)
 	overlay.entry.AddPlayer(overlay.cursor.Name, overlay.cursor.IsAutoplay)
 
 	overlay.initArrows()
@@ -382,7 +414,12 @@ func (overlay *ScoreOverlay) initUnderlay() {
 func (overlay *ScoreOverlay) hitReceived(c *graphics.Cursor, judgementResult osu.JudgementResult, score osu.Score) {
 	object := overlay.ruleset.GetBeatMap().HitObjects[judgementResult.Number]
 
-	if judgementResult.HitResult&(osu.BaseHitsM) > 0 {
+	// Bubbles hides judgements, they would be obscured by bubbles anyway
+	if overlay.bubbles != nil {
+		if judgementResult.HitResult&(osu.SpinnerHits|osu.PositionalMiss) == 0 && judgementResult.HitResult != osu.Ignore && judgementResult.MaxResult != osu.SliderFinish {
+			overlay.bubbles.AddBubble(judgementResult.Time, judgementResult.Position.Copy64(), object, int64(score.CurrentCombo), judgementResult.HitResult&(osu.Miss|osu.SliderMiss) == 0)
+		}
+	} else if judgementResult.HitResult&(osu.BaseHitsM) > 0 {
 		overlay.results.AddResult(judgementResult.Time, judgementResult.HitResult, judgementResult.Position.Copy64(), object)
 	}
 
@@ -407,6 +444,7 @@ func (overlay *ScoreOverlay) hitReceived(c *graphics.Cursor, judgementResult osu
 		timeDiff := float64(judgementResult.Time) - object.GetStartTime()
 
 		overlay.hitErrorMeter.Add(float64(judgementResult.Time), timeDiff, judgementResult.HitResult == osu.PositionalMiss)
+		overlay.hitErrors = append(overlay.hitErrors, hitError{float64(judgementResult.Time), timeDiff, judgementResult.HitResult == osu.PositionalMiss})
 
 		var startPos *vector.Vector2f
 		if judgementResult.Number > 0 {
@@ -417,6 +455,7 @@ func (overlay *ScoreOverlay) hitReceived(c *graphics.Cursor, judgementResult osu
 		endPos := object.GetStackedStartPositionMod(overlay.ruleset.GetBeatMap().Diff)
 
 		overlay.aimErrorMeter.Add(float64(judgementResult.Time), c.Position, startPos, &endPos)
+		overlay.aimErrors = append(overlay.aimErrors, aimError{float64(judgementResult.Time), c.Position, startPos, &endPos})
 	}
 
 	if judgementResult.HitResult == osu.PositionalMiss {
@@ -429,6 +468,12 @@ func (overlay *ScoreOverlay) hitReceived(c *graphics.Cursor, judgementResult osu
 		overlay.comboCounter.Reset()
 	}
 
+	overlay.hpSections = append(overlay.hpSections, vector.NewVec2d(float64(judgementResult.Time), overlay.ruleset.GetHP(overlay.cursor)))
+
+	overlay.updateScore(false)
+}
+
+func (overlay *ScoreOverlay) updateScore(instant bool) {
 	if overlay.flashlight != nil {
 		overlay.flashlight.UpdateCombo(int64(overlay.comboCounter.GetCombo()))
 	}
@@ -437,12 +482,10 @@ func (overlay *ScoreOverlay) hitReceived(c *graphics.Cursor, judgementResult osu
 
 	overlay.entry.UpdatePlayer(sc.Score, int64(sc.Combo))
 
-	overlay.scoreGlider.SetValue(float64(sc.Score), settings.Gameplay.Score.StaticScore)
-	overlay.accuracyGlider.SetValue(sc.Accuracy*100, settings.Gameplay.Score.StaticAccuracy)
+	overlay.scoreGlider.SetValue(float64(sc.Score), instant || settings.Gameplay.Score.StaticScore)
+	overlay.accuracyGlider.SetValue(sc.Accuracy*100, instant || settings.Gameplay.Score.StaticAccuracy)
 
-	overlay.ppDisplay.Add(score.PP)
-
-	overlay.hpSections = append(overlay.hpSections, vector.NewVec2d(float64(judgementResult.Time), overlay.ruleset.GetHP(overlay.cursor)))
+	overlay.ppDisplay.Add(sc.PP)
 
 	if overlay.oldGrade != sc.Grade {
 		goroutines.Run(func() {
@@ -458,7 +501,7 @@ func (overlay *ScoreOverlay) hitReceived(c *graphics.Cursor, judgementResult osu
 		})
 	}
 
-	overlay.customStats.GetStatHolder().SetScoreStats(score)
+	overlay.customStats.GetStatHolder().SetScoreStats(sc)
 
 	fcPP := overlay.ruleset.GetFCPP(overlay.cursor)
 	ssPP := overlay.ruleset.GetSSPP(overlay.cursor)
@@ -499,10 +542,12 @@ func (overlay *ScoreOverlay) processKey(info *keyInfo, action osu.ButtonAction)
 
 		if info.count < 0 {
 			info.count = 0
+			info.firstPress = overlay.audioTime
 		}
 
 		if overlay.isDrain() && !overlay.failed {
 			info.count++
+			info.presses = append(info.presses, overlay.audioTime)
 		}
 
 		info.text = strconv.Itoa(info.count)
@@ -548,6 +593,11 @@ func (overlay *ScoreOverlay) Update(time float64) {
 	}
 
 	overlay.results.Update(time)
+
+	if overlay.bubbles != nil {
+		overlay.bubbles.Update(time)
+	}
+
 	overlay.hitErrorMeter.Update(time)
 	overlay.aimErrorMeter.Update(time)
 
@@ -615,6 +665,11 @@ func (overlay *ScoreOverlay) updateNormal(time float64) {
 		overlay.flashlight.SetSliding(sliding)
 	}
 
+	if overlay.noScope != nil {
+		overlay.noScope.Update(overlay.audioTime, int64(overlay.ruleset.GetScore(overlay.cursor).CurrentCombo), overlay.breakMode)
+		overlay.cursor.SetAlpha(overlay.noScope.GetAlpha())
+	}
+
 	overlay.mods.Update(time)
 
 	overlay.comboCounter.Update(time)
@@ -691,6 +746,10 @@ func (overlay *ScoreOverlay) DrawNormal(batch *batch.QuadBatch, _ []color2.Color
 
 	overlay.results.DrawTop(batch, 1.0)
 
+	if overlay.bubbles != nil {
+		overlay.bubbles.Draw(batch, alpha)
+	}
+
 	batch.Flush()
 
 	if overlay.flashlight != nil {
@@ -1123,6 +1182,52 @@ func (overlay *ScoreOverlay) DisableAudioSubmission(b bool) {
 	overlay.comboCounter.DisableAudioSubmission(b)
 }
 
+// Rewind brings HUD elements back in sync with the ruleset after the playback was rewound to given time
+func (overlay *ScoreOverlay) Rewind(time float64) {
+	diff := overlay.ruleset.GetBeatMap().Diff
+
+	overlay.audioTime = time
+
+	overlay.results = play.NewHitResults(diff)
+
+	if overlay.bubbles != nil {
+		overlay.bubbles = play.NewBubbles(diff)
+	}
+
+	overlay.hitErrorMeter = play.NewHitErrorMeter(overlay.ScaledWidth, overlay.ScaledHeight, diff)
+	overlay.hitErrors = slices.DeleteFunc(overlay.hitErrors, func(e hitError) bool { return e.time > time })
+
+	for _, e := range overlay.hitErrors {
+		overlay.hitErrorMeter.Add(e.time, e.error, e.positionalMiss)
+	}
+
+	overlay.aimErrorMeter = play.NewAimErrorMeter(diff)
+	overlay.aimErrors = slices.DeleteFunc(overlay.aimErrors, func(e aimError) bool { return e.time > time })
+
+	for _, e := range overlay.aimErrors {
+		overlay.aimErrorMeter.Add(e.time, e.position, e.startPos, e.endPos)
+	}
+
+	overlay.hpSections = slices.DeleteFunc(overlay.hpSections, func(s vector.Vector2d) bool { return s.X > time })
+
+	for _, info := range overlay.keyInfos {
+		info.presses = slices.DeleteFunc(info.presses, func(t float64) bool { return t > time })
+
+		if info.firstPress > time {
+			info.count = -1
+			info.firstPress = math.MaxFloat64
+			info.text = info.name
+		} else {
+			info.count = len(info.presses)
+			info.text = strconv.Itoa(info.count)
+		}
+	}
+
+	overlay.comboCounter.SetCombo(int(overlay.ruleset.GetScore(overlay.cursor).CurrentCombo))
+
+	overlay.updateScore(true)
+}
+
 func (overlay *ScoreOverlay) SetBeatmapEnd(end float64) {
 	overlay.beatmapEnd = end
 }
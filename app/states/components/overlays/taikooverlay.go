@@ -0,0 +1,611 @@
+package overlays
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/dance"
+	"github.com/wieku/danser-go/app/discord"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/rulesets/taiko"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/skin"
+	"github.com/wieku/danser-go/app/utils"
+	"github.com/wieku/danser-go/framework/assets"
+	"github.com/wieku/danser-go/framework/bass"
+	"github.com/wieku/danser-go/framework/graphics/batch"
+	"github.com/wieku/danser-go/framework/graphics/font"
+	"github.com/wieku/danser-go/framework/graphics/texture"
+	"github.com/wieku/danser-go/framework/math/animation"
+	"github.com/wieku/danser-go/framework/math/animation/easing"
+	color2 "github.com/wieku/danser-go/framework/math/color"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"log"
+	"math"
+	"sort"
+	"strings"
+)
+
+var (
+	donColor      = color2.NewRGB(0.92, 0.27, 0.17)
+	katColor      = color2.NewRGB(0.27, 0.55, 0.76)
+	drumRollColor = color2.NewRGB(0.99, 0.73, 0.0)
+	swellColor    = color2.NewRGB(0.99, 0.47, 0.0)
+)
+
+type taikoOverlayPlayer struct {
+	name  string
+	index int
+
+	fade      *animation.Glider
+	scoreDisp *animation.TargetGlider
+	accDisp   *animation.TargetGlider
+
+	sCombo    int64
+	maxCombo  int64
+	score     int64
+	hasBroken bool
+	breakTime int64
+
+	lastHit taiko.HitResult
+	fadeHit *animation.Glider
+}
+
+// TaikoOverlay draws osu!taiko playfield with scrolling notes. Single player gets classic HUD, multiple players are listed below the playfield.
+type TaikoOverlay struct {
+	controller *dance.TaikoController
+	ruleset    *taiko.TaikoRuleSet
+	font       *font.Font
+
+	players      []*taikoOverlayPlayer
+	playersArray []*taikoOverlayPlayer
+	cursors      map[*graphics.Cursor]*taikoOverlayPlayer
+	alivePlayers int
+
+	audioTime  float64
+	normalTime float64
+	music      bass.ITrack
+
+	ScaledHeight float64
+	ScaledWidth  float64
+
+	laneY      float64
+	laneHeight float64
+	hitX       float64
+
+	hitFlash       *animation.Glider
+	judgementFade  *animation.Glider
+	judgementScale *animation.Glider
+	lastJudgement  taiko.HitResult
+
+	hpDisp float64
+
+	circle        *texture.TextureRegion
+	circleOverlay *texture.TextureRegion
+}
+
+func NewTaikoOverlay(controller *dance.TaikoController) *TaikoOverlay {
+	overlay := new(TaikoOverlay)
+	overlay.controller = controller
+	overlay.ruleset = controller.GetRuleset()
+
+	if font.GetFont("Quicksand Bold") == nil {
+		file, _ := assets.Open("assets/fonts/Quicksand-Bold.ttf")
+		font.LoadFont(file)
+		file.Close()
+	}
+
+	overlay.font = font.GetFont("Quicksand Bold")
+
+	overlay.ScaledHeight = 1080.0
+	overlay.ScaledWidth = overlay.ScaledHeight * settings.Graphics.GetAspectRatio()
+
+	overlay.laneHeight = overlay.ScaledHeight * 0.2
+	overlay.laneY = overlay.ScaledHeight * 0.25
+	overlay.hitX = overlay.laneHeight * 1.6
+
+	overlay.hitFlash = animation.NewGlider(0)
+	overlay.judgementFade = animation.NewGlider(0)
+	overlay.judgementScale = animation.NewGlider(1)
+
+	overlay.circle = skin.GetTexture("hitcircle")
+	overlay.circleOverlay = skin.GetTexture("hitcircleoverlay")
+
+	overlay.cursors = make(map[*graphics.Cursor]*taikoOverlayPlayer)
+
+	for i, r := range controller.GetReplays() {
+		player := &taikoOverlayPlayer{
+			name:      r.Name,
+			index:     i,
+			fade:      animation.NewGlider(1),
+			scoreDisp: animation.NewTargetGlider(0, 0),
+			accDisp:   animation.NewTargetGlider(100, 2),
+			maxCombo:  r.MaxCombo,
+			fadeHit:   animation.NewGlider(0),
+		}
+
+		overlay.players = append(overlay.players, player)
+		overlay.cursors[controller.GetCursors()[i]] = player
+	}
+
+	overlay.playersArray = append(overlay.playersArray, overlay.players...)
+	overlay.alivePlayers = len(overlay.players)
+
+	if len(overlay.players) > 1 {
+		discord.UpdateKnockout(len(overlay.players), len(overlay.players))
+	}
+
+	overlay.ruleset.SetListener(overlay.hitReceived)
+
+	return overlay
+}
+
+func (overlay *TaikoOverlay) hitReceived(cursor *graphics.Cursor, judgementResult taiko.JudgementResult, score taiko.Score) {
+	player := overlay.cursors[cursor]
+
+	player.score = score.Score
+	player.scoreDisp.SetValue(float64(score.Score), false)
+	player.accDisp.SetValue(score.Accuracy*100, false)
+
+	if !judgementResult.HitResult.IsJudgement() {
+		return
+	}
+
+	if player.index == 0 {
+		if judgementResult.HitResult != taiko.Miss {
+			overlay.hitFlash.Reset()
+			overlay.hitFlash.AddEventSEase(overlay.normalTime, overlay.normalTime+200, 1, 0, easing.OutQuad)
+		}
+
+		overlay.lastJudgement = judgementResult.HitResult
+
+		overlay.judgementFade.Reset()
+		overlay.judgementFade.AddEventS(overlay.normalTime, overlay.normalTime+100, 0.5, 1)
+		overlay.judgementFade.AddEventS(overlay.normalTime+400, overlay.normalTime+600, 1, 0)
+
+		overlay.judgementScale.Reset()
+		overlay.judgementScale.AddEventSEase(overlay.normalTime, overlay.normalTime+200, 0.6, 1, easing.OutQuad)
+	}
+
+	if judgementResult.HitResult != taiko.Great {
+		player.lastHit = judgementResult.HitResult
+
+		player.fadeHit.Reset()
+		player.fadeHit.AddEventS(overlay.normalTime, overlay.normalTime+300, 0.5, 1)
+		player.fadeHit.AddEventS(overlay.normalTime+600, overlay.normalTime+900, 1, 0)
+	}
+
+	if len(overlay.players) > 1 && !player.hasBroken && (judgementResult.ComboBreak || (settings.Knockout.Mode == settings.SSOrQuit && judgementResult.HitResult != taiko.Great)) {
+		if (settings.Knockout.Mode == settings.SSOrQuit ||
+			(settings.Knockout.Mode == settings.ComboBreak && judgementResult.Time > int64(settings.Knockout.GraceEndTime*1000)) ||
+			(settings.Knockout.Mode == settings.MaxCombo && math.Abs(float64(player.sCombo-player.maxCombo)) < 5)) &&
+			overlay.alivePlayers > settings.Knockout.MinPlayers {
+			player.hasBroken = true
+			player.breakTime = judgementResult.Time
+
+			overlay.alivePlayers--
+
+			player.fade.AddEvent(overlay.normalTime, overlay.normalTime+3000, 0)
+
+			discord.UpdateKnockout(overlay.alivePlayers, len(overlay.players))
+
+			log.Println(player.name, "has broken! Max combo:", player.sCombo)
+		}
+	}
+
+	if judgementResult.ComboBreak {
+		player.sCombo = 0
+	} else {
+		player.sCombo++
+	}
+}
+
+func (overlay *TaikoOverlay) Update(time float64) {
+	if overlay.audioTime == 0 {
+		overlay.audioTime = time
+		overlay.normalTime = time
+	}
+
+	delta := time - overlay.audioTime
+
+	if overlay.music != nil && overlay.music.GetState() == bass.MusicPlaying {
+		delta /= overlay.music.GetSpeed()
+	}
+
+	overlay.normalTime += delta
+
+	overlay.audioTime = time
+
+	overlay.hitFlash.Update(overlay.normalTime)
+	overlay.judgementFade.Update(overlay.normalTime)
+	overlay.judgementScale.Update(overlay.normalTime)
+
+	for _, player := range overlay.players {
+		player.fade.Update(overlay.normalTime)
+		player.fadeHit.Update(overlay.normalTime)
+		player.scoreDisp.Update(overlay.normalTime)
+		player.accDisp.Update(overlay.normalTime)
+	}
+
+	currentHp := overlay.ruleset.GetHP(overlay.controller.GetCursors()[0])
+
+	if overlay.hpDisp < currentHp {
+		overlay.hpDisp = min(1.0, overlay.hpDisp+math.Abs(currentHp-overlay.hpDisp)/4*delta/16.667)
+	} else if overlay.hpDisp > currentHp {
+		overlay.hpDisp = max(0.0, overlay.hpDisp-math.Abs(overlay.hpDisp-currentHp)/6*delta/16.667)
+	}
+
+	if settings.Knockout.LiveSort && len(overlay.players) > 1 {
+		sort.SliceStable(overlay.playersArray, func(i, j int) bool {
+			a, b := overlay.playersArray[i], overlay.playersArray[j]
+
+			if a.hasBroken != b.hasBroken {
+				return !a.hasBroken
+			}
+
+			if a.hasBroken && a.breakTime != b.breakTime {
+				return a.breakTime > b.breakTime
+			}
+
+			return a.score > b.score
+		})
+	}
+}
+
+func (overlay *TaikoOverlay) SetMusic(music bass.ITrack) {
+	overlay.music = music
+}
+
+func (overlay *TaikoOverlay) DrawBackground(_ *batch.QuadBatch, _ []color2.Color, _ float64) {}
+
+func (overlay *TaikoOverlay) DrawBeforeObjects(_ *batch.QuadBatch, _ []color2.Color, _ float64) {}
+
+func (overlay *TaikoOverlay) DrawNormal(_ *batch.QuadBatch, _ []color2.Color, _ float64) {}
+
+func (overlay *TaikoOverlay) DrawHUD(batch *batch.QuadBatch, colors []color2.Color, alpha float64) {
+	batch.ResetTransform()
+
+	overlay.drawPlayfield(batch, alpha)
+	overlay.drawObjects(batch, alpha)
+	overlay.drawDrum(batch, colors, alpha)
+
+	batch.ResetTransform()
+
+	if len(overlay.players) == 1 {
+		overlay.drawSingleHUD(batch, alpha)
+	} else {
+		overlay.drawPlayerList(batch, colors, alpha)
+	}
+
+	batch.ResetTransform()
+	batch.SetColor(1, 1, 1, 1)
+}
+
+func (overlay *TaikoOverlay) drawRect(batch *batch.QuadBatch, x, y, width, height float64) {
+	batch.SetSubScale(width/2, height/2)
+	batch.SetTranslation(vector.NewVec2d(x+width/2, y+height/2))
+	batch.DrawUnit(graphics.Pixel.GetRegion())
+}
+
+func (overlay *TaikoOverlay) drawCircle(batch *batch.QuadBatch, x, y, radius float64, color color2.Color, alpha float64) {
+	batch.SetColor(float64(color.R), float64(color.G), float64(color.B), alpha)
+	batch.SetSubScale(radius, radius)
+	batch.SetTranslation(vector.NewVec2d(x, y))
+	batch.DrawUnit(*overlay.circle)
+
+	batch.SetColor(1, 1, 1, alpha)
+	batch.DrawUnit(*overlay.circleOverlay)
+}
+
+func (overlay *TaikoOverlay) drawPlayfield(batch *batch.QuadBatch, alpha float64) {
+	top := overlay.laneY - overlay.laneHeight/2
+
+	batch.SetColor(0.1, 0.1, 0.1, alpha*0.8)
+	overlay.drawRect(batch, 0, top, overlay.ScaledWidth, overlay.laneHeight)
+
+	batch.SetColor(1, 1, 1, alpha*0.3)
+	overlay.drawRect(batch, 0, top-2, overlay.ScaledWidth, 2)
+	overlay.drawRect(batch, 0, top+overlay.laneHeight, overlay.ScaledWidth, 2)
+
+	// Hit target
+	batch.SetColor(1, 1, 1, alpha*0.25)
+	batch.SetSubScale(overlay.laneHeight*0.3, overlay.laneHeight*0.3)
+	batch.SetTranslation(vector.NewVec2d(overlay.hitX, overlay.laneY))
+	batch.DrawUnit(*overlay.circleOverlay)
+
+	batch.SetColor(1, 1, 1, alpha*0.15)
+	batch.SetSubScale(overlay.laneHeight*0.45, overlay.laneHeight*0.45)
+	batch.DrawUnit(*overlay.circleOverlay)
+
+	if flash := overlay.hitFlash.GetValue(); flash > 0.001 {
+		batch.SetAdditive(true)
+		batch.SetColor(1, 0.9, 0.6, alpha*flash*0.6)
+		batch.SetSubScale(overlay.laneHeight*0.45, overlay.laneHeight*0.45)
+		batch.DrawUnit(*overlay.circle)
+		batch.SetAdditive(false)
+	}
+}
+
+func (overlay *TaikoOverlay) drawObjects(batch *batch.QuadBatch, alpha float64) {
+	time := overlay.audioTime
+	scale := overlay.ScaledHeight / 480
+	cursor := overlay.controller.GetCursors()[0]
+	single := len(overlay.players) == 1
+
+	smallRadius := overlay.laneHeight * 0.3
+	bigRadius := overlay.laneHeight * 0.45
+
+	objects := overlay.ruleset.GetObjects()
+
+	// Draw from the end so earlier objects are on top
+	for i := len(objects) - 1; i >= 0; i-- {
+		o := objects[i]
+
+		x := overlay.hitX + (o.StartTime-time)*o.Velocity*scale
+
+		radius := smallRadius
+		if o.Big {
+			radius = bigRadius
+		}
+
+		switch o.Type {
+		case taiko.Hit:
+			if x-radius > overlay.ScaledWidth || x+radius < 0 {
+				continue
+			}
+
+			result := overlay.ruleset.GetResult(cursor, o.Number)
+
+			if (single && result != taiko.None && result != taiko.Miss) || (!single && time > o.StartTime) {
+				continue
+			}
+
+			color := donColor
+			if o.Kat {
+				color = katColor
+			}
+
+			overlay.drawCircle(batch, x, overlay.laneY, radius, color, alpha)
+		case taiko.DrumRoll:
+			endX := overlay.hitX + (o.EndTime-time)*o.Velocity*scale
+
+			if x-radius > overlay.ScaledWidth || endX+radius < 0 {
+				continue
+			}
+
+			x = max(x, overlay.hitX-radius*2)
+
+			if endX > x {
+				batch.SetColor(float64(drumRollColor.R), float64(drumRollColor.G), float64(drumRollColor.B), alpha*0.8)
+				overlay.drawRect(batch, x, overlay.laneY-radius*0.8, endX-x, radius*1.6)
+
+				overlay.drawCircle(batch, endX, overlay.laneY, radius*0.8, drumRollColor, alpha)
+			}
+
+			overlay.drawCircle(batch, x, overlay.laneY, radius, drumRollColor, alpha)
+		case taiko.Swell:
+			if time > o.EndTime || x-bigRadius > overlay.ScaledWidth {
+				continue
+			}
+
+			if overlay.ruleset.GetResult(cursor, o.Number) != taiko.None {
+				continue
+			}
+
+			x = max(x, overlay.hitX)
+
+			overlay.drawCircle(batch, x, overlay.laneY, bigRadius, swellColor, alpha)
+
+			if time >= o.StartTime {
+				hits, required := overlay.ruleset.GetSwellProgress(cursor, o.Number)
+
+				batch.ResetTransform()
+				batch.SetColor(1, 1, 1, alpha)
+				overlay.font.DrawOrigin(batch, x, overlay.laneY, vector.Centre, bigRadius*0.7, true, fmt.Sprintf("%d", max(0, required-hits)))
+			}
+		}
+	}
+
+	batch.ResetTransform()
+}
+
+func (overlay *TaikoOverlay) drawDrum(batch *batch.QuadBatch, colors []color2.Color, alpha float64) {
+	top := overlay.laneY - overlay.laneHeight/2
+	width := overlay.hitX - overlay.laneHeight*0.55
+
+	batch.SetColor(0.15, 0.15, 0.15, alpha)
+	overlay.drawRect(batch, 0, top, width, overlay.laneHeight)
+
+	size := overlay.laneHeight * 0.8
+	drumX := (width - size) / 2
+	drumY := overlay.laneY - size/2
+
+	input := overlay.controller.GetInput(0)
+
+	// Kat keys are the outer rim, don keys are the inner part
+	parts := []struct {
+		key   int
+		x     float64
+		color color2.Color
+	}{
+		{taiko.LeftKat, drumX, katColor},
+		{taiko.LeftDon, drumX + size/4, donColor},
+		{taiko.RightDon, drumX + size/2, donColor},
+		{taiko.RightKat, drumX + size*3/4, katColor},
+	}
+
+	for _, part := range parts {
+		partAlpha := 0.25
+		if input[part.key] {
+			partAlpha = 1
+		}
+
+		batch.SetColor(float64(part.color.R), float64(part.color.G), float64(part.color.B), alpha*partAlpha)
+		overlay.drawRect(batch, part.x+2, drumY, size/4-4, size)
+	}
+
+	if len(overlay.players) == 1 {
+		batch.ResetTransform()
+		batch.SetColor(1, 1, 1, alpha)
+
+		combo := overlay.ruleset.GetScore(overlay.controller.GetCursors()[0]).Combo
+		if combo > 0 {
+			overlay.font.DrawOrigin(batch, width/2, top+overlay.laneHeight+overlay.laneHeight*0.2, vector.Centre, overlay.laneHeight*0.25, true, fmt.Sprintf("%dx", combo))
+		}
+	} else if len(colors) > 0 {
+		batch.SetColor(float64(colors[0].R), float64(colors[0].G), float64(colors[0].B), alpha)
+		overlay.drawRect(batch, 0, top, 6, overlay.laneHeight)
+	}
+
+	if fade := overlay.judgementFade.GetValue(); fade > 0.001 {
+		if tex := overlay.getJudgementTexture(overlay.lastJudgement); tex != nil {
+			height := overlay.laneHeight * 0.35 * overlay.judgementScale.GetValue()
+
+			batch.SetColor(1, 1, 1, alpha*fade)
+			batch.SetSubScale(height*float64(tex.Width)/float64(tex.Height)/2, height/2)
+			batch.SetTranslation(vector.NewVec2d(overlay.hitX, top-height/2-10))
+			batch.DrawUnit(*tex)
+		}
+	}
+}
+
+func (overlay *TaikoOverlay) getJudgementTexture(result taiko.HitResult) *texture.TextureRegion {
+	switch result {
+	case taiko.Great:
+		return skin.GetTexture("hit300")
+	case taiko.Ok:
+		return skin.GetTexture("hit100")
+	case taiko.Miss:
+		return skin.GetTexture("hit0")
+	}
+
+	return nil
+}
+
+func (overlay *TaikoOverlay) drawSingleHUD(batch *batch.QuadBatch, alpha float64) {
+	cursor := overlay.controller.GetCursors()[0]
+	player := overlay.players[0]
+	score := overlay.ruleset.GetScore(cursor)
+
+	top := overlay.laneY - overlay.laneHeight/2
+
+	// Health bar
+	batch.SetColor(0.2, 0.2, 0.2, alpha*0.8)
+	overlay.drawRect(batch, overlay.hitX, top-14, overlay.ScaledWidth-overlay.hitX-20, 10)
+
+	if overlay.hpDisp >= 0.5 {
+		batch.SetColor(0.4, 0.9, 0.3, alpha)
+	} else {
+		batch.SetColor(0.9, 0.6, 0.2, alpha)
+	}
+
+	overlay.drawRect(batch, overlay.hitX, top-14, (overlay.ScaledWidth-overlay.hitX-20)*overlay.hpDisp, 10)
+
+	batch.ResetTransform()
+	batch.SetColor(1, 1, 1, alpha)
+
+	scl := overlay.ScaledHeight * 0.05
+
+	overlay.font.DrawOrigin(batch, overlay.ScaledWidth-20, 10, vector.TopRight, scl, true, fmt.Sprintf("%08d", int64(player.scoreDisp.GetValue())))
+	overlay.font.DrawOrigin(batch, overlay.ScaledWidth-20, 10+scl, vector.TopRight, scl*0.6, true, fmt.Sprintf("%.2f%%", player.accDisp.GetValue()))
+
+	if score.Grade != osu.NONE {
+		text := skin.GetTexture("ranking-" + score.Grade.TextureName() + "-small")
+
+		batch.SetSubScale(scl*0.3*float64(text.Width)/float64(text.Height), scl*0.3)
+		batch.SetTranslation(vector.NewVec2d(overlay.ScaledWidth-20-overlay.font.GetWidthMonospaced(scl*0.6, fmt.Sprintf("%.2f%%", player.accDisp.GetValue()))-scl*0.5, 10+scl*1.3))
+		batch.DrawUnit(*text)
+	}
+}
+
+func (overlay *TaikoOverlay) drawPlayerList(batch *batch.QuadBatch, colors []color2.Color, alpha float64) {
+	listTop := overlay.laneY + overlay.laneHeight/2 + 20
+	scl := min(overlay.ScaledHeight*0.9/51*1.5, (overlay.ScaledHeight-listTop-20)/float64(len(overlay.players)))
+
+	replays := overlay.controller.GetReplays()
+
+	maxNameWidth := 0.0
+
+	for _, player := range overlay.players {
+		width := overlay.font.GetWidth(scl, player.name)
+
+		if mods := replays[player.index].Mods; mods != "" {
+			width += overlay.font.GetWidth(scl*0.8, "+"+mods)
+		}
+
+		maxNameWidth = max(maxNameWidth, width)
+	}
+
+	for i, player := range overlay.playersArray {
+		rowY := listTop + (float64(i)+0.5)*scl
+		rowAlpha := alpha * max(player.fade.GetValue(), 0.25)
+
+		color := colors[player.index%len(colors)]
+
+		input := overlay.controller.GetInput(player.index)
+
+		keyColors := []color2.Color{katColor, donColor, donColor, katColor}
+
+		for j, key := range []int{taiko.LeftKat, taiko.LeftDon, taiko.RightDon, taiko.RightKat} {
+			keyAlpha := 0.25
+			if input[key] {
+				keyAlpha = 1
+			}
+
+			batch.SetColor(float64(keyColors[j].R), float64(keyColors[j].G), float64(keyColors[j].B), rowAlpha*keyAlpha)
+			overlay.drawRect(batch, 10+float64(j)*scl*0.4, rowY-scl*0.35, scl*0.35, scl*0.7)
+		}
+
+		batch.ResetTransform()
+
+		x := 20 + scl*1.6
+
+		batch.SetColor(1, 1, 1, rowAlpha)
+		overlay.font.DrawOrigin(batch, x, rowY, vector.CentreLeft, scl, true, fmt.Sprintf("%6.2f%%", player.accDisp.GetValue()))
+
+		x += overlay.font.GetWidthMonospaced(scl, "100.00%") + scl*0.5
+
+		batch.SetColor(float64(color.R), float64(color.G), float64(color.B), rowAlpha)
+		overlay.font.DrawOrigin(batch, x, rowY, vector.CentreLeft, scl, false, player.name)
+
+		nameWidth := overlay.font.GetWidth(scl, player.name)
+
+		batch.SetColor(1, 1, 1, rowAlpha)
+
+		if mods := replays[player.index].Mods; mods != "" {
+			overlay.font.DrawOrigin(batch, x+nameWidth, rowY, vector.CentreLeft, scl*0.8, false, "+"+mods)
+		}
+
+		if player.lastHit != taiko.None {
+			if tex := overlay.getJudgementTexture(player.lastHit); tex != nil {
+				batch.SetColor(1, 1, 1, rowAlpha*player.fadeHit.GetValue())
+				batch.SetSubScale(scl*0.8/2*float64(tex.Width)/float64(tex.Height), scl*0.8/2)
+				batch.SetTranslation(vector.NewVec2d(x+maxNameWidth+scl*(0.5+float64(tex.Width)/float64(tex.Height)*0.4), rowY))
+				batch.DrawUnit(*tex)
+				batch.ResetTransform()
+			}
+		}
+
+		batch.SetColor(1, 1, 1, rowAlpha)
+
+		scoreStr := utils.Humanize(int64(player.scoreDisp.GetValue()))
+		overlay.font.DrawOrigin(batch, overlay.ScaledWidth-0.5*scl, rowY, vector.CentreRight, scl, true, scoreStr)
+
+		comboStr := fmt.Sprintf("%dx ", player.sCombo)
+		overlay.font.DrawOrigin(batch, overlay.ScaledWidth-0.5*scl-overlay.font.GetWidthMonospaced(scl, strings.Repeat("0", 12)), rowY, vector.CentreRight, scl, true, comboStr)
+	}
+}
+
+func (overlay *TaikoOverlay) IsBroken(cursor *graphics.Cursor) bool {
+	return overlay.cursors[cursor].hasBroken
+}
+
+func (overlay *TaikoOverlay) DisableAudioSubmission(b bool) {
+	overlay.ruleset.DisableAudioSubmission(b)
+}
+
+func (overlay *TaikoOverlay) ShouldDrawHUDBeforeCursor() bool {
+	return false
+}
+
+// Rewind is a no-op, seeking is not supported in osu!taiko
+func (overlay *TaikoOverlay) Rewind(_ float64) {}
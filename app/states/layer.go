@@ -0,0 +1,25 @@
+package states
+
+// Layer is a part of the frame which can be drawn to a separate framebuffer, e.g. to record it to a separate file
+type Layer int
+
+const (
+	LayerBackground Layer = iota // background, storyboard and logo
+	LayerPlayfield               // hit objects, judgements and cursors
+	LayerHUD                     // score overlay and other UI
+)
+
+// SetLayerHook sets the function called before drawing each part of the frame that belongs to a different layer than
+// the previous one. Parts of layers are interleaved, so the hook can be called for the same layer more than once in a frame.
+func (player *Player) SetLayerHook(hook func(layer Layer)) {
+	player.layerHook = hook
+}
+
+func (player *Player) setLayer(layer Layer) {
+	if player.layerHook == nil || player.currentLayer == layer {
+		return
+	}
+
+	player.currentLayer = layer
+	player.layerHook(layer)
+}
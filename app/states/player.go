@@ -11,6 +11,7 @@ import (
 	"github.com/wieku/danser-go/app/bmath"
 	camera2 "github.com/wieku/danser-go/app/bmath/camera"
 	"github.com/wieku/danser-go/app/dance"
+	"github.com/wieku/danser-go/app/database"
 	"github.com/wieku/danser-go/app/discord"
 	"github.com/wieku/danser-go/app/graphics"
 	"github.com/wieku/danser-go/app/input"
@@ -77,6 +78,7 @@ type Player struct {
 
 	mainCamera   *camera2.Camera
 	objectCamera *camera2.Camera
+	cursorCamera *camera2.Camera
 	bgCamera     *camera2.Camera
 	uiCamera     *camera2.Camera
 
@@ -122,6 +124,11 @@ type Player struct {
 
 	nightcore *common.NightcoreProcessor
 
+	judgementExporter *osu.JudgementExporter
+
+	// health of the player used for storyboard's pass/fail state
+	storyboardHealth func() float64
+
 	realTime         float64
 	objectsAlphaFail *animation.Glider
 	failOX           *animation.Glider
@@ -132,6 +139,12 @@ type Player struct {
 	failAt  float64
 	failed  bool
 
+	// controls queues pause/seek actions from the input thread to the update thread
+	controls chan func()
+
+	layerHook    func(layer Layer)
+	currentLayer Layer
+
 	mProfiler *frame.Counter
 	mStats1   *runtime.MemStats
 	mStats2   *runtime.MemStats
@@ -244,6 +257,11 @@ func NewPlayer(beatMap *beatmap.BeatMap) *Player {
 	player.objectCamera.SetOsuViewport(int(settings.Graphics.GetWidth()), int(settings.Graphics.GetHeight()), settings.Playfield.Scale, true, settings.Playfield.OsuShift)
 	player.objectCamera.Update()
 
+	// Cursors have a separate camera, so they can be rotated along with objects by Barrel Roll
+	player.cursorCamera = camera2.NewCamera()
+	player.cursorCamera.SetOsuViewport(int(settings.Graphics.GetWidth()), int(settings.Graphics.GetHeight()), settings.Playfield.Scale, true, settings.Playfield.OsuShift)
+	player.cursorCamera.Update()
+
 	player.bgCamera = camera2.NewCamera()
 
 	sbScale := 1.0
@@ -262,16 +280,26 @@ func NewPlayer(beatMap *beatmap.BeatMap) *Player {
 	player.uiCamera.SetViewportF(0, int(player.ScaledHeight), int(player.ScaledWidth), 0)
 	player.uiCamera.Update()
 
-	graphics.Camera = player.mainCamera
+	graphics.Camera = player.cursorCamera
 
 	player.bMap.Reset()
 
-	if settings.PLAY {
+	if settings.TAIKO {
+		controller := dance.NewTaikoController()
+		player.controller = controller
+
+		player.controller.SetBeatMap(player.bMap)
+		player.controller.InitCursors()
+
+		player.overlay = overlays.NewTaikoOverlay(controller.(*dance.TaikoController))
+		player.setStoryboardHealth(controller.(*dance.TaikoController).GetRuleset().GetHP)
+	} else if settings.PLAY {
 		player.controller = dance.NewPlayerController()
 
 		player.controller.SetBeatMap(player.bMap)
 		player.controller.InitCursors()
 		player.overlay = overlays.NewScoreOverlay(player.controller.(*dance.PlayerController).GetRuleset(), player.controller.GetCursors()[0])
+		player.setStoryboardHealth(player.controller.(*dance.PlayerController).GetRuleset().GetHP)
 	} else if settings.KNOCKOUT {
 		controller := dance.NewReplayController()
 		player.controller = controller
@@ -281,6 +309,7 @@ func NewPlayer(beatMap *beatmap.BeatMap) *Player {
 
 		if settings.PLAYERS == 1 {
 			player.overlay = overlays.NewScoreOverlay(player.controller.(*dance.ReplayController).GetRuleset(), player.controller.GetCursors()[0])
+			player.setStoryboardHealth(player.controller.(*dance.ReplayController).GetRuleset().GetHP)
 		} else {
 			player.overlay = overlays.NewKnockoutOverlay(controller.(*dance.ReplayController))
 		}
@@ -290,10 +319,37 @@ func NewPlayer(beatMap *beatmap.BeatMap) *Player {
 		player.controller.InitCursors()
 	}
 
+	var ruleset *osu.OsuRuleSet
+
+	switch controller := player.controller.(type) {
+	case *dance.PlayerController:
+		ruleset = controller.GetRuleset()
+	case *dance.ReplayController:
+		ruleset = controller.GetRuleset()
+	}
+
+	if ruleset != nil {
+		if settings.RECORD && settings.Recording.ExportJudgements != "none" {
+			player.judgementExporter = osu.NewJudgementExporter()
+			ruleset.SetExporter(player.judgementExporter)
+		}
+
+		// In play mode -start and -end remove objects from the map, so the result wouldn't be complete
+		if !settings.PLAY || (settings.START <= 0.01 && math.IsInf(settings.END, 1)) {
+			ruleset.SetFinishListener(func() {
+				player.saveScores(ruleset)
+			})
+		}
+	}
+
 	player.lastTime = -1
 
 	player.objectContainer = containers.NewHitObjectContainer(beatMap)
 
+	if cursors := player.controller.GetCursors(); len(cursors) == 1 {
+		player.objectContainer.SetCursor(cursors[0])
+	}
+
 	player.Scl = 1
 	player.fadeOut = 1.0
 	player.fadeIn = 0.0
@@ -470,7 +526,7 @@ func NewPlayer(beatMap *beatmap.BeatMap) *Player {
 		startTime := p.GetStartTime()
 		endTime := p.GetEndTime()
 
-		speed := settings.SPEED * player.bMap.Diff.GetSpeed()
+		speed := settings.SPEED * player.bMap.Diff.GetSpeedAt(startTime)
 
 		if endTime-startTime < 1000*speed || endTime < player.startPoint || startTime > player.MapEnd {
 			continue
@@ -490,6 +546,11 @@ func NewPlayer(beatMap *beatmap.BeatMap) *Player {
 		player.cursorGlider.AddEvent(endTime, endTime+1000*speed, 1.0)
 	}
 
+	// Mod rate is automated by the track, so audio follows it between frames and in offscreen audio chunks
+	player.musicPlayer.SetRateCurve(func(position float64) float64 {
+		return mutils.Lerp(1, player.bMap.Diff.GetSpeedAt(position), player.speedGlider.GetValue())
+	}, player.bMap.Diff.AdjustsPitch())
+
 	player.background.SetTrack(player.musicPlayer)
 
 	player.coin = common.NewDanserCoin()
@@ -529,10 +590,19 @@ func NewPlayer(beatMap *beatmap.BeatMap) *Player {
 		return player
 	}
 
+	if rController, ok := player.controller.(*dance.ReplayController); ok {
+		rController.EnableSnapshots()
+
+		player.controls = make(chan func(), 16)
+		input.RegisterListener(player.KeyEvent)
+	}
+
 	goroutines.RunOS(func() {
 		var lastTimeNano = qpc.GetNanoTime()
 
 		for !input.Win.ShouldClose() {
+			player.processControls()
+
 			currentTimeNano := qpc.GetNanoTime()
 
 			delta := float64(currentTimeNano-lastTimeNano) / 1000000.0
@@ -547,7 +617,7 @@ func NewPlayer(beatMap *beatmap.BeatMap) *Player {
 				if player.rawPositionF < player.startPointE || player.start {
 					player.rawPositionF += delta
 				} else {
-					speed = settings.SPEED * player.bMap.Diff.GetSpeed()
+					speed = settings.SPEED * player.bMap.Diff.GetSpeedAt(player.progressMsF)
 					player.rawPositionF += delta * speed
 				}
 			} else {
@@ -590,6 +660,40 @@ func NewPlayer(beatMap *beatmap.BeatMap) *Player {
 	return player
 }
 
+// saveScores adds results of players that completed the map to local score history
+func (player *Player) saveScores(ruleset *osu.OsuRuleSet) {
+	var scores []*database.Score
+
+	for _, c := range player.controller.GetCursors() {
+		if c.IsAutoplay || ruleset.HasFailed(c) {
+			continue
+		}
+
+		diff := ruleset.GetPlayerDifficulty(c)
+		score := ruleset.GetScore(c)
+
+		scores = append(scores, &database.Score{
+			Player:    c.Name,
+			MD5:       player.bMap.MD5,
+			Mods:      diff.Mods,
+			ModString: diff.GetModString(),
+			Score:     score.Score,
+			MaxCombo:  score.Combo,
+			Count300:  score.Count300,
+			CountGeki: score.CountGeki,
+			Count100:  score.Count100,
+			CountKatu: score.CountKatu,
+			Count50:   score.Count50,
+			CountMiss: score.CountMiss,
+			Accuracy:  score.Accuracy,
+			PP:        score.PP.Total,
+			Date:      c.ScoreTime,
+		})
+	}
+
+	database.SaveScores(scores)
+}
+
 func (player *Player) trySetupFail() {
 	if sO, ok := player.overlay.(*overlays.ScoreOverlay); ok {
 		var ruleset *osu.OsuRuleSet
@@ -644,7 +748,7 @@ func (player *Player) Update(delta float64) bool {
 	if player.musicPlayer.GetState() == bass.MusicPlaying {
 		speed = player.musicPlayer.GetSpeed()
 	} else if !(player.progressMsF < player.startPointE || player.start) {
-		speed = settings.SPEED * player.bMap.Diff.GetSpeed()
+		speed = settings.SPEED * player.bMap.Diff.GetSpeedAt(player.progressMsF)
 	}
 
 	player.rawPositionF += delta * speed
@@ -668,6 +772,14 @@ func (player *Player) Update(delta float64) bool {
 	return false
 }
 
+func (player *Player) GetBeatMap() *beatmap.BeatMap {
+	return player.bMap
+}
+
+func (player *Player) GetController() dance.Controller {
+	return player.controller
+}
+
 func (player *Player) GetTime() float64 {
 	return player.progressMsF
 }
@@ -676,6 +788,31 @@ func (player *Player) GetTimeOffset() float64 {
 	return player.progressMsF - player.startOffset
 }
 
+func (player *Player) setStoryboardHealth(getHP func(cursor *graphics.Cursor) float64) {
+	cursor := player.controller.GetCursors()[0]
+
+	player.storyboardHealth = func() float64 {
+		return getHP(cursor)
+	}
+}
+
+// ExportJudgements saves judgements and clicks collected during recording, basePath is extended with format specific suffixes
+func (player *Player) ExportJudgements(basePath string) {
+	if player.judgementExporter == nil {
+		return
+	}
+
+	paths, err := player.judgementExporter.Save(basePath, settings.Recording.ExportJudgements)
+	if err != nil {
+		log.Println("Failed to export judgements:", err)
+		return
+	}
+
+	for _, path := range paths {
+		log.Println("Judgements exported to:", path)
+	}
+}
+
 func (player *Player) updateMain(delta float64) {
 	player.realTime += delta
 
@@ -706,10 +843,17 @@ func (player *Player) updateMain(delta float64) {
 	player.failOY.Update(player.realTime)
 	player.failRotation.Update(player.realTime)
 
+	barrelRotation := containers.BarrelRollRotation(player.bMap.Diff, player.progressMsF)
+
 	player.objectCamera.SetOrigin(vector.NewVec2d(player.failOX.GetValue(), player.failOY.GetValue()))
-	player.objectCamera.SetRotation(player.failRotation.GetValue())
+	player.objectCamera.SetRotation(player.failRotation.GetValue() + barrelRotation)
 	player.objectCamera.Update()
 
+	if barrelRotation != 0 {
+		player.cursorCamera.SetRotation(barrelRotation)
+		player.cursorCamera.Update()
+	}
+
 	if player.failing && player.realTime >= player.failAt {
 		if !player.failed {
 			player.musicPlayer.Pause()
@@ -719,26 +863,18 @@ func (player *Player) updateMain(delta float64) {
 		player.failed = true
 	}
 
-	speedAdjust := mutils.Lerp(1, settings.SPEED, player.speedGlider.GetValue())
-	freqAdjust := 1.0
-
-	speedVal := mutils.Lerp(1, player.bMap.Diff.GetSpeed(), player.speedGlider.GetValue())
-	if player.bMap.Diff.AdjustsPitch() {
-		freqAdjust = speedVal
-	} else {
-		speedAdjust *= speedVal
-	}
-
-	player.musicPlayer.SetTempo(speedAdjust)
+	player.musicPlayer.SetTempo(mutils.Lerp(1, settings.SPEED, player.speedGlider.GetValue()))
 	player.musicPlayer.SetPitch(mutils.Lerp(1, settings.PITCH, player.pitchGlider.GetValue()))
-	player.musicPlayer.SetRelativeFrequency(freqAdjust * player.frequencyGlider.GetValue())
+	player.musicPlayer.SetRelativeFrequency(player.frequencyGlider.GetValue())
 
 	if player.progressMsF >= player.startPointE {
 		if _, ok := player.controller.(*dance.GenericController); ok {
 			player.bMap.Update(player.progressMsF)
 		}
 
-		player.objectContainer.Update(player.progressMsF)
+		if !settings.TAIKO {
+			player.objectContainer.Update(player.progressMsF)
+		}
 	}
 
 	if player.progressMsF >= player.startPointE || settings.PLAY {
@@ -779,6 +915,10 @@ func (player *Player) updateMain(delta float64) {
 
 	offset = offset.Scl(1 / float64(len(player.controller.GetCursors())))
 
+	if storyboard := player.background.GetStoryboard(); storyboard != nil && player.storyboardHealth != nil {
+		storyboard.UpdateHealth(player.progressMsF, player.storyboardHealth())
+	}
+
 	player.background.Update(player.progressMsF, offset.X*player.cursorGlider.GetValue(), offset.Y*player.cursorGlider.GetValue())
 
 	bgDim := settings.Playfield.Background.Dim
@@ -842,14 +982,26 @@ func (player *Player) DrawMain(float64) {
 	player.lastTime = tim
 
 	objectCameras := player.objectCamera.GenRotated(settings.DIVIDES, -2*math.Pi/float64(settings.DIVIDES))
-	cursorCameras := player.mainCamera.GenRotated(settings.DIVIDES, -2*math.Pi/float64(settings.DIVIDES))
+	cursorCameras := player.cursorCamera.GenRotated(settings.DIVIDES, -2*math.Pi/float64(settings.DIVIDES))
 
 	bgAlpha := player.dimGlider.GetValue()
 	if settings.Playfield.Background.FlashToTheBeat {
 		bgAlpha = mutils.Clamp(bgAlpha*player.Scl, 0, 1)
 	}
 
-	player.background.Draw(player.progressMsF, player.batch, player.blurGlider.GetValue(), bgAlpha, player.bgCamera.GetProjectionView())
+	// the first layer of each frame has to be announced, currentLayer is left from the previous one
+	if player.layerHook != nil {
+		player.currentLayer = LayerBackground
+		player.layerHook(LayerBackground)
+	}
+
+	// transparent recordings are composited over other footage, so the background, storyboard and dim are left out,
+	// unless they go to a separate layer
+	transparent := settings.RECORD && settings.Recording.IsTransparent() && player.layerHook == nil
+
+	if !transparent {
+		player.background.Draw(player.progressMsF, player.batch, player.blurGlider.GetValue(), bgAlpha, player.bgCamera.GetProjectionView())
+	}
 
 	if player.progressMsF > 0 {
 		timeDiff := player.progressMsF - player.lastProgressMsF
@@ -860,9 +1012,11 @@ func (player *Player) DrawMain(float64) {
 	cursorColors := settings.Cursor.GetColors(settings.DIVIDES, len(player.controller.GetCursors()), player.Scl, player.cursorGlider.GetValue())
 
 	if player.overlay != nil {
+		player.setLayer(LayerPlayfield)
 		player.drawOverlayPart(player.overlay.DrawBackground, cursorColors, cursorCameras[0], 1)
 	}
 
+	player.setLayer(LayerHUD)
 	player.drawEpilepsyWarning()
 
 	player.counter += timMs
@@ -874,6 +1028,7 @@ func (player *Player) DrawMain(float64) {
 		}
 	}
 
+	player.setLayer(LayerBackground)
 	player.drawCoin()
 
 	scale2 := player.Scl
@@ -881,7 +1036,8 @@ func (player *Player) DrawMain(float64) {
 		scale2 = 1
 	}
 
-	bloomEnabled := settings.Playfield.Bloom.Enabled
+	// bloom pass can't span framebuffers of separate layers
+	bloomEnabled := settings.Playfield.Bloom.Enabled && player.layerHook == nil
 
 	if bloomEnabled {
 		player.bloomEffect.SetThreshold(settings.Playfield.Bloom.Threshold)
@@ -896,23 +1052,33 @@ func (player *Player) DrawMain(float64) {
 		player.bloomEffect.Begin()
 	}
 
+	player.setLayer(LayerPlayfield)
+
 	if player.overlay != nil {
 		player.drawOverlayPart(player.overlay.DrawBeforeObjects, cursorColors, objectCameras[0], player.objectsAlphaFail.GetValue())
 	}
 
-	player.objectContainer.Draw(player.batch, player.mainCamera.GetProjectionView(), objectCameras, player.progressMsF, float32(player.Scl), float32(player.objectsAlpha.GetValue()*player.objectsAlphaFail.GetValue()))
+	if !settings.TAIKO {
+		player.objectContainer.Draw(player.batch, player.mainCamera.GetProjectionView(), objectCameras, player.progressMsF, float32(player.Scl), float32(player.objectsAlpha.GetValue()*player.objectsAlphaFail.GetValue()))
+	}
 
 	if player.overlay != nil {
 		player.drawOverlayPart(player.overlay.DrawNormal, cursorColors, objectCameras[0], 1)
 	}
 
-	player.background.DrawOverlay(player.progressMsF, player.batch, bgAlpha, player.bgCamera.GetProjectionView())
+	if !transparent {
+		player.setLayer(LayerBackground)
+		player.background.DrawOverlay(player.progressMsF, player.batch, bgAlpha, player.bgCamera.GetProjectionView())
+	}
 
 	if player.overlay != nil && player.overlay.ShouldDrawHUDBeforeCursor() {
+		player.setLayer(LayerHUD)
 		player.drawOverlayPart(player.overlay.DrawHUD, cursorColors, player.uiCamera.GetProjectionView(), 1)
 	}
 
-	if settings.Playfield.DrawCursors {
+	if settings.Playfield.DrawCursors && !settings.TAIKO {
+		player.setLayer(LayerPlayfield)
+
 		for _, g := range player.controller.GetCursors() {
 			g.UpdateRenderer()
 		}
@@ -949,6 +1115,7 @@ func (player *Player) DrawMain(float64) {
 	player.batch.SetAdditive(false)
 
 	if player.overlay != nil && !player.overlay.ShouldDrawHUDBeforeCursor() {
+		player.setLayer(LayerHUD)
 		player.drawOverlayPart(player.overlay.DrawHUD, cursorColors, player.uiCamera.GetProjectionView(), 1)
 	}
 
@@ -1226,4 +1393,8 @@ func (player *Player) Show() {}
 
 func (player *Player) Hide() {}
 
-func (player *Player) Dispose() {}
+// Dispose frees the music stream, background, storyboard and its videos
+func (player *Player) Dispose() {
+	player.musicPlayer.Dispose()
+	player.background.Dispose()
+}
@@ -0,0 +1,167 @@
+package states
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/dance"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/bass"
+	"github.com/wieku/danser-go/framework/goroutines"
+	"github.com/wieku/danser-go/framework/math/mutils"
+	"github.com/wieku/danser-go/framework/platform"
+	"log"
+	"math"
+	"strings"
+)
+
+const frameStep = 1000.0 / 60
+
+// KeyEvent handles pause, seek and frame-step keys while watching replays. Actions are queued and executed on the update thread.
+func (player *Player) KeyEvent(_ *glfw.Window, key glfw.Key, scancode int, action glfw.Action, _ glfw.ModifierKey) {
+	if action != glfw.Press && action != glfw.Repeat {
+		return
+	}
+
+	kName, ok := platform.GetKeyName(key, scancode)
+	if !ok {
+		return
+	}
+
+	var control func()
+
+	switch {
+	case strings.EqualFold(kName, settings.Input.PauseKey):
+		if action == glfw.Press {
+			control = player.togglePause
+		}
+	case strings.EqualFold(kName, settings.Input.SeekBackwardKey):
+		control = func() { player.seek(player.progressMsF - settings.Input.SeekStep) }
+	case strings.EqualFold(kName, settings.Input.SeekForwardKey):
+		control = func() { player.seek(player.progressMsF + settings.Input.SeekStep) }
+	case strings.EqualFold(kName, settings.Input.FrameBackwardKey):
+		control = func() { player.frameStep(-1) }
+	case strings.EqualFold(kName, settings.Input.FrameForwardKey):
+		control = func() { player.frameStep(1) }
+	}
+
+	if control == nil {
+		return
+	}
+
+	select {
+	case player.controls <- control:
+	default:
+	}
+}
+
+func (player *Player) processControls() {
+	for {
+		select {
+		case control := <-player.controls:
+			control()
+		default:
+			return
+		}
+	}
+}
+
+func (player *Player) canSeek() bool {
+	return player.start && !player.failing && player.progressMsF < player.mapEndL
+}
+
+func (player *Player) togglePause() {
+	if !player.canSeek() {
+		return
+	}
+
+	switch player.musicPlayer.GetState() {
+	case bass.MusicPlaying:
+		player.musicPlayer.Pause()
+		log.Println("Playback paused")
+	case bass.MusicPaused:
+		player.musicPlayer.Resume()
+		log.Println("Playback resumed")
+	}
+}
+
+func (player *Player) frameStep(direction float64) {
+	if player.musicPlayer.GetState() != bass.MusicPaused {
+		return
+	}
+
+	player.seek(player.progressMsF + direction*frameStep)
+}
+
+// seek moves the playback to the given time. When going backwards the replay controller is rewound to the nearest snapshot and simulated again up to that time.
+func (player *Player) seek(target float64) {
+	controller, ok := player.controller.(*dance.ReplayController)
+	if !ok || !player.canSeek() {
+		return
+	}
+
+	offset := player.progressMsF - player.rawPositionF
+
+	target = mutils.Clamp(target, max(player.startPointE, player.startPoint+offset), player.mapEndL-1)
+
+	from := player.progressMsF
+
+	if target == from {
+		return
+	}
+
+	if target < from {
+		if !controller.CanRewind() {
+			log.Println("Rewinding is not supported when danser or autopilot players are present")
+			return
+		}
+
+		from = controller.Rewind(target)
+
+		if storyboard := player.background.GetStoryboard(); storyboard != nil {
+			storyboard.Rewind(target)
+		}
+
+		goroutines.CallMain(func() {
+			player.bMap.Rewind(from)
+			player.objectContainer.Rewind(from)
+
+			if player.overlay != nil {
+				player.overlay.Rewind(from)
+			}
+		})
+	}
+
+	muted := make([]objects.IHitObject, 0, len(player.bMap.HitObjects))
+
+	for _, o := range player.bMap.HitObjects {
+		if !o.IsAudioSubmissionDisabled() {
+			o.DisableAudioSubmission(true)
+			muted = append(muted, o)
+		}
+	}
+
+	if player.overlay != nil {
+		player.overlay.DisableAudioSubmission(true)
+	}
+
+	for t := math.Floor(from) + 1; t < target; t++ {
+		player.controller.Update(t, 1)
+
+		if player.overlay != nil {
+			player.overlay.Update(t)
+		}
+	}
+
+	for _, o := range muted {
+		o.DisableAudioSubmission(false)
+	}
+
+	if player.overlay != nil {
+		player.overlay.DisableAudioSubmission(false)
+	}
+
+	player.rawPositionF = target - offset
+	player.progressMsF = target
+
+	player.musicPlayer.SetPosition(player.rawPositionF / 1000)
+}
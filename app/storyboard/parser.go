@@ -21,12 +21,15 @@ func cutWhites(text string) (string, int) {
 	return text, 0
 }
 
-func parseCommands(commands []string) []*animation.Transformation {
+func parseCommands(commands []string) ([]*animation.Transformation, []*TriggerProcessor) {
 	transforms := make([]*animation.Transformation, 0)
 
+	var triggers []*TriggerProcessor
+
 	var currentLoop *LoopProcessor = nil
+	var currentTrigger *TriggerProcessor = nil
 
-	loopDepth := -1
+	groupDepth := -1
 
 	for _, subCommand := range commands {
 		command := strings.Split(subCommand, ",")
@@ -34,19 +37,23 @@ func parseCommands(commands []string) []*animation.Transformation {
 		var removed int
 		command[0], removed = cutWhites(command[0])
 
-		if command[0] == "T" {
-			continue
-		}
-
 		if removed == 1 {
 			if currentLoop != nil {
 				transforms = append(transforms, currentLoop.Unwind()...)
 
 				currentLoop = nil
-				loopDepth = -1
+				groupDepth = -1
+			}
+
+			if currentTrigger != nil {
+				currentTrigger.finalize()
+				triggers = append(triggers, currentTrigger)
+
+				currentTrigger = nil
+				groupDepth = -1
 			}
 
-			if command[0] != "L" {
+			if command[0] != "L" && command[0] != "T" {
 				if parsed := parseCommand(command); parsed != nil {
 					transforms = append(transforms, parsed...)
 				}
@@ -55,9 +62,16 @@ func parseCommands(commands []string) []*animation.Transformation {
 
 		if command[0] == "L" {
 			currentLoop = NewLoopProcessor(command)
-			loopDepth = removed + 1
-		} else if removed == loopDepth && currentLoop != nil {
-			currentLoop.Add(command)
+			groupDepth = removed + 1
+		} else if command[0] == "T" {
+			currentTrigger = NewTriggerProcessor(command)
+			groupDepth = removed + 1
+		} else if removed == groupDepth {
+			if currentLoop != nil {
+				currentLoop.Add(command)
+			} else if currentTrigger != nil {
+				currentTrigger.Add(command)
+			}
 		}
 	}
 
@@ -65,7 +79,12 @@ func parseCommands(commands []string) []*animation.Transformation {
 		transforms = append(transforms, currentLoop.Unwind()...)
 	}
 
-	return transforms
+	if currentTrigger != nil {
+		currentTrigger.finalize()
+		triggers = append(triggers, currentTrigger)
+	}
+
+	return transforms, triggers
 }
 
 func parseCommand(data []string) []*animation.Transformation {
@@ -21,8 +21,10 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 type Storyboard struct {
@@ -31,9 +33,13 @@ type Storyboard struct {
 	textures map[string]*texture.TextureRegion
 	atlas    *texture.TextureAtlas
 
+	// singleTextures are textures too big for the atlas
+	singleTextures []*texture.TextureSingle
+
 	samples map[string]*bass.Sample
 
 	background  *sprite.Manager
+	fail        *sprite.Manager
 	pass        *sprite.Manager
 	foreground  *sprite.Manager
 	overlay     *sprite.Manager
@@ -49,6 +55,16 @@ type Storyboard struct {
 
 	videos     []sprite.ISprite
 	videoAlpha float64
+
+	triggered     []*triggeredSprite
+	pendingEvents []triggerEvent
+	rewindTo      *float64
+	eventMutex    sync.Mutex
+	passing       bool
+	nextBreak     int
+
+	// evaluations holds results of break evaluations done so far
+	evaluations []bool
 }
 
 func getSection(line string) string {
@@ -67,26 +83,29 @@ func NewStoryboard(beatMap *beatmap.BeatMap) *Storyboard {
 		samples:    make(map[string]*bass.Sample),
 		zIndex:     -1,
 		background: sprite.NewManager(),
+		fail:       sprite.NewManager(),
 		pass:       sprite.NewManager(),
 		foreground: sprite.NewManager(),
 		overlay:    sprite.NewManager(),
 		atlas:      nil,
 		videos:     make([]sprite.ISprite, 0),
+		passing:    true,
 	}
 
-	files := []string{
-		filepath.Join(settings.General.GetSongsDir(), beatMap.Dir, beatMap.File),
-	}
+	var files []string
 
+	// .osb is loaded first so difficulty specific sprites are drawn on top of it in each layer
 	if fPath, err := beatMap.GetRelatedFile(files2.FixName(fmt.Sprintf("%s - %s (%s).osb", beatMap.Artist, beatMap.Name, beatMap.Creator))); err == nil {
 		files = append(files, fPath)
 	}
 
+	files = append(files, beatMap.GetFilePath())
+
 	var currentSection string
 	var currentSprite string
 	var commands []string
 
-	variables := make([][2]string, 0)
+	variables := newVariables()
 	hasVideo := false
 	hasAudio := false
 
@@ -102,6 +121,10 @@ func NewStoryboard(beatMap *beatmap.BeatMap) *Storyboard {
 
 		scanner := files2.NewScannerBuf(file, 10*1024*1024)
 
+		currentSection = ""
+		currentSprite = ""
+		commands = nil
+
 		for scanner.Scan() {
 			line := scanner.Text()
 
@@ -122,15 +145,9 @@ func NewStoryboard(beatMap *beatmap.BeatMap) *Storyboard {
 					storyboard.widescreen = true
 				}
 			case "256", "Variables":
-				split := strings.Split(line, "=")
-
-				variables = append(variables, [2]string{split[0], split[1]})
+				variables.parse(line)
 			case "32", "Events":
-				if strings.ContainsRune(line, '$') {
-					for _, v := range variables {
-						line = strings.Replace(line, v[0], v[1], -1)
-					}
-				}
+				line = variables.expand(line)
 
 				if strings.HasPrefix(line, "Sample") || strings.HasPrefix(line, "5") {
 					spl := strings.Split(line, ",")
@@ -210,6 +227,7 @@ func NewStoryboard(beatMap *beatmap.BeatMap) *Storyboard {
 	if storyboard.numSprites == 0 {
 		if storyboard.atlas != nil {
 			storyboard.atlas.Dispose()
+			storyboard.atlas = nil
 		}
 
 		if !hasVideo && !hasAudio {
@@ -281,13 +299,17 @@ func (storyboard *Storyboard) loadSprite(currentSprite string, commands []string
 	if len(textures) != 0 {
 		sbSprite := sprite.NewAnimation(textures, frameDelay, loopForever, float64(storyboard.zIndex), pos, origin)
 
-		transforms := parseCommands(commands)
+		transforms, triggers := parseCommands(commands)
 
 		sbSprite.ShowForever(false)
 		sbSprite.AddTransforms(transforms)
 		sbSprite.AdjustTimesToTransformations()
 		sbSprite.ResetValuesToTransforms()
 
+		if len(triggers) > 0 {
+			storyboard.triggered = append(storyboard.triggered, newTriggeredSprite(sbSprite, triggers))
+		}
+
 		storyboard.addSpriteToLayer(spl[1], sbSprite)
 
 		storyboard.numSprites++
@@ -298,6 +320,8 @@ func (storyboard *Storyboard) addSpriteToLayer(layer string, sbSprite sprite.ISp
 	switch layer {
 	case "0", "Background":
 		storyboard.background.Add(sbSprite)
+	case "1", "Fail":
+		storyboard.fail.Add(sbSprite)
 	case "2", "Pass":
 		storyboard.pass.Add(sbSprite)
 	case "3", "Foreground":
@@ -328,6 +352,7 @@ func (storyboard *Storyboard) getTexture(image string) *texture.TextureRegion {
 					tex := texture.NewTextureSingle(img.Width, img.Height, 0)
 					tex.Bind(0)
 					tex.SetData(0, 0, img.Width, img.Height, img.Data)
+					storyboard.singleTextures = append(storyboard.singleTextures, tex)
 					rg := tex.GetRegion()
 					texture1 = &rg
 				} else {
@@ -359,7 +384,9 @@ func (storyboard *Storyboard) getSample(sample string) (bassSample *bass.Sample)
 			return
 		}
 
-		bassSample = bass.NewSample(path)
+		if bassSample = bass.NewSample(path); bassSample != nil {
+			bassSample.SetStem(bass.StemStoryboard)
+		}
 	}
 
 	return
@@ -391,6 +418,28 @@ func (storyboard *Storyboard) StopThread() {
 	storyboard.shouldRun = false
 }
 
+// Dispose stops the storyboard and frees its textures and videos
+func (storyboard *Storyboard) Dispose() {
+	storyboard.StopThread()
+
+	for _, v := range storyboard.videos {
+		if video, ok := v.(*video2.Video); ok {
+			video.Dispose()
+		}
+	}
+
+	if storyboard.atlas != nil {
+		storyboard.atlas.Dispose()
+		storyboard.atlas = nil
+	}
+
+	for _, tex := range storyboard.singleTextures {
+		tex.Dispose()
+	}
+
+	storyboard.singleTextures = nil
+}
+
 func (storyboard *Storyboard) IsThreadRunning() bool {
 	return storyboard.shouldRun
 }
@@ -407,8 +456,123 @@ func (storyboard *Storyboard) SetFPS(i int) {
 	storyboard.limiter.FPS = i
 }
 
+// HasTriggers returns true if storyboard contains trigger groups that need hitsound and health events
+func (storyboard *Storyboard) HasTriggers() bool {
+	return len(storyboard.triggered) > 0
+}
+
+// OnHitSound queues HitSound trigger activation, it's processed on next Update
+func (storyboard *Storyboard) OnHitSound(time float64, sampleSet, additionSet, hitsound, index int) {
+	storyboard.queueEvent(triggerEvent{
+		time:        time,
+		triggerType: TriggerHitSound,
+		sampleSet:   normalizeSampleSet(sampleSet),
+		additionSet: normalizeSampleSet(additionSet),
+		hitsound:    hitsound,
+		index:       index,
+	})
+}
+
+// UpdateHealth evaluates player's state at the beginning of each break like osu!stable does. The state decides whether Pass or Fail layer is shown, Passing or Failing triggers are activated on each evaluation.
+func (storyboard *Storyboard) UpdateHealth(time, health float64) {
+	pauses := storyboard.beatMap.Pauses
+
+	for storyboard.nextBreak < len(pauses) && time >= pauses[storyboard.nextBreak].StartTime {
+		storyboard.nextBreak++
+
+		storyboard.passing = health >= 0.5
+		storyboard.evaluations = append(storyboard.evaluations, storyboard.passing)
+
+		tType := TriggerFailing
+		if storyboard.passing {
+			tType = TriggerPassing
+		}
+
+		storyboard.queueEvent(triggerEvent{
+			time:        time,
+			triggerType: tType,
+		})
+	}
+}
+
+// Rewind brings back break evaluations and trigger activations to the state they had at the given time.
+// Events after that time are undone, so they can happen again when playback reaches them.
+func (storyboard *Storyboard) Rewind(time float64) {
+	pauses := storyboard.beatMap.Pauses
+
+	for len(storyboard.evaluations) > 0 && time < pauses[len(storyboard.evaluations)-1].StartTime {
+		storyboard.evaluations = storyboard.evaluations[:len(storyboard.evaluations)-1]
+	}
+
+	storyboard.nextBreak = len(storyboard.evaluations)
+	storyboard.passing = storyboard.nextBreak == 0 || storyboard.evaluations[storyboard.nextBreak-1]
+
+	if len(storyboard.triggered) == 0 {
+		return
+	}
+
+	// Trigger activations are undone on the storyboard's update thread, in processEvents
+	storyboard.eventMutex.Lock()
+
+	storyboard.pendingEvents = slices.DeleteFunc(storyboard.pendingEvents, func(event triggerEvent) bool {
+		return event.time > time
+	})
+
+	if storyboard.rewindTo == nil || time < *storyboard.rewindTo {
+		storyboard.rewindTo = &time
+	}
+
+	storyboard.eventMutex.Unlock()
+}
+
+func (storyboard *Storyboard) IsPassing() bool {
+	return storyboard.passing
+}
+
+func (storyboard *Storyboard) queueEvent(event triggerEvent) {
+	if len(storyboard.triggered) == 0 {
+		return
+	}
+
+	storyboard.eventMutex.Lock()
+	storyboard.pendingEvents = append(storyboard.pendingEvents, event)
+	storyboard.eventMutex.Unlock()
+}
+
+func (storyboard *Storyboard) processEvents() {
+	storyboard.eventMutex.Lock()
+	events := storyboard.pendingEvents
+	storyboard.pendingEvents = nil
+	rewindTo := storyboard.rewindTo
+	storyboard.rewindTo = nil
+	storyboard.eventMutex.Unlock()
+
+	if rewindTo != nil {
+		for _, tSprite := range storyboard.triggered {
+			tSprite.rewind(*rewindTo)
+		}
+	}
+
+	for _, event := range events {
+		matches := func(trigger *TriggerProcessor) bool {
+			if trigger.triggerType != event.triggerType {
+				return false
+			}
+
+			return event.triggerType != TriggerHitSound || trigger.matchesHitSound(event.sampleSet, event.additionSet, event.hitsound, event.index)
+		}
+
+		for _, tSprite := range storyboard.triggered {
+			tSprite.trigger(event.time, matches)
+		}
+	}
+}
+
 func (storyboard *Storyboard) Update(time float64) {
+	storyboard.processEvents()
+
 	storyboard.background.Update(time)
+	storyboard.fail.Update(time)
 	storyboard.pass.Update(time)
 	storyboard.foreground.Update(time)
 	storyboard.overlay.Update(time)
@@ -428,7 +592,13 @@ func (storyboard *Storyboard) Draw(time float64, batch *batch.QuadBatch) {
 	profiler.StartGroup("Storyboard.Draw", profiler.PDraw)
 	batch.SetTranslation(vector.NewVec2d(-64, -48))
 	storyboard.background.Draw(time, batch)
-	storyboard.pass.Draw(time, batch)
+
+	if storyboard.passing {
+		storyboard.pass.Draw(time, batch)
+	} else {
+		storyboard.fail.Draw(time, batch)
+	}
+
 	storyboard.foreground.Draw(time, batch)
 	batch.SetTranslation(vector.NewVec2d(0, 0))
 	profiler.EndGroup()
@@ -443,15 +613,15 @@ func (storyboard *Storyboard) DrawOverlay(time float64, batch *batch.QuadBatch)
 }
 
 func (storyboard *Storyboard) GetRenderedSprites() int {
-	return storyboard.background.GetNumRendered() + storyboard.pass.GetNumRendered() + storyboard.foreground.GetNumRendered() + storyboard.overlay.GetNumRendered()
+	return storyboard.background.GetNumRendered() + storyboard.fail.GetNumRendered() + storyboard.pass.GetNumRendered() + storyboard.foreground.GetNumRendered() + storyboard.overlay.GetNumRendered()
 }
 
 func (storyboard *Storyboard) GetProcessedSprites() int {
-	return storyboard.background.GetNumProcessed() + storyboard.pass.GetNumProcessed() + storyboard.foreground.GetNumProcessed() + storyboard.overlay.GetNumProcessed()
+	return storyboard.background.GetNumProcessed() + storyboard.fail.GetNumProcessed() + storyboard.pass.GetNumProcessed() + storyboard.foreground.GetNumProcessed() + storyboard.overlay.GetNumProcessed()
 }
 
 func (storyboard *Storyboard) GetQueueSprites() int {
-	return storyboard.background.GetNumInQueue() + storyboard.pass.GetNumInQueue() + storyboard.foreground.GetNumInQueue() + storyboard.overlay.GetNumInQueue()
+	return storyboard.background.GetNumInQueue() + storyboard.fail.GetNumInQueue() + storyboard.pass.GetNumInQueue() + storyboard.foreground.GetNumInQueue() + storyboard.overlay.GetNumInQueue()
 }
 
 func (storyboard *Storyboard) GetTotalSprites() int {
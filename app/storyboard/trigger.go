@@ -0,0 +1,303 @@
+package storyboard
+
+import (
+	"github.com/wieku/danser-go/framework/graphics/sprite"
+	"github.com/wieku/danser-go/framework/math/animation"
+	"log"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type TriggerType int
+
+const (
+	TriggerNone = TriggerType(iota)
+	TriggerHitSound
+	TriggerPassing
+	TriggerFailing
+)
+
+const (
+	setAll = 0
+)
+
+var triggerSets = []string{"All", "Normal", "Soft", "Drum"}
+
+var triggerAdditions = map[string]int{
+	"Whistle": 2,
+	"Finish":  4,
+	"Clap":    8,
+}
+
+// TriggerProcessor holds commands of a single trigger group (T command). Commands are relative to the moment trigger gets activated.
+type TriggerProcessor struct {
+	triggerType TriggerType
+
+	sampleSet   int
+	additionSet int
+	addition    int
+	customIndex int
+
+	start, end int64
+	group      int64
+
+	transforms []*animation.Transformation
+	duration   float64
+}
+
+func NewTriggerProcessor(data []string) *TriggerProcessor {
+	trigger := &TriggerProcessor{
+		customIndex: -1,
+	}
+
+	trigger.parseName(data[1])
+
+	var err error
+
+	trigger.start, err = strconv.ParseInt(data[2], 10, 64)
+	if err != nil {
+		log.Println("Failed to parse: ", data)
+		panic(err)
+	}
+
+	trigger.end, err = strconv.ParseInt(data[3], 10, 64)
+	if err != nil {
+		log.Println("Failed to parse: ", data)
+		panic(err)
+	}
+
+	if len(data) > 4 {
+		trigger.group, _ = strconv.ParseInt(data[4], 10, 64)
+	}
+
+	return trigger
+}
+
+// parseName parses trigger name: Passing, Failing or HitSound[SampleSet][AdditionsSampleSet][Addition][CustomSampleSet]
+func (trigger *TriggerProcessor) parseName(name string) {
+	switch {
+	case name == "Passing":
+		trigger.triggerType = TriggerPassing
+		return
+	case name == "Failing":
+		trigger.triggerType = TriggerFailing
+		return
+	case !strings.HasPrefix(name, "HitSound"):
+		log.Println("Unsupported storyboard trigger:", name)
+		return
+	}
+
+	trigger.triggerType = TriggerHitSound
+
+	name = strings.TrimPrefix(name, "HitSound")
+
+	var sets []int
+
+	for len(sets) < 2 {
+		found := false
+
+		for i, s := range triggerSets {
+			if strings.HasPrefix(name, s) {
+				sets = append(sets, i)
+				name = name[len(s):]
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			break
+		}
+	}
+
+	for n, a := range triggerAdditions {
+		if strings.HasPrefix(name, n) {
+			trigger.addition = a
+			name = name[len(n):]
+
+			break
+		}
+	}
+
+	switch {
+	case len(sets) == 2:
+		trigger.sampleSet = sets[0]
+		trigger.additionSet = sets[1]
+	case len(sets) == 1 && trigger.addition > 0: // HitSoundDrumWhistle refers to drum whistle
+		trigger.additionSet = sets[0]
+	case len(sets) == 1:
+		trigger.sampleSet = sets[0]
+	}
+
+	if name != "" {
+		if index, err := strconv.Atoi(name); err == nil {
+			trigger.customIndex = index
+		}
+	}
+}
+
+func (trigger *TriggerProcessor) Add(command []string) {
+	if parsed := parseCommand(command); parsed != nil {
+		trigger.transforms = append(trigger.transforms, parsed...)
+	}
+}
+
+// finalize calculates duration of the trigger group, must be called after all commands are added
+func (trigger *TriggerProcessor) finalize() {
+	for _, t := range trigger.transforms {
+		trigger.duration = max(trigger.duration, t.GetTotalEndTime())
+	}
+}
+
+func (trigger *TriggerProcessor) canActivate(time float64) bool {
+	return trigger.triggerType != TriggerNone && len(trigger.transforms) > 0 && time >= float64(trigger.start) && time <= float64(trigger.end)
+}
+
+func (trigger *TriggerProcessor) matchesHitSound(sampleSet, additionSet, hitsound, index int) bool {
+	if trigger.customIndex >= 0 && trigger.customIndex != index {
+		return false
+	}
+
+	if trigger.sampleSet != setAll && trigger.sampleSet != sampleSet {
+		return false
+	}
+
+	if trigger.additionSet != setAll && (trigger.additionSet != additionSet || hitsound&14 == 0) {
+		return false
+	}
+
+	return trigger.addition == 0 || hitsound&trigger.addition > 0
+}
+
+func (trigger *TriggerProcessor) activate(time float64) []*animation.Transformation {
+	transforms := make([]*animation.Transformation, 0, len(trigger.transforms))
+
+	for _, t := range trigger.transforms {
+		transforms = append(transforms, t.Clone(time+t.GetStartTime(), time+t.GetEndTime()))
+	}
+
+	return transforms
+}
+
+// triggeredSprite is a storyboard sprite that has at least one trigger group
+type triggeredSprite struct {
+	sprite   *sprite.Animation
+	triggers []*TriggerProcessor
+
+	// transforms added by the last activation of a group, they are cancelled when the group gets activated again
+	active map[int64][]*animation.Transformation
+
+	// activations done so far, used to undo the ones that happened after the time playback was rewound to
+	activations []triggerActivation
+}
+
+type triggerActivation struct {
+	time       float64
+	group      int64
+	transforms []*animation.Transformation
+}
+
+func newTriggeredSprite(sbSprite *sprite.Animation, triggers []*TriggerProcessor) *triggeredSprite {
+	startTime := sbSprite.GetStartTime()
+	endTime := sbSprite.GetEndTime()
+
+	if startTime == 0 && endTime == 0 { // Sprite has only triggered commands
+		startTime = math.MaxFloat64
+		endTime = -math.MaxFloat64
+	}
+
+	for _, t := range triggers {
+		startTime = min(startTime, float64(t.start))
+		endTime = max(endTime, float64(t.end)+t.duration)
+	}
+
+	sbSprite.SetStartTime(startTime)
+	sbSprite.SetEndTime(endTime)
+
+	return &triggeredSprite{
+		sprite:   sbSprite,
+		triggers: triggers,
+		active:   make(map[int64][]*animation.Transformation),
+	}
+}
+
+func (tSprite *triggeredSprite) trigger(time float64, matches func(trigger *TriggerProcessor) bool) {
+	for _, t := range tSprite.triggers {
+		if !t.canActivate(time) || !matches(t) {
+			continue
+		}
+
+		// Only one trigger of a group can be active at the same time
+		if previous := tSprite.active[t.group]; previous != nil {
+			tSprite.sprite.RemoveTransformations(previous)
+		}
+
+		transforms := t.activate(time)
+
+		tSprite.sprite.AddTransforms(transforms)
+		tSprite.active[t.group] = transforms
+
+		tSprite.activations = append(tSprite.activations, triggerActivation{
+			time:       time,
+			group:      t.group,
+			transforms: transforms,
+		})
+	}
+}
+
+// rewind cancels activations that happened after the given time and brings back the ones they replaced
+func (tSprite *triggeredSprite) rewind(time float64) {
+	index := slices.IndexFunc(tSprite.activations, func(a triggerActivation) bool {
+		return a.time > time
+	})
+
+	if index < 0 {
+		return
+	}
+
+	undone := tSprite.activations[index:]
+	tSprite.activations = tSprite.activations[:index]
+
+	for _, a := range undone {
+		tSprite.sprite.RemoveTransformations(a.transforms)
+		delete(tSprite.active, a.group)
+	}
+
+	for _, a := range undone {
+		if _, ok := tSprite.active[a.group]; ok {
+			continue
+		}
+
+		for i := len(tSprite.activations) - 1; i >= 0; i-- {
+			if previous := tSprite.activations[i]; previous.group == a.group {
+				tSprite.sprite.AddTransforms(previous.transforms)
+				tSprite.active[a.group] = previous.transforms
+
+				break
+			}
+		}
+	}
+}
+
+type triggerEvent struct {
+	time        float64
+	triggerType TriggerType
+
+	sampleSet   int
+	additionSet int
+	hitsound    int
+	index       int
+}
+
+func normalizeSampleSet(sampleSet int) int {
+	if sampleSet == 0 {
+		return 2
+	} else if sampleSet < 0 || sampleSet > 3 {
+		return 1
+	}
+
+	return sampleSet
+}
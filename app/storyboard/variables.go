@@ -0,0 +1,58 @@
+package storyboard
+
+import (
+	"slices"
+	"strings"
+)
+
+type variables struct {
+	names  []string
+	values map[string]string
+}
+
+func newVariables() *variables {
+	return &variables{
+		values: make(map[string]string),
+	}
+}
+
+// parse reads $name=value line, later definitions override earlier ones
+func (v *variables) parse(line string) {
+	split := strings.SplitN(line, "=", 2)
+	if len(split) < 2 {
+		return
+	}
+
+	name := strings.TrimSpace(split[0])
+	if !strings.HasPrefix(name, "$") {
+		return
+	}
+
+	if _, exists := v.values[name]; !exists {
+		v.names = append(v.names, name)
+
+		// Longer names go first so $ab is not replaced by value of $a
+		slices.SortStableFunc(v.names, func(a, b string) int {
+			return len(b) - len(a)
+		})
+	}
+
+	v.values[name] = strings.TrimSpace(split[1])
+}
+
+// expand substitutes all variables in the line, values containing other variables are expanded as well
+func (v *variables) expand(line string) string {
+	for i := 0; i < 10 && strings.ContainsRune(line, '$'); i++ {
+		prev := line
+
+		for _, name := range v.names {
+			line = strings.ReplaceAll(line, name, v.values[name])
+		}
+
+		if line == prev {
+			break
+		}
+	}
+
+	return line
+}
@@ -10,10 +10,33 @@ import (
 	"unsafe"
 )
 
+// curveTracks are tracks with a rate curve, updated before every chunk
+var curveTracks []*TrackBass
+
 func GetMixerRequiredBufferSize(seconds float64) int {
 	return int(C.BASS_ChannelSeconds2Bytes(masterMixer, C.double(seconds)))
 }
 
-func ProcessMixer(buffer []byte) {
+// ProcessMixer fills the buffer with the next chunk of the mix. If stems are enabled, they're written to stems buffers
+// (in the order of Stem constants) and added to the mix. All buffers have to be of the same size.
+func ProcessMixer(buffer []byte, stems ...[]byte) {
+	for _, track := range curveTracks {
+		track.updateRate()
+	}
+
 	C.BASS_ChannelGetData(masterMixer, unsafe.Pointer(&buffer[0]), C.DWORD(len(buffer)))
+
+	if !stemsEnabled || len(stems) == 0 {
+		return
+	}
+
+	mix := unsafe.Slice((*float32)(unsafe.Pointer(&buffer[0])), len(buffer)/4)
+
+	for i, stem := range stems {
+		C.BASS_ChannelGetData(stemMixers[i], unsafe.Pointer(&stem[0]), C.DWORD(len(stem)))
+
+		for j, v := range unsafe.Slice((*float32)(unsafe.Pointer(&stem[0])), len(stem)/4) {
+			mix[j] += v
+		}
+	}
 }
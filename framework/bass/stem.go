@@ -0,0 +1,55 @@
+package bass
+
+/*
+#include "bass.h"
+#include "bassmix.h"
+*/
+import "C"
+
+// Stem is a group of sounds which can be mixed separately in offscreen mode
+type Stem int
+
+const (
+	StemMusic Stem = iota
+	StemHitsounds
+	StemStoryboard
+	StemUI
+)
+
+// StemCount is the number of available stems
+const StemCount = 4
+
+var stemMixers []C.HSTREAM
+
+var stemsEnabled bool
+
+// SetStemsEnabled routes sounds to separate mixers of their stems, so ProcessMixer can output them along with the full mix.
+// Works only in offscreen mode, sounds that are already playing stay in the master mixer.
+func SetStemsEnabled(enabled bool) {
+	stemsEnabled = enabled
+
+	if !enabled || stemMixers != nil {
+		return
+	}
+
+	stemMixers = make([]C.HSTREAM, StemCount)
+
+	for i := range stemMixers {
+		stemMixers[i] = C.BASS_Mixer_StreamCreate(C.DWORD(sampleRate), 2, C.BASS_MIXER_NONSTOP|C.BASS_SAMPLE_FLOAT|C.BASS_STREAM_DECODE)
+		C.BASS_ChannelSetAttribute(stemMixers[i], C.BASS_ATTRIB_BUFFER, 0)
+	}
+}
+
+// getMixer returns the mixer to which sounds of the stem should be added
+func getMixer(stem Stem) C.HSTREAM {
+	if stemsEnabled {
+		return stemMixers[stem]
+	}
+
+	return masterMixer
+}
+
+// SetStem sets the stem of sounds played from this sample, StemUI by default
+func (sample *Sample) SetStem(stem Stem) {
+	sample.stem = stem
+}
@@ -7,6 +7,9 @@ const (
 	MusicPaused  = 3
 )
 
+// RateCurve returns the rate multiplier at given position of the track in milliseconds
+type RateCurve func(position float64) float64
+
 type ITrack interface {
 	AddSilence(seconds float64)
 	Play()
@@ -25,6 +28,7 @@ type ITrack interface {
 	GetPitch() float64
 	SetRelativeFrequency(rFreq float64)
 	GetRelativeFrequency() float64
+	SetRateCurve(curve RateCurve, adjustPitch bool)
 	GetSpeed() float64
 	GetState() int
 	Update()
@@ -35,4 +39,5 @@ type ITrack interface {
 	GetRightLevel() float64
 	GetBoost() float64
 	GetBeat() float64
+	Dispose()
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/wieku/danser-go/app/settings"
 	"math"
 	"runtime"
+	"slices"
 	"unicode/utf16"
 	"unsafe"
 )
@@ -29,6 +30,12 @@ type TrackBass struct {
 	addedToMixer      bool
 	baseFrequency     float64
 	relativeFrequency float64
+
+	rateCurve   RateCurve
+	curvePitch  bool
+	curveRate   float64
+	activeTempo float64
+	activeFreq  float64
 }
 
 func NewTrack(path string) *TrackBass {
@@ -37,6 +44,9 @@ func NewTrack(path string) *TrackBass {
 		speed:             1,
 		pitch:             1,
 		relativeFrequency: 1,
+		curveRate:         1,
+		activeTempo:       1,
+		activeFreq:        1,
 	}
 
 	flags := C.BASS_STREAM_DECODE | C.BASS_STREAM_PRESCAN //| C.BASS_ASYNCFILE
@@ -87,7 +97,7 @@ func (track *TrackBass) AddSilence(seconds float64) {
 func (track *TrackBass) Play() {
 	track.SetVolume(settings.Audio.GeneralVolume * settings.Audio.MusicVolume)
 
-	C.BASS_Mixer_StreamAddChannel(masterMixer, track.channel, C.BASS_MIXER_CHAN_NORAMPIN|C.BASS_MIXER_CHAN_BUFFER)
+	C.BASS_Mixer_StreamAddChannel(getMixer(StemMusic), track.channel, C.BASS_MIXER_CHAN_NORAMPIN|C.BASS_MIXER_CHAN_BUFFER)
 
 	track.playing = true
 	track.addedToMixer = true
@@ -98,7 +108,7 @@ func (track *TrackBass) PlayV(volume float64) {
 
 	track.playing = true
 
-	C.BASS_Mixer_StreamAddChannel(masterMixer, track.channel, C.BASS_MIXER_CHAN_NORAMPIN|C.BASS_MIXER_CHAN_BUFFER)
+	C.BASS_Mixer_StreamAddChannel(getMixer(StemMusic), track.channel, C.BASS_MIXER_CHAN_NORAMPIN|C.BASS_MIXER_CHAN_BUFFER)
 	track.addedToMixer = true
 }
 
@@ -121,6 +131,17 @@ func (track *TrackBass) Stop() {
 	C.BASS_ChannelStop(track.channel)
 }
 
+// Dispose stops the track and frees its stream, the track can't be used afterwards
+func (track *TrackBass) Dispose() {
+	track.playing = false
+	track.addedToMixer = false
+
+	track.SetRateCurve(nil, false)
+
+	C.BASS_Mixer_ChannelRemove(track.channel)
+	C.BASS_StreamFree(track.channel)
+}
+
 func (track *TrackBass) SetVolume(vol float64) {
 	C.BASS_ChannelSetAttribute(track.channel, C.BASS_ATTRIB_VOL, C.float(vol))
 }
@@ -160,7 +181,7 @@ func (track *TrackBass) SetTempo(tempo float64) {
 
 	track.speed = tempo
 
-	C.BASS_ChannelSetAttribute(track.channel, C.BASS_ATTRIB_TEMPO, C.float((tempo-1.0)*100))
+	track.applyRate()
 }
 
 func (track *TrackBass) GetTempo() float64 {
@@ -188,7 +209,7 @@ func (track *TrackBass) SetRelativeFrequency(rFreq float64) {
 
 	track.relativeFrequency = rFreq
 
-	C.BASS_ChannelSetAttribute(track.channel, C.BASS_ATTRIB_FREQ, C.float(rFreq*track.baseFrequency))
+	track.applyRate()
 }
 
 func (track *TrackBass) GetRelativeFrequency() float64 {
@@ -196,7 +217,64 @@ func (track *TrackBass) GetRelativeFrequency() float64 {
 }
 
 func (track *TrackBass) GetSpeed() float64 {
-	return track.speed * track.relativeFrequency
+	return track.activeTempo * track.activeFreq
+}
+
+// SetRateCurve makes the rate of the track follow the curve on top of tempo and relative frequency. The curve is evaluated
+// at the decoding position whenever the track is updated and before every chunk of offscreen mix, so recorded audio follows
+// it regardless of the frame rate. If adjustPitch is true, the curve changes frequency instead of tempo. nil removes the curve.
+func (track *TrackBass) SetRateCurve(curve RateCurve, adjustPitch bool) {
+	track.rateCurve = curve
+	track.curvePitch = adjustPitch
+	track.curveRate = 1
+
+	curveTracks = slices.DeleteFunc(curveTracks, func(t *TrackBass) bool { return t == track })
+
+	if curve != nil {
+		curveTracks = append(curveTracks, track)
+
+		track.updateRate()
+	}
+
+	track.applyRate()
+}
+
+// updateRate evaluates the rate curve at the current decoding position
+func (track *TrackBass) updateRate() {
+	if track.rateCurve == nil {
+		return
+	}
+
+	position := float64(C.BASS_ChannelBytes2Seconds(track.channel, C.BASS_ChannelGetPosition(track.channel, C.BASS_POS_BYTE))) * 1000
+
+	if rate := track.rateCurve(position); rate != track.curveRate {
+		track.curveRate = rate
+
+		track.applyRate()
+	}
+}
+
+// applyRate sets tempo and frequency of the channel combined with the rate curve
+func (track *TrackBass) applyRate() {
+	tempo, freq := track.speed, track.relativeFrequency
+
+	if track.curvePitch {
+		freq *= track.curveRate
+	} else {
+		tempo *= track.curveRate
+	}
+
+	if tempo != track.activeTempo {
+		track.activeTempo = tempo
+
+		C.BASS_ChannelSetAttribute(track.channel, C.BASS_ATTRIB_TEMPO, C.float((tempo-1.0)*100))
+	}
+
+	if freq != track.activeFreq {
+		track.activeFreq = freq
+
+		C.BASS_ChannelSetAttribute(track.channel, C.BASS_ATTRIB_FREQ, C.float(freq*track.baseFrequency))
+	}
 }
 
 func (track *TrackBass) GetState() int {
@@ -214,6 +292,8 @@ func (track *TrackBass) GetState() int {
 }
 
 func (track *TrackBass) Update() {
+	track.updateRate()
+
 	if track.playing {
 		if track.addedToMixer {
 			C.BASS_Mixer_ChannelGetData(track.channel, unsafe.Pointer(&track.fft[0]), C.BASS_DATA_FFT1024)
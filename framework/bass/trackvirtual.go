@@ -21,14 +21,18 @@ type TrackVirtual struct {
 	startTime        float64
 	playing          bool
 	paused           bool
+
+	rateCurve RateCurve
+	curveRate float64
 }
 
 func NewTrackVirtual(length float64) *TrackVirtual {
 	player := &TrackVirtual{
-		fft:    make([]float32, 512),
-		speed:  1,
-		pitch:  1,
-		length: length,
+		fft:       make([]float32, 512),
+		speed:     1,
+		pitch:     1,
+		length:    length,
+		curveRate: 1,
 	}
 
 	return player
@@ -93,7 +97,7 @@ func (track *TrackVirtual) GetPosition() float64 {
 
 	currentPos := float64(C.BASS_ChannelBytes2Seconds(masterMixer, C.BASS_ChannelGetPosition(masterMixer, C.BASS_POS_BYTE)))
 
-	pos := track.previousPosition + (currentPos-track.startTime)*track.speed*track.rFreq
+	pos := track.previousPosition + (currentPos-track.startTime)*track.GetSpeed()
 
 	return mutils.Clamp(pos, 0, track.length+track.tail)
 }
@@ -137,7 +141,26 @@ func (track *TrackVirtual) GetRelativeFrequency() float64 {
 }
 
 func (track *TrackVirtual) GetSpeed() float64 {
-	return track.speed * track.rFreq
+	return track.speed * track.rFreq * track.curveRate
+}
+
+// SetRateCurve makes the rate follow the curve on top of tempo and relative frequency, it's evaluated on every Update.
+// Pitch isn't simulated, so adjustPitch is ignored.
+func (track *TrackVirtual) SetRateCurve(curve RateCurve, _ bool) {
+	track.setCurveRate(1)
+
+	track.rateCurve = curve
+}
+
+func (track *TrackVirtual) setCurveRate(rate float64) {
+	if track.curveRate == rate {
+		return
+	}
+
+	track.previousPosition = track.GetPosition()
+	track.startTime = float64(C.BASS_ChannelBytes2Seconds(masterMixer, C.BASS_ChannelGetPosition(masterMixer, C.BASS_POS_BYTE)))
+
+	track.curveRate = rate
 }
 
 func (track *TrackVirtual) GetState() int {
@@ -158,7 +181,11 @@ func (track *TrackVirtual) GetState() int {
 	return MusicPlaying
 }
 
-func (track *TrackVirtual) Update() {}
+func (track *TrackVirtual) Update() {
+	if track.rateCurve != nil {
+		track.setCurveRate(track.rateCurve(track.GetPosition() * 1000))
+	}
+}
 
 func (track *TrackVirtual) GetFFT() []float32 {
 	return track.fft
@@ -187,3 +214,5 @@ func (track *TrackVirtual) GetBoost() float64 {
 func (track *TrackVirtual) GetBeat() float64 {
 	return 0
 }
+
+func (track *TrackVirtual) Dispose() {}
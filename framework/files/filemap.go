@@ -36,6 +36,19 @@ func NewFileMap(path string) (*FileMap, error) {
 	return fileMap, nil
 }
 
+// NewFileMapFromEntries creates a FileMap without a backing directory, entries map relative file names to absolute paths
+func NewFileMapFromEntries(entries map[string]string) *FileMap {
+	fileMap := &FileMap{
+		pathCache: make(map[string]string, len(entries)),
+	}
+
+	for name, path := range entries {
+		fileMap.pathCache[strings.ToLower(strings.ReplaceAll(name, "\\", "/"))] = path
+	}
+
+	return fileMap
+}
+
 func (f *FileMap) GetFile(path string) (string, error) {
 	sPath := strings.ToLower(f.path)
 	fPath := strings.TrimPrefix(strings.ReplaceAll(strings.ToLower(path), "\\", "/"), sPath)
@@ -3,6 +3,7 @@ package effects
 import (
 	"github.com/wieku/danser-go/framework/assets"
 	"github.com/wieku/danser-go/framework/graphics/attribute"
+	"github.com/wieku/danser-go/framework/graphics/blend"
 	"github.com/wieku/danser-go/framework/graphics/buffer"
 	"github.com/wieku/danser-go/framework/graphics/shader"
 	"github.com/wieku/danser-go/framework/graphics/texture"
@@ -21,6 +22,11 @@ type Blend struct {
 }
 
 func NewBlend(width, height, frames int, weights []float32) *Blend {
+	return NewBlendFormat(width, height, frames, weights, texture.RGB)
+}
+
+// NewBlendFormat creates Blend which keeps frames in the given format, texture.RGBA has to be used to blend the alpha channel
+func NewBlendFormat(width, height, frames int, weights []float32, format texture.Format) *Blend {
 	if frames != len(weights) {
 		panic("Wrong number of weights")
 	}
@@ -71,7 +77,7 @@ func NewBlend(width, height, frames int, weights []float32) *Blend {
 		effect.blendShader.SetUniformArr("weights", i, v/sum)
 	}
 
-	effect.multiTexture = texture.NewTextureMultiLayerFormat(width, height, texture.RGB, 0, frames)
+	effect.multiTexture = texture.NewTextureMultiLayerFormat(width, height, format, 0, frames)
 
 	for i := 0; i < frames; i++ {
 		effect.fbos = append(effect.fbos, buffer.NewFrameLayer(effect.multiTexture, i))
@@ -83,7 +89,7 @@ func NewBlend(width, height, frames int, weights []float32) *Blend {
 func (effect *Blend) Begin() {
 	effect.head = (effect.head + 1) % effect.layers
 	effect.fbos[effect.head].Bind()
-	effect.fbos[effect.head].ClearColor(0, 0, 0, 1)
+	effect.fbos[effect.head].ClearColor(0, 0, 0, 0)
 	viewport.Push(effect.width, effect.height)
 }
 
@@ -99,11 +105,17 @@ func (effect *Blend) Blend() {
 
 	viewport.Push(effect.width, effect.height)
 
+	// result replaces the previous frame, including its alpha
+	blend.Push()
+	blend.Disable()
+
 	effect.blendShader.Bind()
 	effect.vao.Bind()
 	effect.vao.Draw()
 	effect.vao.Unbind()
 	effect.blendShader.Unbind()
 
+	blend.Pop()
+
 	viewport.Pop()
 }
@@ -177,6 +177,13 @@ func (sprite *Sprite) ClearTransformationsOfType(transformationType animation.Tr
 	}
 }
 
+// RemoveTransformations removes given transformations if they weren't finished yet
+func (sprite *Sprite) RemoveTransformations(transformations []*animation.Transformation) {
+	sprite.transforms = slices.DeleteFunc(sprite.transforms, func(t *animation.Transformation) bool {
+		return slices.Contains(transformations, t)
+	})
+}
+
 func (sprite *Sprite) AdjustTimesToTransformations() {
 	if len(sprite.transforms) == 0 {
 		return
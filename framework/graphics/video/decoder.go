@@ -69,19 +69,7 @@ func NewVideoDecoder(filePath string) *VideoDecoder {
 }
 
 func (dec *VideoDecoder) StartFFmpeg(millis int64) {
-	if dec.running {
-		dec.running = false
-		close(dec.decodingQueue)
-
-		dec.wg.Wait()
-
-		if dec.command != nil {
-			err := dec.command.Process.Kill()
-			if err != nil {
-				panic(err)
-			}
-		}
-	}
+	dec.Stop()
 
 	dec.wg.Add(1)
 
@@ -171,6 +159,24 @@ func (dec *VideoDecoder) StartFFmpeg(millis int64) {
 	})
 }
 
+// Stop kills ffmpeg decoding the video, StartFFmpeg can be used to start it again
+func (dec *VideoDecoder) Stop() {
+	if !dec.running {
+		return
+	}
+
+	dec.running = false
+	close(dec.decodingQueue)
+
+	dec.wg.Wait()
+
+	if dec.command != nil {
+		// ffmpeg may have already finished at the end of the video
+		_ = dec.command.Process.Kill()
+		_ = dec.command.Wait()
+	}
+}
+
 func (dec *VideoDecoder) GetFrame() Frame {
 	return <-dec.readyQueue
 }
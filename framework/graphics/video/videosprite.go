@@ -100,3 +100,9 @@ func (video *Video) Draw(time float64, batch *batch.QuadBatch) {
 
 	video.Sprite.Draw(time, batch)
 }
+
+// Dispose stops the decoder and frees the texture
+func (video *Video) Dispose() {
+	video.decoder.Stop()
+	video.texture.Dispose()
+}
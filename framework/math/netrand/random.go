@@ -0,0 +1,104 @@
+package netrand
+
+import "math"
+
+const (
+	mBig  = math.MaxInt32
+	mSeed = 161803398
+)
+
+// Random is a port of .NET's seeded System.Random (Knuth's subtractive generator).
+// lazer uses it to generate seeded beatmap conversions, so the same seed has to give the same sequence.
+type Random struct {
+	seedArray [56]int32
+	iNext     int
+	iNextP    int
+}
+
+func New(seed int32) *Random {
+	rnd := &Random{
+		iNext:  0,
+		iNextP: 21,
+	}
+
+	subtraction := int32(mBig)
+	if seed != math.MinInt32 {
+		subtraction = seed
+		if subtraction < 0 {
+			subtraction = -subtraction
+		}
+	}
+
+	mj := mSeed - subtraction
+	rnd.seedArray[55] = mj
+
+	mk := int32(1)
+
+	for i := 1; i < 55; i++ {
+		ii := (21 * i) % 55
+
+		rnd.seedArray[ii] = mk
+
+		mk = mj - mk
+		if mk < 0 {
+			mk += mBig
+		}
+
+		mj = rnd.seedArray[ii]
+	}
+
+	for k := 1; k < 5; k++ {
+		for i := 1; i < 56; i++ {
+			rnd.seedArray[i] -= rnd.seedArray[1+(i+30)%55]
+			if rnd.seedArray[i] < 0 {
+				rnd.seedArray[i] += mBig
+			}
+		}
+	}
+
+	return rnd
+}
+
+func (rnd *Random) internalSample() int32 {
+	locINext := rnd.iNext + 1
+	if locINext >= 56 {
+		locINext = 1
+	}
+
+	locINextP := rnd.iNextP + 1
+	if locINextP >= 56 {
+		locINextP = 1
+	}
+
+	retVal := rnd.seedArray[locINext] - rnd.seedArray[locINextP]
+
+	if retVal == mBig {
+		retVal--
+	}
+
+	if retVal < 0 {
+		retVal += mBig
+	}
+
+	rnd.seedArray[locINext] = retVal
+
+	rnd.iNext = locINext
+	rnd.iNextP = locINextP
+
+	return retVal
+}
+
+// Next returns a non-negative random integer smaller than math.MaxInt32
+func (rnd *Random) Next() int32 {
+	return rnd.internalSample()
+}
+
+// NextN returns a non-negative random integer smaller than maxValue
+func (rnd *Random) NextN(maxValue int32) int32 {
+	return int32(rnd.NextDouble() * float64(maxValue))
+}
+
+// NextDouble returns a random number in [0, 1) range
+func (rnd *Random) NextDouble() float64 {
+	return float64(rnd.internalSample()) * (1.0 / mBig)
+}
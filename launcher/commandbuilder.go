@@ -115,7 +115,7 @@ func (b *builder) setMap(bMap *beatmap.BeatMap) {
 
 func (b *builder) setReplay(replay *rplpa.Replay) {
 	b.currentReplay = replay
-	b.diff.RemoveMod(^difficulty.None)
+	b.diff.SetMods(difficulty.None)
 
 	if replay.ScoreInfo != nil && replay.ScoreInfo.Mods != nil && len(replay.ScoreInfo.Mods) > 0 {
 		modsNew := make([]rplpa.ModInfo, 0, len(replay.ScoreInfo.Mods))
@@ -128,9 +128,9 @@ func (b *builder) setReplay(replay *rplpa.Replay) {
 		b.baseDiff.SetMods(b.sourceDiff.Mods)
 		b.diff.SetMods2(modsNew)
 	} else {
-		b.sourceDiff.SetMods(difficulty.Modifier(replay.Mods))
-		b.baseDiff.SetMods(difficulty.Modifier(replay.Mods))
-		b.diff.SetMods(difficulty.Modifier(replay.Mods))
+		b.sourceDiff.SetMods(difficulty.ModifierFromLegacy(replay.Mods))
+		b.baseDiff.SetMods(difficulty.ModifierFromLegacy(replay.Mods))
+		b.diff.SetMods(difficulty.ModifierFromLegacy(replay.Mods))
 	}
 
 	if replay.OsuVersion >= 30000000 { // Lazer is 1000 years in the future
@@ -142,7 +142,7 @@ func (b *builder) setReplay(replay *rplpa.Replay) {
 
 func (b *builder) removeReplay() {
 	b.currentReplay = nil
-	b.sourceDiff.RemoveMod(^difficulty.None)
+	b.sourceDiff.SetMods(difficulty.None)
 }
 
 func (b *builder) numKnockoutReplays() (ret int) {
@@ -17,6 +17,7 @@ import (
 	"github.com/wieku/danser-go/app/graphics"
 	"github.com/wieku/danser-go/app/graphics/gui/drawables"
 	"github.com/wieku/danser-go/app/input"
+	"github.com/wieku/danser-go/app/lazer"
 	"github.com/wieku/danser-go/app/osuapi"
 	"github.com/wieku/danser-go/app/settings"
 	"github.com/wieku/danser-go/app/states/components/common"
@@ -165,6 +166,7 @@ type launcher struct {
 	lastKnockoutDir string
 
 	knockoutManager *knockoutManagerPopup
+	replayVerifier  *replayVerifier
 
 	currentEditor     *settingsEditor
 	beatmapDirUpdated bool
@@ -486,7 +488,9 @@ func (l *launcher) loadBeatmaps() {
 		})
 
 		for _, bMap := range beatmaps {
-			l.beatmaps = append(l.beatmaps, bMap)
+			if bMap.Mode == 0 { // launcher supports only osu!standard maps
+				l.beatmaps = append(l.beatmaps, bMap)
+			}
 		}
 
 		//database.Close()
@@ -507,22 +511,26 @@ func (l *launcher) loadLatestReplay() {
 
 	var list []*lastModPath
 
-	entries, err := os.ReadDir(replaysDir)
-	if err != nil {
-		return
-	}
+	entries, _ := os.ReadDir(replaysDir)
 
 	for _, d := range entries {
 		if !d.IsDir() && strings.HasSuffix(d.Name(), ".osr") {
 			if info, err1 := d.Info(); err1 == nil {
 				list = append(list, &lastModPath{
 					tStamp: info.ModTime(),
-					name:   d.Name(),
+					name:   filepath.Join(replaysDir, d.Name()),
 				})
 			}
 		}
 	}
 
+	for _, replay := range lazer.GetReplays() {
+		list = append(list, &lastModPath{
+			tStamp: replay.Date,
+			name:   replay.Path,
+		})
+	}
+
 	if list == nil {
 		return
 	}
@@ -533,7 +541,7 @@ func (l *launcher) loadLatestReplay() {
 
 	// Load the newest that can be used
 	for _, lMP := range list {
-		r, err := l.loadReplay(filepath.Join(replaysDir, lMP.name))
+		r, err := l.loadReplay(lMP.name)
 		if err == nil {
 			l.trySelectReplay(r)
 			break
@@ -931,6 +939,10 @@ func (l *launcher) selectReplay() {
 		imgui.PushTextWrapPosV(contentRegionMax().X / 2)
 		imgui.TextUnformatted(mString)
 		imgui.PopTextWrapPos()
+
+		if l.replayVerifier != nil && l.replayVerifier.path == l.bld.replayPath {
+			l.replayVerifier.draw()
+		}
 	} else {
 		imgui.TextUnformatted("No replay selected")
 	}
@@ -1021,6 +1033,8 @@ func (l *launcher) trySelectReplay(replay *knockoutReplay) {
 			l.bld.setMap(bMap)
 			l.bld.setReplay(replay.parsedReplay)
 
+			l.replayVerifier = newReplayVerifier(replay.path, l.bld.config)
+
 			return
 		}
 	}
@@ -1120,7 +1134,7 @@ func (l *launcher) loadReplay(p string) (*knockoutReplay, error) {
 
 		diff.SetMods2(modsNew)
 	} else {
-		diff.SetMods(difficulty.Modifier(replay.Mods))
+		diff.SetMods(difficulty.ModifierFromLegacy(replay.Mods))
 	}
 
 	if replay.OsuVersion >= 30000000 { // Lazer is 1000 years in the future
@@ -1696,6 +1710,15 @@ func (l *launcher) setConfig(s string) {
 	}
 }
 
+// danserExecutable returns the path of danser's executable launched for playback or recording
+func danserExecutable() string {
+	if build.Stream == "Release" {
+		return filepath.Join(env.LibDir(), build.DanserExec)
+	}
+
+	return os.Args[0]
+}
+
 func (l *launcher) startDanser() {
 	l.recordProgress = 0
 	l.recordStatus = ""
@@ -1703,13 +1726,7 @@ func (l *launcher) startDanser() {
 	l.recordStatusETA = ""
 	l.encodeInProgress = false
 
-	dExec := os.Args[0]
-
-	if build.Stream == "Release" {
-		dExec = filepath.Join(env.LibDir(), build.DanserExec)
-	}
-
-	l.danserCmd = exec.Command(dExec, l.bld.getArguments()...)
+	l.danserCmd = exec.Command(danserExecutable(), l.bld.getArguments()...)
 
 	rFile, oFile, err := os.Pipe()
 	if err != nil {
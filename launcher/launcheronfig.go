@@ -37,6 +37,7 @@ type launcherConf struct {
 	PreviewVolume    float64
 	SortMapsBy       SortBy
 	SortAscending    bool
+	Collection       string
 	LoadLatestReplay bool
 	SkipMapUpdate    bool
 	AutoRefreshDB    bool
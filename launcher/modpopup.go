@@ -6,6 +6,18 @@ import (
 	"strings"
 )
 
+const modsPerRow = 5
+
+// modCategories lists mod categories selectable in the popup, in display order
+var modCategories = []difficulty.ModCategory{
+	difficulty.CategoryReduction,
+	difficulty.CategoryIncrease,
+	difficulty.CategorySpecial,
+	difficulty.CategoryConversion,
+	difficulty.CategoryChallenge,
+	difficulty.CategoryFun,
+}
+
 type modPopup struct {
 	*popup
 
@@ -34,51 +46,9 @@ func (m *modPopup) drawModMenu() {
 	imgui.PushStyleVarVec2(imgui.StyleVarCellPadding, vec2(10, 10))
 
 	if imgui.BeginTable("mfa", 6) {
-		m.drawRow("Reduction:", func() {
-			m.modCheckbox(difficulty.Easy, difficulty.HardRock, difficulty.None)
-
-			m.modCheckbox(difficulty.NoFail, difficulty.SuddenDeath|difficulty.Perfect|difficulty.Relax|difficulty.Relax2, difficulty.None)
-
-			m.modCheckboxMulti(difficulty.HalfTime, difficulty.Daycore, difficulty.DoubleTime|difficulty.Nightcore, difficulty.None)
-		})
-
-		m.drawRow("Increase:", func() {
-			m.modCheckbox(difficulty.HardRock, difficulty.Easy|difficulty.Mirror, difficulty.None)
-
-			m.modCheckboxMulti(difficulty.SuddenDeath, difficulty.Perfect, difficulty.NoFail|difficulty.Relax|difficulty.Relax2, difficulty.None)
-
-			m.modCheckboxMulti(difficulty.DoubleTime, difficulty.Nightcore, difficulty.HalfTime|difficulty.Daycore, difficulty.None)
-
-			m.modCheckbox(difficulty.Hidden, difficulty.Traceable, difficulty.None)
-
-			m.modCheckbox(difficulty.Flashlight, difficulty.None, difficulty.None)
-		})
-
-		m.drawRow("Special:", func() {
-			nfSD := difficulty.NoFail | difficulty.SuddenDeath | difficulty.Perfect
-
-			m.modCheckbox(difficulty.Relax, difficulty.Relax2|nfSD, difficulty.None)
-
-			m.modCheckbox(difficulty.Relax2, difficulty.Relax|difficulty.SpunOut|nfSD, difficulty.None)
-
-			m.modCheckbox(difficulty.SpunOut, difficulty.Relax2, difficulty.None)
-
-			m.modCheckbox(difficulty.DifficultyAdjust, difficulty.None, difficulty.None)
-
-			m.modCheckbox(difficulty.Mirror, difficulty.HardRock, difficulty.None)
-		})
-
-		m.drawRow("Conversion:", func() {
-			m.modCheckbox(difficulty.ScoreV2, difficulty.Lazer|difficulty.Classic, difficulty.None)
-
-			m.modCheckbox(difficulty.Lazer, difficulty.ScoreV2, difficulty.None)
-
-			m.modCheckbox(difficulty.Classic, difficulty.ScoreV2, difficulty.Lazer)
-		})
-
-		m.drawRow("Fun:", func() {
-			m.modCheckbox(difficulty.Traceable, difficulty.Hidden, difficulty.None)
-		})
+		for _, category := range modCategories {
+			m.drawCategory(category)
+		}
 
 		imgui.EndTable()
 	}
@@ -106,19 +76,31 @@ func (m *modPopup) drawModMenu() {
 func (m *modPopup) drawModSettings() {
 	m.settingsDrawn = false
 	m.tryDrawSpeedSettings()
+	m.tryDrawTimeRampSettings()
+	m.tryDrawAdaptiveSpeedSettings()
 	m.tryDrawEasySettings()
 	m.tryDrawClassicSettings()
 	m.tryDrawFlashlightSettings()
 	m.tryDrawDASettings()
 	m.tryDrawMirrorSettings()
+	m.tryDrawAccuracyChallengeSettings()
+	m.tryDrawTargetPracticeSettings()
+	m.tryDrawRandomSettings()
+	m.tryDrawWiggleSettings()
+	m.tryDrawScaleSettings()
+	m.tryDrawBarrelRollSettings()
+	m.tryDrawDepthSettings()
+	m.tryDrawMagnetisedSettings()
+	m.tryDrawRepelSettings()
+	m.tryDrawNoScopeSettings()
 }
 
 func (m *modPopup) tryDrawSpeedSettings() {
-	speedAdjust := difficulty.DoubleTime | difficulty.Nightcore | difficulty.HalfTime | difficulty.Daycore
+	speedAdjust := difficulty.NewModifier(difficulty.DoubleTime, difficulty.Nightcore, difficulty.HalfTime, difficulty.Daycore)
 
 	m.drawSettingsBase(speedAdjust, func() {
 		var minV, maxV = 1.01, 2.0
-		if m.bld.diff.CheckModActive(difficulty.HalfTime | difficulty.Daycore) {
+		if m.bld.diff.CheckModActive(difficulty.HalfTime, difficulty.Daycore) {
 			minV, maxV = 0.5, 0.99
 		}
 
@@ -126,7 +108,7 @@ func (m *modPopup) tryDrawSpeedSettings() {
 
 		sliderFloatReset2("Speed", m.bld.baseDiff.GetSpeed(), &conf.SpeedChange, minV, maxV, "%.2f")
 
-		if !m.bld.diff.CheckModActive(difficulty.Daycore | difficulty.Nightcore) {
+		if !m.bld.diff.CheckModActive(difficulty.Daycore, difficulty.Nightcore) {
 			checkboxOption("Adjust pitch", &conf.AdjustPitch)
 		} else {
 			conf.AdjustPitch = true
@@ -136,8 +118,36 @@ func (m *modPopup) tryDrawSpeedSettings() {
 	})
 }
 
+func (m *modPopup) tryDrawTimeRampSettings() {
+	m.drawSettingsBase(difficulty.NewModifier(difficulty.WindUp, difficulty.WindDown), func() {
+		finalMin, finalMax, finalDefault := 0.51, 2.0, 1.5
+		if m.bld.diff.CheckModActive(difficulty.WindDown) {
+			finalMin, finalMax, finalDefault = 0.5, 1.99, 0.75
+		}
+
+		conf, _ := difficulty.GetModConfig[difficulty.TimeRampSettings](m.bld.diff)
+
+		sliderFloatReset2("Initial rate", 1, &conf.InitialRate, 0.5, 2, "%.2f")
+		sliderFloatReset2("Final rate", finalDefault, &conf.FinalRate, finalMin, finalMax, "%.2f")
+		checkboxOption("Adjust pitch", &conf.AdjustPitch)
+
+		difficulty.SetModConfig(m.bld.diff, conf)
+	})
+}
+
+func (m *modPopup) tryDrawAdaptiveSpeedSettings() {
+	m.drawSettingsBase(difficulty.NewModifier(difficulty.AdaptiveSpeed), func() {
+		conf, _ := difficulty.GetModConfig[difficulty.AdaptiveSpeedSettings](m.bld.diff)
+
+		sliderFloatReset2("Initial rate", 1, &conf.InitialRate, 0.5, 2, "%.2f")
+		checkboxOption("Adjust pitch", &conf.AdjustPitch)
+
+		difficulty.SetModConfig(m.bld.diff, conf)
+	})
+}
+
 func (m *modPopup) tryDrawEasySettings() {
-	m.drawSettingsBase(difficulty.Easy, func() {
+	m.drawSettingsBase(difficulty.NewModifier(difficulty.Easy), func() {
 		conf, _ := difficulty.GetModConfig[difficulty.EasySettings](m.bld.diff)
 
 		sliderIntReset2("Extra lives", 2, &conf.Retries, 0, 10, "%d")
@@ -147,7 +157,7 @@ func (m *modPopup) tryDrawEasySettings() {
 }
 
 func (m *modPopup) tryDrawClassicSettings() {
-	m.drawSettingsBase(difficulty.Classic, func() {
+	m.drawSettingsBase(difficulty.NewModifier(difficulty.Classic), func() {
 		conf, _ := difficulty.GetModConfig[difficulty.ClassicSettings](m.bld.diff)
 
 		checkboxOption("No slider head accuracy requirement", &conf.NoSliderHeadAccuracy)
@@ -160,7 +170,7 @@ func (m *modPopup) tryDrawClassicSettings() {
 }
 
 func (m *modPopup) tryDrawFlashlightSettings() {
-	m.drawSettingsBase(difficulty.Flashlight, func() {
+	m.drawSettingsBase(difficulty.NewModifier(difficulty.Flashlight), func() {
 		conf, _ := difficulty.GetModConfig[difficulty.FlashlightSettings](m.bld.diff)
 
 		sliderFloatResetStep2("Follow delay", 120, &conf.FollowDelay, 120, 1200, 120, "%.f")
@@ -172,7 +182,7 @@ func (m *modPopup) tryDrawFlashlightSettings() {
 }
 
 func (m *modPopup) tryDrawDASettings() {
-	m.drawSettingsBase(difficulty.DifficultyAdjust, func() {
+	m.drawSettingsBase(difficulty.NewModifier(difficulty.DifficultyAdjust), func() {
 		conf, _ := difficulty.GetModConfig[difficulty.DiffAdjustSettings](m.bld.diff)
 
 		arCSMin, vMax := 0.0, 10.0
@@ -204,7 +214,7 @@ var mirrorMapR = map[string]int{
 }
 
 func (m *modPopup) tryDrawMirrorSettings() {
-	m.drawSettingsBase(difficulty.Mirror, func() {
+	m.drawSettingsBase(difficulty.NewModifier(difficulty.Mirror), func() {
 		conf, _ := difficulty.GetModConfig[difficulty.MirrorSettings](m.bld.diff)
 
 		mMode := mirrorMap[conf.FlipMode]
@@ -217,14 +227,177 @@ func (m *modPopup) tryDrawMirrorSettings() {
 	})
 }
 
+var accuracyModeMap = map[int]string{
+	difficulty.AccuracyStandard:      "Standard",
+	difficulty.AccuracyMaxAchievable: "Maximum achievable",
+}
+
+var accuracyModeMapR = map[string]int{
+	"Standard":           difficulty.AccuracyStandard,
+	"Maximum achievable": difficulty.AccuracyMaxAchievable,
+}
+
+func (m *modPopup) tryDrawAccuracyChallengeSettings() {
+	m.drawSettingsBase(difficulty.NewModifier(difficulty.AccuracyChallenge), func() {
+		conf, _ := difficulty.GetModConfig[difficulty.AccuracyChallengeSettings](m.bld.diff)
+
+		sliderFloatResetStep2("Minimum accuracy", 0.9, &conf.MinimumAccuracy, 0.6, 0.99, 0.01, "%.2f")
+
+		aMode := accuracyModeMap[conf.AccuracyJudgeMode]
+
+		if comboOption("Accuracy mode", &aMode, []string{"Standard", "Maximum achievable"}) {
+			conf.AccuracyJudgeMode = accuracyModeMapR[aMode]
+		}
+
+		difficulty.SetModConfig(m.bld.diff, conf)
+	})
+}
+
+func (m *modPopup) tryDrawTargetPracticeSettings() {
+	m.drawSettingsBase(difficulty.NewModifier(difficulty.Target), func() {
+		conf, _ := difficulty.GetModConfig[difficulty.TargetPracticeSettings](m.bld.diff)
+
+		seedOption("Seed", &conf.Seed)
+
+		difficulty.SetModConfig(m.bld.diff, conf)
+	})
+}
+
+func (m *modPopup) tryDrawRandomSettings() {
+	m.drawSettingsBase(difficulty.NewModifier(difficulty.Random), func() {
+		conf, _ := difficulty.GetModConfig[difficulty.RandomSettings](m.bld.diff)
+
+		sliderFloatReset2("Angle sharpness", 7, &conf.AngleSharpness, 1, 10, "%.1f")
+		seedOption("Seed", &conf.Seed)
+
+		difficulty.SetModConfig(m.bld.diff, conf)
+	})
+}
+
+func (m *modPopup) tryDrawWiggleSettings() {
+	m.drawSettingsBase(difficulty.NewModifier(difficulty.Wiggle), func() {
+		conf, _ := difficulty.GetModConfig[difficulty.WiggleSettings](m.bld.diff)
+
+		sliderFloatReset2("Strength", 1, &conf.Strength, 0.1, 2, "%.1f")
+
+		difficulty.SetModConfig(m.bld.diff, conf)
+	})
+}
+
+func (m *modPopup) tryDrawScaleSettings() {
+	m.drawSettingsBase(difficulty.NewModifier(difficulty.Grow, difficulty.Deflate), func() {
+		sMin, sMax, sDefault := 0.0, 0.99, 0.5
+		if m.bld.diff.CheckModActive(difficulty.Deflate) {
+			sMin, sMax, sDefault = 1, 25, 2
+		}
+
+		conf, _ := difficulty.GetModConfig[difficulty.ScaleSettings](m.bld.diff)
+
+		sliderFloatReset2("Starting size", sDefault, &conf.StartScale, sMin, sMax, "%.2f")
+
+		difficulty.SetModConfig(m.bld.diff, conf)
+	})
+}
+
+var rotationMap = map[int]string{
+	difficulty.RotationClockwise:        "Clockwise",
+	difficulty.RotationCounterclockwise: "Counterclockwise",
+}
+
+var rotationMapR = map[string]int{
+	"Clockwise":        difficulty.RotationClockwise,
+	"Counterclockwise": difficulty.RotationCounterclockwise,
+}
+
+func (m *modPopup) tryDrawBarrelRollSettings() {
+	m.drawSettingsBase(difficulty.NewModifier(difficulty.BarrelRoll), func() {
+		conf, _ := difficulty.GetModConfig[difficulty.BarrelRollSettings](m.bld.diff)
+
+		sliderFloatReset2("Roll speed", 0.5, &conf.SpinSpeed, 0.02, 12, "%.2f")
+
+		rMode := rotationMap[conf.Direction]
+
+		if comboOption("Direction", &rMode, []string{"Clockwise", "Counterclockwise"}) {
+			conf.Direction = rotationMapR[rMode]
+		}
+
+		difficulty.SetModConfig(m.bld.diff, conf)
+	})
+}
+
+func (m *modPopup) tryDrawDepthSettings() {
+	m.drawSettingsBase(difficulty.NewModifier(difficulty.Depth), func() {
+		conf, _ := difficulty.GetModConfig[difficulty.DepthSettings](m.bld.diff)
+
+		sliderFloatResetStep2("Maximum depth", 100, &conf.MaxDepth, 50, 200, 10, "%.f")
+		checkboxOption("Show approach circles", &conf.ShowApproachCircles)
+
+		difficulty.SetModConfig(m.bld.diff, conf)
+	})
+}
+
+func (m *modPopup) tryDrawMagnetisedSettings() {
+	m.drawSettingsBase(difficulty.NewModifier(difficulty.Magnetised), func() {
+		conf, _ := difficulty.GetModConfig[difficulty.MagnetisedSettings](m.bld.diff)
+
+		sliderFloatResetStep2("Attraction strength", 0.5, &conf.AttractionStrength, 0.05, 1, 0.05, "%.2f")
+
+		difficulty.SetModConfig(m.bld.diff, conf)
+	})
+}
+
+func (m *modPopup) tryDrawRepelSettings() {
+	m.drawSettingsBase(difficulty.NewModifier(difficulty.Repel), func() {
+		conf, _ := difficulty.GetModConfig[difficulty.RepelSettings](m.bld.diff)
+
+		sliderFloatResetStep2("Repulsion strength", 0.5, &conf.RepulsionStrength, 0.05, 1, 0.05, "%.2f")
+
+		difficulty.SetModConfig(m.bld.diff, conf)
+	})
+}
+
+func (m *modPopup) tryDrawNoScopeSettings() {
+	m.drawSettingsBase(difficulty.NewModifier(difficulty.NoScope), func() {
+		conf, _ := difficulty.GetModConfig[difficulty.NoScopeSettings](m.bld.diff)
+
+		sliderIntReset2("Hidden at combo", 10, &conf.HiddenComboCount, 0, 50, "%d")
+
+		difficulty.SetModConfig(m.bld.diff, conf)
+	})
+}
+
+func seedOption(text string, value *int) {
+	if imgui.BeginTableV(text+"table", 2, 0, vec2(-1, 0), -1) {
+		imgui.TableSetupColumnV(text+"table1", imgui.TableColumnFlagsWidthStretch, 0, imgui.ID(0))
+		imgui.TableSetupColumnV(text+"table2", imgui.TableColumnFlagsWidthFixed, 180, imgui.ID(1))
+
+		imgui.TableNextColumn()
+
+		imgui.AlignTextToFramePadding()
+		imgui.TextUnformatted(text)
+
+		imgui.TableNextColumn()
+
+		imgui.SetNextItemWidth(-1)
+
+		seed := int32(*value)
+
+		if imgui.InputIntV("##seed"+text, &seed, 1, 100, 0) {
+			*value = int(seed)
+		}
+
+		imgui.EndTable()
+	}
+}
+
 func (m *modPopup) drawSettingsBase(mask difficulty.Modifier, draw func()) {
-	if m.bld.diff.CheckModActive(mask) {
+	if m.bld.diff.Mods.Intersects(mask) {
 		if m.settingsDrawn {
 			imgui.Dummy(vec2(0, 10))
 		}
 
 		imgui.PushFont(Font32)
-		imgui.TextUnformatted((m.bld.diff.Mods & mask).StringFull()[0] + ":")
+		imgui.TextUnformatted(m.bld.diff.Mods.Intersect(mask).StringFull()[0] + ":")
 		imgui.PopFont()
 		imgui.WindowDrawList().AddLine(imgui.CursorScreenPos(), imgui.CursorScreenPos().Add(vec2(contentRegionMax().X, 0)), packColor(*imgui.StyleColorVec4(imgui.ColSeparator)))
 
@@ -236,6 +409,39 @@ func (m *modPopup) drawSettingsBase(mask difficulty.Modifier, draw func()) {
 	}
 }
 
+// drawCategory draws checkboxes of all mods in a category, wrapping them into rows of modsPerRow
+func (m *modPopup) drawCategory(category difficulty.ModCategory) {
+	var mods []*difficulty.ModDefinition
+
+	for _, def := range difficulty.GetModsInCategory(category) {
+		// Mods implying another one, like Nightcore, share a checkbox with it
+		if def.Implies != "" && def.Implies.Definition().Category == category {
+			continue
+		}
+
+		mods = append(mods, def)
+	}
+
+	for i := 0; i < len(mods); i += modsPerRow {
+		name := ""
+		if i == 0 {
+			name = category.String() + ":"
+		}
+
+		m.drawRow(name, func() {
+			for _, def := range mods[i:min(i+modsPerRow, len(mods))] {
+				incompat := difficulty.GetIncompatible(def.Mod)
+
+				if implying := difficulty.GetImpliedBy(def.Mod); !implying.IsEmpty() {
+					m.modCheckboxMulti(def.Mod, implying.List()[0], incompat, def.Requires)
+				} else {
+					m.modCheckbox(def.Mod, incompat, def.Requires)
+				}
+			}
+		})
+	}
+}
+
 func (m *modPopup) drawRow(name string, work func()) {
 	imgui.TableNextRow()
 	imgui.TableNextColumn()
@@ -255,10 +461,10 @@ func (m *modPopup) drawRow(name string, work func()) {
 	imgui.TextUnformatted(name)
 }
 
-func (m *modPopup) modCheckbox(mod, incompat, required difficulty.Modifier) (ret bool) {
+func (m *modPopup) modCheckbox(mod difficulty.Mod, incompat difficulty.Modifier, required []difficulty.Mod) (ret bool) {
 	imgui.TableNextColumn()
 
-	req := required == difficulty.None || m.bld.diff.CheckModActive(required)
+	req := len(required) == 0 || m.bld.diff.CheckModActive(required...)
 
 	if !req {
 		imgui.BeginDisabled()
@@ -281,8 +487,8 @@ func (m *modPopup) modCheckbox(mod, incompat, required difficulty.Modifier) (ret
 			m.bld.baseDiff.RemoveMod(mod)
 			m.bld.diff.RemoveMod(mod)
 		} else {
-			m.bld.baseDiff.RemoveMod(incompat)
-			m.bld.diff.RemoveMod(incompat)
+			m.bld.baseDiff.RemoveMod(incompat.List()...)
+			m.bld.diff.RemoveMod(incompat.List()...)
 
 			m.bld.baseDiff.AddMod(mod)
 			m.bld.diff.AddMod(mod)
@@ -302,7 +508,7 @@ func (m *modPopup) modCheckbox(mod, incompat, required difficulty.Modifier) (ret
 	if imgui.IsItemHoveredV(imgui.HoveredFlagsAllowWhenDisabled) || imgui.IsItemActive() {
 		imgui.BeginTooltip()
 
-		modTip := mod.StringFull()[0]
+		modTip := mod.Definition().Name
 		if modTip == "Relax2" {
 			modTip = "AutoPilot"
 		}
@@ -313,10 +519,10 @@ func (m *modPopup) modCheckbox(mod, incompat, required difficulty.Modifier) (ret
 		imgui.PushTextWrapPosV(300)
 
 		if !req {
-			imgui.TextUnformatted("Mods required: " + strings.Join(required.StringFull(), ", "))
+			imgui.TextUnformatted("Mods required: " + strings.Join(difficulty.NewModifier(required...).StringFull(), ", "))
 		}
 
-		if incompat != difficulty.None {
+		if !incompat.IsEmpty() {
 			imgui.TextUnformatted("Incompatible with: " + strings.Join(incompat.StringFull2(), ", "))
 		}
 
@@ -329,7 +535,7 @@ func (m *modPopup) modCheckbox(mod, incompat, required difficulty.Modifier) (ret
 	return
 }
 
-func (m *modPopup) modCheckboxMulti(mod1, mod2, incompat, required difficulty.Modifier) {
+func (m *modPopup) modCheckboxMulti(mod1, mod2 difficulty.Mod, incompat difficulty.Modifier, required []difficulty.Mod) {
 	if !m.bld.diff.CheckModActive(mod2) {
 		if m.modCheckbox(mod1, incompat, required) && !m.bld.diff.CheckModActive(mod1) {
 			m.bld.baseDiff.AddMod(mod2)
@@ -0,0 +1,117 @@
+package launcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/AllenDang/cimgui-go/imgui"
+	"github.com/wieku/danser-go/app/analyzer"
+	"github.com/wieku/danser-go/framework/goroutines"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+type verifyStatus int
+
+const (
+	verifyRunning = verifyStatus(iota)
+	verifyConsistent
+	verifyDesynced
+	verifyFailed
+)
+
+// replayVerifier runs "danser verify" on a replay in the background, so desyncs can be shown before rendering
+type replayVerifier struct {
+	path string
+
+	mutex  sync.Mutex
+	status verifyStatus
+	result *analyzer.Verification
+}
+
+func newReplayVerifier(path, config string) *replayVerifier {
+	v := &replayVerifier{
+		path:   path,
+		status: verifyRunning,
+	}
+
+	args := []string{"verify", "-replay", path, "-json", "-nodbcheck"}
+	if config != "default" {
+		args = append(args, "-settings", config)
+	}
+
+	goroutines.Run(func() {
+		output, err := exec.Command(danserExecutable(), args...).Output()
+
+		var exitErr *exec.ExitError
+		if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == analyzer.ExitDesync) {
+			log.Println("Launcher: Failed to verify the replay:", err)
+			v.setResult(verifyFailed, nil)
+
+			return
+		}
+
+		result := new(analyzer.Verification)
+
+		if err = json.Unmarshal(output, result); err != nil {
+			log.Println("Launcher: Failed to parse replay verification:", err)
+			v.setResult(verifyFailed, nil)
+
+			return
+		}
+
+		if result.Consistent {
+			v.setResult(verifyConsistent, result)
+		} else {
+			v.setResult(verifyDesynced, result)
+		}
+	})
+
+	return v
+}
+
+func (v *replayVerifier) setResult(status verifyStatus, result *analyzer.Verification) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	v.status = status
+	v.result = result
+}
+
+func (v *replayVerifier) draw() {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	switch v.status {
+	case verifyRunning:
+		imgui.PushStyleColorVec4(imgui.ColText, vec4(0.6, 0.6, 0.6, 1))
+		imgui.TextUnformatted("Verifying replay...")
+		imgui.PopStyleColor()
+	case verifyDesynced:
+		imgui.PushStyleColorVec4(imgui.ColText, vec4(1, 0.8, 0.2, 1))
+
+		text := "Warning: replay desyncs in danser"
+		if d := v.result.DivergedBy; d != nil {
+			text += " by " + analyzer.FormatTime(d.Time)
+		}
+
+		imgui.TextUnformatted(text)
+		imgui.PopStyleColor()
+
+		if imgui.IsItemHovered() {
+			var lines []string
+
+			for _, m := range v.result.Mismatches {
+				lines = append(lines, fmt.Sprintf("%s: expected %d, got %d", m.Field, m.Expected, m.Actual))
+			}
+
+			if d := v.result.DivergedBy; d != nil {
+				lines = append(lines, "Diverged by "+analyzer.FormatTime(d.Time)+" at the latest: "+d.Reason)
+			}
+
+			imgui.SetTooltip(strings.Join(lines, "\n"))
+		}
+	}
+}
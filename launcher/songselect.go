@@ -5,7 +5,8 @@ import (
 	"fmt"
 	"github.com/AllenDang/cimgui-go/imgui"
 	"github.com/wieku/danser-go/app/beatmap"
-	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/database"
+	"github.com/wieku/danser-go/app/utils"
 	"github.com/wieku/danser-go/framework/bass"
 	"github.com/wieku/danser-go/framework/graphics/texture"
 	"github.com/wieku/danser-go/framework/math/animation"
@@ -15,7 +16,6 @@ import (
 	"github.com/wieku/danser-go/framework/util"
 	"math"
 	"math/rand"
-	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
@@ -58,7 +58,7 @@ type mapWithName struct {
 
 func newMapWithName(bMap *beatmap.BeatMap) *mapWithName {
 	return &mapWithName{
-		name: strings.ToLower(fmt.Sprintf("%s - %s [%s] by %s %d %d", bMap.Artist, bMap.Name, bMap.Difficulty, bMap.Creator, bMap.SetID, bMap.ID)),
+		name: beatmap.SearchText(bMap),
 		bMap: bMap,
 	}
 }
@@ -82,8 +82,9 @@ func (e maps) Len() int {
 type songSelectPopup struct {
 	*popup
 
-	bld      *builder
-	beatmaps maps
+	bld         *builder
+	beatmaps    maps
+	collections []*database.Collection
 
 	searchResults  []*beatmapSet
 	sizeCalculated int
@@ -104,6 +105,8 @@ type songSelectPopup struct {
 
 	comboOpened bool
 	scrolling   bool
+
+	bests map[string]*database.Score
 }
 
 func newSongSelectPopup(bld *builder, beatmaps []*beatmap.BeatMap) *songSelectPopup {
@@ -128,6 +131,8 @@ func (m *songSelectPopup) setBeatmaps(beatmaps []*beatmap.BeatMap) {
 	}
 
 	m.beatmaps = beatmaps2
+	m.collections = database.GetCollections()
+	m.bests = database.GetPersonalBests()
 	m.search()
 	m.focusTheMap = true
 }
@@ -212,6 +217,37 @@ func (m *songSelectPopup) drawSongSelect() {
 		ImIO.SetFontGlobalScale(1)
 		imgui.PopFont()
 
+		if len(m.collections) > 0 {
+			imgui.SameLine()
+
+			imgui.TextUnformatted("Collection:")
+
+			imgui.SameLine()
+
+			imgui.SetNextItemWidth(200)
+
+			cName := "All"
+			if m.getCollection() != nil {
+				cName = launcherConfig.Collection
+			}
+
+			if imgui.BeginCombo("##collectioncombo", cName) {
+				m.comboOpened = true
+
+				if imgui.SelectableBoolV("All", m.getCollection() == nil, 0, vzero()) {
+					m.setCollection("")
+				}
+
+				for i, c := range m.collections {
+					if imgui.SelectableBoolV(fmt.Sprintf("%s (%d)##col%d", c.Name, c.Len(), i), c.Name == launcherConfig.Collection, 0, vzero()) {
+						m.setCollection(c.Name)
+					}
+				}
+
+				imgui.EndCombo()
+			}
+		}
+
 		imgui.TableNextColumn()
 
 		if imgui.Button("Random") {
@@ -409,6 +445,10 @@ func (m *songSelectPopup) drawSongSelect() {
 			for j, bMap := range b.bMaps {
 				fDiffName := ">   " + bMap.Difficulty
 
+				if best := m.bests[strings.ToLower(bMap.MD5)]; best != nil {
+					fDiffName += fmt.Sprintf("   (PB: %.2f%%)", best.Accuracy*100)
+				}
+
 				tSiz := imgui.CalcTextSizeV(fDiffName, false, 0)
 
 				sPos := imgui.CursorScreenPos()
@@ -468,7 +508,7 @@ func (m *songSelectPopup) showMapTooltip(bMap *beatmap.BeatMap) {
 
 	cPos := imgui.CursorPos()
 
-	thumbPath := filepath.Join(settings.General.GetSongsDir(), bMap.Dir, bMap.Bg)
+	thumbPath := bMap.GetPath(bMap.Bg)
 
 	if m.lastThumbPath != thumbPath {
 		if m.thumbTex != nil {
@@ -551,6 +591,15 @@ func (m *songSelectPopup) showMapTooltip(bMap *beatmap.BeatMap) {
 		imgui.EndTable()
 	}
 
+	if best := m.bests[strings.ToLower(bMap.MD5)]; best != nil {
+		imgui.Separator()
+
+		imgui.TextUnformatted(fmt.Sprintf("Personal best by %s:", best.Player))
+		imgui.TextUnformatted(fmt.Sprintf("%s (%.2f%%) %s", utils.Humanize(best.Score), best.Accuracy*100, best.ModString))
+		imgui.TextUnformatted(fmt.Sprintf("%sx, %s miss, %.2fpp", utils.Humanize(best.MaxCombo), utils.Humanize(best.CountMiss), best.PP))
+		imgui.TextUnformatted(best.Date.Format("2006-01-02 15:04"))
+	}
+
 	imgui.PopFont()
 	imgui.EndTooltip()
 }
@@ -640,12 +689,23 @@ func (m *songSelectPopup) search() {
 	m.sizeCalculated = 0
 	m.searchResults = m.searchResults[:0]
 
-	sString := strings.ToLower(m.searchStr)
+	query, err := beatmap.ParseQuery(m.searchStr)
+	if err != nil { // Incomplete or invalid filters are searched as plain text
+		query = beatmap.NewTextQuery(m.searchStr)
+	}
+
+	database.CheckStatusFilter(query)
+
+	collection := m.getCollection()
 
 	foundMaps := make([]*beatmap.BeatMap, 0, len(m.beatmaps))
 
 	for _, b := range m.beatmaps {
-		if sString != "" && !strings.Contains(b.name, sString) {
+		if !query.MatchesText(b.bMap, b.name) {
+			continue
+		}
+
+		if collection != nil && !collection.Contains(b.bMap.MD5) {
 			continue
 		}
 
@@ -666,8 +726,36 @@ func (m *songSelectPopup) search() {
 	m.postIndex = len(m.searchResults) - 1
 }
 
+func (m *songSelectPopup) getCollection() *database.Collection {
+	if launcherConfig.Collection == "" {
+		return nil
+	}
+
+	for _, c := range m.collections {
+		if c.Name == launcherConfig.Collection {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func (m *songSelectPopup) setCollection(name string) {
+	if name == launcherConfig.Collection {
+		return
+	}
+
+	launcherConfig.Collection = name
+
+	m.search()
+	m.focusTheMap = true
+
+	saveLauncherConfig()
+}
+
 func (m *songSelectPopup) open() {
 	m.focusTheMap = true
+	m.bests = database.GetPersonalBests() // plays started from the launcher may have finished in the meantime
 
 	m.popup.open()
 }
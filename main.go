@@ -12,6 +12,7 @@ import "C"
 
 import (
 	"github.com/wieku/danser-go/app"
+	"github.com/wieku/danser-go/app/analyzer"
 	"github.com/wieku/danser-go/framework/env"
 	"github.com/wieku/danser-go/launcher"
 	"os"
@@ -22,6 +23,18 @@ func main() {
 
 	if len(os.Args) == 1 {
 		launcher.StartLauncher()
+	} else if os.Args[1] == "analyze" {
+		analyzer.Run(os.Args[2:])
+	} else if os.Args[1] == "verify" {
+		analyzer.Verify(os.Args[2:])
+	} else if os.Args[1] == "list" {
+		analyzer.List(os.Args[2:])
+	} else if os.Args[1] == "replayinfo" {
+		analyzer.InspectReplay(os.Args[2:])
+	} else if os.Args[1] == "attributes" {
+		analyzer.Attributes(os.Args[2:])
+	} else if os.Args[1] == "export" {
+		analyzer.Export(os.Args[2:])
 	} else {
 		app.Run()
 	}
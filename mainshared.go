@@ -4,6 +4,7 @@ import "C"
 
 import (
 	"github.com/wieku/danser-go/app"
+	"github.com/wieku/danser-go/app/analyzer"
 	"github.com/wieku/danser-go/framework/env"
 	"github.com/wieku/danser-go/launcher"
 	"os"
@@ -16,6 +17,18 @@ func danserMain(isLauncher bool, args []string) {
 	env.Init("danser")
 	if isLauncher {
 		launcher.StartLauncher()
+	} else if len(args) > 1 && args[1] == "analyze" {
+		analyzer.Run(args[2:])
+	} else if len(args) > 1 && args[1] == "verify" {
+		analyzer.Verify(args[2:])
+	} else if len(args) > 1 && args[1] == "list" {
+		analyzer.List(args[2:])
+	} else if len(args) > 1 && args[1] == "replayinfo" {
+		analyzer.InspectReplay(args[2:])
+	} else if len(args) > 1 && args[1] == "attributes" {
+		analyzer.Attributes(args[2:])
+	} else if len(args) > 1 && args[1] == "export" {
+		analyzer.Export(args[2:])
 	} else {
 		app.Run()
 	}
@@ -0,0 +1,90 @@
+#!/usr/bin/env node
+
+// Exports beatmap sets, skins and scores from osu!lazer's client.realm to client.json placed next to it, which is read by danser.
+// Names of objects and properties are the ones osu!lazer uses in its Realm models (osu.Game/Beatmaps/BeatmapSetInfo.cs,
+// BeatmapInfo.cs, osu.Game/Models/RealmNamedFileUsage.cs, osu.Game/Skinning/SkinInfo.cs, osu.Game/Scoring/ScoreInfo.cs),
+// only the fields needed by danser are written.
+//
+// Usage: node export.js <osu!lazer data directory>
+
+const Realm = require("realm");
+const fs = require("fs");
+const path = require("path");
+
+function exportFiles(files) {
+    return Array.from(files ?? [], f => ({
+        Filename: f.Filename,
+        File: {Hash: f.File?.Hash ?? ""},
+    }));
+}
+
+function exportBeatmapSet(set) {
+    return {
+        ID: set.ID.toString(),
+        OnlineID: set.OnlineID,
+        DateAdded: set.DateAdded,
+        DeletePending: set.DeletePending,
+        Files: exportFiles(set.Files),
+        Beatmaps: Array.from(set.Beatmaps, b => ({
+            ID: b.ID.toString(),
+            Hash: b.Hash,
+            MD5Hash: b.MD5Hash,
+            Hidden: b.Hidden,
+            Status: b.Status,
+            Ruleset: {ShortName: b.Ruleset?.ShortName ?? ""},
+        })),
+    };
+}
+
+function exportSkin(skin) {
+    return {
+        ID: skin.ID.toString(),
+        Name: skin.Name,
+        Creator: skin.Creator,
+        DeletePending: skin.DeletePending,
+        Files: exportFiles(skin.Files),
+    };
+}
+
+function exportScore(score) {
+    return {
+        ID: score.ID.toString(),
+        Date: score.Date,
+        DeletePending: score.DeletePending,
+        User: {Username: score.User?.Username ?? ""},
+        BeatmapInfo: {MD5Hash: score.BeatmapInfo?.MD5Hash ?? ""},
+        Files: exportFiles(score.Files),
+    };
+}
+
+async function main() {
+    const dir = process.argv[2];
+    if (!dir) {
+        console.error("Usage: node export.js <osu!lazer data directory>");
+        process.exit(1);
+    }
+
+    // no schema is given, so the one stored in the file is used and nothing is migrated
+    const realm = await Realm.open({path: path.resolve(dir, "client.realm"), readOnly: true});
+
+    const library = {
+        BeatmapSets: Array.from(realm.objects("BeatmapSet"), exportBeatmapSet),
+        Skins: Array.from(realm.objects("Skin"), exportSkin),
+        Scores: Array.from(realm.objects("Score"), exportScore),
+    };
+
+    realm.close();
+
+    const outPath = path.resolve(dir, "client.json");
+
+    // written to a temporary file first, so danser never reads a half-written snapshot
+    fs.writeFileSync(outPath + ".tmp", JSON.stringify(library));
+    fs.renameSync(outPath + ".tmp", outPath);
+
+    console.log(`Exported ${library.BeatmapSets.length} beatmap sets, ${library.Skins.length} skins and ${library.Scores.length} scores to ${outPath}`);
+}
+
+main().catch(err => {
+    console.error("Failed to export client.realm:", err);
+    process.exit(1);
+});